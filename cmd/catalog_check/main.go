@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/catalogcheck"
+)
+
+// Chequeos de calidad de datos del catálogo (imágenes, stock, temporadas, talles...).
+// Uso: go run ./cmd/catalog_check [-fix] [-checks missing_image,season_mismatch]
+func main() {
+	fix := flag.Bool("fix", false, "aplicar las correcciones seguras")
+	only := flag.String("checks", "", "chequeos a correr, separados por coma (por defecto todos)")
+	flag.Parse()
+
+	opts := catalogcheck.Options{Fix: *fix}
+	if *only != "" {
+		opts.Checks = strings.Split(*only, ",")
+	}
+	db := config.ConnectDatabase()
+	report, err := catalogcheck.Run(db, opts)
+	if err != nil {
+		log.Fatalf("Error en los chequeos: %v", err)
+	}
+
+	for _, is := range report.Issues {
+		variant := ""
+		if is.VariantID != nil {
+			variant = fmt.Sprintf(" variante=%d", *is.VariantID)
+		}
+		fmt.Printf("[%s] %s producto=%d (%s)%s: %s", is.Severity, is.Check, is.ProductID, is.ProductCode, variant, is.Message)
+		if is.Fixed {
+			fmt.Print(" [corregido]")
+		} else if is.Fixable {
+			fmt.Print(" [corregible con -fix]")
+		}
+		fmt.Println()
+	}
+	for _, ch := range report.Checks {
+		fmt.Printf("%-28s %d\n", ch.Name, report.Counts[ch.Name])
+	}
+	fmt.Printf("Productos revisados: %d, problemas: %d, corregibles: %d, corregidos: %d\n",
+		report.ProductsChecked, len(report.Issues), report.Fixable, report.Fixed)
+}
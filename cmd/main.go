@@ -7,19 +7,33 @@ import (
 	"go-modaMayor/config"
 	"go-modaMayor/internal/audit"
 	"go-modaMayor/internal/cart"
+	"go-modaMayor/internal/catalogimport"
 	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/inventory"
 	"go-modaMayor/internal/kit"
 	"go-modaMayor/internal/notification"
 	"go-modaMayor/internal/order"
 	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/purchase"
+	"go-modaMayor/internal/returns"
+	"go-modaMayor/internal/sequence"
 	"go-modaMayor/internal/settings"
+	"go-modaMayor/internal/slug"
+	"go-modaMayor/internal/storage"
 	"go-modaMayor/internal/user"
+	"go-modaMayor/internal/zoologic"
 	"go-modaMayor/routes"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
+	// Almacenamiento de archivos: con STORAGE_DRIVER=s3 mal configurado no se arranca
+	// (caer al disco local perdería las subidas en cada deploy)
+	if err := storage.Init(); err != nil {
+		panic("Configuración de almacenamiento inválida: " + err.Error())
+	}
+
 	// 1. Conectar y obtener la instancia de GORM
 	db := config.ConnectDatabase()
 
@@ -47,6 +61,12 @@ func main() {
 		if err := db.AutoMigrate(&category.Category{}); err != nil {
 			panic("Falló migración Category: " + err.Error())
 		}
+		if err := db.AutoMigrate(&category.CategoryAttribute{}); err != nil {
+			panic("Falló migración CategoryAttribute: " + err.Error())
+		}
+		if err := db.AutoMigrate(&slug.Redirect{}); err != nil {
+			panic("Falló migración SlugRedirect: " + err.Error())
+		}
 		if err := db.AutoMigrate(&cart.Cart{}); err != nil {
 			panic("Falló migración Cart: " + err.Error())
 		}
@@ -56,6 +76,18 @@ func main() {
 		if err := db.AutoMigrate(&product.ProductVariant{}); err != nil {
 			panic("Falló migración ProductVariant: " + err.Error())
 		}
+		if err := db.AutoMigrate(&product.ProductImage{}); err != nil {
+			panic("Falló migración ProductImage: " + err.Error())
+		}
+		if err := db.AutoMigrate(&product.ProductRevision{}); err != nil {
+			panic("Falló migración ProductRevision: " + err.Error())
+		}
+		if err := db.AutoMigrate(&product.ProductAttributeValue{}); err != nil {
+			panic("Falló migración ProductAttributeValue: " + err.Error())
+		}
+		if err := db.AutoMigrate(&product.SeasonClearanceItem{}); err != nil {
+			panic("Falló migración SeasonClearanceItem: " + err.Error())
+		}
 
 		// New product-related migrations (suppliers and sizing)
 		if err := db.AutoMigrate(&product.Supplier{}); err != nil {
@@ -67,6 +99,9 @@ func main() {
 		if err := db.AutoMigrate(&product.SizeValue{}); err != nil {
 			panic("Falló migración SizeValue: " + err.Error())
 		}
+		if err := db.AutoMigrate(&product.SizeCurve{}, &product.SizeCurveItem{}); err != nil {
+			panic("Falló migración SizeCurve: " + err.Error())
+		}
 		if err := db.AutoMigrate(&product.Color{}); err != nil {
 			panic("Falló migración Color: " + err.Error())
 		}
@@ -93,6 +128,10 @@ func main() {
 		if err := db.AutoMigrate(&order.BestsellerSnapshot{}); err != nil {
 			panic("Falló migración BestsellerSnapshot: " + err.Error())
 		}
+		// Product recommendations (bought together / similar)
+		if err := db.AutoMigrate(&order.ProductRecommendation{}); err != nil {
+			panic("Falló migración ProductRecommendation: " + err.Error())
+		}
 		// Round-robin state for seller assignment
 		if err := db.AutoMigrate(&order.RoundRobinState{}); err != nil {
 			panic("Falló migración RoundRobinState: " + err.Error())
@@ -108,6 +147,33 @@ func main() {
 		if err := db.AutoMigrate(&notification.Notification{}); err != nil {
 			panic("Falló migración Notification: " + err.Error())
 		}
+		// Secuencias de numeración de documentos
+		if err := db.AutoMigrate(&sequence.Sequence{}); err != nil {
+			panic("Falló migración Sequence: " + err.Error())
+		}
+		// Puntos de reposición y alertas de stock
+		if err := db.AutoMigrate(&product.ReorderPoint{}); err != nil {
+			panic("Falló migración ReorderPoint: " + err.Error())
+		}
+		if err := db.AutoMigrate(&inventory.StockAlert{}); err != nil {
+			panic("Falló migración StockAlert: " + err.Error())
+		}
+		// Órdenes de compra y recepciones
+		if err := db.AutoMigrate(&purchase.PurchaseOrder{}, &purchase.PurchaseOrderLine{}, &purchase.GoodsReceipt{}, &purchase.GoodsReceiptLine{}); err != nil {
+			panic("Falló migración PurchaseOrder: " + err.Error())
+		}
+		// Devoluciones (RMA) y notas de crédito
+		if err := db.AutoMigrate(&returns.Return{}, &returns.ReturnItem{}, &returns.CreditNote{}); err != nil {
+			panic("Falló migración Return: " + err.Error())
+		}
+		// Importaciones de catálogo
+		if err := db.AutoMigrate(&catalogimport.ImportJob{}); err != nil {
+			panic("Falló migración ImportJob: " + err.Error())
+		}
+		// Lotes de exportación a Zoologic
+		if err := db.AutoMigrate(&zoologic.ExportFormat{}, &zoologic.ExportBatch{}, &zoologic.ExportBatchMovement{}, &zoologic.ExportBatchOrder{}); err != nil {
+			panic("Falló migración ExportBatch: " + err.Error())
+		}
 	}
 
 	// 3. Crear usuario admin si no existe
@@ -137,8 +203,28 @@ func main() {
 	// Start background snapshotter (runs every hour)
 	order.StartBestsellerSnapshotter(time.Hour)
 
+	// Start product recommendations job (runs every 6 hours)
+	order.StartRecommendationsJob(6 * time.Hour)
+
 	// Start cart expiration job (runs every 2 hours)
 	cart.StartCartExpirationJob(2 * time.Hour)
 
+	// Start stock alert job (runs every hour)
+	inventory.StartStockAlertJob(time.Hour)
+
+	// Start season status job: upcoming -> current -> clearance -> closed by date (runs every hour)
+	product.StartSeasonStatusJob(time.Hour)
+
+	// Start Zoologic export job (default every 24 hours; ZOOLOGIC_EXPORT_INTERVAL=0 disables it)
+	zoologicInterval := 24 * time.Hour
+	if v := os.Getenv("ZOOLOGIC_EXPORT_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			zoologicInterval = d
+		}
+	}
+	if zoologicInterval > 0 {
+		zoologic.StartZoologicExportJob(zoologicInterval)
+	}
+
 	router.Run(":8080")
 }
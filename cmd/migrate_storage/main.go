@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/storage"
+	"go-modaMayor/internal/storage/migrate"
+)
+
+// Copia los archivos de la carpeta local de uploads al almacenamiento configurado
+// (STORAGE_DRIVER=s3 y variables S3_*) y reescribe las URLs en la base.
+// Uso: go run ./cmd/migrate_storage [-dry-run] [-delete-source]
+func main() {
+	dryRun := flag.Bool("dry-run", false, "sólo informar qué se copiaría")
+	deleteSource := flag.Bool("delete-source", false, "borrar los archivos locales copiados")
+	flag.Parse()
+
+	dst, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Configuración de almacenamiento inválida: %v", err)
+	}
+	if _, ok := dst.(*storage.Local); ok {
+		log.Fatalf("El destino es el disco local: configure STORAGE_DRIVER=s3 y las variables S3_*")
+	}
+	src := storage.NewLocal(storage.UploadDir(), storage.LocalURLPrefix)
+
+	db := config.ConnectDatabase()
+	report, err := migrate.Run(context.Background(), db, src, dst, migrate.Options{DryRun: *dryRun, DeleteSource: *deleteSource})
+	if err != nil {
+		log.Fatalf("Error en la migración: %v", err)
+	}
+
+	for _, url := range report.Missing {
+		fmt.Printf("archivo faltante: %s\n", url)
+	}
+	for table, n := range report.RowsUpdated {
+		fmt.Printf("%s: %d filas actualizadas\n", table, n)
+	}
+	fmt.Printf("Archivos copiados: %d, faltantes: %d, borrados del origen: %d\n", report.FilesCopied, len(report.Missing), report.DeletedFiles)
+	if *dryRun {
+		fmt.Println("(dry-run: no se copió ni se actualizó nada)")
+	}
+}
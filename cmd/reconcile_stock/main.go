@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/inventory"
+)
+
+// Conciliación del libro de movimientos de stock contra location_stocks.
+// Uso: go run ./cmd/reconcile_stock [-fix]
+func main() {
+	fix := flag.Bool("fix", false, "registrar movimientos de ajuste y corregir reservas")
+	flag.Parse()
+
+	db := config.ConnectDatabase()
+	report, err := inventory.ReconcileStock(db, *fix, nil, "reconcile_stock")
+	if err != nil {
+		log.Fatalf("Error en la conciliación: %v", err)
+	}
+
+	for _, d := range report.Discrepancies {
+		variant := "-"
+		if d.VariantID != nil {
+			variant = fmt.Sprintf("%d", *d.VariantID)
+		}
+		fmt.Printf("producto=%d variante=%s ubicación=%s stock=%d libro=%d dif=%d reservado=%d carritos=%d dif_reserva=%d",
+			d.ProductID, variant, d.Location, d.Stock, d.LedgerStock, d.StockDiff, d.Reserved, d.OpenCartReserved, d.ReservedDiff)
+		if d.MissingRow {
+			fmt.Print(" (sin fila en location_stocks)")
+		}
+		if d.Fixed {
+			fmt.Print(" [corregido]")
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Filas revisadas: %d, diferencias de stock: %d, diferencias de reserva: %d, corregidas: %d\n",
+		report.RowsChecked, report.StockMismatches, report.ReservedMismatches, report.Fixed)
+}
@@ -39,6 +39,8 @@ export default function NuevoProductoPage() {
   const [isFeatured, setIsFeatured] = useState(false);
   const [isOffer, setIsOffer] = useState(false);
   const [isTrending, setIsTrending] = useState(false);
+  // Estado inicial: publicado (visible en la tienda) o borrador
+  const [status, setStatus] = useState<'published' | 'draft'>('published');
   const [step, setStep] = useState<number>(1);
   const [createdProductId, setCreatedProductId] = useState<number | null>(null);
   const [variants, setVariants] = useState<any[]>([]);
@@ -85,6 +87,7 @@ export default function NuevoProductoPage() {
       isFeatured,
       isOffer,
       isTrending,
+      status,
       createdProductId,
       selectedSizes,
       skuPrefix,
@@ -140,6 +143,7 @@ export default function NuevoProductoPage() {
     setIsFeatured(state.isFeatured || false);
     setIsOffer(state.isOffer || false);
     setIsTrending(state.isTrending || false);
+    setStatus(state.status === 'draft' ? 'draft' : 'published');
     setCreatedProductId(state.createdProductId || null);
     setSelectedSizes(state.selectedSizes || []);
     setSkuPrefix(state.skuPrefix || '');
@@ -451,6 +455,7 @@ export default function NuevoProductoPage() {
               is_featured: isFeatured,
               is_offer: isOffer,
               is_trending: isTrending,
+              status,
             }
         })()),
       });
@@ -1030,6 +1035,18 @@ export default function NuevoProductoPage() {
           </p>
         </div>
 
+        <div>
+          <label className="block text-sm font-semibold mb-1 text-gray-900">Estado</label>
+          <select
+            value={status}
+            onChange={(e) => setStatus(e.target.value as 'published' | 'draft')}
+            className="border rounded px-2 py-1 text-gray-900"
+          >
+            <option value="published">Publicado (visible en la tienda)</option>
+            <option value="draft">Borrador (no se muestra hasta publicarlo)</option>
+          </select>
+        </div>
+
         <div className="border rounded p-4 bg-gray-50">
           <label className="block text-sm font-semibold mb-3 text-gray-900">Tags para el Home</label>
           <div className="grid grid-cols-2 gap-3">
@@ -0,0 +1,204 @@
+package barcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Simbologías soportadas
+const (
+	TypeEAN13   = "ean13"
+	TypeCode128 = "code128"
+)
+
+// QuietZone son los módulos en blanco que se dejan a cada lado al dibujar
+const QuietZone = 10
+
+// maxCode128Length limita los códigos importados para que entren en una etiqueta
+const maxCode128Length = 48
+
+// EAN13CheckDigit calcula el dígito verificador de los 12 primeros dígitos
+func EAN13CheckDigit(data string) (byte, error) {
+	if len(data) != 12 || !isDigits(data) {
+		return 0, fmt.Errorf("se esperaban 12 dígitos, se recibió '%s'", data)
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(data[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// ValidEAN13 indica si el código tiene 13 dígitos y el verificador es correcto
+func ValidEAN13(code string) bool {
+	if len(code) != 13 {
+		return false
+	}
+	check, err := EAN13CheckDigit(code[:12])
+	return err == nil && check == code[12]
+}
+
+// Detect devuelve la simbología con la que se imprime un código: EAN-13 si son
+// 13 dígitos (con verificador válido) y Code128 para cualquier otro texto ASCII.
+func Detect(code string) (string, error) {
+	if code == "" {
+		return "", fmt.Errorf("código vacío")
+	}
+	if len(code) == 13 && isDigits(code) {
+		if !ValidEAN13(code) {
+			return "", fmt.Errorf("el EAN-13 '%s' tiene el dígito verificador incorrecto", code)
+		}
+		return TypeEAN13, nil
+	}
+	if len(code) > maxCode128Length {
+		return "", fmt.Errorf("el código '%s' supera los %d caracteres", code, maxCode128Length)
+	}
+	for _, r := range code {
+		if r < 32 || r > 126 {
+			return "", fmt.Errorf("el código '%s' tiene caracteres no imprimibles", code)
+		}
+	}
+	return TypeCode128, nil
+}
+
+// Encode devuelve los módulos (true = barra) del código en la simbología indicada
+func Encode(code, symbology string) ([]bool, error) {
+	switch symbology {
+	case TypeEAN13:
+		return EncodeEAN13(code)
+	case TypeCode128:
+		return EncodeCode128(code)
+	}
+	return nil, fmt.Errorf("simbología desconocida: %s", symbology)
+}
+
+var (
+	ean13L = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	ean13G = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	ean13R = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+	// paridad de los 6 dígitos izquierdos según el primer dígito
+	ean13Parity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EncodeEAN13 codifica un EAN-13 válido en sus 95 módulos
+func EncodeEAN13(code string) ([]bool, error) {
+	if !ValidEAN13(code) {
+		return nil, fmt.Errorf("EAN-13 inválido: '%s'", code)
+	}
+	var sb strings.Builder
+	sb.WriteString("101")
+	parity := ean13Parity[code[0]-'0']
+	for i := 1; i <= 6; i++ {
+		d := code[i] - '0'
+		if parity[i-1] == 'L' {
+			sb.WriteString(ean13L[d])
+		} else {
+			sb.WriteString(ean13G[d])
+		}
+	}
+	sb.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		sb.WriteString(ean13R[code[i]-'0'])
+	}
+	sb.WriteString("101")
+	return bitsFromString(sb.String()), nil
+}
+
+// code128Patterns son los anchos barra/espacio de cada símbolo (0-105) y el stop (106)
+var code128Patterns = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// EncodeCode128 codifica texto ASCII imprimible con el juego B de Code128
+func EncodeCode128(text string) ([]bool, error) {
+	if text == "" {
+		return nil, fmt.Errorf("código vacío")
+	}
+	values := []int{code128StartB}
+	checksum := code128StartB
+	for i, r := range text {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("carácter no soportado en Code128: %q", r)
+		}
+		v := int(r) - 32
+		values = append(values, v)
+		checksum += v * (i + 1)
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var bits []bool
+	for _, v := range values {
+		bar := true
+		for _, w := range code128Patterns[v] {
+			for n := 0; n < int(w-'0'); n++ {
+				bits = append(bits, bar)
+			}
+			bar = !bar
+		}
+	}
+	return bits, nil
+}
+
+// PNG dibuja los módulos con la zona de silencio a cada lado
+func PNG(w io.Writer, modules []bool, moduleWidth, height int) error {
+	if moduleWidth < 1 {
+		moduleWidth = 1
+	}
+	width := (len(modules) + 2*QuietZone) * moduleWidth
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for i, bar := range modules {
+		if !bar {
+			continue
+		}
+		x0 := (QuietZone + i) * moduleWidth
+		for x := x0; x < x0+moduleWidth; x++ {
+			for y := 0; y < height; y++ {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+func bitsFromString(s string) []bool {
+	bits := make([]bool, len(s))
+	for i := range s {
+		bits[i] = s[i] == '1'
+	}
+	return bits
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,76 @@
+package barcode
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestEAN13CheckDigit(t *testing.T) {
+	for data, expected := range map[string]byte{"400638133393": '1', "779123456789": '8', "200000000001": '5'} {
+		got, err := EAN13CheckDigit(data)
+		if err != nil || got != expected {
+			t.Fatalf("EAN13CheckDigit(%s) = %c, %v; expected %c", data, got, err, expected)
+		}
+	}
+	if ValidEAN13("4006381333932") {
+		t.Fatalf("expected wrong check digit to be rejected")
+	}
+	if _, err := Detect("4006381333932"); err == nil {
+		t.Fatalf("expected Detect to reject an EAN-13 with a bad check digit")
+	}
+}
+
+func TestEncodeEAN13(t *testing.T) {
+	bits, err := EncodeEAN13("4006381333931")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bits) != 95 {
+		t.Fatalf("expected 95 modules, got %d", len(bits))
+	}
+	// guardas de inicio, centro y fin
+	for _, idx := range []int{0, 2, 46, 48, 92, 94} {
+		if !bits[idx] {
+			t.Fatalf("expected bar at module %d", idx)
+		}
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	for i, p := range code128Patterns[:106] {
+		sum := 0
+		for _, w := range p {
+			sum += int(w - '0')
+		}
+		if sum != 11 {
+			t.Fatalf("pattern %d has %d modules", i, sum)
+		}
+	}
+	bits, err := EncodeCode128("PROV-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// start + 8 caracteres + checksum = 10 símbolos de 11 módulos, stop de 13
+	if len(bits) != 10*11+13 {
+		t.Fatalf("unexpected module count %d", len(bits))
+	}
+	if typ, err := Detect("PROV-123"); err != nil || typ != TypeCode128 {
+		t.Fatalf("expected code128, got %s %v", typ, err)
+	}
+	if _, err := EncodeCode128("ñandú"); err == nil {
+		t.Fatalf("expected non-ASCII text to be rejected")
+	}
+
+	var buf bytes.Buffer
+	if err := PNG(&buf, bits, 2, 40); err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if img.Bounds().Dx() != (len(bits)+2*QuietZone)*2 || img.Bounds().Dy() != 40 {
+		t.Fatalf("unexpected image size %v", img.Bounds())
+	}
+}
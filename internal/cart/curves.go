@@ -0,0 +1,260 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// curveGroupKey identifica las líneas de una misma curva y color dentro del carrito
+func curveGroupKey(productID, curveID uint, color string) string {
+	return fmt.Sprintf("%d:%d:%s", productID, curveID, strings.ToLower(strings.TrimSpace(color)))
+}
+
+// confirmedItems filtra los items con stock confirmado (los que cuentan para el tier)
+func confirmedItems(items []CartItem) []CartItem {
+	out := make([]CartItem, 0, len(items))
+	for _, it := range items {
+		if it.StockConfirmed {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// countCurves cuenta las curvas completas del carrito: por cada grupo, el mínimo de
+// Quantity/CurveUnits entre sus talles. Si falta alguna línea de la curva (porque se
+// quitó un talle) el grupo no suma curvas.
+func countCurves(db *gorm.DB, items []CartItem) int {
+	type group struct {
+		curveID uint
+		lines   int
+		curves  int
+	}
+	groups := map[string]*group{}
+	for _, it := range items {
+		if it.CurveGroup == "" || it.CurveID == nil || it.CurveUnits <= 0 {
+			continue
+		}
+		n := it.Quantity / it.CurveUnits
+		g, ok := groups[it.CurveGroup]
+		if !ok {
+			groups[it.CurveGroup] = &group{curveID: *it.CurveID, lines: 1, curves: n}
+			continue
+		}
+		g.lines++
+		if n < g.curves {
+			g.curves = n
+		}
+	}
+	if len(groups) == 0 {
+		return 0
+	}
+
+	curveIDs := make([]uint, 0, len(groups))
+	for _, g := range groups {
+		curveIDs = append(curveIDs, g.curveID)
+	}
+	// Unscoped: una curva dada de baja sigue contando para los carritos que ya la tienen
+	var sizes []struct {
+		CurveID uint
+		Sizes   int
+	}
+	if err := db.Unscoped().Model(&product.SizeCurveItem{}).
+		Select("curve_id, COUNT(*) as sizes").
+		Where("curve_id IN ?", curveIDs).
+		Group("curve_id").
+		Scan(&sizes).Error; err != nil {
+		log.Printf("⚠️ Error contando talles de curvas: %v", err)
+		return 0
+	}
+	expected := map[uint]int{}
+	for _, s := range sizes {
+		expected[s.CurveID] = s.Sizes
+	}
+
+	total := 0
+	for _, g := range groups {
+		if g.lines >= expected[g.curveID] {
+			total += g.curves
+		}
+	}
+	return total
+}
+
+// AddCurveToCartInput agrega N curvas de un color como líneas por variante
+type AddCurveToCartInput struct {
+	ProductID          uint   `json:"product_id" binding:"required,gt=0"`
+	CurveID            uint   `json:"curve_id" binding:"required,gt=0"`
+	Color              string `json:"color"`
+	ColorID            *uint  `json:"color_id"`
+	Quantity           int    `json:"quantity" binding:"required,gt=0"` // cantidad de curvas
+	RequiresStockCheck bool   `json:"requires_stock_check,omitempty"`
+	// Optional location to reserve from (only used by sellers)
+	Location string `json:"location,omitempty"`
+}
+
+// addToCartError es un rechazo de la operación que corresponde a un 400
+type addToCartError struct{ msg string }
+
+func (e *addToCartError) Error() string { return e.msg }
+
+// reserveLocationStock reserva delta unidades de la variante en la ubicación si hay stock libre
+func reserveLocationStock(tx *gorm.DB, productID, variantID uint, location string, delta int) error {
+	res := tx.Model(&product.LocationStock{}).
+		Where("product_id = ? AND variant_id = ? AND location = ? AND stock - reserved >= ?", productID, variantID, location, delta).
+		Update("reserved", gorm.Expr("reserved + ?", delta))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return &addToCartError{msg: "Stock insuficiente en la ubicación seleccionada"}
+	}
+	return nil
+}
+
+// POST /cart/add-curve
+// Agrega una o más curvas de talles de un color al carrito en una sola acción.
+// Cada talle queda como una línea de variante marcada con la curva, así el resto del
+// flujo (stock, remitos, órdenes) sigue trabajando por variante.
+func AddCurveToCart(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
+		return
+	}
+	var input AddCurveToCartInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var prod product.Product
+	if err := config.DB.First(&prod, input.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if !prod.IsPurchasable(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El producto no está disponible para la venta"})
+		return
+	}
+
+	curve, lines, err := product.ResolveCurve(config.DB, input.ProductID, input.CurveID, input.ColorID, input.Color)
+	if err != nil {
+		var curveErr *product.CurveError
+		if errors.As(err, &curveErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Curva inválida para el producto", "errors": curveErr.Messages})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Location != "" {
+		locations, _, err := product.CurveAvailability(config.DB, input.ProductID, lines)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		available := 0
+		for _, l := range locations {
+			if l.Location == input.Location {
+				available = l.Curves
+			}
+		}
+		if available < input.Quantity {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":            "Stock insuficiente en la ubicación seleccionada",
+				"available_curves": available,
+			})
+			return
+		}
+	}
+
+	cart, ok := cartForAdd(c, userID)
+	if !ok {
+		return
+	}
+
+	group := curveGroupKey(input.ProductID, curve.ID, lines[0].Variant.Color)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, line := range lines {
+			variantID := line.Variant.ID
+			delta := line.Units * input.Quantity
+
+			var item CartItem
+			err := tx.Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, input.ProductID, variantID).First(&item).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			isNew := errors.Is(err, gorm.ErrRecordNotFound)
+			if isNew {
+				item = CartItem{CartID: cart.ID, ProductID: input.ProductID, VariantID: &variantID}
+			} else if item.CurveGroup != "" && item.CurveGroup != group {
+				return &addToCartError{msg: fmt.Sprintf("El talle %s ya está en el carrito como parte de otra curva", line.Size)}
+			}
+
+			if input.Location != "" {
+				if item.Location != "" && item.Location != input.Location {
+					return &addToCartError{msg: fmt.Sprintf("El talle %s ya está reservado en otra ubicación", line.Size)}
+				}
+				if err := reserveLocationStock(tx, input.ProductID, variantID, input.Location, delta); err != nil {
+					return err
+				}
+				item.Location = input.Location
+				item.ReservedQuantity += delta
+			}
+
+			item.Quantity += delta
+			curveID := curve.ID
+			item.CurveID = &curveID
+			item.CurveGroup = group
+			item.CurveUnits = line.Units
+			if input.RequiresStockCheck || item.RequiresStockCheck {
+				item.RequiresStockCheck = true
+				item.StockConfirmed = checkStockAvailability(input.ProductID, &variantID, item.Quantity)
+			} else {
+				item.StockConfirmed = true
+			}
+
+			if isNew {
+				err = tx.Create(&item).Error
+			} else {
+				err = tx.Save(&item).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return syncCartItemsToOrder(tx, cart.ID)
+	})
+	if err != nil {
+		var cartErr *addToCartError
+		if errors.As(err, &cartErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": cartErr.msg})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updatedCart Cart
+	if err := config.DB.Preload("Items").Preload("Items.Product").Preload("Items.Variant").First(&updatedCart, cart.ID).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Curva agregada al carrito"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Curva agregada al carrito",
+		"curves":      input.Quantity,
+		"curve_group": group,
+		"cart":        updatedCart,
+	})
+}
@@ -123,14 +123,14 @@ func calculateFrozenTierPrice(existingPrice float64, oldQuantity int, newQuantit
 }
 
 // Helper function to calculate price based on tier (quantity-based pricing)
-func calculatePriceForTier(product product.Product, totalQuantity int) float64 {
+func calculatePriceForTier(product product.Product, totalQuantity, curves int) float64 {
 	// Obtener tiers de la base de datos de forma dinámica
 	var tiers []settings.PriceTier
 	config.DB.Where("active = ?", true).Order("min_quantity DESC").Find(&tiers)
 	
-	// Encontrar el tier aplicable (el más alto que cumpla la cantidad mínima)
+	// Encontrar el tier aplicable (el más alto que cumpla la cantidad mínima o las curvas)
 	for _, tier := range tiers {
-		if totalQuantity >= tier.MinQuantity {
+		if tier.Qualifies(totalQuantity, curves) {
 			// Usar los precios pre-calculados del producto según el tier
 			switch tier.Name {
 			case "discount2":
@@ -196,6 +196,8 @@ func syncCartItemsToOrder(tx *gorm.DB, cartID uint) error {
 	for _, item := range cartItems {
 		newTotalQuantity += item.Quantity
 	}
+	newCurves := countCurves(tx, cartItems)
+	kitPrices := KitLinePrices(tx, cartItems)
 
 	// Extract frozen data from existing order items
 	type FrozenData struct {
@@ -234,13 +236,17 @@ func syncCartItemsToOrder(tx *gorm.DB, cartID uint) error {
 		// Get current product cost
 		currentCost := item.Product.CostPrice
 		
-		if frozenData, exists := existingItems[itemKey]; exists {
+		if kitPrice, ok := kitPrices[item.ID]; ok {
+			// Línea de un kit completo con precio fijo: lleva su parte del precio del kit
+			price = kitPrice
+			baseCost = currentCost
+		} else if frozenData, exists := existingItems[itemKey]; exists {
 			// Item existed before - check if price changed
 			frozenBaseCost := frozenData.BaseCost
 			
 			if frozenBaseCost == currentCost {
 				// NO price change: recalculate with new tier
-				price = calculatePriceForTier(item.Product, newTotalQuantity)
+				price = calculatePriceForTier(item.Product, newTotalQuantity, newCurves)
 				baseCost = currentCost
 				log.Printf("♻️ Recalculando tier (sin cambio de precio) - Producto %d variante %d: $%.2f → $%.2f", 
 					item.ProductID, item.VariantID, frozenData.Price, price)
@@ -253,7 +259,7 @@ func syncCartItemsToOrder(tx *gorm.DB, cartID uint) error {
 			}
 		} else {
 			// New item: calculate price based on current tier
-			price = calculatePriceForTier(item.Product, newTotalQuantity)
+			price = calculatePriceForTier(item.Product, newTotalQuantity, newCurves)
 			baseCost = currentCost
 			log.Printf("🆕 Nuevo item - Costo: $%.2f, Precio con tier: $%.2f", baseCost, price)
 		}
@@ -268,9 +274,9 @@ func syncCartItemsToOrder(tx *gorm.DB, cartID uint) error {
 
 		// Insert into order_items with variant details and base_cost
 		result := tx.Exec(`
-			INSERT INTO order_items (order_id, product_id, variant_id, variant_size, variant_color, quantity, price, base_cost, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
-		`, orderID, item.ProductID, item.VariantID, variantSize, variantColor, item.Quantity, price, baseCost)
+			INSERT INTO order_items (order_id, product_id, variant_id, variant_size, variant_color, quantity, price, base_cost, kit_id, kit_group, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		`, orderID, item.ProductID, item.VariantID, variantSize, variantColor, item.Quantity, price, baseCost, item.KitID, item.KitGroup)
 
 		if result.Error != nil {
 			return result.Error
@@ -343,67 +349,17 @@ func checkStockAvailability(productID uint, variantID *uint, quantity int) bool
 	return int(totalStock) >= quantity
 }
 
-// Agregar producto al carrito
-type AddToCartInput struct {
-	ProductID          uint   `json:"product_id" binding:"required,gt=0"`
-	VariantID          *uint  `json:"variant_id"` // Opcional - se buscará automáticamente si no se envía
-	Quantity           int    `json:"quantity" binding:"required,gt=0"`
-	RequiresStockCheck bool   `json:"requires_stock_check,omitempty"`
-	// Optional location to reserve from (only used by sellers)
-	Location string `json:"location,omitempty"`
-}
-
-func AddToCart(c *gin.Context) {
-	userID, ok := getUserID(c)
-	if !ok {
-		// Usuario no autenticado: devolver respuesta indicando que debe loguearse
-		// El frontend manejará el carrito en localStorage temporalmente
-		c.JSON(http.StatusOK, gin.H{
-			"message":         "Para guardar el carrito y realizar la compra, por favor inicia sesión",
-			"requires_login":  true,
-			"cart_local_only": true,
-		})
-		return
-	}
-	var input AddToCartInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Printf("❌ AddToCart - Error parsing JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	log.Printf("✅ AddToCart - Input recibido: product_id=%d, variant_id=%v, quantity=%d, requires_stock_check=%v",
-		input.ProductID, input.VariantID, input.Quantity, input.RequiresStockCheck)
-	
-	// Si no se envió variant_id, intentar buscar la primera variante disponible del producto
-	var variantID *uint
-	if input.VariantID == nil || *input.VariantID == 0 {
-		var variant product.ProductVariant
-		if err := config.DB.Where("product_id = ? AND deleted_at IS NULL", input.ProductID).
-			Order("id ASC").First(&variant).Error; err != nil {
-			// Si no hay variantes, usar NULL (productos sin variantes)
-			if err == gorm.ErrRecordNotFound {
-				variantID = nil
-				log.Printf("📦 AddToCart - Producto sin variantes, usando variant_id=NULL")
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar variantes del producto"})
-				return
-			}
-		} else {
-			variantID = &variant.ID
-			log.Printf("📦 AddToCart - Variante no especificada, usando primera disponible: variant_id=%d", variant.ID)
-		}
-	} else {
-		variantID = input.VariantID
-	}
-	
-	var cart Cart
+// cartForAdd obtiene el carrito al que se agregan productos: el indicado en ?cart_id
+// (vendedora/admin) o el carrito activo del usuario, creando uno si hace falta.
+// Si no se puede usar responde el error y devuelve ok=false.
+func cartForAdd(c *gin.Context, userID uint) (cart Cart, ok bool) {
 	// allow specifying cart_id for seller/admin actions
 	cartIDStr := c.Query("cart_id")
 	if cartIDStr != "" {
 		// try to load by ID
 		if err := config.DB.First(&cart, cartIDStr).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Carrito no encontrado"})
-			return
+			return Cart{}, false
 		}
 		// permiso: si es vendedor o cliente validar acceso
 		roleIfc, _ := c.Get("user_role")
@@ -411,12 +367,12 @@ func AddToCart(c *gin.Context) {
 		if roleIfc == "vendedor" {
 			if cart.VendedorID != userIDIfc.(uint) {
 				c.JSON(http.StatusForbidden, gin.H{"error": "No tienes acceso a este carrito"})
-				return
+				return Cart{}, false
 			}
 		} else if roleIfc == "cliente" || roleIfc == "" {
 			if cart.UserID != userIDIfc.(uint) {
 				c.JSON(http.StatusForbidden, gin.H{"error": "No tienes acceso a este carrito"})
-				return
+				return Cart{}, false
 			}
 		}
 	} else {
@@ -432,11 +388,11 @@ func AddToCart(c *gin.Context) {
 				cart = Cart{UserID: userID, Estado: "edicion"}
 				if err := config.DB.Create(&cart).Error; err != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-					return
+					return Cart{}, false
 				}
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
+				return Cart{}, false
 			}
 		}
 	}
@@ -446,7 +402,7 @@ func AddToCart(c *gin.Context) {
 		// Si se especificó cart_id (vendedora agregando a carrito de cliente), no permitir
 		if cartIDStr != "" {
 			c.JSON(http.StatusForbidden, gin.H{"error": "No se puede modificar el carrito en estado: " + cart.Estado})
-			return
+			return Cart{}, false
 		}
 		// Si es el carrito propio del usuario y está finalizado, crear uno nuevo
 		if cart.Estado == "completado" || cart.Estado == "pagado" || cart.Estado == "listo_para_pago" {
@@ -454,14 +410,85 @@ func AddToCart(c *gin.Context) {
 			newCart := Cart{UserID: userID, Estado: "pendiente"}
 			if err := config.DB.Create(&newCart).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear un nuevo carrito"})
-				return
+				return Cart{}, false
 			}
 			cart = newCart
 		} else {
 			c.JSON(http.StatusForbidden, gin.H{"error": "No se puede modificar el carrito en este estado"})
-			return
+			return Cart{}, false
 		}
 	}
+	return cart, true
+}
+
+// Agregar producto al carrito
+type AddToCartInput struct {
+	ProductID          uint   `json:"product_id" binding:"required,gt=0"`
+	VariantID          *uint  `json:"variant_id"` // Opcional - se buscará automáticamente si no se envía
+	Quantity           int    `json:"quantity" binding:"required,gt=0"`
+	RequiresStockCheck bool   `json:"requires_stock_check,omitempty"`
+	// Optional location to reserve from (only used by sellers)
+	Location string `json:"location,omitempty"`
+}
+
+func AddToCart(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		// Usuario no autenticado: devolver respuesta indicando que debe loguearse
+		// El frontend manejará el carrito en localStorage temporalmente
+		c.JSON(http.StatusOK, gin.H{
+			"message":         "Para guardar el carrito y realizar la compra, por favor inicia sesión",
+			"requires_login":  true,
+			"cart_local_only": true,
+		})
+		return
+	}
+	var input AddToCartInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Printf("❌ AddToCart - Error parsing JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("✅ AddToCart - Input recibido: product_id=%d, variant_id=%v, quantity=%d, requires_stock_check=%v",
+		input.ProductID, input.VariantID, input.Quantity, input.RequiresStockCheck)
+
+	// Sólo se venden productos publicados u ocultos (link directo); borradores y archivados no
+	var prod product.Product
+	if err := config.DB.First(&prod, input.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if !prod.IsPurchasable(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El producto no está disponible para la venta"})
+		return
+	}
+	
+	// Si no se envió variant_id, intentar buscar la primera variante disponible del producto
+	var variantID *uint
+	if input.VariantID == nil || *input.VariantID == 0 {
+		var variant product.ProductVariant
+		if err := config.DB.Where("product_id = ? AND deleted_at IS NULL", input.ProductID).
+			Order("id ASC").First(&variant).Error; err != nil {
+			// Si no hay variantes, usar NULL (productos sin variantes)
+			if err == gorm.ErrRecordNotFound {
+				variantID = nil
+				log.Printf("📦 AddToCart - Producto sin variantes, usando variant_id=NULL")
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al buscar variantes del producto"})
+				return
+			}
+		} else {
+			variantID = &variant.ID
+			log.Printf("📦 AddToCart - Variante no especificada, usando primera disponible: variant_id=%d", variant.ID)
+		}
+	} else {
+		variantID = input.VariantID
+	}
+	
+	cart, ok := cartForAdd(c, userID)
+	if !ok {
+		return
+	}
 	var item CartItem
 	// Buscar item existente, manejando el caso de variant_id NULL
 	query := config.DB.Where("cart_id = ? AND product_id = ?", cart.ID, input.ProductID)
@@ -1121,6 +1148,8 @@ func calculateCartSummaryForSeller(cart Cart) *struct {
 			totalQuantity += item.Quantity
 		}
 	}
+	curves := countCurves(config.DB, confirmedItems(cart.Items))
+	kitPrices := KitLinePrices(config.DB, cart.Items)
 	
 	// Obtener tiers y determinar tier actual
 	var tiers []settings.PriceTier
@@ -1128,7 +1157,7 @@ func calculateCartSummaryForSeller(cart Cart) *struct {
 	
 	var currentTier *settings.PriceTier
 	for i := range tiers {
-		if totalQuantity >= tiers[i].MinQuantity {
+		if tiers[i].Qualifies(totalQuantity, curves) {
 			currentTier = &tiers[i]
 		}
 	}
@@ -1139,7 +1168,10 @@ func calculateCartSummaryForSeller(cart Cart) *struct {
 	
 	for _, item := range cart.Items {
 		// Usar la misma lógica que GetCartSummary para consistencia
-		unitPrice := calculatePriceForTier(item.Product, totalQuantity)
+		unitPrice := calculatePriceForTier(item.Product, totalQuantity, curves)
+		if kitPrice, ok := kitPrices[item.ID]; ok {
+			unitPrice = kitPrice
+		}
 		
 		itemSubtotal := unitPrice * float64(item.Quantity)
 		// Solo sumar al subtotal los items confirmados
@@ -1625,6 +1657,7 @@ func GetCartSummary(c *gin.Context) {
 		Percentage   float64 `json:"percentage"`
 		FlatAmount   float64 `json:"flat_amount"`
 		MinQuantity  int     `json:"min_quantity"`
+		MinCurves    int     `json:"min_curves"`
 		OrderIndex   int     `json:"order_index"`
 		Active       bool    `json:"active"`
 		IsDefault    bool    `json:"is_default"`
@@ -1646,7 +1679,9 @@ func GetCartSummary(c *gin.Context) {
 			totalQuantity += item.Quantity
 		}
 	}
-	log.Printf("🔔 GetCartSummary - Total quantity calculated (confirmed only): %d", totalQuantity)
+	curves := countCurves(config.DB, confirmedItems(cart.Items))
+	kitPrices := KitLinePrices(config.DB, cart.Items)
+	log.Printf("🔔 GetCartSummary - Total quantity calculated (confirmed only): %d, curvas completas: %d", totalQuantity, curves)
 
 	// Encontrar el tier aplicable
 	var applicableTier *struct {
@@ -1658,6 +1693,7 @@ func GetCartSummary(c *gin.Context) {
 		Percentage   float64 `json:"percentage"`
 		FlatAmount   float64 `json:"flat_amount"`
 		MinQuantity  int     `json:"min_quantity"`
+		MinCurves    int     `json:"min_curves"`
 		OrderIndex   int     `json:"order_index"`
 		Active       bool    `json:"active"`
 		IsDefault    bool    `json:"is_default"`
@@ -1667,7 +1703,7 @@ func GetCartSummary(c *gin.Context) {
 
 	for i := range tiers {
 		tier := &tiers[i]
-		if totalQuantity >= tier.MinQuantity {
+		if totalQuantity >= tier.MinQuantity || (tier.MinCurves > 0 && curves >= tier.MinCurves) {
 			if applicableTier == nil || tier.MinQuantity > applicableTier.MinQuantity {
 				applicableTier = tier
 			}
@@ -1696,6 +1732,8 @@ func GetCartSummary(c *gin.Context) {
 		UnitPrice   float64 `json:"unit_price"`
 		Subtotal    float64 `json:"subtotal"`
 		ImageURL    string  `json:"image_url"`
+		KitID       *uint   `json:"kit_id,omitempty"`
+		KitGroup    string  `json:"kit_group,omitempty"`
 	}
 
 	items := make([]ItemSummary, 0)
@@ -1703,7 +1741,10 @@ func GetCartSummary(c *gin.Context) {
 
 	for _, item := range cart.Items {
 		// Usar la misma lógica que syncCartItemsToOrder para consistencia
-		unitPrice := calculatePriceForTier(item.Product, totalQuantity)
+		unitPrice := calculatePriceForTier(item.Product, totalQuantity, curves)
+		if kitPrice, ok := kitPrices[item.ID]; ok {
+			unitPrice = kitPrice
+		}
 		costPrice := item.Product.CostPrice
 
 		itemSubtotal := unitPrice * float64(item.Quantity)
@@ -1733,6 +1774,8 @@ func GetCartSummary(c *gin.Context) {
 			UnitPrice:   unitPrice,
 			Subtotal:    itemSubtotal,
 			ImageURL:    imageURL,
+			KitID:       item.KitID,
+			KitGroup:    item.KitGroup,
 		})
 	}
 
@@ -1763,6 +1806,7 @@ func GetCartSummary(c *gin.Context) {
 	response := gin.H{
 		"cart_id":        cart.ID,
 		"total_quantity": totalQuantity,
+		"total_curves":   curves,
 		"subtotal":       subtotal,
 		"items":          items,
 		"tier":           applicableTier,
@@ -1996,7 +2040,7 @@ func generarRemitosInternosParaCarrito(tx *gorm.DB, cartID uint, items []CartIte
 	// Generar un remito por cada ubicación de origen
 	for ubicacion, itemsUbicacion := range itemsPorUbicacion {
 		// Generar número único
-		numero, err := remito.GenerarNumeroRemito()
+		numero, err := remito.GenerarNumeroRemito(tx)
 		if err != nil {
 			return fmt.Errorf("error al generar número de remito: %v", err)
 		}
@@ -0,0 +1,244 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"go-modaMayor/config"
+	"go-modaMayor/internal/kit"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// kitGroupKey identifica las líneas de un mismo kit dentro del carrito. Incluye las
+// variantes elegidas: el mismo kit con otros talles/colores es otro grupo.
+func kitGroupKey(kitID uint, lines []kit.Line) string {
+	ids := make([]string, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, strconv.FormatUint(uint64(l.Variant.ID), 10))
+	}
+	sort.Strings(ids)
+	return fmt.Sprintf("kit:%d:%s", kitID, strings.Join(ids, "-"))
+}
+
+// KitLinePrices devuelve, por ID de item, el precio unitario de las líneas que vienen de
+// un kit con precio fijo. El kit mantiene su precio sólo mientras esté completo (todas sus
+// variantes con la misma cantidad de kits); si se quitó o editó una línea, el grupo vuelve
+// al precio por tier como cualquier prenda.
+func KitLinePrices(db *gorm.DB, items []CartItem) map[uint]float64 {
+	groups := map[string][]CartItem{}
+	for _, it := range items {
+		if it.KitID != nil && it.KitGroup != "" && it.VariantID != nil {
+			groups[it.KitGroup] = append(groups[it.KitGroup], it)
+		}
+	}
+	out := map[uint]float64{}
+	for _, lines := range groups {
+		var k kit.Kit
+		if err := db.Preload("Items").First(&k, *lines[0].KitID).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("⚠️ Error cargando kit %d: %v", *lines[0].KitID, err)
+			}
+			continue
+		}
+		if k.Price <= 0 {
+			continue
+		}
+		variantIDs := make([]uint, 0, len(lines))
+		known := []kit.Choice{}
+		for _, it := range lines {
+			variantIDs = append(variantIDs, *it.VariantID)
+			if it.KitItemID != nil {
+				known = append(known, kit.Choice{ItemID: *it.KitItemID, VariantID: *it.VariantID})
+			}
+		}
+		choices, err := kit.ChoicesFor(db, &k, variantIDs, known)
+		if err != nil {
+			log.Printf("⚠️ Error reconstruyendo elecciones del kit %d: %v", k.ID, err)
+			continue
+		}
+		kitLines, err := kit.ResolveLines(db, &k, choices)
+		if err != nil || len(kitLines) != len(lines) {
+			continue
+		}
+		byVariant := map[uint]CartItem{}
+		for _, it := range lines {
+			byVariant[*it.VariantID] = it
+		}
+		kits := -1
+		for _, l := range kitLines {
+			it, ok := byVariant[l.Variant.ID]
+			if !ok || it.Quantity%l.Units != 0 || (kits >= 0 && it.Quantity/l.Units != kits) {
+				kits = -1
+				break
+			}
+			kits = it.Quantity / l.Units
+		}
+		if kits <= 0 {
+			continue
+		}
+		prices := kit.ComponentPrices(&k, kitLines)
+		for _, it := range lines {
+			out[it.ID] = prices[*it.VariantID]
+		}
+	}
+	return out
+}
+
+// AddKitToCartInput agrega N kits como líneas por variante de sus componentes
+type AddKitToCartInput struct {
+	KitID              uint `json:"kit_id" binding:"required,gt=0"`
+	Quantity           int  `json:"quantity" binding:"required,gt=0"` // cantidad de kits
+	RequiresStockCheck bool `json:"requires_stock_check,omitempty"`
+	// Variante elegida para cada slot del kit (items sin variante fija)
+	Choices []kit.Choice `json:"choices"`
+	// Optional location to reserve from (only used by sellers)
+	Location string `json:"location,omitempty"`
+}
+
+// POST /cart/add-kit
+// Agrega uno o más kits al carrito. Como las curvas, cada componente queda como una
+// línea de variante marcada con el kit, así las reservas, remitos y órdenes siguen
+// trabajando por variante; el precio fijo del kit se reparte entre sus líneas.
+func AddKitToCart(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No autorizado"})
+		return
+	}
+	var input AddKitToCartInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var k kit.Kit
+	if err := config.DB.Preload("Items").Where("active = ?", true).First(&k, input.KitID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kit no encontrado"})
+		return
+	}
+	lines, err := kit.ResolveLines(config.DB, &k, input.Choices)
+	if err != nil {
+		var kitErr *kit.KitError
+		if errors.As(err, &kitErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El kit no se puede vender", "errors": kitErr.Messages, "unchosen": kitErr.Unchosen})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Con ubicación se valida el stock de esa ubicación; sin ubicación, el de todas.
+	// Si se pide verificación de stock la vendedora lo confirma después.
+	if input.Location != "" || !input.RequiresStockCheck {
+		locations, total, err := kit.ComputeAvailability(config.DB, lines)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		available := total
+		msg := "Stock insuficiente para armar los kits con las variantes elegidas"
+		if input.Location != "" {
+			available = 0
+			msg = "Stock insuficiente en la ubicación seleccionada"
+			for _, l := range locations {
+				if l.Location == input.Location {
+					available = l.Kits
+				}
+			}
+		}
+		if available < input.Quantity {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":          msg,
+				"available_kits": available,
+			})
+			return
+		}
+	}
+
+	cart, ok := cartForAdd(c, userID)
+	if !ok {
+		return
+	}
+
+	group := kitGroupKey(k.ID, lines)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, line := range lines {
+			productID, variantID := line.Product.ID, line.Variant.ID
+			delta := line.Units * input.Quantity
+
+			var item CartItem
+			err := tx.Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, productID, variantID).First(&item).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			isNew := errors.Is(err, gorm.ErrRecordNotFound)
+			if isNew {
+				item = CartItem{CartID: cart.ID, ProductID: productID, VariantID: &variantID}
+			} else if item.KitGroup != group {
+				return &addToCartError{msg: fmt.Sprintf("%s (%s) ya está en el carrito fuera de este kit", line.Product.Name, line.Variant.SKU)}
+			}
+
+			if input.Location != "" {
+				if item.Location != "" && item.Location != input.Location {
+					return &addToCartError{msg: fmt.Sprintf("%s (%s) ya está reservado en otra ubicación", line.Product.Name, line.Variant.SKU)}
+				}
+				if err := reserveLocationStock(tx, productID, variantID, input.Location, delta); err != nil {
+					return err
+				}
+				item.Location = input.Location
+				item.ReservedQuantity += delta
+			}
+
+			item.Quantity += delta
+			kitID := k.ID
+			item.KitID = &kitID
+			item.KitGroup = group
+			item.KitUnits = line.Units
+			kitItemID := line.ItemID
+			item.KitItemID = &kitItemID
+			if input.RequiresStockCheck || item.RequiresStockCheck {
+				item.RequiresStockCheck = true
+				item.StockConfirmed = checkStockAvailability(productID, &variantID, item.Quantity)
+			} else {
+				item.StockConfirmed = true
+			}
+
+			if isNew {
+				err = tx.Create(&item).Error
+			} else {
+				err = tx.Save(&item).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return syncCartItemsToOrder(tx, cart.ID)
+	})
+	if err != nil {
+		var cartErr *addToCartError
+		if errors.As(err, &cartErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": cartErr.msg})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updatedCart Cart
+	if err := config.DB.Preload("Items").Preload("Items.Product").Preload("Items.Variant").First(&updatedCart, cart.ID).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Kit agregado al carrito"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Kit agregado al carrito",
+		"kits":      input.Quantity,
+		"kit_group": group,
+		"cart":      updatedCart,
+	})
+}
@@ -38,4 +38,17 @@ type CartItem struct {
 	ReservedQuantity int `json:"reserved_quantity" gorm:"default:0"`
         // Motivo por el cual el item está pendiente de confirmación de stock
         PendingReason string `json:"pending_reason" gorm:"default:'user_request'"`
+	// Curva de talles desde la que se agregó la línea. Las líneas de una misma
+	// curva y color comparten CurveGroup; CurveUnits son las prendas por curva.
+	CurveID    *uint  `json:"curve_id,omitempty"`
+	CurveGroup string `json:"curve_group,omitempty" gorm:"index"`
+	CurveUnits int    `json:"curve_units,omitempty" gorm:"default:0"`
+	// Kit desde el que se agregó la línea. Las líneas de un mismo kit comparten
+	// KitGroup; KitUnits son las prendas de la variante por kit.
+	KitID    *uint  `json:"kit_id,omitempty"`
+	KitGroup string `json:"kit_group,omitempty" gorm:"index"`
+	KitUnits int    `json:"kit_units,omitempty" gorm:"default:0"`
+	// Item del kit (slot) que eligió esta variante, para reconstruir las elecciones
+	// sin adivinar cuando los slots se superponen
+	KitItemID *uint `json:"kit_item_id,omitempty"`
 }
@@ -0,0 +1,465 @@
+package catalogcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/slug"
+
+	"gorm.io/gorm"
+)
+
+// Severidad de un problema
+const (
+	SeverityError   = "error"   // el producto se ve o se vende mal
+	SeverityWarning = "warning" // dato incompleto o inconsistente
+)
+
+// DefaultLocation es donde se crean las filas de stock faltantes si el producto no tiene otras
+const DefaultLocation = "deposito"
+
+// Issue es un problema encontrado en un producto (o en una de sus variantes)
+type Issue struct {
+	Check       string `json:"check"`
+	Severity    string `json:"severity"`
+	ProductID   uint   `json:"product_id"`
+	ProductCode string `json:"product_code"`
+	ProductName string `json:"product_name"`
+	VariantID   *uint  `json:"variant_id,omitempty"`
+	Message     string `json:"message"`
+	Link        string `json:"link"` // pantalla del admin para corregirlo a mano
+	Fixable     bool   `json:"fixable"`
+	Fixed       bool   `json:"fixed"`
+
+	fix func(tx *gorm.DB) error
+}
+
+// CheckInfo describe un chequeo disponible
+type CheckInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Fixable     bool   `json:"fixable"` // tiene corrección automática (para parte de los casos)
+}
+
+type Report struct {
+	CheckedAt       time.Time      `json:"checked_at"`
+	ProductsChecked int            `json:"products_checked"`
+	Checks          []CheckInfo    `json:"checks"`
+	Counts          map[string]int `json:"counts"`
+	Fixable         int            `json:"fixable"`
+	Fixed           int            `json:"fixed"`
+	Issues          []Issue        `json:"issues"`
+}
+
+type Options struct {
+	Checks []string // vacío = todos
+	Fix    bool     // aplicar las correcciones seguras
+}
+
+// run recibe los productos activos (no archivados) con sus variantes
+type check struct {
+	CheckInfo
+	run func(db *gorm.DB, products []product.Product) ([]Issue, error)
+}
+
+var checks = []check{
+	{CheckInfo{"missing_image", "Productos sin imagen principal ni galería", false}, checkMissingImage},
+	{CheckInfo{"missing_category", "Productos sin categoría o con una categoría eliminada", false}, checkMissingCategory},
+	{CheckInfo{"missing_price", "Productos publicados sin precio mayorista", false}, checkMissingPrice},
+	{CheckInfo{"variant_without_stock", "Variantes sin filas de stock (se crean en 0 en las ubicaciones del producto)", true}, checkVariantWithoutStock},
+	{CheckInfo{"season_mismatch", "season_id inexistente o producto publicado en una temporada cerrada", false}, checkSeasonMismatch},
+	{CheckInfo{"size_type_mismatch", "Tipo de talle que no coincide con los talles de las variantes", true}, checkSizeTypeMismatch},
+	{CheckInfo{"missing_slug", "Productos sin slug público", true}, checkMissingSlug},
+	{CheckInfo{"missing_required_attribute", "Atributos obligatorios de la categoría sin completar", false}, checkRequiredAttributes},
+}
+
+// Checks devuelve los chequeos disponibles
+func Checks() []CheckInfo {
+	out := make([]CheckInfo, len(checks))
+	for i, c := range checks {
+		out[i] = c.CheckInfo
+	}
+	return out
+}
+
+// Run corre los chequeos sobre los productos no archivados. Con Fix=true aplica
+// las correcciones seguras dentro de una transacción y registra una revisión por
+// producto modificado.
+func Run(db *gorm.DB, opts Options) (*Report, error) {
+	selected := checks
+	if len(opts.Checks) > 0 {
+		byName := map[string]check{}
+		for _, c := range checks {
+			byName[c.Name] = c
+		}
+		selected = nil
+		for _, name := range opts.Checks {
+			c, ok := byName[strings.TrimSpace(name)]
+			if !ok {
+				return nil, &UnknownCheckError{Name: name}
+			}
+			selected = append(selected, c)
+		}
+	}
+
+	var products []product.Product
+	if err := db.Preload("Variants").Where("status <> ?", product.StatusArchived).Order("id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	report := &Report{CheckedAt: time.Now(), ProductsChecked: len(products), Counts: map[string]int{}, Issues: []Issue{}}
+	for _, c := range selected {
+		report.Checks = append(report.Checks, c.CheckInfo)
+		issues, err := c.run(db, products)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+		report.Counts[c.Name] = len(issues)
+		report.Issues = append(report.Issues, issues...)
+	}
+	for i := range report.Issues {
+		report.Issues[i].Link = fmt.Sprintf("/admin/productos/%d", report.Issues[i].ProductID)
+		report.Issues[i].Fixable = report.Issues[i].fix != nil
+		if report.Issues[i].Fixable {
+			report.Fixable++
+		}
+	}
+	if !opts.Fix || report.Fixable == 0 {
+		return report, nil
+	}
+
+	// Snapshot previo de los productos a corregir para el historial
+	before := map[uint]*product.ProductSnapshot{}
+	for _, is := range report.Issues {
+		if is.fix != nil && before[is.ProductID] == nil {
+			before[is.ProductID] = product.CaptureRevision(db, is.ProductID)
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range report.Issues {
+			is := &report.Issues[i]
+			if is.fix == nil {
+				continue
+			}
+			if err := is.fix(tx); err != nil {
+				return fmt.Errorf("%s en producto %d: %w", is.Check, is.ProductID, err)
+			}
+			is.Fixed = true
+			report.Fixed++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(before))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		product.RecordRevision(db, nil, id, product.RevisionUpdate, before[id])
+	}
+	return report, nil
+}
+
+// UnknownCheckError indica un nombre de chequeo inexistente (corresponde a un 400)
+type UnknownCheckError struct {
+	Name string
+}
+
+func (e *UnknownCheckError) Error() string { return "chequeo desconocido: " + e.Name }
+
+func newIssue(name, severity string, p product.Product, message string) Issue {
+	return Issue{Check: name, Severity: severity, ProductID: p.ID, ProductCode: p.Code, ProductName: p.Name, Message: message}
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func checkMissingImage(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	var withGallery []uint
+	if err := db.Model(&product.ProductImage{}).Distinct("product_id").Pluck("product_id", &withGallery).Error; err != nil {
+		return nil, err
+	}
+	gallery := map[uint]bool{}
+	for _, id := range withGallery {
+		gallery[id] = true
+	}
+	issues := []Issue{}
+	for _, p := range products {
+		if strings.TrimSpace(p.ImageURL) == "" && !gallery[p.ID] {
+			issues = append(issues, newIssue("missing_image", SeverityError, p, "El producto no tiene imagen principal ni fotos en la galería"))
+		}
+	}
+	return issues, nil
+}
+
+func checkMissingCategory(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	var ids []uint
+	if err := db.Model(&category.Category{}).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	exists := map[uint]bool{}
+	for _, id := range ids {
+		exists[id] = true
+	}
+	issues := []Issue{}
+	for _, p := range products {
+		switch {
+		case p.CategoryID == 0:
+			issues = append(issues, newIssue("missing_category", SeverityError, p, "El producto no tiene categoría"))
+		case !exists[p.CategoryID]:
+			issues = append(issues, newIssue("missing_category", SeverityError, p, fmt.Sprintf("La categoría %d no existe o fue eliminada", p.CategoryID)))
+		}
+	}
+	return issues, nil
+}
+
+func checkMissingPrice(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	issues := []Issue{}
+	for _, p := range products {
+		if p.Status == product.StatusPublished && p.WholesalePrice <= 0 {
+			issues = append(issues, newIssue("missing_price", SeverityError, p, "El producto está publicado sin precio mayorista"))
+		}
+	}
+	return issues, nil
+}
+
+func checkVariantWithoutStock(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	var rows []struct {
+		ProductID uint
+		VariantID *uint
+		Location  string
+	}
+	if err := db.Model(&product.LocationStock{}).Select("product_id, variant_id, location").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	withStock := map[uint]bool{}
+	locations := map[uint]map[string]bool{}
+	for _, r := range rows {
+		if r.VariantID != nil {
+			withStock[*r.VariantID] = true
+		}
+		if locations[r.ProductID] == nil {
+			locations[r.ProductID] = map[string]bool{}
+		}
+		locations[r.ProductID][r.Location] = true
+	}
+	issues := []Issue{}
+	for _, p := range products {
+		locs := []string{}
+		for l := range locations[p.ID] {
+			locs = append(locs, l)
+		}
+		sort.Strings(locs)
+		if len(locs) == 0 {
+			locs = []string{DefaultLocation}
+		}
+		for _, v := range p.Variants {
+			if withStock[v.ID] {
+				continue
+			}
+			variantID := v.ID
+			productID := p.ID
+			is := newIssue("variant_without_stock", SeverityWarning, p, fmt.Sprintf("La variante %s no tiene filas de stock", v.SKU))
+			is.VariantID = &variantID
+			is.fix = func(tx *gorm.DB) error {
+				for _, loc := range locs {
+					if err := tx.Create(&product.LocationStock{ProductID: productID, VariantID: &variantID, Location: loc}).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			issues = append(issues, is)
+		}
+	}
+	return issues, nil
+}
+
+func checkSeasonMismatch(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	var seasons []product.Season
+	if err := db.Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	byID := map[uint]product.Season{}
+	for _, s := range seasons {
+		byID[s.ID] = s
+	}
+	issues := []Issue{}
+	for _, p := range products {
+		if p.SeasonID == nil {
+			continue
+		}
+		s, ok := byID[*p.SeasonID]
+		switch {
+		case !ok:
+			issues = append(issues, newIssue("season_mismatch", SeverityWarning, p, fmt.Sprintf("season_id %d no existe o fue eliminada", *p.SeasonID)))
+		case s.Status == product.SeasonClosed && p.Status == product.StatusPublished:
+			issues = append(issues, newIssue("season_mismatch", SeverityWarning, p, fmt.Sprintf("Sigue publicado en la temporada cerrada '%s'", s.Name)))
+		}
+	}
+	return issues, nil
+}
+
+func checkSizeTypeMismatch(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	var types []product.SizeType
+	if err := db.Preload("Values").Order("id").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	values := map[uint]map[string]uint{} // tipo -> talle normalizado -> size_value_id
+	names := map[uint]string{}
+	for _, st := range types {
+		values[st.ID] = map[string]uint{}
+		names[st.ID] = st.Name
+		for _, v := range st.Values {
+			values[st.ID][normalize(v.Value)] = v.ID
+		}
+	}
+	fits := func(typeID uint, sizes []string) bool {
+		vals, ok := values[typeID]
+		if !ok || len(vals) == 0 {
+			return false
+		}
+		for _, s := range sizes {
+			if _, ok := vals[s]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	issues := []Issue{}
+	for _, p := range products {
+		seen := map[string]bool{}
+		sizes := []string{}
+		for _, v := range p.Variants {
+			if s := normalize(v.Size); s != "" && !seen[s] {
+				seen[s] = true
+				sizes = append(sizes, s)
+			}
+		}
+		if len(sizes) == 0 {
+			continue
+		}
+		sort.Strings(sizes)
+		if p.SizeTypeID != nil && fits(*p.SizeTypeID, sizes) {
+			continue
+		}
+		candidates := []uint{}
+		for _, st := range types {
+			if fits(st.ID, sizes) {
+				candidates = append(candidates, st.ID)
+			}
+		}
+		current := "sin tipo de talle"
+		if p.SizeTypeID != nil {
+			current = fmt.Sprintf("el tipo '%s'", names[*p.SizeTypeID])
+			if _, ok := names[*p.SizeTypeID]; !ok {
+				current = fmt.Sprintf("el tipo %d (inexistente)", *p.SizeTypeID)
+			}
+		}
+		msg := fmt.Sprintf("Talles %s con %s", strings.Join(sizes, ", "), current)
+		if len(candidates) != 1 {
+			is := newIssue("size_type_mismatch", SeverityWarning, p, msg+fmt.Sprintf("; %d tipos de talle los incluyen, corregir a mano", len(candidates)))
+			issues = append(issues, is)
+			continue
+		}
+		// Un único tipo incluye todos los talles: se asigna y se vinculan las variantes a sus valores
+		typeID := candidates[0]
+		productID := p.ID
+		variants := p.Variants
+		is := newIssue("size_type_mismatch", SeverityWarning, p, msg+fmt.Sprintf("; corresponde '%s'", names[typeID]))
+		is.fix = func(tx *gorm.DB) error {
+			if err := tx.Model(&product.Product{}).Where("id = ?", productID).Update("size_type_id", typeID).Error; err != nil {
+				return err
+			}
+			for _, v := range variants {
+				if id, ok := values[typeID][normalize(v.Size)]; ok {
+					if err := tx.Model(&product.ProductVariant{}).Where("id = ?", v.ID).Update("size_value_id", id).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+		issues = append(issues, is)
+	}
+	return issues, nil
+}
+
+func checkMissingSlug(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	issues := []Issue{}
+	for _, p := range products {
+		if p.Slug != "" {
+			continue
+		}
+		productID, name := p.ID, p.Name
+		is := newIssue("missing_slug", SeverityWarning, p, "El producto no tiene slug público")
+		is.fix = func(tx *gorm.DB) error {
+			_, err := slug.Change(tx, slug.EntityProduct, productID, "", name)
+			return err
+		}
+		issues = append(issues, is)
+	}
+	return issues, nil
+}
+
+func checkRequiredAttributes(db *gorm.DB, products []product.Product) ([]Issue, error) {
+	var links []struct {
+		ProductID  uint
+		CategoryID uint
+	}
+	if err := db.Table("product_categories").Select("product_id, category_id").Scan(&links).Error; err != nil {
+		return nil, err
+	}
+	extra := map[uint][]uint{}
+	for _, l := range links {
+		extra[l.ProductID] = append(extra[l.ProductID], l.CategoryID)
+	}
+	var values []struct {
+		ProductID   uint
+		AttributeID uint
+	}
+	if err := db.Model(&product.ProductAttributeValue{}).Select("product_id, attribute_id").Scan(&values).Error; err != nil {
+		return nil, err
+	}
+	filled := map[uint]map[uint]bool{}
+	for _, v := range values {
+		if filled[v.ProductID] == nil {
+			filled[v.ProductID] = map[uint]bool{}
+		}
+		filled[v.ProductID][v.AttributeID] = true
+	}
+
+	// Los atributos efectivos se calculan una vez por combinación de categorías
+	cache := map[string][]category.CategoryAttribute{}
+	issues := []Issue{}
+	for _, p := range products {
+		catIDs := append([]uint{p.CategoryID}, extra[p.ID]...)
+		key := fmt.Sprint(catIDs)
+		attrs, ok := cache[key]
+		if !ok {
+			var err error
+			if attrs, err = category.EffectiveAttributes(db, catIDs); err != nil {
+				return nil, err
+			}
+			cache[key] = attrs
+		}
+		missing := []string{}
+		for _, a := range attrs {
+			if a.Required && !filled[p.ID][a.ID] {
+				missing = append(missing, a.Name)
+			}
+		}
+		if len(missing) > 0 {
+			issues = append(issues, newIssue("missing_required_attribute", SeverityWarning, p, "Faltan atributos obligatorios: "+strings.Join(missing, ", ")))
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,100 @@
+package catalogcheck
+
+import (
+	"testing"
+
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/slug"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRun_ReportsAndFixes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:catalogcheck?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &product.ProductVariant{}, &product.LocationStock{}, &product.SizeType{}, &product.SizeValue{},
+		&product.Season{}, &product.ProductImage{}, &product.ProductRevision{}, &product.ProductAttributeValue{}, &sequence.Sequence{},
+		&category.Category{}, &category.CategoryAttribute{}, &slug.Redirect{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	cat := category.Category{Name: "Remeras"}
+	db.Create(&cat)
+	db.Create(&category.CategoryAttribute{CategoryID: cat.ID, Key: "material", Name: "Material", Type: category.AttributeText, Required: true})
+	closed := product.Season{Code: "SS24", Name: "Primavera/Verano 2024", Year: 2024, Status: product.SeasonClosed}
+	db.Create(&closed)
+	letters := product.SizeType{Name: "Letras"}
+	db.Create(&letters)
+	for i, v := range []string{"S", "M", "L"} {
+		db.Create(&product.SizeValue{SizeTypeID: letters.ID, Value: v, Ordinal: i})
+	}
+	numbers := product.SizeType{Name: "Números"}
+	db.Create(&numbers)
+	db.Create(&product.SizeValue{SizeTypeID: numbers.ID, Value: "38"})
+
+	p := product.Product{Name: "Remera Lisa", CategoryID: cat.ID, SeasonID: &closed.ID, SizeTypeID: &numbers.ID, Status: product.StatusPublished}
+	db.Create(&p)
+	db.Model(&p).UpdateColumn("slug", "")
+	small := product.ProductVariant{ProductID: p.ID, SKU: "RL-S", Size: "S"}
+	db.Create(&small)
+	medium := product.ProductVariant{ProductID: p.ID, SKU: "RL-M", Size: "m"}
+	db.Create(&medium)
+	db.Create(&product.LocationStock{ProductID: p.ID, VariantID: &small.ID, Location: "mendoza", Stock: 3})
+	// Archivado: no se revisa
+	db.Create(&product.Product{Name: "Viejo", CategoryID: cat.ID, Status: product.StatusArchived})
+
+	report, err := Run(db, Options{})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := map[string]int{"missing_image": 1, "missing_category": 0, "missing_price": 1, "variant_without_stock": 1,
+		"season_mismatch": 1, "size_type_mismatch": 1, "missing_slug": 1, "missing_required_attribute": 1}
+	for name, n := range want {
+		if report.Counts[name] != n {
+			t.Errorf("%s: expected %d issues, got %d", name, n, report.Counts[name])
+		}
+	}
+	if report.ProductsChecked != 1 || report.Fixable != 3 || report.Fixed != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Issues[0].Link != "/admin/productos/1" {
+		t.Fatalf("unexpected link %q", report.Issues[0].Link)
+	}
+
+	if _, err := Run(db, Options{Checks: []string{"nope"}}); err == nil {
+		t.Fatal("expected error for unknown check")
+	}
+
+	report, err = Run(db, Options{Fix: true})
+	if err != nil {
+		t.Fatalf("fix: %v", err)
+	}
+	if report.Fixed != 3 {
+		t.Fatalf("expected 3 fixes, got %d", report.Fixed)
+	}
+	var got product.Product
+	db.Preload("Variants").First(&got, p.ID)
+	if got.SizeTypeID == nil || *got.SizeTypeID != letters.ID || got.Slug != "remera-lisa" {
+		t.Fatalf("fixes not applied: %+v", got)
+	}
+	for _, v := range got.Variants {
+		if v.SizeValueID == nil {
+			t.Fatalf("variant %s not linked to its size value", v.SKU)
+		}
+	}
+	var rows int64
+	db.Model(&product.LocationStock{}).Where("variant_id = ? AND location = ?", medium.ID, "mendoza").Count(&rows)
+	if rows != 1 {
+		t.Fatalf("expected zero-stock row for the variant without stock, got %d", rows)
+	}
+
+	report, _ = Run(db, Options{})
+	if report.Fixable != 0 {
+		t.Fatalf("expected nothing left to fix, got %d", report.Fixable)
+	}
+}
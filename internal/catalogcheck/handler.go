@@ -0,0 +1,32 @@
+package catalogcheck
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"go-modaMayor/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogQuality corre los chequeos de calidad del catálogo.
+// GET devuelve el reporte; POST ?fix=true además aplica las correcciones seguras.
+// ?checks=missing_image,season_mismatch limita los chequeos.
+func CatalogQuality(c *gin.Context) {
+	opts := Options{Fix: c.Request.Method == http.MethodPost && c.Query("fix") == "true"}
+	if v := strings.TrimSpace(c.Query("checks")); v != "" {
+		opts.Checks = strings.Split(v, ",")
+	}
+	report, err := Run(config.DB, opts)
+	var unknown *UnknownCheckError
+	if errors.As(err, &unknown) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "checks": Checks()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
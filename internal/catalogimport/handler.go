@@ -0,0 +1,102 @@
+package catalogimport
+
+import (
+	"io"
+	"net/http"
+
+	"go-modaMayor/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limita el tamaño del archivo de catálogo (20 MB)
+const maxImportSize = 20 << 20
+
+// ImportCatalog recibe un archivo CSV/XLSX (campo "file") y lo valida.
+// Con dry_run=true (por defecto) sólo devuelve la validación por fila; con dry_run=false,
+// si no hay errores, crea un ImportJob y lo procesa en segundo plano.
+// Ruta: POST /imports/catalog
+func ImportCatalog(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Falta el archivo (campo 'file')"})
+		return
+	}
+	if fh.Size > maxImportSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El archivo supera el tamaño máximo de 20 MB"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, records, err := ParseFile(fh.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plans, rowErrs, err := Validate(config.DB, records)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dryRun := c.DefaultPostForm("dry_run", c.DefaultQuery("dry_run", "true")) != "false"
+	report := gin.H{
+		"dry_run":    dryRun,
+		"format":     format,
+		"total_rows": len(records),
+		"valid_rows": len(plans),
+		"errors":     rowErrs,
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if len(rowErrs) > 0 {
+		report["error"] = "El archivo tiene errores; corregirlos antes de importar"
+		c.JSON(http.StatusBadRequest, report)
+		return
+	}
+
+	job := ImportJob{FileName: fh.Filename, Format: format, Status: "pendiente", TotalRows: len(plans)}
+	if v, ok := c.Get("user_id"); ok {
+		if uid, ok := v.(uint); ok {
+			job.UserID = &uid
+		}
+	}
+	if err := config.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	go RunJob(config.DB, job.ID, plans)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListImportJobs lista las importaciones más recientes
+func ListImportJobs(c *gin.Context) {
+	var jobs []ImportJob
+	if err := config.DB.Order("created_at DESC").Limit(50).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetImportJob devuelve el estado y avance de una importación
+func GetImportJob(c *gin.Context) {
+	var job ImportJob
+	if err := config.DB.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Importación no encontrada"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,105 @@
+package catalogimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/settings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:catalogimport_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&sequence.Sequence{}, &category.Category{}, &category.Subcategory{}, &product.Product{}, &product.ProductVariant{},
+		&product.LocationStock{}, &product.StockMovement{}, &product.Supplier{}, &product.Season{}, &product.SizeType{}, &product.SizeValue{},
+		&product.Color{}, &product.ProductImage{}, &product.ProductRevision{}, &settings.PriceTier{}, &ImportJob{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	return db
+}
+
+func TestImport_ValidateAndRun(t *testing.T) {
+	db := setupTestDB(t)
+	cat := category.Category{Name: "Remeras"}
+	db.Create(&cat)
+	db.Create(&category.Subcategory{Name: "Básicas", CategoryID: cat.ID})
+	st := product.SizeType{Key: "letras", Name: "Letras"}
+	db.Create(&st)
+	db.Create(&product.SizeValue{SizeTypeID: st.ID, Value: "S", Ordinal: 1})
+	db.Create(&product.SizeValue{SizeTypeID: st.ID, Value: "M", Ordinal: 2})
+	db.Create(&product.Color{Key: "negro", Name: "Negro", Active: true})
+	db.Create(&product.Color{Key: "blanco", Name: "Blanco", Active: true})
+
+	csv := "nombre;categoria;subcategoria;costo;tipo talle;colores;talles;prefijo sku;stock deposito\n" +
+		"Remera Lisa;remeras;basicas;1500,50;letras;negro|blanco;S|M;RL;4\n" +
+		"Remera Rota;remeras;inexistente;abc;letras;violeta;XXL;;-1\n"
+	format, records, err := ParseFile("catalogo.csv", []byte(csv))
+	if err != nil || format != "csv" || len(records) != 2 {
+		t.Fatalf("unexpected parse result: %v %s %d", err, format, len(records))
+	}
+	plans, rowErrs, err := Validate(db, records)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plans) != 1 || plans[0].Product.CostPrice != 1500.50 || len(plans[0].Colors) != 2 || plans[0].Product.VariantType != "ambos" {
+		t.Fatalf("unexpected plans: %+v", plans)
+	}
+	// fila 3: subcategoría, costo, color, talle y stock inválidos
+	if len(rowErrs) != 5 {
+		t.Fatalf("expected 5 row errors, got %+v", rowErrs)
+	}
+	for _, e := range rowErrs {
+		if e.Row != 3 {
+			t.Fatalf("expected errors on row 3, got %+v", e)
+		}
+	}
+
+	job := ImportJob{FileName: "catalogo.csv", Format: format, TotalRows: len(plans)}
+	db.Create(&job)
+	RunJob(db, job.ID, plans)
+
+	db.First(&job, job.ID)
+	if job.Status != "completado" || job.CreatedProducts != 1 || job.CreatedVariants != 4 || job.Progress() != 100 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	var stocks []product.LocationStock
+	db.Where("location = ?", "deposito").Find(&stocks)
+	var movements int64
+	db.Model(&product.StockMovement{}).Where("movement_type = ?", "initial").Count(&movements)
+	if len(stocks) != 4 || stocks[0].Stock != 4 || movements != 4 {
+		t.Fatalf("expected 4 variant stocks of 4 with movements, got %d stocks, %d movements", len(stocks), movements)
+	}
+}
+
+func TestParseFile_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Hoja1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>Nombre</t></si><si><t>Costo</t></si><si><r><t>Buzo </t></r><r><t>Canguro</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row><row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>2500</v></c></row></sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	_, records, err := ParseFile("catalogo.xlsx", buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 || records[0].Fields["name"] != "Buzo Canguro" || records[0].Fields["cost_price"] != "2500" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+}
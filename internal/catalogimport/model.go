@@ -0,0 +1,60 @@
+package catalogimport
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RowError es un error de validación o de carga asociado a una fila del archivo
+// (Row usa la numeración de la planilla: la fila 1 es el encabezado).
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportJob registra una importación de catálogo ejecutada en segundo plano.
+// Estados: pendiente, procesando, completado, completado_con_errores, fallido
+type ImportJob struct {
+	gorm.Model
+	FileName        string     `json:"file_name"`
+	Format          string     `json:"format" gorm:"type:varchar(10)"` // csv, xlsx
+	Status          string     `json:"status" gorm:"type:varchar(30);default:'pendiente';index"`
+	TotalRows       int        `json:"total_rows"`
+	ProcessedRows   int        `json:"processed_rows"`
+	CreatedProducts int        `json:"created_products"`
+	CreatedVariants int        `json:"created_variants"`
+	ErrorsJSON      string     `json:"-" gorm:"column:errors;type:text"`
+	Errors          []RowError `json:"errors" gorm:"-"`
+	UserID          *uint      `json:"user_id"`
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+// Progress devuelve el porcentaje de filas procesadas
+func (j *ImportJob) Progress() float64 {
+	if j.TotalRows == 0 {
+		return 0
+	}
+	return float64(j.ProcessedRows) * 100 / float64(j.TotalRows)
+}
+
+// AfterFind decodifica los errores guardados como JSON
+func (j *ImportJob) AfterFind(tx *gorm.DB) error {
+	j.Errors = []RowError{}
+	if j.ErrorsJSON != "" {
+		return json.Unmarshal([]byte(j.ErrorsJSON), &j.Errors)
+	}
+	return nil
+}
+
+// MarshalJSON agrega el progreso calculado a la respuesta
+func (j ImportJob) MarshalJSON() ([]byte, error) {
+	type alias ImportJob
+	return json.Marshal(struct {
+		alias
+		Progress float64 `json:"progress"`
+	}{alias(j), j.Progress()})
+}
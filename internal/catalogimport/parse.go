@@ -0,0 +1,160 @@
+package catalogimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/xlsx"
+)
+
+// Campos reconocidos y los encabezados aceptados para cada uno (normalizados:
+// minúsculas, sin acentos, espacios como guion bajo). Las columnas stock_<ubicación>
+// indican el stock inicial por variante en esa ubicación.
+var columnAliases = map[string][]string{
+	"code":         {"code", "codigo"},
+	"name":         {"name", "nombre"},
+	"description":  {"description", "descripcion"},
+	"category":     {"category", "categoria"},
+	"subcategory":  {"subcategory", "subcategoria"},
+	"cost_price":   {"cost_price", "costo", "precio_costo"},
+	"supplier":     {"supplier", "proveedor"},
+	"season":       {"season", "temporada"},
+	"year":         {"year", "anio", "ano"},
+	"size_type":    {"size_type", "tipo_talle"},
+	"colors":       {"colors", "colores"},
+	"sizes":        {"sizes", "talles"},
+	"sku_prefix":   {"sku_prefix", "prefijo_sku"},
+	"variant_type": {"variant_type", "tipo_variante"},
+	"image_url":    {"image_url", "imagen"},
+}
+
+const stockColumnPrefix = "stock_"
+
+// Record es una fila del archivo con sus valores por campo
+type Record struct {
+	Row    int
+	Fields map[string]string
+	Stocks map[string]string // ubicación -> cantidad (texto)
+}
+
+// ParseFile lee un CSV (separado por coma o punto y coma) o XLSX y mapea las columnas
+func ParseFile(fileName string, data []byte) (string, []Record, error) {
+	var rows [][]string
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+	switch format {
+	case "csv":
+		var err error
+		rows, err = readCSV(data)
+		if err != nil {
+			return format, nil, err
+		}
+	case "xlsx":
+		var err error
+		rows, err = xlsx.ReadRows(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return format, nil, err
+		}
+	default:
+		return format, nil, fmt.Errorf("formato no soportado: %s (usar .csv o .xlsx)", format)
+	}
+	if len(rows) < 2 {
+		return format, nil, fmt.Errorf("el archivo no tiene filas de datos")
+	}
+
+	byHeader := map[string]string{}
+	for field, aliases := range columnAliases {
+		for _, a := range aliases {
+			byHeader[a] = field
+		}
+	}
+	headers := rows[0]
+	fields := make([]string, len(headers))
+	stockLocations := make([]string, len(headers))
+	seen := map[string]bool{}
+	for i, h := range headers {
+		key := normalizeHeader(h)
+		if strings.HasPrefix(key, stockColumnPrefix) && len(key) > len(stockColumnPrefix) {
+			stockLocations[i] = strings.TrimPrefix(key, stockColumnPrefix)
+			continue
+		}
+		field, ok := byHeader[key]
+		if !ok {
+			continue // columnas desconocidas se ignoran
+		}
+		if seen[field] {
+			return format, nil, fmt.Errorf("columna duplicada: %s", h)
+		}
+		seen[field] = true
+		fields[i] = field
+	}
+	if !seen["name"] {
+		return format, nil, fmt.Errorf("falta la columna obligatoria 'nombre'")
+	}
+
+	records := make([]Record, 0, len(rows)-1)
+	for r, row := range rows[1:] {
+		rec := Record{Row: r + 2, Fields: map[string]string{}, Stocks: map[string]string{}}
+		empty := true
+		for i, v := range row {
+			if i >= len(headers) {
+				break
+			}
+			v = strings.TrimSpace(v)
+			if v != "" {
+				empty = false
+			}
+			if fields[i] != "" {
+				rec.Fields[fields[i]] = v
+			} else if stockLocations[i] != "" && v != "" {
+				rec.Stocks[stockLocations[i]] = v
+			}
+		}
+		if !empty {
+			records = append(records, rec)
+		}
+	}
+	return format, records, nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM de Excel
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %v", err)
+	}
+	return rows, nil
+}
+
+func normalizeHeader(h string) string {
+	h = product.NormalizeSearchText(strings.TrimSpace(h))
+	return strings.Join(strings.Fields(h), "_")
+}
+
+// splitList separa listas de colores/talles escritas con | ; o ,
+func splitList(s string) []string {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '|' || r == ';' || r == ',' })
+	out := make([]string, 0, len(parts))
+	seen := map[string]bool{}
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[strings.ToLower(p)] {
+			continue
+		}
+		seen[strings.ToLower(p)] = true
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+package catalogimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/settings"
+
+	"gorm.io/gorm"
+)
+
+// progressEvery indica cada cuántas filas se persiste el avance del job
+const progressEvery = 10
+
+// applyPlan crea el producto, sus variantes (misma lógica de SKU que GenerateVariants)
+// y el stock inicial por ubicación con su movimiento "initial", dentro de tx.
+func applyPlan(tx *gorm.DB, p Plan, tiers []settings.PriceTier, reference string, userID *uint) (int, error) {
+	prod := p.Product
+	prices := settings.CalculateProductPricesFromList(prod.CostPrice, tiers)
+	prod.WholesalePrice = prices.WholesalePrice
+	prod.Discount1Price = prices.Discount1Price
+	prod.Discount2Price = prices.Discount2Price
+	if err := tx.Create(&prod).Error; err != nil {
+		return 0, err
+	}
+
+	variants, _, err := product.GenerateVariantsTx(tx, prod.ID, p.Colors, p.Sizes, p.SKUPrefix)
+	if err != nil {
+		return 0, err
+	}
+
+	locations := make([]string, 0, len(p.Stocks))
+	for loc := range p.Stocks {
+		locations = append(locations, loc)
+	}
+	sort.Strings(locations)
+	for _, loc := range locations {
+		qty := p.Stocks[loc]
+		targets := []*uint{nil}
+		if len(variants) > 0 {
+			targets = targets[:0]
+			for i := range variants {
+				targets = append(targets, &variants[i].ID)
+			}
+		}
+		for _, variantID := range targets {
+			if err := tx.Create(&product.LocationStock{ProductID: prod.ID, VariantID: variantID, Location: loc, Stock: qty}).Error; err != nil {
+				return 0, err
+			}
+			if err := tx.Create(&product.StockMovement{
+				ProductID:     prod.ID,
+				VariantID:     variantID,
+				Location:      loc,
+				MovementType:  "initial",
+				Quantity:      qty,
+				PreviousStock: 0,
+				NewStock:      qty,
+				Reason:        "Stock inicial por importación de catálogo",
+				Reference:     reference,
+				UserID:        userID,
+			}).Error; err != nil {
+				return 0, err
+			}
+		}
+	}
+	// Va en un savepoint: si falla el historial la fila se importa igual
+	product.RecordRevision(tx, nil, prod.ID, product.RevisionCreate, nil)
+	return len(variants), nil
+}
+
+// RunJob procesa los planes de un ImportJob, una transacción por fila, actualizando el avance.
+// Una fila que falla al grabarse queda registrada en los errores del job y no frena el resto.
+func RunJob(db *gorm.DB, jobID uint, plans []Plan) {
+	var job ImportJob
+	if err := db.First(&job, jobID).Error; err != nil {
+		log.Printf("❌ Importación de catálogo: job %d no encontrado: %v", jobID, err)
+		return
+	}
+	now := time.Now()
+	job.Status = "procesando"
+	job.StartedAt = &now
+	db.Model(&job).Updates(map[string]interface{}{"status": job.Status, "started_at": now})
+
+	var tiers []settings.PriceTier
+	if err := db.Where("active = ?", true).Order("order_index ASC").Find(&tiers).Error; err != nil {
+		finishJob(db, &job, "fallido", []RowError{{Message: err.Error()}})
+		return
+	}
+
+	reference := fmt.Sprintf("IMPORT-%d", job.ID)
+	rowErrs := []RowError{}
+	for i, p := range plans {
+		var created int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			created, err = applyPlan(tx, p, tiers, reference, job.UserID)
+			return err
+		})
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: p.Row, Message: err.Error()})
+		} else {
+			job.CreatedProducts++
+			job.CreatedVariants += created
+		}
+		job.ProcessedRows = i + 1
+		if job.ProcessedRows%progressEvery == 0 {
+			db.Model(&job).Updates(map[string]interface{}{
+				"processed_rows":   job.ProcessedRows,
+				"created_products": job.CreatedProducts,
+				"created_variants": job.CreatedVariants,
+			})
+		}
+	}
+
+	status := "completado"
+	if len(rowErrs) > 0 {
+		status = "completado_con_errores"
+	}
+	finishJob(db, &job, status, rowErrs)
+	log.Printf("✅ Importación de catálogo %d: %d productos, %d variantes, %d errores", job.ID, job.CreatedProducts, job.CreatedVariants, len(rowErrs))
+}
+
+func finishJob(db *gorm.DB, job *ImportJob, status string, errs []RowError) {
+	now := time.Now()
+	job.Status = status
+	job.FinishedAt = &now
+	job.Errors = errs
+	data, _ := json.Marshal(errs)
+	job.ErrorsJSON = string(data)
+	db.Model(job).Updates(map[string]interface{}{
+		"status":           status,
+		"finished_at":      now,
+		"processed_rows":   job.ProcessedRows,
+		"created_products": job.CreatedProducts,
+		"created_variants": job.CreatedVariants,
+		"errors":           job.ErrorsJSON,
+	})
+}
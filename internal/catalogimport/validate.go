@@ -0,0 +1,277 @@
+package catalogimport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+
+	"gorm.io/gorm"
+)
+
+var validVariantTypes = map[string]bool{"talle_unico": true, "color_surtido": true, "ambos": true, "sin_variantes": true}
+
+// Plan es una fila validada, lista para crear el producto con sus variantes y stock
+type Plan struct {
+	Row       int
+	Product   product.Product
+	Colors    []string
+	Sizes     []string
+	SKUPrefix string
+	Stocks    map[string]int // ubicación -> stock por variante
+}
+
+type lookups struct {
+	categories    map[string]category.Category
+	subcategories map[uint]map[string]category.Subcategory
+	suppliers     map[string]product.Supplier
+	seasons       map[string]product.Season
+	sizeTypes     map[string]product.SizeType
+	colors        map[string]product.Color
+	codes         map[string]bool
+}
+
+func key(s string) string {
+	return product.NormalizeSearchText(strings.TrimSpace(s))
+}
+
+func loadLookups(db *gorm.DB) (*lookups, error) {
+	l := &lookups{
+		categories:    map[string]category.Category{},
+		subcategories: map[uint]map[string]category.Subcategory{},
+		suppliers:     map[string]product.Supplier{},
+		seasons:       map[string]product.Season{},
+		sizeTypes:     map[string]product.SizeType{},
+		colors:        map[string]product.Color{},
+		codes:         map[string]bool{},
+	}
+	var cats []category.Category
+	// Sólo categorías raíz: en el árbol puede haber subcategorías con el mismo nombre
+	if err := db.Preload("Subcategories").Where("parent_id IS NULL").Find(&cats).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range cats {
+		l.categories[key(c.Name)] = c
+		l.subcategories[c.ID] = map[string]category.Subcategory{}
+		for _, s := range c.Subcategories {
+			l.subcategories[c.ID][key(s.Name)] = s
+		}
+	}
+	var suppliers []product.Supplier
+	if err := db.Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range suppliers {
+		l.suppliers[key(s.Name)] = s
+		if s.Code != "" {
+			l.suppliers[key(s.Code)] = s
+		}
+	}
+	var seasons []product.Season
+	if err := db.Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range seasons {
+		l.seasons[key(s.Code)] = s
+		l.seasons[key(s.Name)] = s
+	}
+	var sizeTypes []product.SizeType
+	if err := db.Preload("Values").Find(&sizeTypes).Error; err != nil {
+		return nil, err
+	}
+	for _, st := range sizeTypes {
+		l.sizeTypes[key(st.Key)] = st
+		if st.Name != "" {
+			l.sizeTypes[key(st.Name)] = st
+		}
+	}
+	var colors []product.Color
+	if err := db.Where("active = ?", true).Find(&colors).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range colors {
+		l.colors[key(c.Key)] = c
+		if c.Name != "" {
+			l.colors[key(c.Name)] = c
+		}
+	}
+	var codes []string
+	if err := db.Model(&product.Product{}).Pluck("code", &codes).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range codes {
+		l.codes[strings.ToUpper(c)] = true
+	}
+	return l, nil
+}
+
+// parseDecimal acepta tanto "1234.50" como "1234,50"
+func parseDecimal(s string) (float64, error) {
+	if strings.Contains(s, ",") && !strings.Contains(s, ".") {
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Validate resuelve las referencias por nombre y valida cada fila sin escribir nada.
+// Devuelve los planes de las filas válidas y los errores de las inválidas.
+func Validate(db *gorm.DB, records []Record) ([]Plan, []RowError, error) {
+	l, err := loadLookups(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	plans := make([]Plan, 0, len(records))
+	errs := []RowError{}
+	fileCodes := map[string]int{}
+
+	for _, rec := range records {
+		f := rec.Fields
+		rowErrs := []RowError{}
+		fail := func(column, format string, args ...interface{}) {
+			rowErrs = append(rowErrs, RowError{Row: rec.Row, Column: column, Message: fmt.Sprintf(format, args...)})
+		}
+		p := Plan{Row: rec.Row, SKUPrefix: f["sku_prefix"], Stocks: map[string]int{}}
+		p.Product.Name = f["name"]
+		p.Product.Description = f["description"]
+		p.Product.ImageURL = f["image_url"]
+		p.Product.VariantType = "sin_variantes"
+
+		if n := len([]rune(p.Product.Name)); n < 2 || n > 100 {
+			fail("nombre", "el nombre es obligatorio (2 a 100 caracteres)")
+		}
+
+		if code := strings.ToUpper(f["code"]); code != "" {
+			if l.codes[code] {
+				fail("codigo", "ya existe un producto con código %s", code)
+			} else if prev, dup := fileCodes[code]; dup {
+				fail("codigo", "código %s repetido (fila %d)", code, prev)
+			} else {
+				fileCodes[code] = rec.Row
+				p.Product.Code = code
+			}
+		}
+
+		cat, ok := l.categories[key(f["category"])]
+		if !ok {
+			fail("categoria", "categoría '%s' no encontrada", f["category"])
+		} else {
+			p.Product.CategoryID = cat.ID
+			sub, ok := l.subcategories[cat.ID][key(f["subcategory"])]
+			if !ok {
+				fail("subcategoria", "subcategoría '%s' no encontrada en %s", f["subcategory"], cat.Name)
+			} else {
+				p.Product.SubcategoryID = sub.ID
+			}
+		}
+
+		if cost, err := parseDecimal(f["cost_price"]); err != nil || cost <= 0 {
+			fail("costo", "costo inválido: '%s'", f["cost_price"])
+		} else {
+			p.Product.CostPrice = cost
+		}
+
+		if v := f["supplier"]; v != "" {
+			if s, ok := l.suppliers[key(v)]; ok {
+				id := s.ID
+				p.Product.SupplierID = &id
+			} else {
+				fail("proveedor", "proveedor '%s' no encontrado", v)
+			}
+		}
+		if v := f["season"]; v != "" {
+			if s, ok := l.seasons[key(v)]; ok {
+				id := s.ID
+				p.Product.SeasonID = &id
+			} else {
+				fail("temporada", "temporada '%s' no encontrada", v)
+			}
+		}
+		if v := f["year"]; v != "" {
+			if y, err := strconv.Atoi(v); err != nil || y < 2000 || y > 2100 {
+				fail("anio", "año inválido: '%s'", v)
+			} else {
+				p.Product.Year = &y
+			}
+		}
+		if v := f["variant_type"]; v != "" {
+			if !validVariantTypes[v] {
+				fail("tipo_variante", "tipo de variante inválido: '%s'", v)
+			} else {
+				p.Product.VariantType = v
+			}
+		}
+
+		for _, c := range splitList(f["colors"]) {
+			if len(l.colors) == 0 {
+				p.Colors = append(p.Colors, c)
+				continue
+			}
+			col, ok := l.colors[key(c)]
+			if !ok {
+				fail("colores", "color '%s' desconocido", c)
+				continue
+			}
+			name := col.Name
+			if name == "" {
+				name = col.Key
+			}
+			p.Colors = append(p.Colors, name)
+		}
+
+		sizes := splitList(f["sizes"])
+		if v := f["size_type"]; v != "" {
+			st, ok := l.sizeTypes[key(v)]
+			if !ok {
+				fail("tipo_talle", "tipo de talle '%s' no encontrado", v)
+			} else {
+				id := st.ID
+				p.Product.SizeTypeID = &id
+				allowed := map[string]string{}
+				for _, sv := range st.Values {
+					allowed[key(sv.Value)] = sv.Value
+				}
+				for _, s := range sizes {
+					if len(allowed) == 0 {
+						p.Sizes = append(p.Sizes, s)
+					} else if canonical, ok := allowed[key(s)]; ok {
+						p.Sizes = append(p.Sizes, canonical)
+					} else {
+						fail("talles", "talle '%s' no pertenece al tipo %s", s, st.Key)
+					}
+				}
+			}
+		} else {
+			p.Sizes = sizes
+		}
+		if f["variant_type"] == "" && (len(p.Colors) > 0 || len(p.Sizes) > 0) {
+			switch {
+			case len(p.Colors) > 0 && len(p.Sizes) > 0:
+				p.Product.VariantType = "ambos"
+			case len(p.Colors) > 0:
+				p.Product.VariantType = "color_surtido"
+			default:
+				p.Product.VariantType = "talle_unico"
+			}
+		}
+
+		for loc, raw := range rec.Stocks {
+			qty, err := strconv.Atoi(raw)
+			if err != nil || qty < 0 {
+				fail(stockColumnPrefix+loc, "stock inválido: '%s'", raw)
+				continue
+			}
+			if qty > 0 {
+				p.Stocks[loc] = qty
+			}
+		}
+
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		plans = append(plans, p)
+	}
+	return plans, errs, nil
+}
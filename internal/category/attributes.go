@@ -0,0 +1,359 @@
+package category
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-modaMayor/internal/slug"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Tipos de atributo soportados
+const (
+	AttributeText        = "text"
+	AttributeNumber      = "number"
+	AttributeBoolean     = "boolean"
+	AttributeSelect      = "select"
+	AttributeMultiselect = "multiselect"
+)
+
+var attributeTypes = map[string]bool{
+	AttributeText: true, AttributeNumber: true, AttributeBoolean: true, AttributeSelect: true, AttributeMultiselect: true,
+}
+
+// CategoryAttribute es un atributo propio de una categoría (tela, calce, escote...).
+// Lo heredan todas sus subcategorías; si una subcategoría define la misma Key, gana la más profunda.
+type CategoryAttribute struct {
+	gorm.Model
+	CategoryID uint       `json:"category_id" gorm:"index;not null"`
+	Key        string     `json:"key" gorm:"type:varchar(64);not null"`
+	Name       string     `json:"name" gorm:"not null"`
+	Type       string     `json:"type" gorm:"type:varchar(16);not null"`
+	Options    StringList `json:"options" gorm:"type:text"`
+	Unit       string     `json:"unit"`
+	Required   bool       `json:"required"`
+	Filterable bool       `json:"filterable" gorm:"default:true"`
+	Position   int        `json:"position" gorm:"default:0"`
+}
+
+// StringList guarda una lista de textos como JSON en una columna de texto
+type StringList []string
+
+// Value serializa la lista como JSON
+func (s StringList) Value() (driver.Value, error) {
+	if s == nil {
+		s = StringList{}
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan lee la lista desde JSON
+func (s *StringList) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("tipo no soportado para StringList: %T", value)
+	}
+	if len(data) == 0 {
+		*s = nil
+		return nil
+	}
+	return json.Unmarshal(data, s)
+}
+
+// AttributeKey normaliza la clave usada en filtros (?attr.<key>=...)
+func AttributeKey(s string) string {
+	return strings.ReplaceAll(slug.Make(s), "-", "_")
+}
+
+// NormalizeValues valida el valor recibido (texto, número, booleano o lista) según
+// el tipo del atributo y devuelve los valores a guardar. En select/multiselect
+// devuelve la opción tal como está definida.
+func (a CategoryAttribute) NormalizeValues(raw any) ([]string, error) {
+	var items []any
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		items = v
+	case []string:
+		for _, s := range v {
+			items = append(items, s)
+		}
+	default:
+		items = []any{v}
+	}
+	if len(items) > 1 && a.Type != AttributeMultiselect {
+		return nil, fmt.Errorf("el atributo '%s' admite un solo valor", a.Name)
+	}
+
+	var out []string
+	seen := map[string]bool{}
+	for _, item := range items {
+		text := strings.TrimSpace(fmt.Sprint(item))
+		if item == nil || text == "" {
+			continue
+		}
+		switch a.Type {
+		case AttributeNumber:
+			n, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", "."), 64)
+			if err != nil {
+				return nil, fmt.Errorf("el atributo '%s' debe ser numérico", a.Name)
+			}
+			text = strconv.FormatFloat(n, 'f', -1, 64)
+		case AttributeBoolean:
+			b, err := strconv.ParseBool(strings.ToLower(text))
+			if err != nil {
+				switch strings.ToLower(text) {
+				case "si", "sí":
+					b, err = true, nil
+				case "no":
+					b, err = false, nil
+				default:
+					return nil, fmt.Errorf("el atributo '%s' debe ser sí o no", a.Name)
+				}
+			}
+			text = strconv.FormatBool(b)
+		case AttributeSelect, AttributeMultiselect:
+			option, ok := a.option(text)
+			if !ok {
+				return nil, fmt.Errorf("'%s' no es una opción válida para '%s'", text, a.Name)
+			}
+			text = option
+		}
+		if !seen[text] {
+			seen[text] = true
+			out = append(out, text)
+		}
+	}
+	return out, nil
+}
+
+func (a CategoryAttribute) option(value string) (string, bool) {
+	for _, o := range a.Options {
+		if strings.EqualFold(o, value) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// EffectiveAttributes devuelve los atributos que aplican a las categorías dadas,
+// incluidos los heredados de sus ancestros
+func EffectiveAttributes(db *gorm.DB, categoryIDs []uint) ([]CategoryAttribute, error) {
+	if len(categoryIDs) == 0 {
+		return []CategoryAttribute{}, nil
+	}
+	var cats []Category
+	if err := db.Where("id IN ?", categoryIDs).Find(&cats).Error; err != nil {
+		return nil, err
+	}
+	depth := map[uint]int{}
+	for _, c := range cats {
+		for d, id := range c.PathIDs() {
+			if cur, ok := depth[id]; !ok || d > cur {
+				depth[id] = d
+			}
+		}
+	}
+	ids := make([]uint, 0, len(depth))
+	for id := range depth {
+		ids = append(ids, id)
+	}
+	var attrs []CategoryAttribute
+	if err := db.Where("category_id IN ?", ids).Order("position ASC, name ASC").Find(&attrs).Error; err != nil {
+		return nil, err
+	}
+	byKey := map[string]int{}
+	out := []CategoryAttribute{}
+	for _, a := range attrs {
+		if i, ok := byKey[a.Key]; ok {
+			if depth[a.CategoryID] > depth[out[i].CategoryID] {
+				out[i] = a
+			}
+			continue
+		}
+		byKey[a.Key] = len(out)
+		out = append(out, a)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Position < out[j].Position })
+	return out, nil
+}
+
+type AttributeInput struct {
+	Key        string   `json:"key"`
+	Name       string   `json:"name" binding:"required"`
+	Type       string   `json:"type"`
+	Options    []string `json:"options"`
+	Unit       string   `json:"unit"`
+	Required   bool     `json:"required"`
+	Filterable *bool    `json:"filterable"`
+	Position   int      `json:"position"`
+}
+
+func cleanOptions(options []string) StringList {
+	out := StringList{}
+	seen := map[string]bool{}
+	for _, o := range options {
+		o = strings.TrimSpace(o)
+		if o == "" || seen[strings.ToLower(o)] {
+			continue
+		}
+		seen[strings.ToLower(o)] = true
+		out = append(out, o)
+	}
+	return out
+}
+
+// Listar atributos de una categoría (incluye los heredados de sus ancestros salvo ?inherited=false)
+func ListCategoryAttributes(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cat Category
+		if err := db.First(&cat, c.Param("category_id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+			return
+		}
+		if c.Query("inherited") == "false" {
+			var attrs []CategoryAttribute
+			if err := db.Where("category_id = ?", cat.ID).Order("position ASC, name ASC").Find(&attrs).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, attrs)
+			return
+		}
+		attrs, err := EffectiveAttributes(db, []uint{cat.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, attrs)
+	}
+}
+
+// Crear atributo de categoría
+func CreateCategoryAttribute(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cat Category
+		if err := db.First(&cat, c.Param("category_id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+			return
+		}
+		var input AttributeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		attr := CategoryAttribute{
+			CategoryID: cat.ID,
+			Key:        AttributeKey(input.Key),
+			Name:       strings.TrimSpace(input.Name),
+			Type:       input.Type,
+			Options:    cleanOptions(input.Options),
+			Unit:       strings.TrimSpace(input.Unit),
+			Required:   input.Required,
+			Filterable: input.Filterable == nil || *input.Filterable,
+			Position:   input.Position,
+		}
+		if attr.Key == "" {
+			attr.Key = AttributeKey(attr.Name)
+		}
+		if attr.Type == "" {
+			attr.Type = AttributeText
+		}
+		if !attributeTypes[attr.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de atributo inválido (text, number, boolean, select o multiselect)"})
+			return
+		}
+		if attr.Key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El atributo necesita un nombre o clave"})
+			return
+		}
+		if (attr.Type == AttributeSelect || attr.Type == AttributeMultiselect) && len(attr.Options) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Los atributos de selección necesitan al menos una opción"})
+			return
+		}
+		var count int64
+		db.Model(&CategoryAttribute{}).Where("category_id = ? AND key = ?", cat.ID, attr.Key).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ya existe un atributo con esa clave en esta categoría"})
+			return
+		}
+		if err := db.Create(&attr).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, attr)
+	}
+}
+
+// Editar atributo de categoría. La clave y el tipo no cambian para no invalidar los valores cargados.
+func UpdateCategoryAttribute(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var attr CategoryAttribute
+		if err := db.First(&attr, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Atributo no encontrado"})
+			return
+		}
+		var input AttributeInput
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if input.Type != "" && input.Type != attr.Type {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se puede cambiar el tipo de un atributo existente"})
+			return
+		}
+		options := cleanOptions(input.Options)
+		if (attr.Type == AttributeSelect || attr.Type == AttributeMultiselect) && len(options) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Los atributos de selección necesitan al menos una opción"})
+			return
+		}
+		filterable := attr.Filterable
+		if input.Filterable != nil {
+			filterable = *input.Filterable
+		}
+		if err := db.Model(&attr).Select("name", "options", "unit", "required", "filterable", "position").Updates(CategoryAttribute{
+			Name:       strings.TrimSpace(input.Name),
+			Options:    options,
+			Unit:       strings.TrimSpace(input.Unit),
+			Required:   input.Required,
+			Filterable: filterable,
+			Position:   input.Position,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		db.First(&attr, attr.ID)
+		c.JSON(http.StatusOK, attr)
+	}
+}
+
+// Eliminar atributo de categoría
+func DeleteCategoryAttribute(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := db.Delete(&CategoryAttribute{}, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Atributo eliminado"})
+	}
+}
@@ -1,31 +1,137 @@
 package category
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+
+	"go-modaMayor/internal/slug"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// Crear categoría
+type CategoryInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+	Slug        string `json:"slug"`
+	Position    int    `json:"position"`
+	ImageURL    string `json:"image_url"`
+}
+
+// nameTaken valida nombre duplicado entre hermanas (mismo padre)
+func nameTaken(db *gorm.DB, name string, parentID *uint, excludeID uint) bool {
+	q := db.Model(&Category{}).Where("LOWER(name) = LOWER(?) AND id <> ?", strings.TrimSpace(name), excludeID)
+	if parentID == nil {
+		q = q.Where("parent_id IS NULL")
+	} else {
+		q = q.Where("parent_id = ?", *parentID)
+	}
+	var count int64
+	q.Count(&count)
+	return count > 0
+}
+
+func respondTreeError(c *gin.Context, err error) {
+	if errors.Is(err, ErrCategoryCycle) || err.Error() == "categoría padre no encontrada" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// Crear categoría (raíz o hija de parent_id)
 func CreateCategory(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var input Category
+		var input CategoryInput
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		// Validar categoría duplicada por nombre
-		var existing Category
-		if err := db.Where("name = ?", input.Name).First(&existing).Error; err == nil {
+		if input.ParentID != nil && *input.ParentID == 0 {
+			input.ParentID = nil
+		}
+		// Validar categoría duplicada por nombre dentro del mismo padre
+		if nameTaken(db, input.Name, input.ParentID, 0) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Ya existe una categoría con ese nombre"})
 			return
 		}
-		if err := db.Create(&input).Error; err != nil {
+		base := input.Slug
+		if slug.Make(base) == "" {
+			base = input.Name
+		}
+		catSlug, err := slug.Unique(db, slug.EntityCategory, base, 0)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusCreated, input)
+		cat := Category{
+			Name:        strings.TrimSpace(input.Name),
+			Description: input.Description,
+			ParentID:    input.ParentID,
+			Slug:        catSlug,
+			Position:    input.Position,
+			ImageURL:    input.ImageURL,
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&cat).Error; err != nil {
+				return err
+			}
+			return refreshPath(tx, &cat)
+		}); err != nil {
+			respondTreeError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, cat)
+	}
+}
+
+// Categoría por slug con su breadcrumb y sus hijas directas. Un slug anterior
+// (la categoría cambió de nombre) redirige con 301 al actual.
+func GetCategoryBySlug(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, redirected, err := slug.Resolve(db, slug.EntityCategory, c.Param("slug"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var cat Category
+		if err := db.Preload("Children", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("position ASC, name ASC")
+		}).First(&cat, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
+			return
+		}
+		if redirected {
+			c.Redirect(http.StatusMovedPermanently, strings.TrimSuffix(c.Request.URL.Path, c.Param("slug"))+cat.Slug)
+			return
+		}
+		breadcrumb, err := Ancestors(db, cat.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"category": cat, "breadcrumb": breadcrumb})
+	}
+}
+
+// Árbol completo de categorías con sus hijas anidadas
+func GetCategoryTree(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tree, err := BuildTree(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if tree == nil {
+			tree = []Category{}
+		}
+		c.JSON(http.StatusOK, tree)
 	}
 }
 
@@ -50,23 +156,86 @@ func UpdateCategory(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Categoría no encontrada"})
 			return
 		}
-		var input Category
+		var input struct {
+			Name        *string `json:"name"`
+			Description *string `json:"description"`
+			ParentID    *uint   `json:"parent_id"`
+			Slug        *string `json:"slug"`
+			Position    *int    `json:"position"`
+			ImageURL    *string `json:"image_url"`
+		}
 		if err := c.ShouldBindJSON(&input); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if err := db.Model(&category).Updates(input).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// parent_id 0 mueve la categoría a la raíz; sin parent_id queda donde está
+		parentID := category.ParentID
+		moved := false
+		if input.ParentID != nil {
+			moved = true
+			parentID = input.ParentID
+			if *parentID == 0 {
+				parentID = nil
+			}
+		}
+		name := category.Name
+		if input.Name != nil && strings.TrimSpace(*input.Name) != "" {
+			name = strings.TrimSpace(*input.Name)
+		}
+		if nameTaken(db, name, parentID, category.ID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Ya existe una categoría con ese nombre"})
 			return
 		}
+		updates := map[string]any{"name": name, "parent_id": parentID}
+		if input.Description != nil {
+			updates["description"] = *input.Description
+		}
+		if input.Position != nil {
+			updates["position"] = *input.Position
+		}
+		if input.ImageURL != nil {
+			updates["image_url"] = *input.ImageURL
+		}
+		// El slug sigue al nombre salvo que se envíe uno explícito; el anterior queda como redirección
+		slugBase := ""
+		if input.Slug != nil && slug.Make(*input.Slug) != "" {
+			slugBase = *input.Slug
+		} else if name != category.Name || category.Slug == "" {
+			slugBase = name
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&category).Updates(updates).Error; err != nil {
+				return err
+			}
+			if slugBase != "" {
+				if _, err := slug.Change(tx, slug.EntityCategory, category.ID, category.Slug, slugBase); err != nil {
+					return err
+				}
+			}
+			category.ParentID = parentID
+			if moved || category.Path == "" {
+				return refreshPath(tx, &category)
+			}
+			return nil
+		}); err != nil {
+			respondTreeError(c, err)
+			return
+		}
+		db.First(&category, category.ID)
 		c.JSON(http.StatusOK, category)
 	}
 }
 
-// Eliminar categoría
+// Eliminar categoría (sólo si no tiene subcategorías en el árbol)
 func DeleteCategory(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
+		var children int64
+		db.Model(&Category{}).Where("parent_id = ?", id).Count(&children)
+		if children > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La categoría tiene subcategorías; muévalas o elimínelas primero"})
+			return
+		}
 		if err := db.Delete(&Category{}, id).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
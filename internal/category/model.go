@@ -2,11 +2,21 @@ package category
 
 import "gorm.io/gorm"
 
+// Category es un nodo del árbol de categorías. Path guarda los IDs de la raíz
+// al nodo ("/1/5/12/") para resolver subárboles con un LIKE.
 type Category struct {
 	gorm.Model
-	Name          string        `json:"name" gorm:"unique;not null"`
-	Description   string        `json:"description"`
-	Subcategories []Subcategory `json:"subcategories" gorm:"foreignKey:CategoryID"`
+	Name          string              `json:"name" gorm:"not null"`
+	Description   string              `json:"description"`
+	ParentID      *uint               `json:"parent_id" gorm:"index"`
+	Slug          string              `json:"slug" gorm:"type:varchar(160);index"`
+	Position      int                 `json:"position" gorm:"default:0"`
+	ImageURL      string              `json:"image_url"`
+	Path          string              `json:"path" gorm:"type:varchar(255);index"`
+	Depth         int                 `json:"depth" gorm:"default:0"`
+	Children      []Category          `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	Attributes    []CategoryAttribute `json:"attributes,omitempty" gorm:"foreignKey:CategoryID"`
+	Subcategories []Subcategory       `json:"subcategories" gorm:"foreignKey:CategoryID"`
 }
 
 type Subcategory struct {
@@ -0,0 +1,122 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrCategoryCycle se devuelve al intentar mover una categoría debajo de sí misma
+var ErrCategoryCycle = errors.New("una categoría no puede quedar dentro de sí misma o de una de sus subcategorías")
+
+// refreshPath recalcula Path y Depth de la categoría según su padre y propaga
+// el cambio a todos sus descendientes
+func refreshPath(tx *gorm.DB, cat *Category) error {
+	path := fmt.Sprintf("/%d/", cat.ID)
+	depth := 0
+	if cat.ParentID != nil {
+		var parent Category
+		if err := tx.First(&parent, *cat.ParentID).Error; err != nil {
+			return fmt.Errorf("categoría padre no encontrada")
+		}
+		if strings.Contains(parent.Path, path) || parent.ID == cat.ID {
+			return ErrCategoryCycle
+		}
+		path = parent.Path + strconv.FormatUint(uint64(cat.ID), 10) + "/"
+		depth = parent.Depth + 1
+	}
+	oldPath := cat.Path
+	if err := tx.Model(cat).Updates(map[string]any{"path": path, "depth": depth}).Error; err != nil {
+		return err
+	}
+	cat.Path, cat.Depth = path, depth
+	if oldPath == "" || oldPath == path {
+		return nil
+	}
+
+	var descendants []Category
+	if err := tx.Where("path LIKE ? AND id <> ?", oldPath+"%", cat.ID).Find(&descendants).Error; err != nil {
+		return err
+	}
+	for _, d := range descendants {
+		newPath := path + strings.TrimPrefix(d.Path, oldPath)
+		if err := tx.Model(&Category{}).Where("id = ?", d.ID).
+			Updates(map[string]any{"path": newPath, "depth": strings.Count(newPath, "/") - 2}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PathIDs devuelve los IDs de la raíz hasta la categoría (inclusive)
+func (c Category) PathIDs() []uint {
+	var ids []uint
+	for _, part := range strings.Split(strings.Trim(c.Path, "/"), "/") {
+		if id, err := strconv.ParseUint(part, 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) == 0 && c.ID != 0 {
+		ids = []uint{c.ID}
+	}
+	return ids
+}
+
+// Ancestors devuelve las categorías de la raíz hasta la indicada (breadcrumb)
+func Ancestors(db *gorm.DB, id uint) ([]Category, error) {
+	var cat Category
+	if err := db.First(&cat, id).Error; err != nil {
+		return nil, err
+	}
+	ids := cat.PathIDs()
+	var found []Category
+	if err := db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := map[uint]Category{}
+	for _, f := range found {
+		byID[f.ID] = f
+	}
+	out := make([]Category, 0, len(ids))
+	for _, i := range ids {
+		if f, ok := byID[i]; ok {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// SubtreeIDs arma la subconsulta con los IDs de la categoría y todos sus descendientes
+func SubtreeIDs(db *gorm.DB, id uint) *gorm.DB {
+	return db.Table("categories AS c").Select("c.id").
+		Joins("JOIN categories AS root ON root.id = ?", id).
+		Where("c.deleted_at IS NULL AND (c.id = root.id OR (root.path <> '' AND c.path LIKE root.path || '%'))")
+}
+
+// BuildTree arma el árbol completo ordenado por posición y nombre
+func BuildTree(db *gorm.DB) ([]Category, error) {
+	var all []Category
+	if err := db.Order("position ASC, name ASC").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	children := map[uint][]Category{}
+	var roots []Category
+	for _, c := range all {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+	var attach func(nodes []Category) []Category
+	attach = func(nodes []Category) []Category {
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].ID])
+		}
+		return nodes
+	}
+	return attach(roots), nil
+}
@@ -0,0 +1,110 @@
+package category
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-modaMayor/internal/slug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCategoryTree_MovePathsAndInheritedAttributes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:category_tree?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&Category{}, &Subcategory{}, &CategoryAttribute{}, &slug.Redirect{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/categories", CreateCategory(db))
+	router.PUT("/categories/:id", UpdateCategory(db))
+	router.DELETE("/categories/:id", DeleteCategory(db))
+	router.POST("/categories/:category_id/attributes", CreateCategoryAttribute(db))
+	send := func(method, path string, body any) (*httptest.ResponseRecorder, Category) {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var cat Category
+		json.Unmarshal(w.Body.Bytes(), &cat)
+		return w, cat
+	}
+
+	_, mujer := send(http.MethodPost, "/categories", gin.H{"name": "Mujer"})
+	_, hombre := send(http.MethodPost, "/categories", gin.H{"name": "Hombre"})
+	_, remeras := send(http.MethodPost, "/categories", gin.H{"name": "Remeras", "parent_id": mujer.ID})
+	_, basicas := send(http.MethodPost, "/categories", gin.H{"name": "Básicas", "parent_id": remeras.ID})
+	if basicas.Depth != 2 || basicas.Path != "/1/3/4/" || basicas.Slug != "basicas" {
+		t.Fatalf("unexpected node: %+v", basicas)
+	}
+	// Mismo nombre con otro padre es válido; el slug no se repite
+	w, remerasH := send(http.MethodPost, "/categories", gin.H{"name": "Remeras", "parent_id": hombre.ID})
+	if w.Code != http.StatusCreated || remerasH.Slug != "remeras-2" {
+		t.Fatalf("expected sibling-scoped name, got %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := send(http.MethodPost, "/categories", gin.H{"name": "remeras", "parent_id": mujer.ID}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected duplicate sibling rejected, got %d", w.Code)
+	}
+
+	// Mover debajo de un descendiente es un ciclo
+	if w, _ := send(http.MethodPut, "/categories/1", gin.H{"parent_id": basicas.ID}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected cycle rejected, got %d %s", w.Code, w.Body.String())
+	}
+	// Mover Remeras bajo Hombre actualiza el camino de Básicas
+	if w, _ := send(http.MethodPut, "/categories/3", gin.H{"parent_id": hombre.ID, "name": "Remeras mujer"}); w.Code != http.StatusOK {
+		t.Fatalf("move: %d %s", w.Code, w.Body.String())
+	}
+	var moved Category
+	db.First(&moved, basicas.ID)
+	if moved.Path != "/2/3/4/" || moved.Depth != 2 {
+		t.Fatalf("descendant path not updated: %+v", moved)
+	}
+	// El cambio de nombre regenera el slug y el anterior redirige
+	if id, redirected, err := slug.Resolve(db, slug.EntityCategory, "remeras"); err != nil || id != remeras.ID || !redirected {
+		t.Fatalf("expected redirect from old slug, got %d %v %v", id, redirected, err)
+	}
+	var ids []uint
+	SubtreeIDs(db, hombre.ID).Order("c.id").Pluck("c.id", &ids)
+	if len(ids) != 4 {
+		t.Fatalf("expected hombre subtree of 4, got %v", ids)
+	}
+	if w, _ := send(http.MethodDelete, "/categories/3", nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected delete with children rejected, got %d", w.Code)
+	}
+
+	// Atributos: Básicas hereda "tela" y redefine "calce"
+	send(http.MethodPost, "/categories/2/attributes", gin.H{"name": "Tela", "type": "select", "options": []string{"Algodón", "Lino"}})
+	send(http.MethodPost, "/categories/2/attributes", gin.H{"name": "Calce", "type": "text"})
+	if w, _ := send(http.MethodPost, "/categories/4/attributes", gin.H{"key": "calce", "name": "Calce básico", "type": "select", "options": []string{"Regular", "Oversize"}}); w.Code != http.StatusCreated {
+		t.Fatalf("create attr: %d %s", w.Code, w.Body.String())
+	}
+	if w, _ := send(http.MethodPost, "/categories/4/attributes", gin.H{"name": "Escote", "type": "select"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected select without options rejected, got %d", w.Code)
+	}
+	attrs, err := EffectiveAttributes(db, []uint{basicas.ID})
+	if err != nil || len(attrs) != 2 {
+		t.Fatalf("expected 2 effective attributes, got %v %v", attrs, err)
+	}
+	for _, a := range attrs {
+		if a.Key == "calce" && a.CategoryID != basicas.ID {
+			t.Fatalf("expected deepest definition to win: %+v", a)
+		}
+		if a.Key == "tela" {
+			if v, err := a.NormalizeValues("algodón"); err != nil || v[0] != "Algodón" {
+				t.Fatalf("expected canonical option, got %v %v", v, err)
+			}
+			if _, err := a.NormalizeValues([]any{"Lino", "Algodón"}); err == nil {
+				t.Fatalf("expected single value for select")
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/xlsx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:exports_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&sequence.Sequence{}, &category.Category{}, &category.Subcategory{}, &product.Product{},
+		&product.ProductVariant{}, &product.LocationStock{}, &product.Supplier{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	config.DB = db
+	return db
+}
+
+func seedCatalog(db *gorm.DB) {
+	cat := category.Category{Name: "Remeras"}
+	db.Create(&cat)
+	p := product.Product{Name: "Remera Lisa", CategoryID: cat.ID, WholesalePrice: 1000, Discount1Price: 950, Discount2Price: 900}
+	db.Create(&p)
+	negro := product.ProductVariant{ProductID: p.ID, Color: "Negro", Size: "M", SKU: "RL-NEGRO-M"}
+	blanco := product.ProductVariant{ProductID: p.ID, Color: "Blanco", Size: "M", SKU: "RL-BLANCO-M"}
+	db.Create(&negro)
+	db.Create(&blanco)
+	db.Create(&product.LocationStock{ProductID: p.ID, VariantID: &negro.ID, Location: "deposito", Stock: 10, Reserved: 2})
+	db.Create(&product.LocationStock{ProductID: p.ID, VariantID: &negro.ID, Location: "mendoza", Stock: 3})
+	db.Create(&product.LocationStock{ProductID: p.ID, VariantID: &blanco.ID, Location: "deposito", Stock: 5})
+	db.Create(&product.Product{Name: "Jean Mom", WholesalePrice: 5000})
+}
+
+func performExport(handler gin.HandlerFunc, query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/export", handler)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/export?"+query, nil)
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestExportProducts_CSVWithFilters(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(db)
+
+	w := performExport(ExportProducts, "color=negro,blanco")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(w.Body.String(), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	// encabezado + 2 variantes (el jean queda fuera por el filtro de color)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 rows, got %q", records)
+	}
+	header := strings.Join(records[0], ",")
+	if !strings.Contains(header, "stock_deposito,stock_mendoza,stock_total,reservado,disponible") || strings.Contains(header, "costo") {
+		t.Fatalf("unexpected header: %s", header)
+	}
+	negro := records[2]
+	if negro[5] != "RL-NEGRO-M" || negro[2] != "Remeras" || negro[8] != "1000.00" || negro[11] != "10" || negro[12] != "3" || negro[14] != "2" || negro[15] != "11" {
+		t.Fatalf("unexpected row: %q", negro)
+	}
+}
+
+func TestExportStock_XLSXByLocation(t *testing.T) {
+	db := setupTestDB(t)
+	seedCatalog(db)
+
+	w := performExport(ExportStock, "format=xlsx&location=deposito")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.Bytes()
+	rows, err := xlsx.ReadRows(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("invalid xlsx: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header + 2 rows, got %q", rows)
+	}
+	for _, row := range rows[1:] {
+		if row[5] != "deposito" {
+			t.Fatalf("unexpected location row: %q", row)
+		}
+	}
+	if rows[2][2] != "RL-NEGRO-M" || rows[2][8] != "8" {
+		t.Fatalf("unexpected row: %q", rows[2])
+	}
+}
+
+func TestExport_InvalidParams(t *testing.T) {
+	setupTestDB(t)
+	if w := performExport(ExportProducts, "format=pdf"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for format, got %d", w.Code)
+	}
+	if w := performExport(ExportStock, "price_tier=vip"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for price_tier, got %d", w.Code)
+	}
+}
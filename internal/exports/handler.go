@@ -0,0 +1,212 @@
+package exports
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// batchSize es la cantidad de productos que se cargan por consulta al exportar
+const batchSize = 200
+
+// ExportProducts exporta el catálogo con una fila por variante: precios por tier,
+// stock por ubicación y totales de stock y reservado. La lista se comparte con
+// revendedores, así que no incluye el costo.
+// GET /exports/products?format=csv|xlsx acepta los mismos filtros que GET /products.
+func ExportProducts(c *gin.Context) {
+	ids, format, ok := prepareExport(c)
+	if !ok {
+		return
+	}
+	locations, err := stockLocations(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	suppliers, err := supplierNames(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	header := []any{"codigo", "producto", "categoria", "subcategoria", "proveedor", "sku", "color", "talle",
+		"precio_mayorista", "precio_descuento1", "precio_descuento2"}
+	for _, loc := range locations {
+		header = append(header, "stock_"+loc)
+	}
+	header = append(header, "stock_total", "reservado", "disponible")
+
+	streamExport(c, format, "productos", ids, header, func(w rowWriter, p product.Product) error {
+		for _, line := range variantLines(p) {
+			byLocation := make(map[string]int, len(line.stocks))
+			total, reserved := 0, 0
+			for _, ls := range line.stocks {
+				byLocation[ls.Location] += ls.Stock
+				total += ls.Stock
+				reserved += ls.Reserved
+			}
+			row := []any{p.Code, p.Name, p.Category.Name, p.Subcategory.Name, supplierName(suppliers, p.SupplierID),
+				line.sku, line.color, line.size, p.WholesalePrice, p.Discount1Price, p.Discount2Price}
+			for _, loc := range locations {
+				row = append(row, byLocation[loc])
+			}
+			row = append(row, total, reserved, total-reserved)
+			if err := w.WriteRow(row...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExportStock exporta una fila por variante y ubicación con stock, reservado,
+// disponible y precios por tier. Con ?location= sólo se listan filas de esa ubicación.
+// GET /exports/stock?format=csv|xlsx acepta los mismos filtros que GET /products.
+func ExportStock(c *gin.Context) {
+	ids, format, ok := prepareExport(c)
+	if !ok {
+		return
+	}
+	location := c.Query("location")
+	header := []any{"codigo", "producto", "sku", "color", "talle", "ubicacion", "stock", "reservado", "disponible",
+		"precio_mayorista", "precio_descuento1", "precio_descuento2"}
+
+	streamExport(c, format, "stock", ids, header, func(w rowWriter, p product.Product) error {
+		for _, line := range variantLines(p) {
+			for _, ls := range line.stocks {
+				if location != "" && ls.Location != location {
+					continue
+				}
+				if err := w.WriteRow(p.Code, p.Name, line.sku, line.color, line.size, ls.Location, ls.Stock, ls.Reserved,
+					ls.Stock-ls.Reserved, p.WholesalePrice, p.Discount1Price, p.Discount2Price); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// prepareExport valida el formato y resuelve los productos filtrados. Si algo
+// falla responde el error y devuelve ok=false.
+func prepareExport(c *gin.Context) ([]uint, string, bool) {
+	format := c.DefaultQuery("format", formatCSV)
+	if _, ok := contentTypes[format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format inválido: use csv o xlsx"})
+		return nil, "", false
+	}
+	ids, err := product.FilteredProductIDs(c, config.DB)
+	if err != nil {
+		var filterErr *product.FilterError
+		if errors.As(err, &filterErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": filterErr.Message})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return nil, "", false
+	}
+	return ids, format, true
+}
+
+// streamExport escribe encabezado y filas en la respuesta, cargando los productos
+// por lotes y enviando cada lote al cliente antes de leer el siguiente.
+func streamExport(c *gin.Context, format, name string, ids []uint, header []any, writeProduct func(rowWriter, product.Product) error) {
+	fileName := fmt.Sprintf("%s_%s.%s", name, time.Now().Format("20060102"), format)
+	c.Header("Content-Type", contentTypes[format])
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Status(http.StatusOK)
+
+	w, err := newRowWriter(c.Writer, format, name)
+	if err == nil {
+		err = w.WriteRow(header...)
+	}
+	for start := 0; err == nil && start < len(ids); start += batchSize {
+		batch := ids[start:min(start+batchSize, len(ids))]
+		var products []product.Product
+		err = config.DB.Preload("Category").Preload("Subcategory").
+			Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("color, size, id") }).
+			Preload("LocationStocks", func(db *gorm.DB) *gorm.DB { return db.Order("location") }).
+			Where("id IN ?", batch).Order("id").Find(&products).Error
+		for _, p := range products {
+			if err != nil {
+				break
+			}
+			err = writeProduct(w, p)
+		}
+		if err == nil {
+			err = w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		// Los encabezados ya se enviaron: sólo queda cortar la descarga
+		log.Printf("❌ Exportación %s: %v", name, err)
+		c.Abort()
+	}
+}
+
+// variantLine agrupa el stock de una variante (o del producto sin variantes)
+type variantLine struct {
+	sku, color, size string
+	stocks           []product.LocationStock
+}
+
+func variantLines(p product.Product) []variantLine {
+	if len(p.Variants) == 0 {
+		line := variantLine{}
+		for _, ls := range p.LocationStocks {
+			if ls.VariantID == nil {
+				line.stocks = append(line.stocks, ls)
+			}
+		}
+		return []variantLine{line}
+	}
+	lines := make([]variantLine, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		line := variantLine{sku: v.SKU, color: v.Color, size: v.Size}
+		for _, ls := range p.LocationStocks {
+			if ls.VariantID != nil && *ls.VariantID == v.ID {
+				line.stocks = append(line.stocks, ls)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// stockLocations lista las ubicaciones con stock registrado, para las columnas stock_<ubicacion>
+func stockLocations(db *gorm.DB) ([]string, error) {
+	var locations []string
+	err := db.Model(&product.LocationStock{}).Distinct("location").Order("location").Pluck("location", &locations).Error
+	return locations, err
+}
+
+func supplierNames(db *gorm.DB) (map[uint]string, error) {
+	var suppliers []product.Supplier
+	if err := db.Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(suppliers))
+	for _, s := range suppliers {
+		names[s.ID] = s.Name
+	}
+	return names, nil
+}
+
+func supplierName(names map[uint]string, id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return names[*id]
+}
@@ -0,0 +1,70 @@
+// Package exports genera listados de catálogo y stock en CSV o XLSX para
+// revendedores y contaduría. Las filas se escriben a medida que se leen los
+// productos por lotes, sin cargar el catálogo completo en memoria.
+package exports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"go-modaMayor/internal/xlsx"
+)
+
+// rowWriter es la salida común a CSV y XLSX
+type rowWriter interface {
+	WriteRow(values ...any) error
+	Flush() error
+	Close() error
+}
+
+const (
+	formatCSV  = "csv"
+	formatXLSX = "xlsx"
+)
+
+var contentTypes = map[string]string{
+	formatCSV:  "text/csv; charset=utf-8",
+	formatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func newRowWriter(w io.Writer, format, sheetName string) (rowWriter, error) {
+	switch format {
+	case formatCSV:
+		// BOM para que Excel detecte UTF-8 (acentos) al abrir el CSV
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case formatXLSX:
+		return xlsx.NewWriter(w, sheetName)
+	}
+	return nil, fmt.Errorf("formato inválido: %s (csv o xlsx)", format)
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch n := v.(type) {
+		case float64:
+			record[i] = strconv.FormatFloat(n, 'f', 2, 64)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
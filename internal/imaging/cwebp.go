@@ -0,0 +1,68 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// WebPQuality es la calidad de las variantes WebP con pérdida (cwebp -q)
+const WebPQuality = 80
+
+// CWebPEncoder devuelve un encoder WebP con pérdida que usa el binario cwebp de
+// libwebp. La imagen se le pasa como PNG (conserva la transparencia) por archivos
+// temporales y se descartan los metadatos.
+func CWebPEncoder(path string, quality int) func(io.Writer, image.Image) error {
+	return func(w io.Writer, img image.Image) error {
+		dir, err := os.MkdirTemp("", "cwebp")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		in, out := filepath.Join(dir, "in.png"), filepath.Join(dir, "out.webp")
+		f, err := os.Create(in)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		cmd := exec.Command(path, "-quiet", "-q", strconv.Itoa(quality), "-metadata", "none", in, "-o", out)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("cwebp: %v: %s", err, bytes.TrimSpace(output))
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
+
+// defaultWebPEncoder usa cwebp (CWEBP_PATH o el del PATH) si está instalado. Si no,
+// devuelve nil y las imágenes se publican sólo en JPEG/PNG.
+func defaultWebPEncoder() func(io.Writer, image.Image) error {
+	path := os.Getenv("CWEBP_PATH")
+	if path == "" {
+		path, _ = exec.LookPath("cwebp")
+	}
+	if path == "" {
+		log.Println("⚠️ cwebp no está instalado: las imágenes se publican sin variantes WebP")
+		return nil
+	}
+	return CWebPEncoder(path, WebPQuality)
+}
@@ -0,0 +1,61 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCWebPEncoder_RunsCWebP(t *testing.T) {
+	// cwebp falso: guarda los argumentos y escribe un archivo en -o
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := filepath.Join(dir, "cwebp")
+	os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > "+args+"\nwhile [ \"$1\" != \"-o\" ]; do shift; done\nprintf RIFFWEBP > \"$2\"\n"), 0o755)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	var buf bytes.Buffer
+	if err := CWebPEncoder(script, 75)(&buf, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "RIFFWEBP" {
+		t.Fatalf("expected cwebp output, got %q", buf.String())
+	}
+	got, _ := os.ReadFile(args)
+	if !strings.HasPrefix(string(got), "-quiet -q 75 -metadata none ") {
+		t.Fatalf("unexpected cwebp arguments: %s", got)
+	}
+
+	failing := filepath.Join(dir, "failing")
+	os.WriteFile(failing, []byte("#!/bin/sh\necho 'Could not read input' >&2\nexit 1\n"), 0o755)
+	if err := CWebPEncoder(failing, 75)(&buf, img); err == nil || !strings.Contains(err.Error(), "Could not read input") {
+		t.Fatalf("expected cwebp error, got %v", err)
+	}
+}
+
+func TestCWebPEncoder_LossySmallerThanJPEG(t *testing.T) {
+	path := os.Getenv("CWEBP_PATH")
+	if path == "" {
+		t.Skip("cwebp no está instalado")
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			i := img.PixOffset(x, y)
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = uint8(x*y), uint8(x+y*3), uint8(x^y), 255
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	renditions, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(renditions) != 2 || renditions[1].Format != "webp" {
+		t.Fatalf("expected a lossy webp rendition, got %+v", renditions)
+	}
+}
@@ -0,0 +1,329 @@
+// Package imaging procesa las imágenes subidas por el admin: valida tipo y
+// tamaño, descarta los metadatos EXIF (aplicando antes la orientación), genera
+// los tamaños thumb/card/zoom en JPEG o PNG más su variante WebP y los nombra
+// por hash de contenido. El resultado se describe con un ImageSet listo para
+// usar en srcset.
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"go-modaMayor/internal/storage"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// MaxUploadSize es el tamaño máximo aceptado por archivo
+const MaxUploadSize = 10 << 20
+
+// MaxPixels evita decodificar imágenes gigantes (bombas de descompresión)
+const MaxPixels = 40_000_000
+
+// JPEGQuality es la calidad de los JPEG generados
+const JPEGQuality = 85
+
+// Size es un tamaño de salida: el ancho máximo en píxeles (no se amplía)
+type Size struct {
+	Name  string
+	Width int
+}
+
+// Sizes son los tamaños generados para cada imagen
+var Sizes = []Size{
+	{Name: "thumb", Width: 200},
+	{Name: "card", Width: 600},
+	{Name: "zoom", Width: 1600},
+}
+
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// InvalidImageError indica un archivo rechazado por tipo, tamaño o contenido (corresponde a un 400)
+type InvalidImageError struct {
+	Message string
+}
+
+func (e *InvalidImageError) Error() string { return e.Message }
+
+// Rendition es un archivo generado
+type Rendition struct {
+	Size   string `json:"size"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"` // jpeg, png o webp
+	URL    string `json:"url"`
+	Name   string `json:"-"`
+	Data   []byte `json:"-"`
+}
+
+// ImageSet describe todas las versiones de una imagen. Src es la versión más
+// grande en formato compatible; Srcset y WebPSrcset se usan tal cual en <img>/<source>.
+type ImageSet struct {
+	Hash       string      `json:"hash"`
+	Src        string      `json:"src"`
+	Srcset     string      `json:"srcset"`
+	WebPSrcset string      `json:"webp_srcset,omitempty"`
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Renditions []Rendition `json:"renditions"`
+}
+
+// Value guarda el ImageSet como JSON en una columna de texto
+func (s ImageSet) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan lee el ImageSet desde JSON
+func (s *ImageSet) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*s = ImageSet{}
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("tipo no soportado para ImageSet: %T", value)
+	}
+	if len(data) == 0 {
+		*s = ImageSet{}
+		return nil
+	}
+	return json.Unmarshal(data, s)
+}
+
+// Process valida y transforma la imagen original. Los nombres de archivo son
+// <hash>_<tamaño>.<ext>, con el hash SHA-256 del contenido original, de modo que
+// subir dos veces el mismo archivo no genera copias ni pisa otras imágenes.
+func Process(data []byte) ([]Rendition, error) {
+	if len(data) == 0 {
+		return nil, &InvalidImageError{Message: "El archivo está vacío"}
+	}
+	if len(data) > MaxUploadSize {
+		return nil, &InvalidImageError{Message: fmt.Sprintf("La imagen supera el máximo de %d MB", MaxUploadSize>>20)}
+	}
+	mimeType := http.DetectContentType(data)
+	if !allowedTypes[mimeType] {
+		return nil, &InvalidImageError{Message: "Tipo de archivo no permitido (" + mimeType + "): use JPEG, PNG, GIF o WebP"}
+	}
+	cfg, err := decodeConfig(mimeType, data)
+	if err != nil {
+		return nil, &InvalidImageError{Message: "No se pudo leer la imagen: " + err.Error()}
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, &InvalidImageError{Message: fmt.Sprintf("La imagen es demasiado grande (%dx%d)", cfg.Width, cfg.Height)}
+	}
+	img, err := decode(mimeType, data)
+	if err != nil {
+		return nil, &InvalidImageError{Message: "No se pudo leer la imagen: " + err.Error()}
+	}
+	if mimeType == "image/jpeg" {
+		// Al volver a codificar se pierden los metadatos EXIF: la orientación se aplica antes
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])[:20]
+	opaque := isOpaque(img)
+
+	renditions := []Rendition{}
+	srcBounds := img.Bounds()
+	lastWidth := 0
+	for _, size := range Sizes {
+		width := min(size.Width, srcBounds.Dx())
+		if width == lastWidth {
+			// La original es más chica que este tamaño: no se repite la misma versión
+			continue
+		}
+		lastWidth = width
+		height := max(1, srcBounds.Dy()*width/srcBounds.Dx())
+		scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, srcBounds, draw.Src, nil)
+
+		var buf bytes.Buffer
+		format := "jpeg"
+		if opaque {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: JPEGQuality})
+		} else {
+			format = "png"
+			err = png.Encode(&buf, scaled)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fallback := Rendition{Size: size.Name, Width: width, Height: height, Format: format, Data: buf.Bytes()}
+		renditions = append(renditions, fallback)
+
+		if WebPEncoder == nil {
+			continue
+		}
+		var webpBuf bytes.Buffer
+		if err := WebPEncoder(&webpBuf, scaled); err != nil {
+			return nil, err
+		}
+		// Sólo se publica si pesa menos que el JPEG/PNG
+		if webpBuf.Len() < buf.Len() {
+			renditions = append(renditions, Rendition{Size: size.Name, Width: width, Height: height, Format: "webp", Data: webpBuf.Bytes()})
+		}
+	}
+	for i := range renditions {
+		ext := renditions[i].Format
+		if ext == "jpeg" {
+			ext = "jpg"
+		}
+		renditions[i].Name = fmt.Sprintf("%s_%s.%s", hash, renditions[i].Size, ext)
+	}
+	return renditions, nil
+}
+
+// WebPEncoder codifica las variantes WebP con pérdida mediante cwebp. Es nil si
+// cwebp no está instalado: en ese caso no se generan variantes WebP.
+var WebPEncoder = defaultWebPEncoder()
+
+// BuildImageSet arma la descripción srcset a partir de renditions con URL asignada
+func BuildImageSet(renditions []Rendition) *ImageSet {
+	set := &ImageSet{Renditions: renditions}
+	var srcset, webpSrcset []string
+	for _, r := range renditions {
+		if set.Hash == "" {
+			set.Hash, _, _ = strings.Cut(r.Name, "_")
+		}
+		entry := fmt.Sprintf("%s %dw", r.URL, r.Width)
+		if r.Format == "webp" {
+			webpSrcset = append(webpSrcset, entry)
+			continue
+		}
+		srcset = append(srcset, entry)
+		set.Src, set.Width, set.Height = r.URL, r.Width, r.Height
+	}
+	set.Srcset = strings.Join(srcset, ", ")
+	set.WebPSrcset = strings.Join(webpSrcset, ", ")
+	return set
+}
+
+// RewriteURLs aplica fn a todas las URLs del set (src, renditions y srcsets),
+// por ejemplo al mover los archivos a otro almacenamiento.
+func (s *ImageSet) RewriteURLs(fn func(string) string) {
+	s.Src = fn(s.Src)
+	for i := range s.Renditions {
+		s.Renditions[i].URL = fn(s.Renditions[i].URL)
+	}
+	rewrite := func(srcset string) string {
+		if srcset == "" {
+			return ""
+		}
+		entries := strings.Split(srcset, ", ")
+		for i, entry := range entries {
+			url, width, _ := strings.Cut(entry, " ")
+			entries[i] = fn(url) + " " + width
+		}
+		return strings.Join(entries, ", ")
+	}
+	s.Srcset = rewrite(s.Srcset)
+	s.WebPSrcset = rewrite(s.WebPSrcset)
+}
+
+// ReadUpload lee un archivo de un formulario multipart respetando MaxUploadSize
+func ReadUpload(fh *multipart.FileHeader) ([]byte, error) {
+	if fh.Size > MaxUploadSize {
+		return nil, &InvalidImageError{Message: fmt.Sprintf("La imagen supera el máximo de %d MB", MaxUploadSize>>20)}
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// StoreUpload procesa el archivo subido y guarda sus versiones en el almacenamiento.
+// Los archivos ya existentes (mismo hash) no se vuelven a escribir.
+func StoreUpload(ctx context.Context, s storage.Storage, fh *multipart.FileHeader) (*ImageSet, error) {
+	data, err := ReadUpload(fh)
+	if err != nil {
+		return nil, err
+	}
+	renditions, err := Process(data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range renditions {
+		r := &renditions[i]
+		exists, err := s.Exists(ctx, r.Name)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			r.URL = s.URL(r.Name)
+			continue
+		}
+		if r.URL, err = s.Save(ctx, r.Name, bytes.NewReader(r.Data), int64(len(r.Data)), "image/"+r.Format); err != nil {
+			return nil, err
+		}
+	}
+	return BuildImageSet(renditions), nil
+}
+
+func decodeConfig(mimeType string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch mimeType {
+	case "image/jpeg":
+		return jpeg.DecodeConfig(r)
+	case "image/png":
+		return png.DecodeConfig(r)
+	case "image/gif":
+		return gif.DecodeConfig(r)
+	default:
+		return webp.DecodeConfig(r)
+	}
+}
+
+func decode(mimeType string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch mimeType {
+	case "image/jpeg":
+		return jpeg.Decode(r)
+	case "image/png":
+		return png.Decode(r)
+	case "image/gif":
+		return gif.Decode(r) // sólo el primer cuadro
+	default:
+		return webp.Decode(r)
+	}
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+// withOrientation inserta un bloque EXIF (TIFF little endian) con el tag Orientation
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)      // una entrada
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112) // Orientation
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)      // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xff, 0xe1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcess_JPEGOrientationAndSizes(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 400))
+	for y := 0; y < 400; y++ {
+		for x := 0; x < 800; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 90, 255})
+		}
+	}
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, img, nil)
+	data := withOrientation(buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("expected orientation 6 to be read")
+	}
+
+	renditions, err := Process(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fallbacks []Rendition
+	for _, r := range renditions {
+		if r.Format != "webp" {
+			fallbacks = append(fallbacks, r)
+		}
+	}
+	// rotada 90°: 400x800; thumb y card, zoom no se amplía más allá de 400
+	if len(fallbacks) != 2 || fallbacks[0].Width != 200 || fallbacks[0].Height != 400 || fallbacks[1].Width != 400 || fallbacks[1].Height != 800 {
+		t.Fatalf("unexpected renditions: %+v", fallbacks)
+	}
+	for _, r := range fallbacks {
+		if r.Format != "jpeg" || !strings.HasSuffix(r.Name, "_"+r.Size+".jpg") || jpegOrientation(r.Data) != 1 {
+			t.Fatalf("unexpected rendition %s (%s)", r.Name, r.Format)
+		}
+	}
+
+	again, _ := Process(data)
+	if again[0].Name != renditions[0].Name {
+		t.Fatalf("expected content-hash names to be stable")
+	}
+
+	for i := range renditions {
+		renditions[i].URL = "/uploads/" + renditions[i].Name
+	}
+	set := BuildImageSet(renditions)
+	if set.Src != "/uploads/"+fallbacks[1].Name || set.Width != 400 || !strings.Contains(set.Srcset, " 200w, ") || set.Hash == "" {
+		t.Fatalf("unexpected image set: %+v", set)
+	}
+}
+
+func TestProcess_PNGWithAlphaKeepsPNG(t *testing.T) {
+	defer func(enc func(io.Writer, image.Image) error) { WebPEncoder = enc }(WebPEncoder)
+	WebPEncoder = nil
+
+	img := image.NewNRGBA(image.Rect(0, 0, 120, 60))
+	img.SetNRGBA(5, 5, color.NRGBA{255, 0, 0, 128})
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+
+	renditions, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renditions[0].Format != "png" || renditions[0].Width != 120 {
+		t.Fatalf("unexpected rendition: %+v", renditions[0])
+	}
+	// sin cwebp no hay variante WebP
+	if len(renditions) != 1 {
+		t.Fatalf("expected only the png rendition, got %+v", renditions)
+	}
+}
+
+func TestProcess_RejectsInvalidFiles(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("%PDF-1.4 no es una imagen"), append([]byte{0xff, 0xd8, 0xff}, make([]byte, 20)...)} {
+		_, err := Process(data)
+		var invalid *InvalidImageError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("expected InvalidImageError, got %v", err)
+		}
+	}
+}
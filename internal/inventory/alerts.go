@@ -0,0 +1,161 @@
+package inventory
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/notification"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/user"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StockAlert registra que una variante quedó en o por debajo de su punto de
+// reposición en una ubicación. Mientras la alerta esté abierta no se vuelve a
+// notificar; se resuelve sola cuando el disponible supera el umbral.
+type StockAlert struct {
+	gorm.Model
+	ProductID  uint       `json:"product_id" gorm:"index"`
+	VariantID  uint       `json:"variant_id" gorm:"index"`
+	Location   string     `json:"location" gorm:"type:varchar(50);index"`
+	Available  int        `json:"available"`
+	Threshold  int        `json:"threshold"`
+	Status     string     `json:"status" gorm:"type:varchar(20);default:'abierta';index"` // abierta, resuelta
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+// EvaluateStockAlerts compara stock - reserved contra los puntos de reposición,
+// abre alertas nuevas (notificando a admins y encargados) y resuelve las que ya no aplican.
+func EvaluateStockAlerts(db *gorm.DB) error {
+	low, err := product.FindLowStock(db, -1)
+	if err != nil {
+		return err
+	}
+
+	var open []StockAlert
+	if err := db.Where("status = ?", "abierta").Find(&open).Error; err != nil {
+		return err
+	}
+	openByKey := make(map[string]StockAlert, len(open))
+	for _, a := range open {
+		openByKey[alertKey(a.VariantID, a.Location)] = a
+	}
+
+	var recipients []user.User
+	if err := db.Where("role IN ?", []string{"admin", "encargado"}).Find(&recipients).Error; err != nil {
+		return err
+	}
+
+	stillLow := make(map[string]bool, len(low))
+	created := 0
+	for _, r := range low {
+		key := alertKey(r.VariantID, r.Location)
+		stillLow[key] = true
+		if existing, ok := openByKey[key]; ok {
+			// Mantener el disponible actualizado sin volver a notificar
+			if existing.Available != r.Available {
+				db.Model(&existing).Update("available", r.Available)
+			}
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			alert := StockAlert{
+				ProductID: r.ProductID,
+				VariantID: r.VariantID,
+				Location:  r.Location,
+				Available: r.Available,
+				Threshold: r.Threshold,
+				Status:    "abierta",
+			}
+			if err := tx.Create(&alert).Error; err != nil {
+				return err
+			}
+			msg := fmt.Sprintf("Stock bajo: %s (%s) en %s — disponible %d, punto de reposición %d", r.ProductName, r.SKU, r.Location, r.Available, r.Threshold)
+			for _, u := range recipients {
+				if err := tx.Create(&notification.Notification{UserID: u.ID, Message: msg}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("❌ Error creando alerta de stock para variante %d en %s: %v", r.VariantID, r.Location, err)
+			continue
+		}
+		created++
+	}
+
+	resolved := 0
+	now := time.Now()
+	for key, a := range openByKey {
+		if stillLow[key] {
+			continue
+		}
+		if err := db.Model(&a).Updates(map[string]interface{}{"status": "resuelta", "resolved_at": now}).Error; err != nil {
+			log.Printf("❌ Error resolviendo alerta de stock %d: %v", a.ID, err)
+			continue
+		}
+		resolved++
+	}
+
+	if created > 0 || resolved > 0 {
+		log.Printf("📉 Alertas de stock: %d nuevas, %d resueltas", created, resolved)
+	}
+	return nil
+}
+
+func alertKey(variantID uint, location string) string {
+	return fmt.Sprintf("%d|%s", variantID, location)
+}
+
+// StartStockAlertJob lanza una goroutine que evalúa las alertas de stock periódicamente
+func StartStockAlertJob(interval time.Duration) {
+	go func() {
+		log.Printf("🚀 Iniciando job de alertas de stock (intervalo: %v)", interval)
+
+		if err := EvaluateStockAlerts(config.DB); err != nil {
+			log.Printf("❌ Error en primera ejecución del job de alertas de stock: %v", err)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			if err := EvaluateStockAlerts(config.DB); err != nil {
+				log.Printf("❌ Error en job de alertas de stock: %v", err)
+			}
+		}
+	}()
+}
+
+// ListStockAlerts lista alertas de stock (?status=abierta|resuelta, ?location=)
+func ListStockAlerts(c *gin.Context) {
+	query := config.DB.Model(&StockAlert{}).Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	var alerts []StockAlert
+	if err := query.Limit(500).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// RunStockAlertsNow ejecuta la evaluación de alertas a demanda (admin)
+func RunStockAlertsNow(c *gin.Context) {
+	if err := EvaluateStockAlerts(config.DB); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alertas de stock evaluadas"})
+}
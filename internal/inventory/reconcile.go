@@ -0,0 +1,221 @@
+package inventory
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// closedCartStates son los estados de carrito que ya no retienen reservas
+var closedCartStates = []string{"pagado", "enviado", "completado", "cancelado", "expirado"}
+
+// LedgerDiscrepancy es una variante/ubicación cuyo saldo no coincide con el
+// libro de movimientos o cuya reserva no coincide con los carritos abiertos.
+type LedgerDiscrepancy struct {
+	ProductID        uint   `json:"product_id"`
+	VariantID        *uint  `json:"variant_id"`
+	Location         string `json:"location"`
+	Stock            int    `json:"stock"`        // location_stocks.stock
+	LedgerStock      int    `json:"ledger_stock"` // suma de stock_movements.quantity
+	StockDiff        int    `json:"stock_diff"`   // stock - ledger_stock
+	Reserved         int    `json:"reserved"`     // location_stocks.reserved
+	OpenCartReserved int    `json:"open_cart_reserved"`
+	ReservedDiff     int    `json:"reserved_diff"` // reserved - open_cart_reserved
+	MissingRow       bool   `json:"missing_row"`   // hay movimientos o reservas pero no fila en location_stocks
+	Fixed            bool   `json:"fixed"`
+}
+
+type ReconciliationReport struct {
+	CheckedAt          time.Time           `json:"checked_at"`
+	RowsChecked        int                 `json:"rows_checked"`
+	StockMismatches    int                 `json:"stock_mismatches"`
+	ReservedMismatches int                 `json:"reserved_mismatches"`
+	Fixed              int                 `json:"fixed"`
+	Discrepancies      []LedgerDiscrepancy `json:"discrepancies"`
+}
+
+type ledgerKey struct {
+	ProductID uint
+	VariantID uint // 0 = sin variante
+	Location  string
+}
+
+// ReconcileStock reproduce stock_movements por producto/variante/ubicación, lo compara con
+// location_stocks y contrasta reserved con las reservas de carritos abiertos.
+// Con fix=true registra un movimiento "adjustment" por la diferencia de stock (así el libro
+// explica el saldo actual, que se toma como el conteo vigente) y corrige reserved al valor
+// de los carritos abiertos, todo dentro de una transacción.
+func ReconcileStock(db *gorm.DB, fix bool, userID *uint, userName string) (*ReconciliationReport, error) {
+	type balanceRow struct {
+		ProductID uint
+		VariantID uint
+		Location  string
+		Total     int
+		Extra     int
+	}
+	rows := map[ledgerKey]*LedgerDiscrepancy{}
+	get := func(k ledgerKey) *LedgerDiscrepancy {
+		d, ok := rows[k]
+		if !ok {
+			d = &LedgerDiscrepancy{ProductID: k.ProductID, Location: k.Location, MissingRow: true}
+			if k.VariantID != 0 {
+				v := k.VariantID
+				d.VariantID = &v
+			}
+			rows[k] = d
+		}
+		return d
+	}
+
+	var stocks []balanceRow
+	if err := db.Table("location_stocks").
+		Select("product_id, COALESCE(variant_id, 0) as variant_id, location, SUM(stock) as total, SUM(reserved) as extra").
+		Where("deleted_at IS NULL").
+		Group("product_id, COALESCE(variant_id, 0), location").
+		Scan(&stocks).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range stocks {
+		d := get(ledgerKey{s.ProductID, s.VariantID, s.Location})
+		d.MissingRow = false
+		d.Stock = s.Total
+		d.Reserved = s.Extra
+	}
+
+	var ledger []balanceRow
+	if err := db.Table("stock_movements").
+		Select("product_id, COALESCE(variant_id, 0) as variant_id, location, SUM(quantity) as total").
+		Where("deleted_at IS NULL").
+		Group("product_id, COALESCE(variant_id, 0), location").
+		Scan(&ledger).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range ledger {
+		get(ledgerKey{l.ProductID, l.VariantID, l.Location}).LedgerStock = l.Total
+	}
+
+	var reservations []balanceRow
+	if err := db.Table("cart_items ci").
+		Select("ci.product_id, COALESCE(ci.variant_id, 0) as variant_id, ci.location, SUM(ci.reserved_quantity) as total").
+		Joins("JOIN carts ON carts.id = ci.cart_id AND carts.deleted_at IS NULL").
+		Where("ci.deleted_at IS NULL AND ci.reserved_quantity > 0 AND ci.location <> ''").
+		Where("carts.estado NOT IN ?", closedCartStates).
+		Group("ci.product_id, COALESCE(ci.variant_id, 0), ci.location").
+		Scan(&reservations).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range reservations {
+		get(ledgerKey{r.ProductID, r.VariantID, r.Location}).OpenCartReserved = r.Total
+	}
+
+	report := &ReconciliationReport{CheckedAt: time.Now(), RowsChecked: len(rows), Discrepancies: []LedgerDiscrepancy{}}
+	for _, d := range rows {
+		d.StockDiff = d.Stock - d.LedgerStock
+		d.ReservedDiff = d.Reserved - d.OpenCartReserved
+		if d.StockDiff == 0 && d.ReservedDiff == 0 {
+			continue
+		}
+		if d.StockDiff != 0 {
+			report.StockMismatches++
+		}
+		if d.ReservedDiff != 0 {
+			report.ReservedMismatches++
+		}
+		report.Discrepancies = append(report.Discrepancies, *d)
+	}
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		a, b := report.Discrepancies[i], report.Discrepancies[j]
+		if a.ProductID != b.ProductID {
+			return a.ProductID < b.ProductID
+		}
+		av, bv := uint(0), uint(0)
+		if a.VariantID != nil {
+			av = *a.VariantID
+		}
+		if b.VariantID != nil {
+			bv = *b.VariantID
+		}
+		if av != bv {
+			return av < bv
+		}
+		return a.Location < b.Location
+	})
+
+	if !fix || len(report.Discrepancies) == 0 {
+		return report, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range report.Discrepancies {
+			d := &report.Discrepancies[i]
+			if d.StockDiff != 0 {
+				if err := tx.Create(&product.StockMovement{
+					ProductID:     d.ProductID,
+					VariantID:     d.VariantID,
+					Location:      d.Location,
+					MovementType:  "adjustment",
+					Quantity:      d.StockDiff,
+					PreviousStock: d.LedgerStock,
+					NewStock:      d.Stock,
+					Reason:        "Conciliación de stock: ajuste del libro al saldo actual",
+					Reference:     fmt.Sprintf("CONCILIACION-%s", report.CheckedAt.Format("20060102-150405")),
+					UserID:        userID,
+					UserName:      userName,
+				}).Error; err != nil {
+					return err
+				}
+				d.Fixed = true
+			}
+			// Sin fila en location_stocks no hay reserva que corregir: queda informada
+			if d.ReservedDiff != 0 && !d.MissingRow {
+				query := tx.Model(&product.LocationStock{}).Where("product_id = ? AND location = ?", d.ProductID, d.Location)
+				if d.VariantID != nil {
+					query = query.Where("variant_id = ?", *d.VariantID)
+				} else {
+					query = query.Where("variant_id IS NULL OR variant_id = 0")
+				}
+				if err := query.Update("reserved", d.OpenCartReserved).Error; err != nil {
+					return err
+				}
+				d.Fixed = true
+			}
+			if d.Fixed {
+				report.Fixed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// StockReconciliation devuelve el reporte de conciliación; por POST con ?fix=true aplica los ajustes
+// Rutas: GET /admin/stock/reconciliation, POST /admin/stock/reconciliation?fix=true
+func StockReconciliation(c *gin.Context) {
+	fix := c.Request.Method == http.MethodPost && c.Query("fix") == "true"
+	var userID *uint
+	if v, ok := c.Get("user_id"); ok {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+	userName := ""
+	if v, ok := c.Get("user_name"); ok {
+		userName, _ = v.(string)
+	}
+	report, err := ReconcileStock(config.DB, fix, userID, userName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,60 @@
+package inventory
+
+import (
+	"testing"
+
+	"go-modaMayor/internal/product"
+)
+
+func TestReconcileStock_ReportsAndFixes(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&product.StockMovement{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	// Tablas de carrito mínimas para evitar importar el paquete cart
+	db.Exec("CREATE TABLE IF NOT EXISTS carts (id INTEGER PRIMARY KEY, estado TEXT, deleted_at DATETIME)")
+	db.Exec("CREATE TABLE IF NOT EXISTS cart_items (id INTEGER PRIMARY KEY, cart_id INTEGER, product_id INTEGER, variant_id INTEGER, location TEXT, reserved_quantity INTEGER, deleted_at DATETIME)")
+	db.Exec("DELETE FROM carts")
+	db.Exec("DELETE FROM cart_items")
+
+	prod := product.Product{Name: "Campera", Code: "TEST-REC-1"}
+	db.Create(&prod)
+	pv := product.ProductVariant{ProductID: prod.ID, SKU: "rec-sku-1"}
+	db.Create(&pv)
+
+	// Saldo 10 pero el libro sólo explica 6; reservado 3 pero los carritos abiertos reservan 2
+	db.Create(&product.LocationStock{ProductID: prod.ID, VariantID: &pv.ID, Location: "salta", Stock: 10, Reserved: 3})
+	db.Create(&product.StockMovement{ProductID: prod.ID, VariantID: &pv.ID, Location: "salta", MovementType: "initial", Quantity: 8})
+	db.Create(&product.StockMovement{ProductID: prod.ID, VariantID: &pv.ID, Location: "salta", MovementType: "venta", Quantity: -2})
+	db.Exec("INSERT INTO carts (id, estado) VALUES (901, 'listo_para_pago'), (902, 'pagado')")
+	db.Exec("INSERT INTO cart_items (cart_id, product_id, variant_id, location, reserved_quantity) VALUES (901, ?, ?, 'salta', 2), (902, ?, ?, 'salta', 5)",
+		prod.ID, pv.ID, prod.ID, pv.ID)
+
+	report, err := ReconcileStock(db, false, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var found *LedgerDiscrepancy
+	for i := range report.Discrepancies {
+		d := report.Discrepancies[i]
+		if d.VariantID != nil && *d.VariantID == pv.ID && d.Location == "salta" {
+			found = &report.Discrepancies[i]
+		}
+	}
+	if found == nil || found.StockDiff != 4 || found.ReservedDiff != 1 || found.OpenCartReserved != 2 {
+		t.Fatalf("expected stock_diff=4 reserved_diff=1, got %+v", found)
+	}
+
+	if _, err := ReconcileStock(db, true, nil, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report, err = ReconcileStock(db, false, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, d := range report.Discrepancies {
+		if d.VariantID != nil && *d.VariantID == pv.ID {
+			t.Fatalf("expected no discrepancy after fix, got %+v", d)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package inventory
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DepositoLocation es la ubicación central desde la que se abastece a las sucursales
+const DepositoLocation = "deposito"
+
+// ReplenishmentLine es una sugerencia de reposición para una variante en una ubicación
+type ReplenishmentLine struct {
+	ProductID         uint   `json:"product_id"`
+	ProductName       string `json:"product_name"`
+	SupplierID        *uint  `json:"supplier_id"`
+	VariantID         uint   `json:"variant_id"`
+	SKU               string `json:"sku"`
+	Color             string `json:"color"`
+	Size              string `json:"size"`
+	Location          string `json:"location"`
+	Stock             int    `json:"stock"`
+	Reserved          int    `json:"reserved"`
+	Available         int    `json:"available"`
+	MinStock          int    `json:"min_stock"`
+	MaxStock          int    `json:"max_stock"`
+	ReorderPoint      int    `json:"reorder_point"`
+	Needed            int    `json:"needed"`
+	SuggestedTransfer int    `json:"suggested_transfer"` // desde el depósito
+	SuggestedPurchase int    `json:"suggested_purchase"` // a pedir al proveedor
+}
+
+// SupplierSummary agrupa las cantidades a pedir por proveedor
+type SupplierSummary struct {
+	SupplierID *uint `json:"supplier_id"`
+	Lines      int   `json:"lines"`
+	Quantity   int   `json:"quantity"`
+}
+
+type replenishmentRow struct {
+	ProductID    uint
+	ProductName  string
+	SupplierID   *uint
+	VariantID    uint
+	SKU          string
+	Color        string
+	Size         string
+	Location     string
+	Stock        int
+	Reserved     int
+	MinStock     int
+	MaxStock     int
+	ReorderPoint int
+}
+
+// BuildReplenishment calcula las sugerencias de reposición para todas las
+// variantes/ubicaciones en o por debajo de su punto de reposición. Primero
+// intenta cubrir sucursales con el sobrante del depósito (lo que excede su
+// propio umbral) y el resto se sugiere como compra al proveedor.
+func BuildReplenishment(db *gorm.DB) ([]ReplenishmentLine, error) {
+	var rows []replenishmentRow
+	err := db.Table("reorder_points rp").
+		Select("rp.product_id, products.name as product_name, products.supplier_id, rp.variant_id, pv.sku, pv.color, pv.size, rp.location, COALESCE(ls.stock,0) as stock, COALESCE(ls.reserved,0) as reserved, rp.min_stock, rp.max_stock, rp.reorder_point").
+		Joins("JOIN product_variants pv ON pv.id = rp.variant_id AND pv.deleted_at IS NULL").
+		Joins("JOIN products ON products.id = rp.product_id AND products.deleted_at IS NULL").
+		Joins("LEFT JOIN location_stocks ls ON ls.variant_id = rp.variant_id AND ls.location = rp.location AND ls.deleted_at IS NULL").
+		Where("rp.deleted_at IS NULL").
+		Order("rp.product_id, rp.variant_id, rp.location").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Disponible del depósito por variante (aunque no tenga punto de reposición)
+	type depotRow struct {
+		VariantID uint
+		Available int
+	}
+	var depot []depotRow
+	if err := db.Table("location_stocks").
+		Select("variant_id, SUM(stock - reserved) as available").
+		Where("location = ? AND variant_id IS NOT NULL AND deleted_at IS NULL", DepositoLocation).
+		Group("variant_id").
+		Scan(&depot).Error; err != nil {
+		return nil, err
+	}
+	spare := make(map[uint]int, len(depot))
+	for _, d := range depot {
+		spare[d.VariantID] = d.Available
+	}
+	// El depósito conserva su propio umbral antes de transferir
+	for _, r := range rows {
+		if r.Location == DepositoLocation {
+			rp := product.ReorderPoint{MinStock: r.MinStock, ReorderPoint: r.ReorderPoint}
+			spare[r.VariantID] -= rp.Trigger()
+		}
+	}
+
+	// Atender primero a las sucursales con menor disponible
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Stock-rows[i].Reserved < rows[j].Stock-rows[j].Reserved
+	})
+
+	lines := make([]ReplenishmentLine, 0)
+	for _, r := range rows {
+		rp := product.ReorderPoint{MinStock: r.MinStock, MaxStock: r.MaxStock, ReorderPoint: r.ReorderPoint}
+		available := r.Stock - r.Reserved
+		if available > rp.Trigger() {
+			continue
+		}
+		needed := rp.Target() - available
+		if needed <= 0 {
+			continue
+		}
+		line := ReplenishmentLine{
+			ProductID:    r.ProductID,
+			ProductName:  r.ProductName,
+			SupplierID:   r.SupplierID,
+			VariantID:    r.VariantID,
+			SKU:          r.SKU,
+			Color:        r.Color,
+			Size:         r.Size,
+			Location:     r.Location,
+			Stock:        r.Stock,
+			Reserved:     r.Reserved,
+			Available:    available,
+			MinStock:     r.MinStock,
+			MaxStock:     r.MaxStock,
+			ReorderPoint: r.ReorderPoint,
+			Needed:       needed,
+		}
+		if r.Location != DepositoLocation {
+			transfer := needed
+			if s := spare[r.VariantID]; s < transfer {
+				transfer = s
+			}
+			if transfer < 0 {
+				transfer = 0
+			}
+			spare[r.VariantID] -= transfer
+			line.SuggestedTransfer = transfer
+		}
+		line.SuggestedPurchase = needed - line.SuggestedTransfer
+		lines = append(lines, line)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].ProductID != lines[j].ProductID {
+			return lines[i].ProductID < lines[j].ProductID
+		}
+		if lines[i].VariantID != lines[j].VariantID {
+			return lines[i].VariantID < lines[j].VariantID
+		}
+		return lines[i].Location < lines[j].Location
+	})
+	return lines, nil
+}
+
+// GetReplenishmentReport devuelve las sugerencias de reposición.
+// Query params opcionales: location, supplier_id
+// Ruta: GET /inventory/replenishment
+func GetReplenishmentReport(c *gin.Context) {
+	lines, err := BuildReplenishment(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	location := c.Query("location")
+	supplierID := c.Query("supplier_id")
+	filtered := make([]ReplenishmentLine, 0, len(lines))
+	bySupplier := make(map[uint]*SupplierSummary)
+	var noSupplier *SupplierSummary
+	totalTransfer, totalPurchase := 0, 0
+	for _, l := range lines {
+		if location != "" && l.Location != location {
+			continue
+		}
+		if supplierID != "" && (l.SupplierID == nil || uintToString(*l.SupplierID) != supplierID) {
+			continue
+		}
+		filtered = append(filtered, l)
+		totalTransfer += l.SuggestedTransfer
+		totalPurchase += l.SuggestedPurchase
+		if l.SuggestedPurchase == 0 {
+			continue
+		}
+		var s *SupplierSummary
+		if l.SupplierID == nil {
+			if noSupplier == nil {
+				noSupplier = &SupplierSummary{}
+			}
+			s = noSupplier
+		} else {
+			if bySupplier[*l.SupplierID] == nil {
+				id := *l.SupplierID
+				bySupplier[id] = &SupplierSummary{SupplierID: &id}
+			}
+			s = bySupplier[*l.SupplierID]
+		}
+		s.Lines++
+		s.Quantity += l.SuggestedPurchase
+	}
+
+	suppliers := make([]SupplierSummary, 0, len(bySupplier)+1)
+	for _, s := range bySupplier {
+		suppliers = append(suppliers, *s)
+	}
+	sort.Slice(suppliers, func(i, j int) bool { return *suppliers[i].SupplierID < *suppliers[j].SupplierID })
+	if noSupplier != nil {
+		suppliers = append(suppliers, *noSupplier)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items":          filtered,
+		"total_transfer": totalTransfer,
+		"total_purchase": totalPurchase,
+		"by_supplier":    suppliers,
+	})
+}
+
+func uintToString(v uint) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
@@ -0,0 +1,57 @@
+package inventory
+
+import (
+	"testing"
+
+	"go-modaMayor/internal/product"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:inventory_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &product.ProductVariant{}, &product.LocationStock{}, &product.ReorderPoint{}, &StockAlert{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	return db
+}
+
+func TestBuildReplenishment_TransfersFromDepotBeforePurchase(t *testing.T) {
+	db := setupTestDB(t)
+	prod := product.Product{Name: "Remera", Code: "TEST-REP-1"}
+	db.Create(&prod)
+	pv := product.ProductVariant{ProductID: prod.ID, SKU: "rep-sku-1"}
+	db.Create(&pv)
+
+	// Sucursal: disponible 1 (stock 3, reservado 2), punto 2, objetivo 10 -> necesita 9
+	db.Create(&product.LocationStock{ProductID: prod.ID, VariantID: &pv.ID, Location: "mendoza", Stock: 3, Reserved: 2})
+	db.Create(&product.ReorderPoint{ProductID: prod.ID, VariantID: pv.ID, Location: "mendoza", ReorderPoint: 2, MaxStock: 10})
+	// Depósito: disponible 8, conserva su umbral de 3 -> puede transferir 5
+	db.Create(&product.LocationStock{ProductID: prod.ID, VariantID: &pv.ID, Location: DepositoLocation, Stock: 8})
+	db.Create(&product.ReorderPoint{ProductID: prod.ID, VariantID: pv.ID, Location: DepositoLocation, ReorderPoint: 3, MaxStock: 20})
+
+	lines, err := BuildReplenishment(db)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var branch *ReplenishmentLine
+	for i := range lines {
+		if lines[i].VariantID == pv.ID && lines[i].Location == "mendoza" {
+			branch = &lines[i]
+		}
+		if lines[i].VariantID == pv.ID && lines[i].Location == DepositoLocation {
+			t.Fatalf("depot above its reorder point should not need replenishment: %+v", lines[i])
+		}
+	}
+	if branch == nil {
+		t.Fatalf("expected a replenishment line for mendoza, got %+v", lines)
+	}
+	if branch.Needed != 9 || branch.SuggestedTransfer != 5 || branch.SuggestedPurchase != 4 {
+		t.Fatalf("expected needed=9 transfer=5 purchase=4, got %+v", *branch)
+	}
+}
@@ -1,9 +1,11 @@
 package kit
 
 import (
+	"errors"
 	"go-modaMayor/config"
 	"go-modaMayor/internal/product"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -12,6 +14,7 @@ import (
 // CreateKit creates a new kit with items
 func CreateKit(c *gin.Context) {
 	var payload Kit
+	payload.Active = true
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -23,8 +26,8 @@ func CreateKit(c *gin.Context) {
 		return
 	}
 
-	// validate items: product existence, optional variant and fill unit prices
-	var sum float64
+	// validate items: product existence and optional variant. UnitPrice is only
+	// stored when sent as an override; otherwise prices follow the product.
 	for i := range payload.Items {
 		it := &payload.Items[i]
 		var p product.Product
@@ -32,10 +35,6 @@ func CreateKit(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "product not found", "product_id": it.ProductID})
 			return
 		}
-		// default unit price to product wholesale if not provided
-		if it.UnitPrice == 0 {
-			it.UnitPrice = p.WholesalePrice
-		}
 		// if variant specified, verify it belongs to product
 		if it.VariantID != nil {
 			var v product.ProductVariant
@@ -49,12 +48,13 @@ func CreateKit(c *gin.Context) {
 			}
 			// if unit price still zero, could consult variant price (not present) so keep product price
 		}
-		qty := it.Quantity
-		if qty <= 0 {
-			qty = 1
+		if msg := validateSlot(db, *it); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg, "product_id": it.ProductID})
+			return
+		}
+		if it.Quantity <= 0 {
 			it.Quantity = 1
 		}
-		sum += it.UnitPrice * float64(qty)
 	}
 
 	// create kit
@@ -62,36 +62,49 @@ func CreateKit(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	// attach computed sum
-	payload.SumIndividuals = sum
+	// active has a DB default of true, so an explicit false is not inserted by Create
+	if !payload.Active {
+		db.Model(&payload).Update("active", false)
+	}
+	if err := decorate(db, &payload); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusCreated, payload)
 }
 
-// ListKits returns all kits
+// ListKits returns kits with live pricing and availability.
+// Buyers (public route) only see active kits; staff also sees inactive ones.
 func ListKits(c *gin.Context) {
 	db := config.DB
 	var kits []Kit
-	if err := db.Preload("Items").Find(&kits).Error; err != nil {
+	q := db.Preload("Items")
+	if !product.IsStaff(c) {
+		q = q.Where("active = ?", true)
+	}
+	if err := q.Order("id").Find(&kits).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	// compute sum_individuals for each kit
 	for ki := range kits {
-		var sum float64
-		for _, it := range kits[ki].Items {
-			sum += it.UnitPrice * float64(it.Quantity)
+		if err := decorate(db, &kits[ki]); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-		kits[ki].SumIndividuals = sum
 	}
 	c.JSON(http.StatusOK, kits)
 }
 
-// GetKit returns a kit by id
+// GetKit returns a kit by id with live pricing and per-location availability
 func GetKit(c *gin.Context) {
 	id := c.Param("id")
 	db := config.DB
 	var k Kit
-	if err := db.Preload("Items").First(&k, id).Error; err != nil {
+	q := db.Preload("Items")
+	if !product.IsStaff(c) {
+		q = q.Where("active = ?", true)
+	}
+	if err := q.First(&k, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "kit not found"})
 			return
@@ -99,18 +112,26 @@ func GetKit(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	var sum float64
-	for _, it := range k.Items {
-		sum += it.UnitPrice * float64(it.Quantity)
+	if err := decorate(db, &k); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	k.SumIndividuals = sum
+	slots, err := Options(db, &k)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	k.Slots = slots
 	c.JSON(http.StatusOK, k)
 }
 
 // UpdateKit updates kit metadata and items (replace items)
 func UpdateKit(c *gin.Context) {
 	id := c.Param("id")
-	var payload Kit
+	var payload struct {
+		Kit
+		Active *bool `json:"active"` // omitted = keep current value
+	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -130,6 +151,9 @@ func UpdateKit(c *gin.Context) {
 	existing.Name = payload.Name
 	existing.Description = payload.Description
 	existing.Price = payload.Price
+	if payload.Active != nil {
+		existing.Active = *payload.Active
+	}
 
 	tx := db.Begin()
 	if err := tx.Save(&existing).Error; err != nil {
@@ -144,7 +168,6 @@ func UpdateKit(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	var sum float64
 	for i := range payload.Items {
 		it := &payload.Items[i]
 		it.KitID = existing.ID
@@ -155,9 +178,6 @@ func UpdateKit(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "product not found", "product_id": it.ProductID})
 			return
 		}
-		if it.UnitPrice == 0 {
-			it.UnitPrice = p.WholesalePrice
-		}
 		if it.VariantID != nil {
 			var v product.ProductVariant
 			if err := tx.First(&v, *it.VariantID).Error; err != nil {
@@ -171,10 +191,14 @@ func UpdateKit(c *gin.Context) {
 				return
 			}
 		}
+		if msg := validateSlot(tx, *it); msg != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg, "product_id": it.ProductID})
+			return
+		}
 		if it.Quantity <= 0 {
 			it.Quantity = 1
 		}
-		sum += it.UnitPrice * float64(it.Quantity)
 	}
 	if len(payload.Items) > 0 {
 		if err := tx.Create(&payload.Items).Error; err != nil {
@@ -187,12 +211,10 @@ func UpdateKit(c *gin.Context) {
 	tx.Commit()
 	// return updated kit
 	db.Preload("Items").First(&existing, existing.ID)
-	// compute sum
-	var sum2 float64
-	for _, it := range existing.Items {
-		sum2 += it.UnitPrice * float64(it.Quantity)
+	if err := decorate(db, &existing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	existing.SumIndividuals = sum2
 	c.JSON(http.StatusOK, existing)
 }
 
@@ -224,3 +246,76 @@ func DeleteKit(c *gin.Context) {
 	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"deleted": true})
 }
+
+// validateSlot checks the allowed colors/sizes of an item: they only apply to slots
+// (items without variant) and must leave at least one variant to choose from.
+// Returns an error message, or "" if the item is valid.
+func validateSlot(db *gorm.DB, it KitItem) string {
+	if len(it.AllowedColors) == 0 && len(it.AllowedSizes) == 0 {
+		return ""
+	}
+	if !it.IsSlot() {
+		return "allowed_colors/allowed_sizes only apply to items without variant_id"
+	}
+	variants, err := allowedVariants(db, it)
+	if err != nil {
+		return err.Error()
+	}
+	if len(variants) == 0 {
+		return "no variant of the product matches allowed_colors/allowed_sizes"
+	}
+	return ""
+}
+
+// KitAvailability returns stock and component prices for a set of choices:
+// GET /public/kits/:id/availability?choices[<item_id>]=<variant_id>
+func KitAvailability(c *gin.Context) {
+	db := config.DB
+	var k Kit
+	q := db.Preload("Items")
+	if !product.IsStaff(c) {
+		q = q.Where("active = ?", true)
+	}
+	if err := q.First(&k, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "kit not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var choices []Choice
+	for itemID, variantID := range c.QueryMap("choices") {
+		item, err1 := strconv.ParseUint(itemID, 10, 64)
+		variant, err2 := strconv.ParseUint(variantID, 10, 64)
+		if err1 != nil || err2 != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid choice", "item_id": itemID, "variant_id": variantID})
+			return
+		}
+		choices = append(choices, Choice{ItemID: uint(item), VariantID: uint(variant)})
+	}
+
+	lines, err := ResolveLines(db, &k, choices)
+	if err != nil {
+		var kitErr *KitError
+		if errors.As(err, &kitErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El kit no se puede armar con esas opciones", "errors": kitErr.Messages, "unchosen": kitErr.Unchosen})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	locations, total, err := ComputeAvailability(db, lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"kit_id":           k.ID,
+		"lines":            lines,
+		"locations":        locations,
+		"total":            total,
+		"component_prices": ComponentPrices(&k, lines),
+	})
+}
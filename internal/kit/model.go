@@ -1,6 +1,8 @@
 package kit
 
 import (
+	"go-modaMayor/internal/category"
+
 	"gorm.io/gorm"
 )
 
@@ -9,12 +11,17 @@ type Kit struct {
 	gorm.Model
 	Name        string `json:"name" gorm:"not null"`
 	Description string `json:"description"`
-	// Price for the whole kit (optional). If zero, price will be computed from items.
+	// Price for the whole kit (optional). If zero, items are charged at their tier price.
 	Price float64 `json:"price"`
+	// Inactive kits are hidden from buyers and can't be added to a cart
+	Active bool `json:"active" gorm:"default:true"`
 	// Items in the kit
 	Items []KitItem `json:"items" gorm:"foreignKey:KitID"`
-	// Sum of individual items (computed, not persisted)
+	// Sum of individual items at current wholesale prices (computed, not persisted)
 	SumIndividuals float64 `json:"sum_individuals" gorm:"-"`
+	Pricing        *Pricing      `json:"pricing,omitempty" gorm:"-"`
+	Availability   *Availability `json:"availability,omitempty" gorm:"-"`
+	Slots          []SlotOptions `json:"slots,omitempty" gorm:"-"` // choice options (kit detail)
 }
 
 // KitItem links a product (optionally a specific variant) to the kit
@@ -24,5 +31,9 @@ type KitItem struct {
 	ProductID uint    `json:"product_id"` // product included in kit
 	VariantID *uint   `json:"variant_id"` // optional: specific variant
 	Quantity  int     `json:"quantity" gorm:"default:1"`
-	UnitPrice float64 `json:"unit_price"` // optional override
+	UnitPrice float64 `json:"unit_price"` // optional override; zero = current product price
+	// Without VariantID the item is a slot: the buyer picks the variant when adding the
+	// kit to the cart, limited to these colors/sizes (empty = any)
+	AllowedColors category.StringList `json:"allowed_colors" gorm:"type:text"`
+	AllowedSizes  category.StringList `json:"allowed_sizes" gorm:"type:text"`
 }
@@ -0,0 +1,312 @@
+package kit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-modaMayor/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// KitError junta los motivos por los que un kit no se puede vender (400)
+type KitError struct {
+	Messages []string
+	Unchosen []uint // slots que faltan elegir (ID de item)
+}
+
+func (e *KitError) Error() string { return strings.Join(e.Messages, "; ") }
+
+// Line es un componente del kit resuelto a una variante concreta
+type Line struct {
+	ItemID    uint                   `json:"item_id"`
+	Product   product.Product        `json:"product"`
+	Variant   product.ProductVariant `json:"variant"`
+	Units     int                    `json:"units"`      // prendas de la variante por kit
+	UnitPrice float64                `json:"unit_price"` // precio individual vigente
+}
+
+// Pricing compara el precio del kit con la suma vigente de sus componentes
+type Pricing struct {
+	Price          float64            `json:"price"`           // precio fijo del kit (0 = se cobra por tier)
+	SumIndividuals float64            `json:"sum_individuals"` // suma a precio mayorista
+	TierSums       map[string]float64 `json:"tier_sums"`       // suma por tier: wholesale, discount1, discount2
+	Savings        float64            `json:"savings"`         // sum_individuals - price (si hay precio fijo)
+}
+
+// LocationAvailability indica cuántos kits completos se pueden armar en una ubicación
+type LocationAvailability struct {
+	Location    string `json:"location"`
+	Kits        int    `json:"kits"`
+	LimitingSKU string `json:"limiting_sku,omitempty"`
+}
+
+// Availability resume el stock de un kit
+type Availability struct {
+	Locations []LocationAvailability `json:"locations"`
+	Total     int                    `json:"total"`
+	Errors    []string               `json:"errors,omitempty"` // por qué no se puede armar
+	// El stock depende de las variantes que elija el comprador (ver slots y
+	// GET /public/kits/:id/availability)
+	NeedsChoice bool `json:"needs_choice,omitempty"`
+}
+
+// tierPrice devuelve el precio de un producto en el tier indicado, con el mayorista como piso
+func tierPrice(p product.Product, tier string) float64 {
+	switch tier {
+	case "discount1":
+		if p.Discount1Price > 0 {
+			return p.Discount1Price
+		}
+	case "discount2":
+		if p.Discount2Price > 0 {
+			return p.Discount2Price
+		}
+	}
+	return p.WholesalePrice
+}
+
+// itemPrice es el precio unitario vigente de un item: su UnitPrice si se cargó
+// como override, si no el precio del producto en el tier
+func itemPrice(it KitItem, p product.Product, tier string) float64 {
+	if it.UnitPrice > 0 {
+		return it.UnitPrice
+	}
+	return tierPrice(p, tier)
+}
+
+func loadProducts(db *gorm.DB, items []KitItem) (map[uint]product.Product, error) {
+	ids := make([]uint, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ProductID)
+	}
+	out := map[uint]product.Product{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var products []product.Product
+	if err := db.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		out[p.ID] = p
+	}
+	return out, nil
+}
+
+// ComputePricing calcula el precio del kit contra los precios actuales de los productos
+// (no contra los de cuando se armó el kit) y completa SumIndividuals y Pricing.
+func ComputePricing(db *gorm.DB, k *Kit) error {
+	products, err := loadProducts(db, k.Items)
+	if err != nil {
+		return err
+	}
+	pricing := &Pricing{Price: k.Price, TierSums: map[string]float64{}}
+	for _, tier := range []string{"wholesale", "discount1", "discount2"} {
+		sum := 0.0
+		for _, it := range k.Items {
+			sum += itemPrice(it, products[it.ProductID], tier) * float64(it.Quantity)
+		}
+		pricing.TierSums[tier] = sum
+	}
+	pricing.SumIndividuals = pricing.TierSums["wholesale"]
+	if k.Price > 0 {
+		pricing.Savings = pricing.SumIndividuals - k.Price
+	}
+	k.SumIndividuals = pricing.SumIndividuals
+	k.Pricing = pricing
+	return nil
+}
+
+// ResolveLines resuelve cada item a la variante que se agrega al carrito. En los slots
+// (items sin variante fija) se usa la elección del comprador, que tiene que cumplir los
+// colores/talles permitidos; si el slot admite una sola variante no hace falta elegir.
+func ResolveLines(db *gorm.DB, k *Kit, choices []Choice) ([]Line, error) {
+	if len(k.Items) == 0 {
+		return nil, &KitError{Messages: []string{"El kit no tiene productos"}}
+	}
+	products, err := loadProducts(db, k.Items)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var messages []string
+	var unchosen []uint
+
+	items := map[uint]KitItem{}
+	for _, it := range k.Items {
+		items[it.ID] = it
+	}
+	chosen := map[uint]uint{}
+	for _, ch := range choices {
+		it, ok := items[ch.ItemID]
+		if !ok {
+			messages = append(messages, fmt.Sprintf("El item %d no pertenece al kit", ch.ItemID))
+			continue
+		}
+		if !it.IsSlot() {
+			if *it.VariantID != ch.VariantID {
+				messages = append(messages, fmt.Sprintf("El item %d tiene una variante fija", ch.ItemID))
+			}
+			continue
+		}
+		chosen[ch.ItemID] = ch.VariantID
+	}
+
+	lines := []Line{}
+	byVariant := map[uint]int{}
+	for _, it := range k.Items {
+		p, ok := products[it.ProductID]
+		if !ok {
+			messages = append(messages, fmt.Sprintf("El producto %d ya no existe", it.ProductID))
+			continue
+		}
+		if !p.IsPurchasable(now) {
+			messages = append(messages, fmt.Sprintf("%s no está disponible para la venta", p.Name))
+			continue
+		}
+		var variant product.ProductVariant
+		if !it.IsSlot() {
+			if err := db.Where("id = ? AND product_id = ?", *it.VariantID, p.ID).First(&variant).Error; err != nil {
+				messages = append(messages, fmt.Sprintf("La variante %d de %s ya no existe", *it.VariantID, p.Name))
+				continue
+			}
+		} else {
+			options, err := allowedVariants(db, it)
+			if err != nil {
+				return nil, err
+			}
+			id, ok := chosen[it.ID]
+			switch {
+			case ok:
+				found := false
+				for _, v := range options {
+					if v.ID == id {
+						variant, found = v, true
+					}
+				}
+				if !found {
+					messages = append(messages, fmt.Sprintf("La variante %d no es una opción válida para %s", id, p.Name))
+					continue
+				}
+			case len(options) == 1:
+				variant = options[0]
+			case len(options) == 0:
+				messages = append(messages, fmt.Sprintf("%s no tiene variantes que cumplan las opciones del kit", p.Name))
+				continue
+			default:
+				messages = append(messages, fmt.Sprintf("Falta elegir la variante de %s", p.Name))
+				unchosen = append(unchosen, it.ID)
+				continue
+			}
+		}
+		units := it.Quantity
+		if units <= 0 {
+			units = 1
+		}
+		// Dos items con la misma variante se suman en una sola línea
+		if i, ok := byVariant[variant.ID]; ok {
+			lines[i].Units += units
+			continue
+		}
+		byVariant[variant.ID] = len(lines)
+		lines = append(lines, Line{ItemID: it.ID, Product: p, Variant: variant, Units: units, UnitPrice: itemPrice(it, p, "wholesale")})
+	}
+	if len(messages) > 0 {
+		return nil, &KitError{Messages: messages, Unchosen: unchosen}
+	}
+	return lines, nil
+}
+
+// ComponentPrices reparte el precio fijo del kit entre sus variantes en proporción a
+// su precio individual vigente. Devuelve el precio unitario por variante, o nil si el
+// kit no tiene precio fijo (los componentes se cobran por tier como cualquier prenda).
+func ComponentPrices(k *Kit, lines []Line) map[uint]float64 {
+	if k.Price <= 0 || len(lines) == 0 {
+		return nil
+	}
+	base, units := 0.0, 0
+	for _, l := range lines {
+		base += l.UnitPrice * float64(l.Units)
+		units += l.Units
+	}
+	out := make(map[uint]float64, len(lines))
+	for _, l := range lines {
+		if base > 0 {
+			out[l.Variant.ID] = k.Price * l.UnitPrice / base
+		} else {
+			out[l.Variant.ID] = k.Price / float64(units)
+		}
+	}
+	return out
+}
+
+// ComputeAvailability calcula cuántos kits completos se pueden armar en cada ubicación
+// con el stock libre de reservas. Como las curvas, un kit se arma desde una sola
+// ubicación, por eso el total es la suma por ubicación.
+func ComputeAvailability(db *gorm.DB, lines []Line) ([]LocationAvailability, int, error) {
+	ids := make([]uint, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, l.Variant.ID)
+	}
+	var stocks []product.LocationStock
+	if err := db.Where("variant_id IN ?", ids).Find(&stocks).Error; err != nil {
+		return nil, 0, err
+	}
+	free := map[string]map[uint]int{}
+	for _, ls := range stocks {
+		if free[ls.Location] == nil {
+			free[ls.Location] = map[uint]int{}
+		}
+		free[ls.Location][*ls.VariantID] += ls.Stock - ls.Reserved
+	}
+
+	result := make([]LocationAvailability, 0, len(free))
+	total := 0
+	for location, byVariant := range free {
+		av := LocationAvailability{Location: location, Kits: -1}
+		for _, l := range lines {
+			n := byVariant[l.Variant.ID] / l.Units
+			if n < 0 {
+				n = 0
+			}
+			if av.Kits < 0 || n < av.Kits {
+				av.Kits = n
+				av.LimitingSKU = l.Variant.SKU
+			}
+		}
+		if av.Kits < 0 {
+			av.Kits = 0
+		}
+		total += av.Kits
+		result = append(result, av)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Location < result[j].Location })
+	return result, total, nil
+}
+
+// decorate completa precio y disponibilidad de un kit para las respuestas
+func decorate(db *gorm.DB, k *Kit) error {
+	if err := ComputePricing(db, k); err != nil {
+		return err
+	}
+	av := &Availability{Locations: []LocationAvailability{}}
+	lines, err := ResolveLines(db, k, nil)
+	var kitErr *KitError
+	if errors.As(err, &kitErr) {
+		if len(kitErr.Unchosen) == len(kitErr.Messages) {
+			av.NeedsChoice = true
+		} else {
+			av.Errors = kitErr.Messages
+		}
+	} else if err != nil {
+		return err
+	} else if av.Locations, av.Total, err = ComputeAvailability(db, lines); err != nil {
+		return err
+	}
+	k.Availability = av
+	return nil
+}
@@ -0,0 +1,140 @@
+package kit
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/slug"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestKitPricingLinesAndAvailability(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:kits?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &product.ProductVariant{}, &product.LocationStock{}, &sequence.Sequence{}, &slug.Redirect{}, &Kit{}, &KitItem{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	remera := product.Product{Name: "Remera", WholesalePrice: 100, Discount1Price: 90, Status: product.StatusPublished}
+	db.Create(&remera)
+	jean := product.Product{Name: "Jean", WholesalePrice: 300, Status: product.StatusPublished}
+	db.Create(&jean)
+	remeraM := product.ProductVariant{ProductID: remera.ID, SKU: "REM-M", Size: "M"}
+	db.Create(&remeraM)
+	jean40 := product.ProductVariant{ProductID: jean.ID, SKU: "JEAN-40", Size: "40"}
+	db.Create(&jean40)
+	for _, ls := range []product.LocationStock{
+		{ProductID: remera.ID, VariantID: &remeraM.ID, Location: "deposito", Stock: 9, Reserved: 1},
+		{ProductID: jean.ID, VariantID: &jean40.ID, Location: "deposito", Stock: 3},
+		{ProductID: remera.ID, VariantID: &remeraM.ID, Location: "mendoza", Stock: 5},
+	} {
+		db.Create(&ls)
+	}
+
+	k := Kit{Name: "Combo", Price: 400, Active: true, Items: []KitItem{
+		{ProductID: remera.ID, Quantity: 2},
+		{ProductID: jean.ID, VariantID: &jean40.ID, Quantity: 1},
+	}}
+	db.Create(&k)
+
+	// El precio sigue al producto, no al valor del alta
+	db.Model(&jean).Update("wholesale_price", 200)
+	if err := ComputePricing(db, &k); err != nil {
+		t.Fatalf("pricing: %v", err)
+	}
+	if k.SumIndividuals != 400 || k.Pricing.TierSums["discount1"] != 380 || k.Pricing.Savings != 0 {
+		t.Fatalf("unexpected pricing: %+v", k.Pricing)
+	}
+
+	lines, err := ResolveLines(db, &k, nil)
+	if err != nil || len(lines) != 2 || lines[0].Variant.ID != remeraM.ID || lines[0].Units != 2 {
+		t.Fatalf("unexpected lines: %+v %v", lines, err)
+	}
+	prices := ComponentPrices(&k, lines)
+	total := prices[remeraM.ID]*2 + prices[jean40.ID]
+	if math.Abs(total-400) > 0.001 {
+		t.Fatalf("component prices should add up to the kit price, got %v", total)
+	}
+
+	// deposito: 8 remeras libres / 2 = 4 kits, 3 jeans -> 3; mendoza no tiene jeans
+	locations, available, err := ComputeAvailability(db, lines)
+	if err != nil || available != 3 || len(locations) != 2 || locations[0].Kits != 3 || locations[1].Kits != 0 || locations[1].LimitingSKU != "JEAN-40" {
+		t.Fatalf("unexpected availability: %+v total=%d err=%v", locations, available, err)
+	}
+
+	// Con otra variante el item pasa a requerir que el comprador elija
+	remeraL := product.ProductVariant{ProductID: remera.ID, SKU: "REM-L", Size: "L"}
+	db.Create(&remeraL)
+	var kitErr *KitError
+	if _, err := ResolveLines(db, &k, nil); !errors.As(err, &kitErr) || len(kitErr.Unchosen) != 1 || kitErr.Unchosen[0] != k.Items[0].ID {
+		t.Fatalf("expected unchosen slot, got %v", err)
+	}
+	if lines, err := ResolveLines(db, &k, []Choice{{ItemID: k.Items[0].ID, VariantID: remeraL.ID}}); err != nil || lines[0].Variant.ID != remeraL.ID {
+		t.Fatalf("choice not applied: %+v %v", lines, err)
+	}
+	// La elección tiene que respetar los talles permitidos del slot
+	db.Model(&k.Items[0]).Update("allowed_sizes", `["m"]`)
+	db.Preload("Items").First(&k, k.ID)
+	if _, err := ResolveLines(db, &k, []Choice{{ItemID: k.Items[0].ID, VariantID: remeraL.ID}}); !errors.As(err, &kitErr) {
+		t.Fatalf("expected size L to be rejected, got %v", err)
+	}
+	if lines, err := ResolveLines(db, &k, nil); err != nil || lines[0].Variant.ID != remeraM.ID {
+		t.Fatalf("single allowed variant should not need a choice: %+v %v", lines, err)
+	}
+	slots, err := Options(db, &k)
+	if err != nil || len(slots) != 2 || slots[0].Fixed || len(slots[0].Variants) != 1 || slots[0].Variants[0].Available != 13 || !slots[1].Fixed {
+		t.Fatalf("unexpected options: %+v %v", slots, err)
+	}
+	if choices, err := ChoicesFor(db, &k, []uint{jean40.ID, remeraM.ID}, nil); err != nil || len(choices) != 1 || choices[0].VariantID != remeraM.ID {
+		t.Fatalf("unexpected choices: %+v %v", choices, err)
+	}
+}
+
+func TestChoicesFor_KeepsKnownChoicesWithOverlappingSlots(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:kitslots?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &product.ProductVariant{}, &sequence.Sequence{}, &slug.Redirect{}, &Kit{}, &KitItem{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+
+	remera := product.Product{Name: "Remera", WholesalePrice: 100, Status: product.StatusPublished}
+	db.Create(&remera)
+	rojo := product.ProductVariant{ProductID: remera.ID, SKU: "REM-ROJO", Color: "Rojo"}
+	db.Create(&rojo)
+	azul := product.ProductVariant{ProductID: remera.ID, SKU: "REM-AZUL", Color: "Azul"}
+	db.Create(&azul)
+
+	// A admite cualquier color, B sólo rojo: el comprador eligió A=azul y B=rojo
+	k := Kit{Name: "Dúo", Price: 150, Active: true, Items: []KitItem{
+		{ProductID: remera.ID, Quantity: 1},
+		{ProductID: remera.ID, Quantity: 1, AllowedColors: category.StringList{"rojo"}},
+	}}
+	db.Create(&k)
+	a, b := k.Items[0].ID, k.Items[1].ID
+
+	known := []Choice{{ItemID: a, VariantID: azul.ID}, {ItemID: b, VariantID: rojo.ID}}
+	choices, err := ChoicesFor(db, &k, []uint{rojo.ID, azul.ID}, known)
+	if err != nil || len(choices) != 2 || choices[0].VariantID != azul.ID || choices[1].VariantID != rojo.ID {
+		t.Fatalf("known choices not kept: %+v %v", choices, err)
+	}
+	lines, err := ResolveLines(db, &k, choices)
+	if err != nil || len(lines) != 2 {
+		t.Fatalf("expected two lines, got %+v %v", lines, err)
+	}
+
+	// Un slot sin elección guardada toma la variante que quedó libre
+	choices, err = ChoicesFor(db, &k, []uint{rojo.ID, azul.ID}, known[1:])
+	if err != nil || len(choices) != 2 || choices[0].VariantID != azul.ID {
+		t.Fatalf("unexpected fallback choice: %+v %v", choices, err)
+	}
+}
@@ -0,0 +1,168 @@
+package kit
+
+import (
+	"strings"
+
+	"go-modaMayor/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// Choice es la variante que eligió el comprador para un slot del kit
+type Choice struct {
+	ItemID    uint `json:"item_id"`
+	VariantID uint `json:"variant_id"`
+}
+
+// VariantOption es una variante elegible para un slot, con su stock libre total
+type VariantOption struct {
+	ID        uint   `json:"id"`
+	SKU       string `json:"sku"`
+	Color     string `json:"color"`
+	Size      string `json:"size"`
+	ImageURL  string `json:"image_url,omitempty"`
+	Available int    `json:"available"`
+}
+
+// SlotOptions lista qué se puede elegir en un item del kit. Los items con variante
+// fija devuelven sólo esa variante y Fixed=true.
+type SlotOptions struct {
+	ItemID      uint            `json:"item_id"`
+	ProductID   uint            `json:"product_id"`
+	ProductName string          `json:"product_name"`
+	Quantity    int             `json:"quantity"`
+	Fixed       bool            `json:"fixed"`
+	Variants    []VariantOption `json:"variants"`
+}
+
+// IsSlot indica si el comprador elige la variante del item al comprar
+func (it KitItem) IsSlot() bool {
+	return it.VariantID == nil
+}
+
+func containsFold(list []string, v string) bool {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for _, s := range list {
+		if strings.ToLower(strings.TrimSpace(s)) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows indica si la variante cumple las restricciones del item: mismo producto,
+// la variante fija si la hay, y los colores/talles permitidos (vacío = cualquiera)
+func (it KitItem) Allows(v product.ProductVariant) bool {
+	if v.ProductID != it.ProductID {
+		return false
+	}
+	if it.VariantID != nil {
+		return v.ID == *it.VariantID
+	}
+	if len(it.AllowedColors) > 0 && !containsFold(it.AllowedColors, v.Color) {
+		return false
+	}
+	if len(it.AllowedSizes) > 0 && !containsFold(it.AllowedSizes, v.Size) {
+		return false
+	}
+	return true
+}
+
+// allowedVariants devuelve las variantes del producto que el item permite
+func allowedVariants(db *gorm.DB, it KitItem) ([]product.ProductVariant, error) {
+	var variants []product.ProductVariant
+	if err := db.Where("product_id = ?", it.ProductID).Order("id").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	out := variants[:0]
+	for _, v := range variants {
+		if it.Allows(v) {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// Options lista, por item, las variantes elegibles con su stock libre
+func Options(db *gorm.DB, k *Kit) ([]SlotOptions, error) {
+	products, err := loadProducts(db, k.Items)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SlotOptions, 0, len(k.Items))
+	for _, it := range k.Items {
+		variants, err := allowedVariants(db, it)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint, 0, len(variants))
+		for _, v := range variants {
+			ids = append(ids, v.ID)
+		}
+		free := map[uint]int{}
+		if len(ids) > 0 {
+			var rows []struct {
+				VariantID uint
+				Free      int
+			}
+			if err := db.Model(&product.LocationStock{}).Select("variant_id, SUM(stock - reserved) AS free").
+				Where("variant_id IN ?", ids).Group("variant_id").Scan(&rows).Error; err != nil {
+				return nil, err
+			}
+			for _, r := range rows {
+				free[r.VariantID] = r.Free
+			}
+		}
+		slot := SlotOptions{ItemID: it.ID, ProductID: it.ProductID, ProductName: products[it.ProductID].Name, Quantity: it.Quantity, Fixed: !it.IsSlot(), Variants: []VariantOption{}}
+		for _, v := range variants {
+			slot.Variants = append(slot.Variants, VariantOption{ID: v.ID, SKU: v.SKU, Color: v.Color, Size: v.Size, ImageURL: v.ImageURL, Available: free[v.ID]})
+		}
+		out = append(out, slot)
+	}
+	return out, nil
+}
+
+// ChoicesFor reconstruye las elecciones de los slots a partir de las variantes que
+// quedaron en el carrito. Las elecciones conocidas (el item guardado en cada línea) se
+// respetan; los slots sin elección guardada, que se sumaron a la línea de otro item o
+// vienen de carritos anteriores, toman la primera variante que cumple sus
+// restricciones, prefiriendo las que no usó otro slot.
+func ChoicesFor(db *gorm.DB, k *Kit, variantIDs []uint, known []Choice) ([]Choice, error) {
+	if len(variantIDs) == 0 {
+		return nil, nil
+	}
+	var variants []product.ProductVariant
+	if err := db.Where("id IN ?", variantIDs).Order("id").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	used := map[uint]bool{}
+	knownByItem := map[uint]uint{}
+	for _, ch := range known {
+		knownByItem[ch.ItemID] = ch.VariantID
+		used[ch.VariantID] = true
+	}
+	choices := []Choice{}
+	for _, it := range k.Items {
+		if !it.IsSlot() {
+			continue
+		}
+		if variantID, ok := knownByItem[it.ID]; ok {
+			choices = append(choices, Choice{ItemID: it.ID, VariantID: variantID})
+			continue
+		}
+		var pick *product.ProductVariant
+		for i := range variants {
+			if !it.Allows(variants[i]) {
+				continue
+			}
+			if pick == nil || (used[pick.ID] && !used[variants[i].ID]) {
+				pick = &variants[i]
+			}
+		}
+		if pick != nil {
+			used[pick.ID] = true
+			choices = append(choices, Choice{ItemID: it.ID, VariantID: pick.ID})
+		}
+	}
+	return choices, nil
+}
@@ -39,7 +39,8 @@ func GetLatestBestsellers(c *gin.Context) {
 	}
 	var products []product.Product
 	if len(ids) > 0 {
-		if err := config.DB.Where("id IN ?", ids).Find(&products).Error; err != nil {
+		// Sólo productos publicados: los archivados u ocultos no se muestran en la tienda
+		if err := config.DB.Scopes(product.Published).Where("id IN ?", ids).Find(&products).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -19,7 +19,7 @@ func RunBestsellerSnapshotOnce(db *gorm.DB) error {
 		TotalRevenue float64
 	}
 	var rows []aggRow
-	q := db.Table("order_items").Select("product_id, SUM(quantity) as quantity_sold, SUM(quantity * price) as total_revenue").Where("created_at >= ?", since)
+	q := db.Table("order_items").Select(netSalesSelect).Where("created_at >= ?", since)
 	if err := q.Group("product_id").Order("quantity_sold desc").Limit(200).Scan(&rows).Error; err != nil {
 		return err
 	}
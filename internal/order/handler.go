@@ -245,6 +245,7 @@ func CheckoutCart(c *gin.Context) {
 	for _, item := range confirmedItems {
 		totalQty += item.Quantity
 	}
+	kitPrices := cart.KitLinePrices(config.DB, confirmedItems)
 	       var itemsWithStock []cart.CartItem
 	       var itemsOutOfStock []cart.CartItem
 			       for _, item := range confirmedItems {
@@ -290,10 +291,16 @@ func CheckoutCart(c *gin.Context) {
 						       precio = prod.CostPrice
 					       }
 				       }
+				       // Las líneas de un kit completo con precio fijo llevan su parte del precio del kit
+				       if kitPrice, ok := kitPrices[item.ID]; ok {
+					       precio = kitPrice
+				       }
 				       orderItems = append(orderItems, OrderItem{
 					       ProductID: prod.ID,
 					       Quantity:  item.Quantity,
 					       Price:     precio,
+					       KitID:     item.KitID,
+					       KitGroup:  item.KitGroup,
 				       })
 				       total += precio * float64(item.Quantity)
 				       itemsWithStock = append(itemsWithStock, item)
@@ -412,6 +419,7 @@ func SubmitCartForAssignment(c *gin.Context) {
 	for _, item := range confirmedItems2 {
 		totalQty += item.Quantity
 	}
+	kitPrices := cart.KitLinePrices(config.DB, confirmedItems2)
 	for _, item := range confirmedItems2 {
 		var prod product.Product
 		if err := config.DB.First(&prod, item.ProductID).Error; err != nil {
@@ -436,10 +444,15 @@ func SubmitCartForAssignment(c *gin.Context) {
 				precio = prod.CostPrice
 			}
 		}
+		if kitPrice, ok := kitPrices[item.ID]; ok {
+			precio = kitPrice
+		}
 		orderItems = append(orderItems, OrderItem{
 			ProductID: prod.ID,
 			Quantity:  item.Quantity,
 			Price:     precio,
+			KitID:     item.KitID,
+			KitGroup:  item.KitGroup,
 		})
 		total += precio * float64(item.Quantity)
 	}
@@ -1,12 +1,14 @@
 package order
 
 import (
+	"go-modaMayor/internal/sequence"
 	"go-modaMayor/internal/user"
 	"gorm.io/gorm"
 )
 
 type Order struct {
 	gorm.Model
+	Number string      `json:"number" gorm:"size:32;uniqueIndex"` // número correlativo (ORD-000001)
 	UserID uint        `json:"user_id"`
 	User   user.User   `json:"User" gorm:"foreignKey:UserID"`
 	CartID *uint       `json:"cart_id" gorm:"index"` // Referencia al carrito original
@@ -19,9 +21,23 @@ type Order struct {
 	// Payment details (simple): método y referencia cuando corresponda
 	PaymentMethod    string `json:"payment_method" gorm:"size:64"`
 	PaymentReference string `json:"payment_reference" gorm:"size:255"`
+	// Monto acreditado/reembolsado por devoluciones; ya descontado de Total
+	ReturnedTotal float64 `json:"returned_total" gorm:"default:0"`
 	// Puedes agregar más campos como dirección, etc.
 }
 
+// BeforeCreate asigna el número de orden desde la secuencia "order"
+func (o *Order) BeforeCreate(tx *gorm.DB) error {
+	if o.Number == "" {
+		number, err := sequence.Next(tx, sequence.Order)
+		if err != nil {
+			return err
+		}
+		o.Number = number
+	}
+	return nil
+}
+
 // Agregar campos para asignación a vendedor y método de pago/nota
 type OrderAssignment struct {
 	AssignedTo uint `json:"assigned_to"`
@@ -17,4 +17,9 @@ type OrderItem struct {
 	Quantity     int             `json:"quantity"`
 	Price        float64         `json:"price"`     // Precio unitario al momento de la compra (con tier aplicado)
 	BaseCost     float64         `json:"base_cost"` // Costo base del producto cuando se creó el item (sin tier)
+	// Unidades devueltas por el cliente (ver internal/returns); las ventas netas usan quantity - returned_quantity
+	ReturnedQuantity int `json:"returned_quantity" gorm:"default:0"`
+	// Kit del que salió la línea (si se vendió como parte de un kit)
+	KitID    *uint  `json:"kit_id,omitempty" gorm:"index"`
+	KitGroup string `json:"kit_group,omitempty"`
 }
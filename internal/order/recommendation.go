@@ -0,0 +1,26 @@
+package order
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// RecommendationBoughtTogether: productos que aparecen en las mismas órdenes
+	RecommendationBoughtTogether = "bought_together"
+	// RecommendationSimilar: productos con categorías, temporada o colores en común
+	RecommendationSimilar = "similar"
+)
+
+// ProductRecommendation guarda un producto recomendado para otro, calculado por el
+// job de recomendaciones. Cada corrida reemplaza todas las filas.
+type ProductRecommendation struct {
+	gorm.Model
+	ProductID     uint      `json:"product_id" gorm:"index:idx_recommendation_product_kind"`
+	Kind          string    `json:"kind" gorm:"type:varchar(20);index:idx_recommendation_product_kind"`
+	RecommendedID uint      `json:"recommended_id" gorm:"index"`
+	Score         float64   `json:"score"`
+	Rank          int       `json:"rank"`
+	ComputedAt    time.Time `json:"computed_at" gorm:"index"`
+}
@@ -0,0 +1,91 @@
+package order
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inStockProducts son los productos con stock libre de reservas en alguna ubicación
+const inStockProducts = "SELECT product_id FROM location_stocks WHERE deleted_at IS NULL AND stock - reserved > 0"
+
+// maxRecommendationLimit es el máximo de recomendaciones por tipo que devuelve el endpoint
+const maxRecommendationLimit = 12
+
+type recommendationRow struct {
+	Product product.Product `json:"product"`
+	Score   float64         `json:"score"`
+}
+
+// GET /products/:id/recommendations?limit=N
+// Devuelve "comprados juntos" y "similares" del último cálculo del job, sólo con
+// productos publicados y con stock disponible.
+func GetProductRecommendations(c *gin.Context) {
+	limit := 8
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 && v <= maxRecommendationLimit {
+		limit = v
+	}
+
+	var p product.Product
+	if err := config.DB.First(&p, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	// Mismo criterio que el detalle: lo que no se ve por link directo no tiene recomendaciones
+	if !product.IsStaff(c) && !p.IsPurchasable(time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	var recs []ProductRecommendation
+	if err := config.DB.Where("product_id = ?", p.ID).Order("kind, rank").Find(&recs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ids := make([]uint, 0, len(recs))
+	for _, r := range recs {
+		ids = append(ids, r.RecommendedID)
+	}
+	prodMap := make(map[uint]product.Product)
+	if len(ids) > 0 {
+		var products []product.Product
+		if err := config.DB.Scopes(product.Published).Where("products.id IN ?", ids).
+			Where("products.id IN (" + inStockProducts + ")").Find(&products).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, rp := range products {
+			prodMap[rp.ID] = rp
+		}
+	}
+
+	together := []recommendationRow{}
+	similar := []recommendationRow{}
+	var computedAt *time.Time
+	for _, r := range recs {
+		rp, ok := prodMap[r.RecommendedID]
+		if !ok {
+			continue
+		}
+		at := r.ComputedAt
+		computedAt = &at
+		row := recommendationRow{Product: rp, Score: r.Score}
+		switch {
+		case r.Kind == RecommendationBoughtTogether && len(together) < limit:
+			together = append(together, row)
+		case r.Kind == RecommendationSimilar && len(similar) < limit:
+			similar = append(similar, row)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":                 p.ID,
+		"computed_at":                computedAt,
+		"frequently_bought_together": together,
+		"similar":                    similar,
+	})
+}
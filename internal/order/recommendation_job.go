@@ -0,0 +1,236 @@
+package order
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// maxRecommendations es la cantidad de recomendaciones que se guardan por producto y
+// tipo. Es bastante más que lo que se muestra porque el stock se filtra al responder:
+// si se agotan las primeras, quedan otras para completar la lista.
+const maxRecommendations = 50
+
+// Pesos de la similitud entre productos
+const (
+	similarCategoryWeight = 3.0
+	similarSeasonWeight   = 2.0
+	similarColorWeight    = 1.0
+)
+
+// coOccurrenceSelect cuenta, para cada par de productos, las órdenes en las que se
+// compraron juntos. No cuenta órdenes canceladas, líneas devueltas por completo ni
+// dos líneas del mismo kit (ya se venden juntas por definición).
+const coOccurrenceSelect = `SELECT a.product_id AS product_id, b.product_id AS other_id, COUNT(DISTINCT a.order_id) AS orders
+FROM order_items a
+JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id AND b.deleted_at IS NULL
+	AND b.quantity > b.returned_quantity
+	AND (COALESCE(a.kit_group, '') = '' OR COALESCE(b.kit_group, '') <> a.kit_group)
+JOIN orders o ON o.id = a.order_id AND o.deleted_at IS NULL AND o.status <> 'cancelado'
+WHERE a.deleted_at IS NULL AND a.quantity > a.returned_quantity AND a.created_at >= ?
+GROUP BY a.product_id, b.product_id`
+
+type scored struct {
+	id    uint
+	score float64
+}
+
+// before ordena por puntaje descendente y, a igual puntaje, por ID
+func (s scored) before(o scored) bool {
+	if s.score != o.score {
+		return s.score > o.score
+	}
+	return s.id < o.id
+}
+
+// pushTop inserta s en list (ordenada con before) y la corta en k elementos, para no
+// guardar en memoria todos los candidatos de cada producto
+func pushTop(list []scored, s scored, k int) []scored {
+	if len(list) >= k && !s.before(list[len(list)-1]) {
+		return list
+	}
+	i := sort.Search(len(list), func(i int) bool { return s.before(list[i]) })
+	if len(list) < k {
+		list = append(list, scored{})
+	}
+	copy(list[i+1:], list[i:])
+	list[i] = s
+	return list
+}
+
+// RunRecommendationsOnce recalcula las recomendaciones de todos los productos y
+// reemplaza las guardadas. El stock no se mira acá: cambia más seguido que el job y
+// se filtra al responder.
+func RunRecommendationsOnce(db *gorm.DB) error {
+	computedAt := time.Now()
+
+	var products []product.Product
+	if err := db.Select("id", "category_id", "season_id").Where("status <> ?", product.StatusArchived).Find(&products).Error; err != nil {
+		return err
+	}
+	active := make(map[uint]product.Product, len(products))
+	for _, p := range products {
+		active[p.ID] = p
+	}
+
+	together, err := boughtTogether(db, active, computedAt.AddDate(0, 0, -180))
+	if err != nil {
+		return err
+	}
+	similar, err := similarProducts(db, products, active)
+	if err != nil {
+		return err
+	}
+
+	rows := []ProductRecommendation{}
+	add := func(kind string, lists map[uint][]scored) {
+		for productID, list := range lists {
+			for i, s := range list {
+				rows = append(rows, ProductRecommendation{ProductID: productID, Kind: kind, RecommendedID: s.id, Score: s.score, Rank: i + 1, ComputedAt: computedAt})
+			}
+		}
+	}
+	add(RecommendationBoughtTogether, together)
+	add(RecommendationSimilar, similar)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(&ProductRecommendation{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+}
+
+// boughtTogether arma, por producto, los productos comprados en las mismas órdenes
+// desde since, puntuados por la cantidad de órdenes en común. Cada lista queda ordenada
+// y con a lo sumo maxRecommendations elementos.
+func boughtTogether(db *gorm.DB, active map[uint]product.Product, since time.Time) (map[uint][]scored, error) {
+	var pairs []struct {
+		ProductID uint
+		OtherID   uint
+		Orders    int
+	}
+	if err := db.Raw(coOccurrenceSelect, since).Scan(&pairs).Error; err != nil {
+		return nil, err
+	}
+	out := map[uint][]scored{}
+	for _, p := range pairs {
+		if _, ok := active[p.ProductID]; !ok {
+			continue
+		}
+		if _, ok := active[p.OtherID]; !ok {
+			continue
+		}
+		out[p.ProductID] = pushTop(out[p.ProductID], scored{id: p.OtherID, score: float64(p.Orders)}, maxRecommendations)
+	}
+	return out, nil
+}
+
+// similarProducts puntúa los productos que comparten al menos una categoría
+// (principal o adicional), sumando temporada y colores en común. Como boughtTogether,
+// guarda sólo los mejores maxRecommendations por producto.
+func similarProducts(db *gorm.DB, products []product.Product, active map[uint]product.Product) (map[uint][]scored, error) {
+	categories := map[uint]map[uint]bool{} // producto -> categorías
+	byCategory := map[uint][]uint{}        // categoría -> productos
+	addCategory := func(productID, categoryID uint) {
+		if categoryID == 0 {
+			return
+		}
+		if categories[productID] == nil {
+			categories[productID] = map[uint]bool{}
+		}
+		if !categories[productID][categoryID] {
+			categories[productID][categoryID] = true
+			byCategory[categoryID] = append(byCategory[categoryID], productID)
+		}
+	}
+	seasons := map[uint]uint{}
+	for _, p := range products {
+		addCategory(p.ID, p.CategoryID)
+		if p.SeasonID != nil {
+			seasons[p.ID] = *p.SeasonID
+		}
+	}
+	var extra []struct {
+		ProductID  uint
+		CategoryID uint
+	}
+	if err := db.Table("product_categories").Select("product_id, category_id").Scan(&extra).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range extra {
+		if _, ok := active[e.ProductID]; ok {
+			addCategory(e.ProductID, e.CategoryID)
+		}
+	}
+
+	var variantColors []struct {
+		ProductID uint
+		Color     string
+	}
+	if err := db.Model(&product.ProductVariant{}).Select("DISTINCT product_id, color").Where("color <> ''").Scan(&variantColors).Error; err != nil {
+		return nil, err
+	}
+	colors := map[uint]map[string]bool{}
+	for _, vc := range variantColors {
+		if colors[vc.ProductID] == nil {
+			colors[vc.ProductID] = map[string]bool{}
+		}
+		colors[vc.ProductID][strings.ToLower(strings.TrimSpace(vc.Color))] = true
+	}
+
+	out := map[uint][]scored{}
+	for _, p := range products {
+		seen := map[uint]bool{p.ID: true}
+		for categoryID := range categories[p.ID] {
+			for _, otherID := range byCategory[categoryID] {
+				if seen[otherID] {
+					continue
+				}
+				seen[otherID] = true
+				score := 0.0
+				for c := range categories[otherID] {
+					if categories[p.ID][c] {
+						score += similarCategoryWeight
+					}
+				}
+				if seasons[p.ID] > 0 && seasons[p.ID] == seasons[otherID] {
+					score += similarSeasonWeight
+				}
+				for color := range colors[otherID] {
+					if colors[p.ID][color] {
+						score += similarColorWeight
+					}
+				}
+				out[p.ID] = pushTop(out[p.ID], scored{id: otherID, score: score}, maxRecommendations)
+			}
+		}
+	}
+	return out, nil
+}
+
+// StartRecommendationsJob lanza una goroutine que recalcula las recomendaciones periódicamente
+func StartRecommendationsJob(interval time.Duration) {
+	go func() {
+		if err := RunRecommendationsOnce(config.DB); err != nil {
+			log.Printf("❌ Error calculando recomendaciones: %v", err)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			if err := RunRecommendationsOnce(config.DB); err != nil {
+				log.Printf("❌ Error calculando recomendaciones: %v", err)
+			}
+		}
+	}()
+}
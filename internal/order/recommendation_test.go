@@ -0,0 +1,101 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/slug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRecommendations_JobAndEndpoint(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:recommendations?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &product.ProductVariant{}, &product.LocationStock{}, &category.Category{},
+		&sequence.Sequence{}, &slug.Redirect{}, &Order{}, &OrderItem{}, &ProductRecommendation{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	config.DB = db
+
+	remeras := category.Category{Name: "Remeras"}
+	db.Create(&remeras)
+	jeans := category.Category{Name: "Jeans"}
+	db.Create(&jeans)
+	newProduct := func(name string, cat uint, color string, stock int) product.Product {
+		p := product.Product{Name: name, CategoryID: cat, Status: product.StatusPublished}
+		db.Create(&p)
+		v := product.ProductVariant{ProductID: p.ID, SKU: fmt.Sprintf("SKU-%d", p.ID), Color: color}
+		db.Create(&v)
+		db.Create(&product.LocationStock{ProductID: p.ID, VariantID: &v.ID, Location: "deposito", Stock: stock})
+		return p
+	}
+	lisa := newProduct("Remera Lisa", remeras.ID, "Negro", 5)
+	estampada := newProduct("Remera Estampada", remeras.ID, "Negro", 5)
+	rayada := newProduct("Remera Rayada", remeras.ID, "Blanco", 5)
+	agotada := newProduct("Remera Agotada", remeras.ID, "Negro", 0)
+	jean := newProduct("Jean Recto", jeans.ID, "Azul", 5)
+
+	sell := func(status string, productIDs ...uint) {
+		o := Order{Status: status}
+		db.Create(&o)
+		for _, id := range productIDs {
+			db.Create(&OrderItem{OrderID: o.ID, ProductID: id, Quantity: 1})
+		}
+	}
+	sell("completado", lisa.ID, jean.ID)
+	sell("pagado", lisa.ID, jean.ID, agotada.ID)
+	sell("cancelado", lisa.ID, rayada.ID)
+
+	if err := RunRecommendationsOnce(db); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// Correrlo de nuevo reemplaza, no duplica
+	if err := RunRecommendationsOnce(db); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/products/:id/recommendations", GetProductRecommendations)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/products/%d/recommendations", lisa.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("recommendations: %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Together []recommendationRow `json:"frequently_bought_together"`
+		Similar  []recommendationRow `json:"similar"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	// Sin la orden cancelada ni el producto sin stock, sólo queda el jean (2 órdenes)
+	if len(resp.Together) != 1 || resp.Together[0].Product.ID != jean.ID || resp.Together[0].Score != 2 {
+		t.Fatalf("unexpected bought together: %+v", resp.Together)
+	}
+	// Misma categoría; el mismo color pesa más
+	if len(resp.Similar) != 2 || resp.Similar[0].Product.ID != estampada.ID || resp.Similar[1].Product.ID != rayada.ID {
+		t.Fatalf("unexpected similar: %+v", resp.Similar)
+	}
+}
+
+func TestPushTop_KeepsBestK(t *testing.T) {
+	var list []scored
+	for i, score := range []float64{1, 5, 3, 5, 2, 4} {
+		list = pushTop(list, scored{id: uint(i + 1), score: score}, 3)
+	}
+	// 5 (id 2), 5 (id 4), 4 (id 6): a igual puntaje gana el ID menor
+	if len(list) != 3 || list[0].id != 2 || list[1].id != 4 || list[2].id != 6 {
+		t.Fatalf("unexpected top: %+v", list)
+	}
+}
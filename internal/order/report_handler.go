@@ -10,7 +10,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Reporte de ventas por período
+// netSalesSelect agrega ventas por producto descontando las unidades devueltas
+const netSalesSelect = "product_id, SUM(quantity - returned_quantity) as quantity_sold, SUM((quantity - returned_quantity) * price) as total_revenue"
+
+// Reporte de ventas por período (total neto de devoluciones)
 func SalesReport(c *gin.Context) {
 	start := c.Query("start")
 	end := c.Query("end")
@@ -30,7 +33,10 @@ func SalesReport(c *gin.Context) {
 			return
 		}
 	}
-	var total float64
+	var totals struct {
+		Total    float64
+		Returned float64
+	}
 	var count int64
 	query := config.DB.Model(&Order{})
 	if !startDate.IsZero() {
@@ -40,8 +46,8 @@ func SalesReport(c *gin.Context) {
 		query = query.Where("created_at <= ?", endDate)
 	}
 	query.Count(&count)
-	query.Select("SUM(total)").Scan(&total)
-	c.JSON(http.StatusOK, gin.H{"total_ventas": total, "cantidad_pedidos": count})
+	query.Select("COALESCE(SUM(total),0) as total, COALESCE(SUM(returned_total),0) as returned").Scan(&totals)
+	c.JSON(http.StatusOK, gin.H{"total_ventas": totals.Total, "total_devoluciones": totals.Returned, "cantidad_pedidos": count})
 }
 
 // Productos más vendidos
@@ -81,7 +87,7 @@ func TopProductsReport(c *gin.Context) {
 		TotalRevenue float64 `json:"total_revenue"`
 	}
 	var rows []aggRow
-	q := config.DB.Table("order_items").Select("product_id, SUM(quantity) as quantity_sold, SUM(quantity * price) as total_revenue")
+	q := config.DB.Table("order_items").Select(netSalesSelect)
 	if !from.IsZero() && !to.IsZero() {
 		q = q.Where("created_at BETWEEN ? AND ?", from, to)
 	} else if !from.IsZero() {
@@ -58,7 +58,7 @@ func TopProductsReportLegacy(c *gin.Context) {
 	}
 	var rows []aggRow
 	// Note: table name is order_items
-	if err := config.DB.Table("order_items").Select("product_id, SUM(quantity) as quantity_sold, SUM(quantity * price) as total_revenue").Where("created_at BETWEEN ? AND ?", from, to).Group("product_id").Order("quantity_sold desc").Limit(limit).Scan(&rows).Error; err != nil {
+	if err := config.DB.Table("order_items").Select(netSalesSelect).Where("created_at BETWEEN ? AND ?", from, to).Group("product_id").Order("quantity_sold desc").Limit(limit).Scan(&rows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
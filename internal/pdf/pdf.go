@@ -0,0 +1,150 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tamaño A4 en puntos (1 pt = 1/72 pulgada)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// MM convierte milímetros a puntos
+func MM(mm float64) float64 { return mm * 72 / 25.4 }
+
+// Document arma un PDF simple con rectángulos rellenos y texto en Helvetica
+// (WinAnsiEncoding, alcanza para textos en castellano). Las coordenadas de
+// las páginas se expresan desde la esquina superior izquierda.
+type Document struct {
+	pages []*Page
+}
+
+// Page es una página con su contenido en operadores PDF
+type Page struct {
+	width, height float64
+	content       bytes.Buffer
+}
+
+// New crea un documento vacío
+func New() *Document { return &Document{} }
+
+// AddPage agrega una página del tamaño indicado en puntos
+func (d *Document) AddPage(width, height float64) *Page {
+	p := &Page{width: width, height: height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Rect dibuja un rectángulo negro relleno
+func (p *Page) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f %.3f re f\n", x, p.height-y-h, w, h)
+}
+
+// Text escribe una línea con la base en y
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.3f %.3f Td (%s) Tj ET\n", font, size, x, p.height-y, escape(s))
+}
+
+// TextWidth estima el ancho del texto en Helvetica (ancho promedio de glifo)
+func TextWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * size * 0.52
+}
+
+// Fit recorta el texto con "…" para que entre en el ancho dado
+func Fit(s string, size, width float64) string {
+	runes := []rune(s)
+	if TextWidth(s, size) <= width {
+		return s
+	}
+	for len(runes) > 0 && TextWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// WriteTo serializa el documento
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	var offsets []int64
+	obj := func(body string) {
+		offsets = append(offsets, cw.n)
+		fmt.Fprintf(cw, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	io.WriteString(cw, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1: catálogo, 2: árbol de páginas, 3-4: fuentes, luego página + contenido por cada una
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			p.width, p.height, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.content.Len(), p.content.String()))
+	}
+
+	xref := cw.n
+	fmt.Fprintf(cw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(cw, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(cw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// escape convierte a WinAnsi (Latin-1 más comillas/guiones tipográficos) y escapa los delimitadores
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '…':
+			b.WriteByte(0x85)
+		case r == '–':
+			b.WriteByte(0x96)
+		case r == '€':
+			b.WriteByte(0x80)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,58 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestDocumentXrefOffsets(t *testing.T) {
+	doc := New()
+	p := doc.AddPage(A4Width, A4Height)
+	p.Rect(10, 10, 2, 30)
+	p.Text(10, 60, 8, true, "Remera (básica) – Año")
+	doc.AddPage(A4Width, A4Height).Text(10, 20, 8, false, "segunda")
+
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("missing header or trailer")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Fatalf("expected 2 pages")
+	}
+	// texto escapado y en Latin-1
+	if !bytes.Contains(out, []byte("(Remera \\(b\xe1sica\\) \x96 A\xf1o)")) {
+		t.Fatalf("text not escaped as WinAnsi: %q", out)
+	}
+
+	// cada entrada del xref apunta al comienzo de su objeto
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(out)
+	start, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out[start:], -1)
+	if len(entries) != 8 {
+		t.Fatalf("expected 8 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if !bytes.HasPrefix(out[off:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+			t.Fatalf("xref entry %d points to wrong offset", i+1)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	if got := Fit("Corto", 8, 100); got != "Corto" {
+		t.Fatalf("unexpected %q", got)
+	}
+	long := "Campera de jean con corderito desmontable"
+	got := Fit(long, 8, 60)
+	if TextWidth(got, 8) > 60 || []rune(got)[len([]rune(got))-1] != '…' {
+		t.Fatalf("text not fitted: %q", got)
+	}
+}
@@ -0,0 +1,373 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/category"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ProductAttributeValue es el valor de un atributo de categoría para un producto.
+// Los multiselect guardan una fila por opción; los numéricos además completan
+// NumberValue para poder filtrar por rango.
+type ProductAttributeValue struct {
+	ID          uint                       `json:"id" gorm:"primaryKey"`
+	ProductID   uint                       `json:"product_id" gorm:"index;not null"`
+	AttributeID uint                       `json:"attribute_id" gorm:"index;not null"`
+	Value       string                     `json:"value" gorm:"not null"`
+	NumberValue *float64                   `json:"number_value,omitempty"`
+	Attribute   category.CategoryAttribute `json:"attribute" gorm:"foreignKey:AttributeID"`
+}
+
+// AttributeInputError indica categorías o valores de atributos inválidos (corresponde a un 400)
+type AttributeInputError struct {
+	Message string
+}
+
+func (e *AttributeInputError) Error() string { return e.Message }
+
+// productCategoryIDs devuelve la categoría principal más las adicionales
+func productCategoryIDs(tx *gorm.DB, p *Product) ([]uint, error) {
+	var extra []uint
+	if err := tx.Table("product_categories").Where("product_id = ?", p.ID).Pluck("category_id", &extra).Error; err != nil {
+		return nil, err
+	}
+	ids := []uint{}
+	if p.CategoryID > 0 {
+		ids = append(ids, p.CategoryID)
+	}
+	return append(ids, extra...), nil
+}
+
+// SetProductCategories reemplaza las categorías adicionales del producto (la
+// principal sigue siendo category_id) y descarta los valores de atributos que
+// dejaron de corresponder.
+func SetProductCategories(tx *gorm.DB, p *Product, categoryIDs []uint) error {
+	seen := map[uint]bool{p.CategoryID: true}
+	ids := []uint{}
+	for _, id := range categoryIDs {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	cats := []category.Category{}
+	if len(ids) > 0 {
+		if err := tx.Where("id IN ?", ids).Find(&cats).Error; err != nil {
+			return err
+		}
+		if len(cats) != len(ids) {
+			return &AttributeInputError{Message: "Alguna de las categorías adicionales no existe"}
+		}
+	}
+	if err := tx.Model(p).Association("Categories").Replace(cats); err != nil {
+		return err
+	}
+	return pruneAttributeValues(tx, p)
+}
+
+// pruneAttributeValues borra los valores de atributos que no pertenecen a las categorías actuales
+func pruneAttributeValues(tx *gorm.DB, p *Product) error {
+	catIDs, err := productCategoryIDs(tx, p)
+	if err != nil {
+		return err
+	}
+	attrs, err := category.EffectiveAttributes(tx, catIDs)
+	if err != nil {
+		return err
+	}
+	q := tx.Where("product_id = ?", p.ID)
+	if len(attrs) > 0 {
+		keep := make([]uint, len(attrs))
+		for i, a := range attrs {
+			keep[i] = a.ID
+		}
+		q = q.Where("attribute_id NOT IN ?", keep)
+	}
+	return q.Delete(&ProductAttributeValue{}).Error
+}
+
+// SetProductAttributes guarda los valores por clave de atributo. Con replace=true
+// los atributos no enviados se borran y se exige completar los obligatorios.
+func SetProductAttributes(tx *gorm.DB, p *Product, values map[string]any, replace bool) error {
+	catIDs, err := productCategoryIDs(tx, p)
+	if err != nil {
+		return err
+	}
+	attrs, err := category.EffectiveAttributes(tx, catIDs)
+	if err != nil {
+		return err
+	}
+	byKey := map[string]category.CategoryAttribute{}
+	for _, a := range attrs {
+		byKey[a.Key] = a
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		attr, ok := byKey[category.AttributeKey(key)]
+		if !ok {
+			return &AttributeInputError{Message: fmt.Sprintf("El atributo '%s' no corresponde a las categorías del producto", key)}
+		}
+		normalized, err := attr.NormalizeValues(values[key])
+		if err != nil {
+			return &AttributeInputError{Message: err.Error()}
+		}
+		if err := tx.Where("product_id = ? AND attribute_id = ?", p.ID, attr.ID).Delete(&ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		for _, v := range normalized {
+			row := ProductAttributeValue{ProductID: p.ID, AttributeID: attr.ID, Value: v}
+			if attr.Type == category.AttributeNumber {
+				n, _ := strconv.ParseFloat(v, 64)
+				row.NumberValue = &n
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+	}
+	if !replace {
+		return nil
+	}
+
+	sent := map[string]bool{}
+	for _, k := range keys {
+		sent[category.AttributeKey(k)] = true
+	}
+	current, err := productAttributeValues(tx, p.ID)
+	if err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		if !sent[a.Key] {
+			if err := tx.Where("product_id = ? AND attribute_id = ?", p.ID, a.ID).Delete(&ProductAttributeValue{}).Error; err != nil {
+				return err
+			}
+			delete(current, a.Key)
+		}
+		if a.Required && len(current[a.Key]) == 0 {
+			return &AttributeInputError{Message: fmt.Sprintf("El atributo '%s' es obligatorio", a.Name)}
+		}
+	}
+	return nil
+}
+
+// restoreAttributeValues vuelve a cargar los valores de una revisión tal como
+// estaban; se omiten las claves que ya no aplican a las categorías del producto
+func restoreAttributeValues(tx *gorm.DB, p *Product, values map[string][]string) error {
+	if err := tx.Where("product_id = ?", p.ID).Delete(&ProductAttributeValue{}).Error; err != nil {
+		return err
+	}
+	catIDs, err := productCategoryIDs(tx, p)
+	if err != nil {
+		return err
+	}
+	attrs, err := category.EffectiveAttributes(tx, catIDs)
+	if err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		for _, v := range values[a.Key] {
+			row := ProductAttributeValue{ProductID: p.ID, AttributeID: a.ID, Value: v}
+			if a.Type == category.AttributeNumber {
+				if n, err := strconv.ParseFloat(v, 64); err == nil {
+					row.NumberValue = &n
+				}
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// productAttributeValues devuelve los valores cargados por clave de atributo
+func productAttributeValues(db *gorm.DB, productID uint) (map[string][]string, error) {
+	var rows []struct {
+		Key   string
+		Value string
+	}
+	if err := db.Table("product_attribute_values pav").
+		Select("ca.key as key, pav.value as value").
+		Joins("JOIN category_attributes ca ON ca.id = pav.attribute_id AND ca.deleted_at IS NULL").
+		Where("pav.product_id = ?", productID).
+		Order("ca.key, pav.id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := map[string][]string{}
+	for _, r := range rows {
+		out[r.Key] = append(out[r.Key], r.Value)
+	}
+	return out, nil
+}
+
+// respondAttributeError traduce los errores de categorías/atributos a la respuesta HTTP
+func respondAttributeError(c *gin.Context, err error) {
+	var ae *AttributeInputError
+	if errors.As(err, &ae) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ae.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GetProductAttributes devuelve los atributos que aplican al producto (según sus
+// categorías) junto con los valores cargados; lo usa el wizard de producto.
+func GetProductAttributes(c *gin.Context) {
+	var p Product
+	if err := config.DB.First(&p, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	catIDs, err := productCategoryIDs(config.DB, &p)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	attrs, err := category.EffectiveAttributes(config.DB, catIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	values, err := productAttributeValues(config.DB, p.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"category_ids": catIDs, "attributes": attrs, "values": values})
+}
+
+// UpdateProductAttributes reemplaza las categorías adicionales (si se envían) y los valores de atributos
+func UpdateProductAttributes(c *gin.Context) {
+	var p Product
+	if err := config.DB.First(&p, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	var input struct {
+		CategoryIDs *[]uint        `json:"category_ids"`
+		Attributes  map[string]any `json:"attributes"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := CaptureRevision(config.DB, p.ID)
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if input.CategoryIDs != nil {
+			if err := SetProductCategories(tx, &p, *input.CategoryIDs); err != nil {
+				return err
+			}
+		}
+		return SetProductAttributes(tx, &p, input.Attributes, true)
+	}); err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+	RecordRevision(config.DB, c, p.ID, RevisionUpdate, before)
+	values, err := productAttributeValues(config.DB, p.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"values": values})
+}
+
+// applyAttributeFilters agrega los filtros ?attr.<clave>=v1,v2 (cualquiera de los valores)
+// y ?attr.<clave>.min= / .max= para atributos numéricos. Sólo aplican atributos filtrables.
+func applyAttributeFilters(c *gin.Context, base *gorm.DB) (*gorm.DB, error) {
+	const attrQuery = "SELECT pav.product_id FROM product_attribute_values pav " +
+		"JOIN category_attributes ca ON ca.id = pav.attribute_id AND ca.deleted_at IS NULL " +
+		"WHERE ca.filterable = ? AND ca.key = ?"
+
+	params := c.Request.URL.Query()
+	names := make([]string, 0, len(params))
+	for name := range params {
+		if strings.HasPrefix(name, "attr.") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		raw := strings.TrimSpace(params.Get(name))
+		if raw == "" {
+			continue
+		}
+		key := strings.TrimPrefix(name, "attr.")
+		switch {
+		case strings.HasSuffix(key, ".min"), strings.HasSuffix(key, ".max"):
+			n, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", "."), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s inválido", name)
+			}
+			op := ">="
+			if strings.HasSuffix(key, ".max") {
+				op = "<="
+			}
+			key = category.AttributeKey(key[:len(key)-4])
+			base = base.Where("products.id IN ("+attrQuery+" AND pav.number_value "+op+" ?)", true, key, n)
+		default:
+			values := []string{}
+			for _, v := range strings.Split(raw, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, strings.ToLower(v))
+				}
+			}
+			base = base.Where("products.id IN ("+attrQuery+" AND LOWER(pav.value) IN ?)", true, category.AttributeKey(key), values)
+		}
+	}
+	return base, nil
+}
+
+// AttributeFacet cuenta productos por valor de un atributo filtrable
+type AttributeFacet struct {
+	Key    string        `json:"key"`
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Unit   string        `json:"unit,omitempty"`
+	Values []FacetBucket `json:"values"`
+}
+
+func attributeFacets(db *gorm.DB, ids []uint) ([]AttributeFacet, error) {
+	var rows []struct {
+		Key   string
+		Name  string
+		Type  string
+		Unit  string
+		Value string
+		Count int64
+	}
+	if err := db.Table("product_attribute_values pav").
+		Select("ca.key as key, MIN(ca.name) as name, MIN(ca.type) as type, MIN(ca.unit) as unit, pav.value as value, COUNT(DISTINCT pav.product_id) as count").
+		Joins("JOIN category_attributes ca ON ca.id = pav.attribute_id AND ca.deleted_at IS NULL").
+		Where("pav.product_id IN ? AND ca.filterable = ?", ids, true).
+		Group("ca.key, pav.value").
+		Order("ca.key, count DESC, pav.value").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := []AttributeFacet{}
+	for _, r := range rows {
+		if len(out) == 0 || out[len(out)-1].Key != r.Key {
+			out = append(out, AttributeFacet{Key: r.Key, Name: r.Name, Type: r.Type, Unit: r.Unit, Values: []FacetBucket{}})
+		}
+		f := &out[len(out)-1]
+		f.Values = append(f.Values, FacetBucket{Value: r.Value, Count: r.Count})
+	}
+	return out, nil
+}
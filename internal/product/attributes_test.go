@@ -0,0 +1,121 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-modaMayor/internal/category"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProductAttributes_TreeCategoryAndAttributeFilters(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&category.Subcategory{}, &Season{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	for _, table := range []string{"products", "product_variants", "location_stocks", "product_categories", "product_attribute_values", "category_attributes", "categories"} {
+		db.Exec("DELETE FROM " + table)
+	}
+
+	mujer := category.Category{Name: "Mujer", Slug: "mujer"}
+	db.Create(&mujer)
+	db.Model(&mujer).Update("path", fmt.Sprintf("/%d/", mujer.ID))
+	remeras := category.Category{Name: "Remeras", Slug: "remeras", ParentID: &mujer.ID, Depth: 1}
+	db.Create(&remeras)
+	db.Model(&remeras).Update("path", fmt.Sprintf("/%d/%d/", mujer.ID, remeras.ID))
+	sale := category.Category{Name: "Sale", Slug: "sale"}
+	db.Create(&sale)
+	db.Model(&sale).Update("path", fmt.Sprintf("/%d/", sale.ID))
+	db.Create(&category.CategoryAttribute{CategoryID: mujer.ID, Key: "tela", Name: "Tela", Type: category.AttributeMultiselect, Options: category.StringList{"Algodón", "Lino", "Modal"}, Filterable: true})
+	db.Create(&category.CategoryAttribute{CategoryID: remeras.ID, Key: "gramaje", Name: "Gramaje", Type: category.AttributeNumber, Unit: "g/m²", Required: true, Filterable: true})
+
+	lino := Product{Name: "Remera Lino", Code: "TEST-AT-1", CategoryID: remeras.ID, Status: StatusPublished}
+	db.Create(&lino)
+	modal := Product{Name: "Remera Modal", Code: "TEST-AT-2", CategoryID: remeras.ID, Status: StatusPublished}
+	db.Create(&modal)
+	otra := Product{Name: "Pantalón", Code: "TEST-AT-3", CategoryID: sale.ID, Status: StatusPublished}
+	db.Create(&otra)
+
+	router := gin.New()
+	router.GET("/products", GetProducts)
+	router.GET("/products/:id/attributes", GetProductAttributes)
+	router.PUT("/products/:id/attributes", UpdateProductAttributes)
+	put := func(id uint, body gin.H) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/products/%d/attributes", id), bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	get := func(query string) []uint {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Items []Product `json:"items"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ids := []uint{}
+		for _, p := range resp.Items {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	// Gramaje es obligatorio y la tela debe ser una de las opciones
+	if w := put(lino.ID, gin.H{"attributes": gin.H{"tela": []string{"lino"}}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected required attribute error, got %d %s", w.Code, w.Body.String())
+	}
+	if w := put(lino.ID, gin.H{"attributes": gin.H{"tela": "Seda", "gramaje": 150}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid option error, got %d %s", w.Code, w.Body.String())
+	}
+	if w := put(lino.ID, gin.H{"attributes": gin.H{"tela": []string{"lino", "Algodón"}, "gramaje": "180"}}); w.Code != http.StatusOK {
+		t.Fatalf("set attributes: %d %s", w.Code, w.Body.String())
+	}
+	// La remera modal además figura en Sale
+	if w := put(modal.ID, gin.H{"category_ids": []uint{sale.ID}, "attributes": gin.H{"tela": "Modal", "gramaje": 140}}); w.Code != http.StatusOK {
+		t.Fatalf("set categories: %d %s", w.Code, w.Body.String())
+	}
+
+	if ids := get(fmt.Sprintf("category=%d&sort=name_asc", mujer.ID)); len(ids) != 2 || ids[0] != lino.ID {
+		t.Fatalf("category subtree: got %v", ids)
+	}
+	if ids := get(fmt.Sprintf("category=%d&sort=name_asc", sale.ID)); len(ids) != 2 || ids[0] != otra.ID || ids[1] != modal.ID {
+		t.Fatalf("additional category: got %v", ids)
+	}
+	if ids := get("attr.tela=algodón,seda"); len(ids) != 1 || ids[0] != lino.ID {
+		t.Fatalf("attribute filter: got %v", ids)
+	}
+	if ids := get("attr.gramaje.max=150"); len(ids) != 1 || ids[0] != modal.ID {
+		t.Fatalf("numeric range filter: got %v", ids)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/products?facets=true&category=%d", mujer.ID), nil))
+	var resp struct {
+		Facets ProductFacets `json:"facets"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Facets.Attributes) != 2 || resp.Facets.Attributes[1].Key != "tela" || len(resp.Facets.Attributes[1].Values) != 3 {
+		t.Fatalf("unexpected attribute facets: %+v", resp.Facets.Attributes)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/products/%d/attributes", lino.ID), nil))
+	var detail struct {
+		Attributes []category.CategoryAttribute `json:"attributes"`
+		Values     map[string][]string          `json:"values"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	if len(detail.Attributes) != 2 || detail.Values["gramaje"][0] != "180" || len(detail.Values["tela"]) != 2 {
+		t.Fatalf("unexpected product attributes: %s", w.Body.String())
+	}
+}
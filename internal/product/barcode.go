@@ -0,0 +1,527 @@
+package product
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-modaMayor/config"
+	"go-modaMayor/internal/barcode"
+	"go-modaMayor/internal/pdf"
+	"go-modaMayor/internal/sequence"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BarcodeError es un código de barras rechazado (inválido o repetido), corresponde a un 400
+type BarcodeError struct {
+	Message string
+}
+
+func (e *BarcodeError) Error() string { return e.Message }
+
+// NextEAN13 emite un EAN-13 nuevo: prefijo de empresa (prefijo de la secuencia
+// "barcode", editable en /sequences) + número de artículo + dígito verificador.
+// Se saltea cualquier número que ya esté usado por un código importado.
+func NextEAN13(tx *gorm.DB) (string, error) {
+	for {
+		seq, err := sequence.NextValue(tx, sequence.Barcode)
+		if err != nil {
+			return "", err
+		}
+		prefix := strings.TrimSpace(seq.Prefix)
+		if len(prefix) < 2 || len(prefix) > 11 || strings.Trim(prefix, "0123456789") != "" {
+			return "", fmt.Errorf("el prefijo de empresa para códigos de barras debe tener entre 2 y 11 dígitos (actual: '%s')", seq.Prefix)
+		}
+		data := fmt.Sprintf("%s%0*d", prefix, 12-len(prefix), seq.CurrentValue)
+		if len(data) > 12 {
+			return "", fmt.Errorf("se agotaron los códigos EAN-13 para el prefijo %s", prefix)
+		}
+		check, err := barcode.EAN13CheckDigit(data)
+		if err != nil {
+			return "", err
+		}
+		code := data + string(check)
+		var count int64
+		if err := tx.Unscoped().Model(&ProductVariant{}).Where("barcode = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+}
+
+// validateBarcode valida un código importado y que no lo use otra variante
+// (incluidas las eliminadas, que conservan el índice único)
+func validateBarcode(tx *gorm.DB, code string, variantID uint) (string, error) {
+	typ, err := barcode.Detect(code)
+	if err != nil {
+		return "", &BarcodeError{Message: err.Error()}
+	}
+	var other ProductVariant
+	err = tx.Unscoped().Where("barcode = ? AND id <> ?", code, variantID).First(&other).Error
+	if err == nil {
+		return "", &BarcodeError{Message: fmt.Sprintf("el código %s ya está asignado a la variante %s", code, other.SKU)}
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return typ, nil
+}
+
+// assignBarcode valida el código que trae la variante o, si no tiene, genera un EAN-13
+func assignBarcode(tx *gorm.DB, v *ProductVariant) error {
+	if v.Barcode != nil {
+		code := strings.TrimSpace(*v.Barcode)
+		if code != "" {
+			typ, err := validateBarcode(tx, code, v.ID)
+			if err != nil {
+				return err
+			}
+			v.Barcode, v.BarcodeType = &code, typ
+			return nil
+		}
+	}
+	code, err := NextEAN13(tx)
+	if err != nil {
+		return err
+	}
+	v.Barcode, v.BarcodeType = &code, barcode.TypeEAN13
+	return nil
+}
+
+func respondBarcodeError(c *gin.Context, err error) {
+	var bcErr *BarcodeError
+	if errors.As(err, &bcErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": bcErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GetVariantByBarcode busca la variante escaneada (por código de barras o SKU)
+// y devuelve el producto y el stock por ubicación.
+// GET /variants/by-barcode/:code
+func GetVariantByBarcode(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+	var variant ProductVariant
+	err := config.DB.Preload("ColorInfo").Preload("SizeValue").Where("barcode = ?", code).First(&variant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = config.DB.Preload("ColorInfo").Preload("SizeValue").Where("sku = ?", code).First(&variant).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No hay ninguna variante con ese código"})
+		return
+	}
+	var prod Product
+	if err := config.DB.First(&prod, variant.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	var stocks []LocationStock
+	if err := config.DB.Where("variant_id = ?", variant.ID).Order("location").Find(&stocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	available := 0
+	for _, ls := range stocks {
+		available += ls.Stock - ls.Reserved
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"variant":         variant,
+		"product":         gin.H{"id": prod.ID, "code": prod.Code, "name": prod.Name, "status": prod.Status, "wholesale_price": prod.WholesalePrice, "discount1_price": prod.Discount1Price, "discount2_price": prod.Discount2Price},
+		"stocks":          stocks,
+		"total_available": available,
+	})
+}
+
+// SetVariantBarcode asigna un código importado del proveedor; sin código se genera un EAN-13 nuevo.
+// PUT /variants/:id/barcode
+func SetVariantBarcode(c *gin.Context) {
+	var variant ProductVariant
+	if err := config.DB.First(&variant, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variante no encontrada"})
+		return
+	}
+	var input struct {
+		Barcode string `json:"barcode"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		code := input.Barcode
+		variant.Barcode = &code
+		if err := assignBarcode(tx, &variant); err != nil {
+			return err
+		}
+		return tx.Model(&variant).Updates(map[string]interface{}{"barcode": variant.Barcode, "barcode_type": variant.BarcodeType}).Error
+	})
+	if err != nil {
+		respondBarcodeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, variant)
+}
+
+// ImportVariantBarcodes asigna en bloque códigos de proveedor identificando la variante por id o SKU.
+// Si alguna fila tiene error no se aplica ninguna.
+// POST /variants/barcodes/import
+func ImportVariantBarcodes(c *gin.Context) {
+	var input struct {
+		Codes []struct {
+			VariantID uint   `json:"variant_id"`
+			SKU       string `json:"sku"`
+			Barcode   string `json:"barcode" binding:"required"`
+		} `json:"codes" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var rowErrors []gin.H
+	updated := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		seen := map[string]int{}
+		for i, row := range input.Codes {
+			code := strings.TrimSpace(row.Barcode)
+			var variant ProductVariant
+			var err error
+			switch {
+			case row.VariantID != 0:
+				err = tx.First(&variant, row.VariantID).Error
+			case strings.TrimSpace(row.SKU) != "":
+				err = tx.Where("sku = ?", strings.TrimSpace(row.SKU)).First(&variant).Error
+			default:
+				rowErrors = append(rowErrors, gin.H{"row": i + 1, "error": "falta variant_id o sku"})
+				continue
+			}
+			if err != nil {
+				rowErrors = append(rowErrors, gin.H{"row": i + 1, "error": "variante no encontrada"})
+				continue
+			}
+			if prev, dup := seen[code]; dup {
+				rowErrors = append(rowErrors, gin.H{"row": i + 1, "error": fmt.Sprintf("código repetido en la fila %d", prev)})
+				continue
+			}
+			seen[code] = i + 1
+			variant.Barcode = &code
+			if err := assignBarcode(tx, &variant); err != nil {
+				var bcErr *BarcodeError
+				if !errors.As(err, &bcErr) {
+					return err
+				}
+				rowErrors = append(rowErrors, gin.H{"row": i + 1, "sku": variant.SKU, "error": bcErr.Message})
+				continue
+			}
+			if err := tx.Model(&variant).Updates(map[string]interface{}{"barcode": variant.Barcode, "barcode_type": variant.BarcodeType}).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		if len(rowErrors) > 0 {
+			return &BarcodeError{Message: "hay códigos con errores"}
+		}
+		return nil
+	})
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se importó ningún código", "errors": rowErrors})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Códigos importados", "updated": updated})
+}
+
+// GenerateMissingBarcodes genera EAN-13 para las variantes que no tienen código
+// (todas o las de un producto).
+// POST /variants/barcodes/generate
+func GenerateMissingBarcodes(c *gin.Context) {
+	var input struct {
+		ProductID uint `json:"product_id"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	generated := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("(barcode IS NULL OR barcode = '')").Order("id")
+		if input.ProductID != 0 {
+			q = q.Where("product_id = ?", input.ProductID)
+		}
+		var variants []ProductVariant
+		if err := q.Find(&variants).Error; err != nil {
+			return err
+		}
+		for i := range variants {
+			v := &variants[i]
+			v.Barcode = nil
+			if err := assignBarcode(tx, v); err != nil {
+				return err
+			}
+			if err := tx.Model(v).Updates(map[string]interface{}{"barcode": v.Barcode, "barcode_type": v.BarcodeType}).Error; err != nil {
+				return err
+			}
+			generated++
+		}
+		return nil
+	})
+	if err != nil {
+		respondBarcodeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Códigos generados", "generated": generated})
+}
+
+// GetVariantBarcodeImage devuelve el código de barras de la variante como PNG
+// GET /variants/:id/barcode.png?scale=2&height=80
+func GetVariantBarcodeImage(c *gin.Context) {
+	var variant ProductVariant
+	if err := config.DB.First(&variant, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variante no encontrada"})
+		return
+	}
+	if variant.Barcode == nil || *variant.Barcode == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "La variante no tiene código de barras"})
+		return
+	}
+	modules, err := barcode.Encode(*variant.Barcode, barcodeType(variant))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	scale, _ := strconv.Atoi(c.DefaultQuery("scale", "2"))
+	height, _ := strconv.Atoi(c.DefaultQuery("height", "80"))
+	if scale < 1 || scale > 10 {
+		scale = 2
+	}
+	if height < 10 || height > 600 {
+		height = 80
+	}
+	var buf bytes.Buffer
+	if err := barcode.PNG(&buf, modules, scale, height); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", buf.Bytes())
+}
+
+func barcodeType(v ProductVariant) string {
+	if v.BarcodeType != "" {
+		return v.BarcodeType
+	}
+	typ, _ := barcode.Detect(*v.Barcode)
+	return typ
+}
+
+// Planilla A4 de 3×8 etiquetas de 70×37,125 mm
+const (
+	labelColumns = 3
+	labelRows    = 8
+	labelPadding = 3 // mm
+)
+
+type LabelsInput struct {
+	VariantIDs []uint `json:"variant_ids"`
+	ProductID  uint   `json:"product_id"`
+	Copies     int    `json:"copies"` // etiquetas por variante, por defecto 1
+	Price      string `json:"price"`  // wholesale (default), discount1, discount2 o none
+	Skip       int    `json:"skip"`   // etiquetas a dejar en blanco al principio (planillas ya usadas)
+}
+
+// PrintBarcodeLabels genera un PDF con etiquetas (código de barras, nombre, talle, color y precio)
+// POST /variants/labels
+func PrintBarcodeLabels(c *gin.Context) {
+	var input LabelsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(input.VariantIDs) == 0 && input.ProductID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Indicar variant_ids o product_id"})
+		return
+	}
+	if input.Copies <= 0 {
+		input.Copies = 1
+	}
+	if input.Copies > 100 || input.Skip < 0 || input.Skip >= labelColumns*labelRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "copies debe ser entre 1 y 100 y skip menor a 24"})
+		return
+	}
+	switch input.Price {
+	case "":
+		input.Price = "wholesale"
+	case "wholesale", "discount1", "discount2", "none":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "price debe ser wholesale, discount1, discount2 o none"})
+		return
+	}
+
+	q := config.DB.Scopes(withVariantRefs)
+	if len(input.VariantIDs) > 0 {
+		q = q.Where("id IN ?", input.VariantIDs)
+	} else {
+		q = q.Where("product_id = ?", input.ProductID)
+	}
+	var variants []ProductVariant
+	if err := q.Find(&variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(variants) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No se encontraron variantes"})
+		return
+	}
+	// Respetar el orden pedido en variant_ids
+	if len(input.VariantIDs) > 0 {
+		pos := map[uint]int{}
+		for i, id := range input.VariantIDs {
+			if _, ok := pos[id]; !ok {
+				pos[id] = i
+			}
+		}
+		ordered := make([]ProductVariant, len(variants))
+		copy(ordered, variants)
+		for i := 1; i < len(ordered); i++ {
+			for j := i; j > 0 && pos[ordered[j].ID] < pos[ordered[j-1].ID]; j-- {
+				ordered[j], ordered[j-1] = ordered[j-1], ordered[j]
+			}
+		}
+		variants = ordered
+	}
+
+	productIDs := make([]uint, 0, len(variants))
+	var missing []string
+	for _, v := range variants {
+		productIDs = append(productIDs, v.ProductID)
+		if v.Barcode == nil || *v.Barcode == "" {
+			missing = append(missing, v.SKU)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hay variantes sin código de barras", "skus": missing})
+		return
+	}
+	var products []Product
+	if err := config.DB.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byID := make(map[uint]Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	doc := pdf.New()
+	var page *pdf.Page
+	slot := input.Skip
+	for _, v := range variants {
+		for n := 0; n < input.Copies; n++ {
+			if page == nil || slot == labelColumns*labelRows {
+				page = doc.AddPage(pdf.A4Width, pdf.A4Height)
+				if slot == labelColumns*labelRows {
+					slot = 0
+				}
+			}
+			if err := drawLabel(page, slot, v, byID[v.ProductID], input.Price); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			slot++
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := doc.WriteTo(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="etiquetas.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// drawLabel dibuja una etiqueta en la posición slot de la planilla
+func drawLabel(page *pdf.Page, slot int, v ProductVariant, p Product, priceField string) error {
+	w, h := pdf.A4Width/labelColumns, pdf.A4Height/labelRows
+	x0, y0 := float64(slot%labelColumns)*w, float64(slot/labelColumns)*h
+	pad := pdf.MM(labelPadding)
+	inner := w - 2*pad
+
+	page.Text(x0+pad, y0+pad+8, 8, true, pdf.Fit(p.Name, 8, inner))
+	var attrs []string
+	if v.Size != "" {
+		attrs = append(attrs, "Talle "+v.Size)
+	}
+	if v.Color != "" {
+		attrs = append(attrs, v.Color)
+	}
+	line := strings.Join(attrs, " · ")
+	if price := labelPrice(p, priceField); price > 0 {
+		text := formatPrice(price)
+		page.Text(x0+w-pad-pdf.TextWidth(text, 9), y0+pad+19, 9, true, text)
+		inner -= pdf.TextWidth(text, 9) + 4
+	}
+	page.Text(x0+pad, y0+pad+19, 7, false, pdf.Fit(line, 7, inner))
+
+	modules, err := barcode.Encode(*v.Barcode, barcodeType(v))
+	if err != nil {
+		return err
+	}
+	total := float64(len(modules) + 2*barcode.QuietZone)
+	module := (w - 2*pad) / total
+	if module > 1.5 {
+		module = 1.5
+	}
+	barsX := x0 + (w-total*module)/2 + float64(barcode.QuietZone)*module
+	barsY, barsH := y0+pad+25, h-2*pad-36
+	for i := 0; i < len(modules); {
+		if !modules[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(modules) && modules[j] {
+			j++
+		}
+		page.Rect(barsX+float64(i)*module, barsY, float64(j-i)*module, barsH)
+		i = j
+	}
+	code := *v.Barcode
+	page.Text(x0+(w-pdf.TextWidth(code, 8))/2, barsY+barsH+9, 8, false, code)
+	return nil
+}
+
+func labelPrice(p Product, field string) float64 {
+	switch field {
+	case "discount1":
+		return p.Discount1Price
+	case "discount2":
+		return p.Discount2Price
+	case "none":
+		return 0
+	}
+	return p.WholesalePrice
+}
+
+// formatPrice da formato de moneda local: $ 12.345,50
+func formatPrice(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	intPart, dec := s[:len(s)-3], s[len(s)-2:]
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return "$ " + b.String() + "," + dec
+}
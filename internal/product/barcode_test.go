@@ -0,0 +1,97 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go-modaMayor/internal/barcode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestVariantBarcodes_GenerateLookupImportAndLabels(t *testing.T) {
+	db := setupTestDB(t)
+	prod := Product{Name: "Campera", Code: "TEST-BC-1", WholesalePrice: 12345.5}
+	db.Create(&prod)
+	var variants []ProductVariant
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		variants, _, err = GenerateVariantsTx(tx, prod.ID, []string{"Negro"}, []string{"S", "M"}, "BC")
+		return err
+	}); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	for _, v := range variants {
+		if v.Barcode == nil || !barcode.ValidEAN13(*v.Barcode) || !strings.HasPrefix(*v.Barcode, "200") || v.BarcodeType != barcode.TypeEAN13 {
+			t.Fatalf("expected generated EAN-13 with company prefix, got %+v", v)
+		}
+	}
+	if *variants[0].Barcode == *variants[1].Barcode {
+		t.Fatalf("expected distinct barcodes")
+	}
+
+	router := gin.New()
+	router.GET("/variants/by-barcode/:code", GetVariantByBarcode)
+	router.POST("/variants/barcodes/import", ImportVariantBarcodes)
+	router.POST("/variants/labels", PrintBarcodeLabels)
+	post := func(path string, body gin.H) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/variants/by-barcode/"+*variants[1].Barcode, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"sku":"`+variants[1].SKU+`"`) {
+		t.Fatalf("lookup: %d %s", w.Code, w.Body.String())
+	}
+
+	// Un código repetido o un EAN con verificador incorrecto rechazan todo el lote
+	w = post("/variants/barcodes/import", gin.H{"codes": []gin.H{
+		{"sku": variants[0].SKU, "barcode": "PROV-0001"},
+		{"sku": variants[1].SKU, "barcode": "4006381333932"},
+	}})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var unchanged ProductVariant
+	db.First(&unchanged, variants[0].ID)
+	if *unchanged.Barcode != *variants[0].Barcode {
+		t.Fatalf("expected no changes after failed import")
+	}
+
+	w = post("/variants/barcodes/import", gin.H{"codes": []gin.H{{"sku": variants[0].SKU, "barcode": "PROV-0001"}}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/variants/by-barcode/PROV-0001", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"barcode_type":"code128"`) {
+		t.Fatalf("lookup imported: %d %s", w.Code, w.Body.String())
+	}
+
+	w = post("/variants/labels", gin.H{"product_id": prod.ID, "copies": 13})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Fatalf("labels: %d %s", w.Code, w.Body.String())
+	}
+	// 26 etiquetas ocupan dos planillas de 24
+	if !bytes.Contains(w.Body.Bytes(), []byte("/Count 2")) || !bytes.Contains(w.Body.Bytes(), []byte("($ 12.345,50)")) {
+		t.Fatalf("unexpected pdf content")
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	for v, expected := range map[float64]string{0.5: "$ 0,50", 999: "$ 999,00", 1234567.891: "$ 1.234.567,89"} {
+		if got := formatPrice(v); got != expected {
+			t.Fatalf("formatPrice(%v) = %s, expected %s", v, got, expected)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package product
+
+import (
+	"go-modaMayor/internal/settings"
+
+	"gorm.io/gorm"
+)
+
+// ApplyCostPrice actualiza el costo del producto y recalcula sus precios por tier
+// (wholesale/discount1/discount2) con los price tiers activos, dentro de tx.
+func ApplyCostPrice(tx *gorm.DB, productID uint, costPrice float64) error {
+	var tiers []settings.PriceTier
+	if err := tx.Where("active = ?", true).Order("order_index ASC").Find(&tiers).Error; err != nil {
+		return err
+	}
+	prices := settings.CalculateProductPricesFromList(costPrice, tiers)
+	return tx.Model(&Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"cost_price":      costPrice,
+		"wholesale_price": prices.WholesalePrice,
+		"discount1_price": prices.Discount1Price,
+		"discount2_price": prices.Discount2Price,
+	}).Error
+}
+
+// WeightedAverageCost calcula el costo promedio ponderado al ingresar newQty
+// unidades a newCost sobre currentQty unidades existentes a currentCost.
+func WeightedAverageCost(currentCost float64, currentQty int, newCost float64, newQty int) float64 {
+	if currentQty < 0 {
+		currentQty = 0
+	}
+	total := currentQty + newQty
+	if total <= 0 {
+		return newCost
+	}
+	return (currentCost*float64(currentQty) + newCost*float64(newQty)) / float64(total)
+}
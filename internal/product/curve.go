@@ -0,0 +1,524 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"go-modaMayor/config"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SizeCurve es una curva de talles: un pack con una cantidad fija por talle
+// (ej: 1×S, 2×M, 2×L, 1×XL) que se vende como unidad para un color.
+type SizeCurve struct {
+	gorm.Model
+	SizeTypeID uint            `json:"size_type_id" gorm:"not null;index"`
+	Name       string          `json:"name" gorm:"not null"`
+	Active     bool            `json:"active" gorm:"default:true"`
+	Items      []SizeCurveItem `json:"items" gorm:"foreignKey:CurveID"`
+}
+
+// SizeCurveItem indica cuántas unidades de un talle lleva cada curva
+type SizeCurveItem struct {
+	gorm.Model
+	CurveID     uint       `json:"curve_id" gorm:"not null;index"`
+	SizeValueID uint       `json:"size_value_id" gorm:"not null"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	SizeValue   *SizeValue `json:"size_value,omitempty" gorm:"foreignKey:SizeValueID"`
+}
+
+// UnitsPerCurve devuelve la cantidad de prendas de una curva
+func (sc *SizeCurve) UnitsPerCurve() int {
+	total := 0
+	for _, it := range sc.Items {
+		total += it.Quantity
+	}
+	return total
+}
+
+// CurveError junta los motivos por los que una curva no se puede armar para un producto/color (400)
+type CurveError struct {
+	Messages []string
+}
+
+func (e *CurveError) Error() string { return strings.Join(e.Messages, "; ") }
+
+// CurveLine es la variante concreta que corresponde a un talle de la curva
+type CurveLine struct {
+	Variant ProductVariant `json:"variant"`
+	Size    string         `json:"size"`
+	Units   int            `json:"units"`
+}
+
+// CurveLocationAvailability indica cuántas curvas completas hay en una ubicación
+type CurveLocationAvailability struct {
+	Location     string `json:"location"`
+	Curves       int    `json:"curves"`
+	LimitingSize string `json:"limiting_size,omitempty"`
+}
+
+// orderCurveItems ordena los talles de la curva por ordinal
+func orderCurveItems(db *gorm.DB) *gorm.DB {
+	return db.Order("COALESCE((SELECT sv.ordinal FROM size_values sv WHERE sv.id = size_curve_items.size_value_id), 999999), size_curve_items.id")
+}
+
+func loadCurve(db *gorm.DB, id interface{}) (*SizeCurve, error) {
+	var curve SizeCurve
+	if err := db.Preload("Items", orderCurveItems).Preload("Items.SizeValue").First(&curve, id).Error; err != nil {
+		return nil, err
+	}
+	return &curve, nil
+}
+
+// ResolveCurve valida que la curva aplique al producto y devuelve, para el color pedido,
+// la variante de cada talle con las unidades por curva. Si falta alguna variante
+// devuelve *CurveError listando los talles faltantes.
+func ResolveCurve(db *gorm.DB, productID, curveID uint, colorID *uint, color string) (*SizeCurve, []CurveLine, error) {
+	var p Product
+	if err := db.Select("id", "size_type_id").First(&p, productID).Error; err != nil {
+		return nil, nil, err
+	}
+	curve, err := loadCurve(db, curveID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, &CurveError{Messages: []string{"Curva no encontrada"}}
+		}
+		return nil, nil, err
+	}
+	if !curve.Active {
+		return nil, nil, &CurveError{Messages: []string{"La curva no está activa"}}
+	}
+	if p.SizeTypeID == nil || *p.SizeTypeID != curve.SizeTypeID {
+		return nil, nil, &CurveError{Messages: []string{"La curva no corresponde al tipo de talle del producto"}}
+	}
+	if len(curve.Items) == 0 {
+		return nil, nil, &CurveError{Messages: []string{"La curva no tiene talles"}}
+	}
+
+	vc, err := loadVariantCatalog(db, p.SizeTypeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	cid, colorName, err := vc.resolveColor(colorID, color)
+	if err != nil {
+		return nil, nil, &CurveError{Messages: []string{err.Error()}}
+	}
+	if cid == nil && colorName == "" {
+		return nil, nil, &CurveError{Messages: []string{"Debe indicar el color de la curva"}}
+	}
+
+	var variants []ProductVariant
+	if err := db.Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+		return nil, nil, err
+	}
+	bySize := map[uint]ProductVariant{}
+	bySizeName := map[string]ProductVariant{}
+	for _, v := range variants {
+		sameColor := (cid != nil && v.ColorID != nil && *v.ColorID == *cid) || normalizeColor(v.Color) == normalizeColor(colorName)
+		if !sameColor {
+			continue
+		}
+		if v.SizeValueID != nil {
+			bySize[*v.SizeValueID] = v
+		}
+		bySizeName[normalizeColor(v.Size)] = v
+	}
+
+	lines := make([]CurveLine, 0, len(curve.Items))
+	var missing []string
+	for _, it := range curve.Items {
+		size := ""
+		if it.SizeValue != nil {
+			size = it.SizeValue.Value
+		}
+		v, ok := bySize[it.SizeValueID]
+		if !ok {
+			v, ok = bySizeName[normalizeColor(size)]
+		}
+		if !ok || size == "" {
+			missing = append(missing, fmt.Sprintf("no hay variante talle %s en color %s", size, colorName))
+			continue
+		}
+		lines = append(lines, CurveLine{Variant: v, Size: size, Units: it.Quantity})
+	}
+	if len(missing) > 0 {
+		return nil, nil, &CurveError{Messages: missing}
+	}
+	return curve, lines, nil
+}
+
+// CurveAvailability calcula cuántas curvas completas se pueden armar en cada ubicación
+// (stock libre de reservas dividido las unidades por talle, tomando el talle más escaso).
+// Una curva se arma desde una sola ubicación, por eso el total es la suma por ubicación.
+func CurveAvailability(db *gorm.DB, productID uint, lines []CurveLine) ([]CurveLocationAvailability, int, error) {
+	ids := make([]uint, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, l.Variant.ID)
+	}
+	var stocks []LocationStock
+	if err := db.Where("product_id = ? AND variant_id IN ?", productID, ids).Find(&stocks).Error; err != nil {
+		return nil, 0, err
+	}
+	free := map[string]map[uint]int{}
+	for _, ls := range stocks {
+		if ls.VariantID == nil {
+			continue
+		}
+		if free[ls.Location] == nil {
+			free[ls.Location] = map[uint]int{}
+		}
+		free[ls.Location][*ls.VariantID] += ls.Stock - ls.Reserved
+	}
+
+	result := make([]CurveLocationAvailability, 0, len(free))
+	total := 0
+	for location, byVariant := range free {
+		av := CurveLocationAvailability{Location: location, Curves: -1}
+		for _, l := range lines {
+			n := byVariant[l.Variant.ID] / l.Units
+			if n < 0 {
+				n = 0
+			}
+			if av.Curves < 0 || n < av.Curves {
+				av.Curves = n
+				av.LimitingSize = l.Size
+			}
+		}
+		if av.Curves < 0 {
+			av.Curves = 0
+		}
+		total += av.Curves
+		result = append(result, av)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Location < result[j].Location })
+	return result, total, nil
+}
+
+// respondCurveError responde 400 si la curva no aplica y 500 ante otros errores
+func respondCurveError(c *gin.Context, err error) {
+	var curveErr *CurveError
+	if errors.As(err, &curveErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Curva inválida para el producto", "errors": curveErr.Messages})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// --- SizeCurves CRUD ---
+
+type SizeCurveItemInput struct {
+	SizeValueID uint `json:"size_value_id" binding:"required"`
+	Quantity    int  `json:"quantity" binding:"required,gt=0"`
+}
+
+type SizeCurveInput struct {
+	SizeTypeID uint                 `json:"size_type_id"`
+	Name       string               `json:"name"`
+	Active     *bool                `json:"active"`
+	Items      []SizeCurveItemInput `json:"items"`
+}
+
+// buildCurveItems valida que los talles pertenezcan al tipo de talle y no se repitan
+func buildCurveItems(tx *gorm.DB, sizeTypeID uint, input []SizeCurveItemInput) ([]SizeCurveItem, error) {
+	if len(input) == 0 {
+		return nil, &CurveError{Messages: []string{"La curva debe tener al menos un talle"}}
+	}
+	var values []SizeValue
+	if err := tx.Where("size_type_id = ?", sizeTypeID).Find(&values).Error; err != nil {
+		return nil, err
+	}
+	valid := map[uint]bool{}
+	for _, v := range values {
+		valid[v.ID] = true
+	}
+	seen := map[uint]bool{}
+	var msgs []string
+	items := make([]SizeCurveItem, 0, len(input))
+	for _, in := range input {
+		switch {
+		case !valid[in.SizeValueID]:
+			msgs = append(msgs, fmt.Sprintf("size_value_id %d no pertenece al tipo de talle", in.SizeValueID))
+		case seen[in.SizeValueID]:
+			msgs = append(msgs, fmt.Sprintf("size_value_id %d repetido", in.SizeValueID))
+		case in.Quantity <= 0:
+			msgs = append(msgs, fmt.Sprintf("la cantidad del size_value_id %d debe ser mayor a 0", in.SizeValueID))
+		}
+		seen[in.SizeValueID] = true
+		items = append(items, SizeCurveItem{SizeValueID: in.SizeValueID, Quantity: in.Quantity})
+	}
+	if len(msgs) > 0 {
+		return nil, &CurveError{Messages: msgs}
+	}
+	return items, nil
+}
+
+func respondCurveInputError(c *gin.Context, err error) {
+	var curveErr *CurveError
+	if errors.As(err, &curveErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Curva inválida", "errors": curveErr.Messages})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// GET /size-curves?size_type_id=
+func ListSizeCurves(c *gin.Context) {
+	q := config.DB.Preload("Items", orderCurveItems).Preload("Items.SizeValue").Order("size_type_id, name")
+	if typeID := c.Query("size_type_id"); typeID != "" {
+		q = q.Where("size_type_id = ?", typeID)
+	}
+	var curves []SizeCurve
+	if err := q.Find(&curves).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, curves)
+}
+
+// POST /size-curves
+func CreateSizeCurve(c *gin.Context) {
+	var input SizeCurveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.SizeTypeID == 0 || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size_type_id y name son requeridos"})
+		return
+	}
+	if err := config.DB.First(&SizeType{}, input.SizeTypeID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "SizeType no encontrado"})
+		return
+	}
+	curve := SizeCurve{SizeTypeID: input.SizeTypeID, Name: strings.TrimSpace(input.Name), Active: true}
+	if input.Active != nil {
+		curve.Active = *input.Active
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		items, err := buildCurveItems(tx, input.SizeTypeID, input.Items)
+		if err != nil {
+			return err
+		}
+		curve.Items = items
+		return tx.Create(&curve).Error
+	})
+	if err != nil {
+		respondCurveInputError(c, err)
+		return
+	}
+	saved, _ := loadCurve(config.DB, curve.ID)
+	c.JSON(http.StatusCreated, saved)
+}
+
+// PUT /size-curves/:id
+// Si se envían items reemplazan a los actuales
+func UpdateSizeCurve(c *gin.Context) {
+	curve, err := loadCurve(config.DB, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Curva no encontrada"})
+		return
+	}
+	var input SizeCurveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.SizeTypeID != 0 && input.SizeTypeID != curve.SizeTypeID && input.Items == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Para cambiar el tipo de talle hay que enviar los talles de la curva"})
+		return
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{}
+		if name := strings.TrimSpace(input.Name); name != "" {
+			updates["name"] = name
+		}
+		if input.Active != nil {
+			updates["active"] = *input.Active
+		}
+		sizeTypeID := curve.SizeTypeID
+		if input.SizeTypeID != 0 {
+			sizeTypeID = input.SizeTypeID
+			updates["size_type_id"] = sizeTypeID
+		}
+		if input.Items != nil {
+			items, err := buildCurveItems(tx, sizeTypeID, input.Items)
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("curve_id = ?", curve.ID).Delete(&SizeCurveItem{}).Error; err != nil {
+				return err
+			}
+			for i := range items {
+				items[i].CurveID = curve.ID
+			}
+			if err := tx.Create(&items).Error; err != nil {
+				return err
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&SizeCurve{}).Where("id = ?", curve.ID).Updates(updates).Error
+	})
+	if err != nil {
+		respondCurveInputError(c, err)
+		return
+	}
+	saved, _ := loadCurve(config.DB, curve.ID)
+	c.JSON(http.StatusOK, saved)
+}
+
+// DELETE /size-curves/:id
+func DeleteSizeCurve(c *gin.Context) {
+	id := c.Param("id")
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("curve_id = ?", id).Delete(&SizeCurveItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&SizeCurve{}, id).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Curva eliminada"})
+}
+
+// --- Curvas por producto ---
+
+// visibleProduct carga el producto respetando la visibilidad para quienes no son staff
+func visibleProduct(c *gin.Context) (*Product, bool) {
+	var p Product
+	if err := config.DB.First(&p, c.Param("id")).Error; err != nil || (!IsStaff(c) && !p.IsPurchasable(time.Now())) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return nil, false
+	}
+	return &p, true
+}
+
+func optionalColorID(c *gin.Context) *uint {
+	raw := c.Query("color_id")
+	if raw == "" {
+		return nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil
+	}
+	v := uint(id)
+	return &v
+}
+
+// GetProductCurves lista las curvas del tipo de talle del producto por color,
+// indicando si se pueden armar y cuántas curvas completas hay por ubicación.
+// GET /products/:id/curves?color=&color_id=
+func GetProductCurves(c *gin.Context) {
+	p, ok := visibleProduct(c)
+	if !ok {
+		return
+	}
+	if p.SizeTypeID == nil {
+		c.JSON(http.StatusOK, []gin.H{})
+		return
+	}
+	var curves []SizeCurve
+	if err := config.DB.Where("size_type_id = ? AND active = ?", *p.SizeTypeID, true).Order("name").Find(&curves).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type colorRef struct {
+		ID   *uint
+		Name string
+	}
+	var colors []colorRef
+	if colorID, color := optionalColorID(c), c.Query("color"); colorID != nil || color != "" {
+		colors = append(colors, colorRef{ID: colorID, Name: color})
+	} else {
+		var variants []ProductVariant
+		if err := config.DB.Where("product_id = ?", p.ID).Order("color, id").Find(&variants).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		seen := map[string]bool{}
+		for _, v := range variants {
+			key := normalizeColor(v.Color)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			colors = append(colors, colorRef{ID: v.ColorID, Name: v.Color})
+		}
+	}
+
+	result := make([]gin.H, 0)
+	for _, sc := range curves {
+		for _, col := range colors {
+			curve, lines, err := ResolveCurve(config.DB, p.ID, sc.ID, col.ID, col.Name)
+			entry := gin.H{"curve_id": sc.ID, "name": sc.Name, "color": col.Name, "color_id": col.ID}
+			var curveErr *CurveError
+			if errors.As(err, &curveErr) {
+				entry["complete"] = false
+				entry["errors"] = curveErr.Messages
+				result = append(result, entry)
+				continue
+			} else if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			locations, total, err := CurveAvailability(config.DB, p.ID, lines)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			entry["complete"] = true
+			entry["units_per_curve"] = curve.UnitsPerCurve()
+			entry["lines"] = lines
+			entry["locations"] = locations
+			entry["available_curves"] = total
+			result = append(result, entry)
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCurveAvailability devuelve cuántas curvas completas de un color hay por ubicación
+// GET /products/:id/curves/:curveId/availability?color=&color_id=
+func GetCurveAvailability(c *gin.Context) {
+	p, ok := visibleProduct(c)
+	if !ok {
+		return
+	}
+	curveID, err := strconv.ParseUint(c.Param("curveId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de curva inválido"})
+		return
+	}
+	curve, lines, err := ResolveCurve(config.DB, p.ID, uint(curveID), optionalColorID(c), c.Query("color"))
+	if err != nil {
+		respondCurveError(c, err)
+		return
+	}
+	locations, total, err := CurveAvailability(config.DB, p.ID, lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"curve":            curve,
+		"units_per_curve":  curve.UnitsPerCurve(),
+		"lines":            lines,
+		"locations":        locations,
+		"available_curves": total,
+	})
+}
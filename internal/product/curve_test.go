@@ -0,0 +1,79 @@
+package product
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveCurve_AvailabilityPerLocation(t *testing.T) {
+	db := setupTestDB(t)
+	st := SizeType{Key: "letras-curva", Name: "Letras"}
+	db.Create(&st)
+	s := SizeValue{SizeTypeID: st.ID, Value: "S", Ordinal: 1}
+	m := SizeValue{SizeTypeID: st.ID, Value: "M", Ordinal: 2}
+	l := SizeValue{SizeTypeID: st.ID, Value: "L", Ordinal: 3}
+	db.Create(&s)
+	db.Create(&m)
+	db.Create(&l)
+	prod := Product{Name: "Remera curva", Code: "TEST-CURVA-1", SizeTypeID: &st.ID}
+	db.Create(&prod)
+	variants := map[string]*ProductVariant{}
+	for _, sv := range []SizeValue{s, m, l} {
+		id := sv.ID
+		v := ProductVariant{ProductID: prod.ID, Color: "Negro", Size: sv.Value, SizeValueID: &id, SKU: "CURVA-NEGRO-" + sv.Value}
+		db.Create(&v)
+		variants[sv.Value] = &v
+	}
+	curve := SizeCurve{SizeTypeID: st.ID, Name: "1-2-1", Active: true, Items: []SizeCurveItem{
+		{SizeValueID: s.ID, Quantity: 1}, {SizeValueID: m.ID, Quantity: 2}, {SizeValueID: l.ID, Quantity: 1},
+	}}
+	db.Create(&curve)
+
+	stock := func(size, location string, qty, reserved int) {
+		id := variants[size].ID
+		db.Create(&LocationStock{ProductID: prod.ID, VariantID: &id, Location: location, Stock: qty, Reserved: reserved})
+	}
+	// deposito: M limita a 2 curvas (5 libres / 2); local: falta L
+	stock("S", "deposito", 4, 0)
+	stock("M", "deposito", 6, 1)
+	stock("L", "deposito", 3, 0)
+	stock("S", "local", 3, 0)
+	stock("M", "local", 4, 0)
+
+	got, lines, err := ResolveCurve(db, prod.ID, curve.ID, nil, " negro ")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got.UnitsPerCurve() != 4 || len(lines) != 3 || lines[1].Size != "M" || lines[1].Units != 2 {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+	locations, total, err := CurveAvailability(db, prod.ID, lines)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	if total != 2 || len(locations) != 2 {
+		t.Fatalf("expected 2 curves in 2 locations, got %d: %+v", total, locations)
+	}
+	if locations[0].Location != "deposito" || locations[0].Curves != 2 || locations[0].LimitingSize != "M" {
+		t.Fatalf("unexpected deposito availability: %+v", locations[0])
+	}
+	if locations[1].Location != "local" || locations[1].Curves != 0 || locations[1].LimitingSize != "L" {
+		t.Fatalf("unexpected local availability: %+v", locations[1])
+	}
+
+	// Un color sin todos los talles no arma la curva
+	db.Create(&ProductVariant{ProductID: prod.ID, Color: "Blanco", Size: "S", SizeValueID: &s.ID, SKU: "CURVA-BLANCO-S"})
+	var curveErr *CurveError
+	if _, _, err := ResolveCurve(db, prod.ID, curve.ID, nil, "Blanco"); !errors.As(err, &curveErr) || len(curveErr.Messages) != 2 {
+		t.Fatalf("expected missing sizes error, got %v", err)
+	}
+
+	// Una curva de otro tipo de talle no aplica al producto
+	other := SizeType{Key: "numerico-curva", Name: "Numérico"}
+	db.Create(&other)
+	foreign := SizeCurve{SizeTypeID: other.ID, Name: "otra", Active: true}
+	db.Create(&foreign)
+	if _, _, err := ResolveCurve(db, prod.ID, foreign.ID, nil, "Negro"); !errors.As(err, &curveErr) {
+		t.Fatalf("expected size type mismatch error, got %v", err)
+	}
+}
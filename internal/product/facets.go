@@ -0,0 +1,143 @@
+package product
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// FacetBucket es un valor de faceta con la cantidad de productos que lo tienen
+type FacetBucket struct {
+	ID    *uint  `json:"id,omitempty"`
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeBucket cuenta productos por rango de precio mayorista (Max nil = sin tope)
+type PriceRangeBucket struct {
+	Label string   `json:"label"`
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
+}
+
+type ProductFacets struct {
+	Categories    []FacetBucket      `json:"categories"`
+	Subcategories []FacetBucket      `json:"subcategories"`
+	Colors        []FacetBucket      `json:"colors"`
+	Sizes         []FacetBucket      `json:"sizes"`
+	Seasons       []FacetBucket      `json:"seasons"`
+	PriceRanges   []PriceRangeBucket `json:"price_ranges"`
+	Attributes    []AttributeFacet   `json:"attributes"`
+	InStock       int64              `json:"in_stock"`
+	OutOfStock    int64              `json:"out_of_stock"`
+}
+
+// PriceFacetBounds define los cortes de los rangos de precio de la faceta
+var PriceFacetBounds = []float64{5000, 10000, 20000, 40000}
+
+// BuildProductFacets calcula los conteos por faceta sobre el conjunto de productos ids
+func BuildProductFacets(db *gorm.DB, ids []uint) (*ProductFacets, error) {
+	f := &ProductFacets{
+		Categories:    []FacetBucket{},
+		Subcategories: []FacetBucket{},
+		Colors:        []FacetBucket{},
+		Sizes:         []FacetBucket{},
+		Seasons:       []FacetBucket{},
+		PriceRanges:   []PriceRangeBucket{},
+		Attributes:    []AttributeFacet{},
+	}
+	if len(ids) == 0 {
+		return f, nil
+	}
+
+	type idRow struct {
+		ID    uint
+		Value string
+		Count int64
+	}
+	byID := func(table, fk string) ([]FacetBucket, error) {
+		var rows []idRow
+		err := db.Table("products").
+			Select(fmt.Sprintf("%s.id as id, %s.name as value, COUNT(DISTINCT products.id) as count", table, table)).
+			Joins(fmt.Sprintf("JOIN %s ON %s.id = products.%s", table, table, fk)).
+			Where("products.id IN ?", ids).
+			Group(fmt.Sprintf("%s.id, %s.name", table, table)).
+			Order("count DESC").
+			Scan(&rows).Error
+		out := make([]FacetBucket, 0, len(rows))
+		for _, r := range rows {
+			id := r.ID
+			out = append(out, FacetBucket{ID: &id, Value: r.Value, Count: r.Count})
+		}
+		return out, err
+	}
+	byVariant := func(column string) ([]FacetBucket, error) {
+		var rows []idRow
+		err := db.Table("product_variants").
+			Select(fmt.Sprintf("%s as value, COUNT(DISTINCT product_id) as count", column)).
+			Where(fmt.Sprintf("product_id IN ? AND deleted_at IS NULL AND %s <> ''", column), ids).
+			Group(column).
+			Order("count DESC").
+			Scan(&rows).Error
+		out := make([]FacetBucket, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, FacetBucket{Value: r.Value, Count: r.Count})
+		}
+		return out, err
+	}
+
+	var err error
+	if f.Categories, err = byID("categories", "category_id"); err != nil {
+		return nil, err
+	}
+	if f.Subcategories, err = byID("subcategories", "subcategory_id"); err != nil {
+		return nil, err
+	}
+	if f.Seasons, err = byID("seasons", "season_id"); err != nil {
+		return nil, err
+	}
+	if f.Colors, err = byVariant("color"); err != nil {
+		return nil, err
+	}
+	if f.Sizes, err = byVariant("size"); err != nil {
+		return nil, err
+	}
+	if f.Attributes, err = attributeFacets(db, ids); err != nil {
+		return nil, err
+	}
+
+	var prices []float64
+	if err := db.Model(&Product{}).Where("id IN ?", ids).Pluck("wholesale_price", &prices).Error; err != nil {
+		return nil, err
+	}
+	for i := 0; i <= len(PriceFacetBounds); i++ {
+		b := PriceRangeBucket{}
+		if i > 0 {
+			b.Min = PriceFacetBounds[i-1]
+		}
+		if i < len(PriceFacetBounds) {
+			upper := PriceFacetBounds[i]
+			b.Max = &upper
+			b.Label = fmt.Sprintf("%.0f-%.0f", b.Min, upper)
+		} else {
+			b.Label = fmt.Sprintf("%.0f+", b.Min)
+		}
+		for _, p := range prices {
+			if p >= b.Min && (b.Max == nil || p < *b.Max) {
+				b.Count++
+			}
+		}
+		f.PriceRanges = append(f.PriceRanges, b)
+	}
+
+	if err := db.Raw(`SELECT COUNT(*) FROM (
+		SELECT product_id FROM location_stocks
+		WHERE product_id IN ? AND deleted_at IS NULL
+		GROUP BY product_id HAVING SUM(stock - reserved) > 0
+	) t`, ids).Scan(&f.InStock).Error; err != nil {
+		return nil, err
+	}
+	f.OutOfStock = int64(len(ids)) - f.InStock
+	return f, nil
+}
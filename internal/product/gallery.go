@@ -0,0 +1,396 @@
+package product
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/imaging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Roles de imagen en la galería
+const (
+	ImageRoleMain   = "main"
+	ImageRoleModel  = "model"
+	ImageRoleHanger = "hanger"
+	ImageRoleDetail = "detail"
+)
+
+var validImageRoles = map[string]bool{ImageRoleMain: true, ImageRoleModel: true, ImageRoleHanger: true, ImageRoleDetail: true}
+
+// ProductImage es una imagen de la galería del producto. Con Color se asocia a
+// las variantes de ese color (comparación LOWER(TRIM(color))). Los campos
+// ImageURL/ImageModel/ImageHanger del producto y ImageURL de las variantes se
+// mantienen sincronizados desde la galería por compatibilidad.
+type ProductImage struct {
+	gorm.Model
+	ProductID uint              `json:"product_id" gorm:"index;not null"`
+	Role      string            `json:"role" gorm:"size:20;not null;default:'detail'"`
+	Color     string            `json:"color" gorm:"size:100;index"`
+	Position  int               `json:"position" gorm:"default:0"`
+	AltText   string            `json:"alt_text" gorm:"size:255"`
+	URL       string            `json:"url" gorm:"type:text;not null"`
+	ImageSet  *imaging.ImageSet `json:"image_set,omitempty" gorm:"type:text"`
+}
+
+func normalizeColor(color string) string {
+	return strings.TrimSpace(strings.ToLower(color))
+}
+
+// addProductImage agrega una imagen al final de la galería
+func addProductImage(tx *gorm.DB, img *ProductImage) error {
+	var last struct{ Position *int }
+	if err := tx.Model(&ProductImage{}).Where("product_id = ?", img.ProductID).Select("MAX(position) as position").Scan(&last).Error; err != nil {
+		return err
+	}
+	img.Position = 0
+	if last.Position != nil {
+		img.Position = *last.Position + 1
+	}
+	return tx.Create(img).Error
+}
+
+// setPrimaryImage reemplaza la primera imagen del rol (sin color) o la agrega si no hay.
+// Lo usan las subidas por slot (image_main, image_model, image_hanger).
+func setPrimaryImage(tx *gorm.DB, productID uint, role string, set *imaging.ImageSet) error {
+	var existing ProductImage
+	err := tx.Where("product_id = ? AND role = ? AND color = ''", productID, role).Order("position, id").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return addProductImage(tx, &ProductImage{ProductID: productID, Role: role, URL: set.Src, ImageSet: set})
+	}
+	if err != nil {
+		return err
+	}
+	existing.URL, existing.ImageSet = set.Src, set
+	return tx.Save(&existing).Error
+}
+
+// setColorImage reemplaza la primera imagen del color o la agrega si el color no tiene.
+// Lo usa la propagación de imagen por color de las variantes.
+func setColorImage(tx *gorm.DB, productID uint, color, url string, set *imaging.ImageSet) error {
+	var existing ProductImage
+	err := tx.Where("product_id = ? AND LOWER(TRIM(color)) = ?", productID, normalizeColor(color)).Order("position, id").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return addProductImage(tx, &ProductImage{ProductID: productID, Role: ImageRoleMain, Color: strings.TrimSpace(color), URL: url, ImageSet: set})
+	}
+	if err != nil {
+		return err
+	}
+	existing.URL, existing.ImageSet = url, set
+	return tx.Save(&existing).Error
+}
+
+// syncLegacyImages copia a Product.ImageURL/ImageModel/ImageHanger la primera
+// imagen de cada rol (la principal cae en la primera de la galería si no hay
+// "main") y a cada variante la primera imagen de su color.
+func syncLegacyImages(tx *gorm.DB, productID uint) error {
+	var images []ProductImage
+	if err := tx.Where("product_id = ?", productID).Order("position, id").Find(&images).Error; err != nil {
+		return err
+	}
+	firstByRole := map[string]*ProductImage{}
+	firstByColor := map[string]*ProductImage{}
+	for i := range images {
+		img := &images[i]
+		if img.Color == "" {
+			if _, ok := firstByRole[img.Role]; !ok {
+				firstByRole[img.Role] = img
+			}
+		} else if _, ok := firstByColor[normalizeColor(img.Color)]; !ok {
+			firstByColor[normalizeColor(img.Color)] = img
+		}
+	}
+	if firstByRole[ImageRoleMain] == nil && len(images) > 0 {
+		firstByRole[ImageRoleMain] = &images[0]
+	}
+
+	updates := map[string]interface{}{}
+	for role, cols := range map[string][2]string{
+		ImageRoleMain:   {"image_url", "image_set"},
+		ImageRoleModel:  {"image_model", "image_model_set"},
+		ImageRoleHanger: {"image_hanger", "image_hanger_set"},
+	} {
+		if img := firstByRole[role]; img != nil {
+			updates[cols[0]], updates[cols[1]] = img.URL, img.ImageSet
+		}
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(&Product{}).Where("id = ?", productID).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(firstByColor) == 0 {
+		return nil
+	}
+	var variants []ProductVariant
+	if err := tx.Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+		return err
+	}
+	for _, v := range variants {
+		img := firstByColor[normalizeColor(v.Color)]
+		if img == nil || v.ImageURL == img.URL {
+			continue
+		}
+		if err := tx.Model(&ProductVariant{}).Where("id = ?", v.ID).UpdateColumns(map[string]interface{}{"image_url": img.URL, "image_set": img.ImageSet}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImagesForColor devuelve las imágenes de un color (o las generales si el color no tiene propias)
+func ImagesForColor(db *gorm.DB, productID uint, color string) ([]ProductImage, error) {
+	var images []ProductImage
+	if err := db.Where("product_id = ? AND LOWER(TRIM(color)) = ?", productID, normalizeColor(color)).Order("position, id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	if len(images) > 0 || color == "" {
+		return images, nil
+	}
+	err := db.Where("product_id = ? AND color = ''", productID).Order("position, id").Find(&images).Error
+	return images, err
+}
+
+// ListProductImages devuelve la galería ordenada (?color= filtra por color)
+// GET /products/:id/gallery
+func ListProductImages(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err == nil && !IsStaff(c) {
+		var product Product
+		if e := config.DB.First(&product, productID).Error; e != nil || !product.IsPurchasable(time.Now()) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+			return
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var images []ProductImage
+	if color, ok := c.GetQuery("color"); ok {
+		images, err = ImagesForColor(config.DB, uint(productID), color)
+	} else {
+		err = config.DB.Where("product_id = ?", productID).Order("position, id").Find(&images).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+// AddProductImages sube una o más imágenes (multipart "images" o "image") con
+// role, color y alt_text opcionales; se agregan al final de la galería.
+// POST /products/:id/gallery
+func AddProductImages(c *gin.Context) {
+	var product Product
+	if err := config.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	role := c.DefaultPostForm("role", ImageRoleDetail)
+	if !validImageRoles[role] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rol inválido: use main, model, hanger o detail"})
+		return
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error al procesar formulario"})
+		return
+	}
+	files := append(form.File["images"], form.File["image"]...)
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se recibió ninguna imagen"})
+		return
+	}
+
+	images := make([]ProductImage, 0, len(files))
+	for _, fh := range files {
+		store, ok := uploadStorage(c)
+		if !ok {
+			return
+		}
+		set, err := imaging.StoreUpload(c.Request.Context(), store, fh)
+		if err != nil {
+			respondImageError(c, err)
+			return
+		}
+		images = append(images, ProductImage{ProductID: product.ID, Role: role, Color: strings.TrimSpace(c.PostForm("color")),
+			AltText: c.PostForm("alt_text"), URL: set.Src, ImageSet: set})
+	}
+	before := CaptureRevision(config.DB, product.ID)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range images {
+			if err := addProductImage(tx, &images[i]); err != nil {
+				return err
+			}
+		}
+		return syncLegacyImages(tx, product.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	RecordRevision(config.DB, c, product.ID, RevisionImages, before)
+	c.JSON(http.StatusCreated, images)
+}
+
+// UpdateProductImage modifica rol, color o texto alternativo
+// PUT /products/:id/gallery/:imageId
+func UpdateProductImage(c *gin.Context) {
+	var img ProductImage
+	if err := config.DB.Where("product_id = ?", c.Param("id")).First(&img, c.Param("imageId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada"})
+		return
+	}
+	var input struct {
+		Role    *string `json:"role"`
+		Color   *string `json:"color"`
+		AltText *string `json:"alt_text"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Role != nil {
+		if !validImageRoles[*input.Role] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Rol inválido: use main, model, hanger o detail"})
+			return
+		}
+		img.Role = *input.Role
+	}
+	if input.Color != nil {
+		img.Color = strings.TrimSpace(*input.Color)
+	}
+	if input.AltText != nil {
+		img.AltText = *input.AltText
+	}
+	before := CaptureRevision(config.DB, img.ProductID)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&img).Error; err != nil {
+			return err
+		}
+		return syncLegacyImages(tx, img.ProductID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	RecordRevision(config.DB, c, img.ProductID, RevisionImages, before)
+	c.JSON(http.StatusOK, img)
+}
+
+// ReorderProductImages fija el orden de la galería: {"image_ids": [5, 2, 9]}.
+// Las imágenes no incluidas quedan después, en su orden actual.
+// PUT /products/:id/gallery/reorder
+func ReorderProductImages(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	var input struct {
+		ImageIDs []uint `json:"image_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var images []ProductImage
+	before := CaptureRevision(config.DB, uint(productID))
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Order("position, id").Find(&images).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint]*ProductImage, len(images))
+		for i := range images {
+			byID[images[i].ID] = &images[i]
+		}
+		ordered := make([]*ProductImage, 0, len(images))
+		seen := map[uint]bool{}
+		for _, id := range input.ImageIDs {
+			img, ok := byID[id]
+			if !ok {
+				return &galleryError{"La imagen " + strconv.FormatUint(uint64(id), 10) + " no pertenece al producto"}
+			}
+			if !seen[id] {
+				seen[id] = true
+				ordered = append(ordered, img)
+			}
+		}
+		for i := range images {
+			if !seen[images[i].ID] {
+				ordered = append(ordered, &images[i])
+			}
+		}
+		for pos, img := range ordered {
+			img.Position = pos
+			if err := tx.Model(&ProductImage{}).Where("id = ?", img.ID).UpdateColumn("position", pos).Error; err != nil {
+				return err
+			}
+		}
+		return syncLegacyImages(tx, uint(productID))
+	})
+	var gErr *galleryError
+	if errors.As(err, &gErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": gErr.msg})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	RecordRevision(config.DB, c, uint(productID), RevisionImages, before)
+	if err := config.DB.Where("product_id = ?", productID).Order("position, id").Find(&images).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+// DeleteProductImage quita una imagen de la galería. Los archivos no se borran
+// del almacenamiento porque, al nombrarse por hash, pueden estar en uso por otra imagen.
+// DELETE /products/:id/gallery/:imageId
+func DeleteProductImage(c *gin.Context) {
+	var img ProductImage
+	if err := config.DB.Where("product_id = ?", c.Param("id")).First(&img, c.Param("imageId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada"})
+		return
+	}
+	before := CaptureRevision(config.DB, img.ProductID)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&img).Error; err != nil {
+			return err
+		}
+		// Limpiar los campos legacy que apuntaban a esta imagen antes de resincronizar
+		for col, setCol := range map[string]string{"image_url": "image_set", "image_model": "image_model_set", "image_hanger": "image_hanger_set"} {
+			if err := tx.Model(&Product{}).Where("id = ? AND "+col+" = ?", img.ProductID, img.URL).
+				UpdateColumns(map[string]interface{}{col: "", setCol: nil}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&ProductVariant{}).Where("product_id = ? AND image_url = ?", img.ProductID, img.URL).
+			UpdateColumns(map[string]interface{}{"image_url": "", "image_set": nil}).Error; err != nil {
+			return err
+		}
+		return syncLegacyImages(tx, img.ProductID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	RecordRevision(config.DB, c, img.ProductID, RevisionImages, before)
+	c.JSON(http.StatusOK, gin.H{"message": "Imagen eliminada"})
+}
+
+type galleryError struct{ msg string }
+
+func (e *galleryError) Error() string { return e.msg }
@@ -0,0 +1,96 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-modaMayor/internal/imaging"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGallery_ReorderSyncsLegacyFields(t *testing.T) {
+	db := setupTestDB(t)
+	prod := Product{Name: "Galeria"}
+	db.Create(&prod)
+	rojo := ProductVariant{ProductID: prod.ID, SKU: "gal-rojo", Color: "Rojo"}
+	azul := ProductVariant{ProductID: prod.ID, SKU: "gal-azul", Color: "azul"}
+	db.Create(&rojo)
+	db.Create(&azul)
+
+	imgs := []ProductImage{
+		{ProductID: prod.ID, Role: ImageRoleMain, URL: "/uploads/a.jpg"},
+		{ProductID: prod.ID, Role: ImageRoleMain, URL: "/uploads/b.jpg", ImageSet: &imaging.ImageSet{Src: "/uploads/b.jpg"}},
+		{ProductID: prod.ID, Role: ImageRoleModel, URL: "/uploads/m.jpg"},
+		{ProductID: prod.ID, Role: ImageRoleMain, Color: "rojo ", URL: "/uploads/rojo.jpg"},
+	}
+	for i := range imgs {
+		if err := addProductImage(db, &imgs[i]); err != nil {
+			t.Fatalf("add image: %v", err)
+		}
+		if imgs[i].Position != i {
+			t.Fatalf("image %d: position %d", i, imgs[i].Position)
+		}
+	}
+	if err := syncLegacyImages(db, prod.ID); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	db.First(&prod, prod.ID)
+	if prod.ImageURL != "/uploads/a.jpg" || prod.ImageModel != "/uploads/m.jpg" {
+		t.Fatalf("legacy fields not synced: %q %q", prod.ImageURL, prod.ImageModel)
+	}
+	db.First(&rojo, rojo.ID)
+	db.First(&azul, azul.ID)
+	if rojo.ImageURL != "/uploads/rojo.jpg" || azul.ImageURL != "" {
+		t.Fatalf("variant images: rojo=%q azul=%q", rojo.ImageURL, azul.ImageURL)
+	}
+
+	router := gin.Default()
+	router.PUT("/products/:id/gallery/reorder", ReorderProductImages)
+	router.DELETE("/products/:id/gallery/:imageId", DeleteProductImage)
+
+	// Poner b primero: pasa a ser la imagen principal
+	body, _ := json.Marshal(map[string][]uint{"image_ids": {imgs[1].ID}})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/products/%d/gallery/reorder", prod.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("reorder: %d %s", w.Code, w.Body.String())
+	}
+	var ordered []ProductImage
+	json.Unmarshal(w.Body.Bytes(), &ordered)
+	if len(ordered) != 4 || ordered[0].ID != imgs[1].ID || ordered[1].ID != imgs[0].ID {
+		t.Fatalf("unexpected order: %+v", ordered)
+	}
+	db.First(&prod, prod.ID)
+	if prod.ImageURL != "/uploads/b.jpg" || prod.ImageSet == nil || prod.ImageSet.Src != "/uploads/b.jpg" {
+		t.Fatalf("main image not updated after reorder: %q", prod.ImageURL)
+	}
+
+	// Una imagen de otro producto no se puede ordenar
+	body, _ = json.Marshal(map[string][]uint{"image_ids": {9999}})
+	req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/products/%d/gallery/reorder", prod.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for foreign image, got %d", w.Code)
+	}
+
+	// Borrar la imagen del color limpia la de las variantes
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/products/%d/gallery/%d", prod.ID, imgs[3].ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	db.First(&rojo, rojo.ID)
+	if rojo.ImageURL != "" {
+		t.Fatalf("variant image should be cleared, got %q", rojo.ImageURL)
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"go-modaMayor/config"
 	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/imaging"
 	"go-modaMayor/internal/settings"
+	"go-modaMayor/internal/slug"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,7 +22,7 @@ import (
 func GetProductVariants(c *gin.Context) {
 	productID := c.Param("id")
 	var variants []ProductVariant
-	if err := config.DB.Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+	if err := config.DB.Scopes(withVariantRefs).Where("product_id = ?", productID).Find(&variants).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -140,12 +143,14 @@ func GetVariantStock(c *gin.Context) {
 }
 
 // Listar productos
+// Filtros: category (con sus subcategorías del árbol), subcategory, attr.<clave>, search y los de applyProductFilters (color, size, season_id,
+// supplier_id, min_price/max_price/price_tier, tags, in_stock, location).
+// Orden (?sort=): stock (por defecto), price_asc, price_desc, newest, bestseller, name_asc, name_desc;
+// con search y sin sort se ordena por relevancia. Con ?facets=true (o search) se devuelven facetas.
 func GetProducts(c *gin.Context) {
 	// Server-side search + pagination
-	var products []Product
 	search := strings.TrimSpace(c.Query("search"))
-	categoryQ := c.Query("category")
-	subcategoryQ := c.Query("subcategory")
+	sortQ := c.Query("sort")
 
 	pageStr := c.DefaultQuery("page", "1")
 	limitStr := c.DefaultQuery("limit", "25")
@@ -159,90 +164,147 @@ func GetProducts(c *gin.Context) {
 	}
 	offset := (page - 1) * limit
 
-	// Base query with preloads
-	// Seleccionar explícitamente products.* para evitar columnas ambiguas cuando hacemos JOINs
-	base := config.DB.Model(&Product{}).Select("products.*").Preload("Category").Preload("Subcategory").Preload("Variants").Preload("LocationStocks")
-
-	// Apply category/subcategory filters from explicit params
-	if categoryQ != "" {
-		// Qualify column with table name to avoid ambiguity when joining categories/subcategories
-		base = base.Where("products.category_id = ?", categoryQ)
+	// Base query: only products table plus filters; full records are loaded afterwards by id
+	base, joined, err := applyProductFilters(c, config.DB.Model(&Product{}))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if subcategoryQ != "" {
-		base = base.Where("products.subcategory_id = ?", subcategoryQ)
+	// El público sólo ve productos publicados; el staff ve todos (y puede filtrar con ?status=)
+	if !IsStaff(c) {
+		base = base.Scopes(Published)
 	}
-
-	// If search provided, join categories/subcategories and filter by name
-	if search != "" {
-		like := "%" + strings.ToLower(search) + "%"
-		// Join categories, subcategories and variants to search across name, category, subcategory and variant SKU/description
-		base = base.Joins("LEFT JOIN categories ON categories.id = products.category_id").Joins("LEFT JOIN subcategories ON subcategories.id = products.subcategory_id").Joins("LEFT JOIN product_variants ON product_variants.product_id = products.id").Where(
-			"LOWER(products.name) LIKE ? OR LOWER(products.description) LIKE ? OR LOWER(categories.name) LIKE ? OR LOWER(subcategories.name) LIKE ? OR LOWER(product_variants.sku) LIKE ?",
-			like, like, like, like, like,
-		)
-	}
-
-	// Count total distinct products matching filters
-	var total int64
-	countQuery := base.Session(&gorm.Session{})
-	if err := countQuery.Distinct("products.id").Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	priceColumn := priceTierColumns["wholesale"]
+	if col, ok := priceTierColumns[c.Query("price_tier")]; ok {
+		priceColumn = col
+	}
+	sortSpec, ok := productSortFor(sortQ, priceColumn)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort inválido: " + sortQ})
 		return
 	}
 
-	// Fetch paged results
-	// We want to order products so those with stock appear first. Compute total stock per product
-	// via a subquery and left join it as `stocks`, then order by COALESCE(stocks.total_stock,0) DESC
-	stockSubquery := "(SELECT product_id, SUM(COALESCE(stock,0)) as total_stock FROM location_stocks GROUP BY product_id)"
-	base = base.Joins("LEFT JOIN " + stockSubquery + " stocks ON stocks.product_id = products.id")
-	// When we joined variants/categories the base query can return duplicate product rows
-	// (one per variant). To avoid returning duplicate products (which causes React key warnings)
-	// we first fetch the distinct product IDs for the current page, then load full product
-	// records with preloads in a second query.
-	var productIDs []uint
+	// If search provided, restrict to full-text matches (ranked by relevance, see search.go)
+	var hits []SearchHit
 	if search != "" {
-		// Query only ids (distinct) with pagination
-		// Para usar DISTINCT con ORDER BY, necesitamos incluir la columna del ORDER BY en el SELECT
-		type IDWithStock struct {
-			ID         uint
-			TotalStock int
-		}
-		var idsWithStock []IDWithStock
-		if err := base.Select("DISTINCT products.id, COALESCE(stocks.total_stock,0) as total_stock").Order("total_stock DESC, products.id DESC").Offset(offset).Limit(limit).Scan(&idsWithStock).Error; err != nil {
+		hits, err = SearchProductIDs(config.DB, search)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		// Extraer solo los IDs
-		for _, item := range idsWithStock {
-			productIDs = append(productIDs, item.ID)
+		hitIDs := make([]uint, 0, len(hits))
+		for _, h := range hits {
+			hitIDs = append(hitIDs, h.ID)
 		}
-
-		if len(productIDs) > 0 {
-			// Load full product records with preloads, manteniendo el orden
-			if err := config.DB.Preload("Category").Preload("Subcategory").Preload("Variants").Preload("LocationStocks").Where("products.id IN ?", productIDs).Order("products.id DESC").Find(&products).Error; err != nil {
+		if len(hitIDs) == 0 {
+			facets, err := BuildProductFacets(config.DB, nil)
+			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-		} else {
-			products = []Product{}
+			c.JSON(http.StatusOK, gin.H{"items": []Product{}, "total": 0, "facets": facets})
+			return
+		}
+		base = base.Where("products.id IN ?", hitIDs)
+	}
+
+	// Every distinct product id matching the filters (for total and facets)
+	var matchedIDs []uint
+	if err := base.Session(&gorm.Session{}).Distinct("products.id").Pluck("products.id", &matchedIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	total := len(matchedIDs)
+
+	var pageIDs []uint
+	if search != "" && sortQ == "" {
+		// Relevance order: keep the search ranking and page in memory
+		matched := make(map[uint]bool, len(matchedIDs))
+		for _, id := range matchedIDs {
+			matched[id] = true
+		}
+		ordered := make([]uint, 0, len(matchedIDs))
+		for _, h := range hits {
+			if matched[h.ID] {
+				ordered = append(ordered, h.ID)
+			}
+		}
+		if offset < len(ordered) {
+			pageIDs = ordered[offset:min(offset+limit, len(ordered))]
 		}
 	} else {
-		// No joins/search involved — simple paged fetch is fine
-		if err := base.Order("COALESCE(stocks.total_stock,0) DESC").Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+		// Filters may join variants, so the query can return one row per variant. To avoid
+		// duplicate products (which causes React key warnings) we fetch the distinct product IDs
+		// for the current page — including the ORDER BY column in the DISTINCT select — and
+		// then load full product records with preloads in a second query.
+		query := base.Session(&gorm.Session{})
+		if sortSpec.join != "" {
+			query = query.Joins(sortSpec.join)
+		}
+		type idWithSort struct {
+			ID uint
+		}
+		var rows []idWithSort
+		selectExpr := "products.id, " + sortSpec.column
+		if joined {
+			selectExpr = "DISTINCT " + selectExpr
+		}
+		if err := query.Select(selectExpr).Order(sortSpec.orderBy).Offset(offset).Limit(limit).Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, r := range rows {
+			pageIDs = append(pageIDs, r.ID)
+		}
+	}
+
+	products := []Product{}
+	if len(pageIDs) > 0 {
+		var found []Product
+		if err := config.DB.Preload("Category").Preload("Subcategory").Preload("Categories").Scopes(PreloadVariants).Preload("LocationStocks").Where("products.id IN ?", pageIDs).Find(&found).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		// Keep page order
+		byID := make(map[uint]Product, len(found))
+		for _, p := range found {
+			byID[p.ID] = p
+		}
+		for _, id := range pageIDs {
+			if p, ok := byID[id]; ok {
+				products = append(products, p)
+			}
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"items": products, "total": total})
+	resp := gin.H{"items": products, "total": total}
+	if search != "" || c.Query("facets") == "true" {
+		facets, err := BuildProductFacets(config.DB, matchedIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp["facets"] = facets
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // GetProduct obtiene el detalle de un producto por id
 func GetProduct(c *gin.Context) {
-	id := c.Param("id")
+	respondProductDetail(c, c.Param("id"))
+}
+
+// respondProductDetail responde el detalle del producto (compartido con la búsqueda por slug)
+func respondProductDetail(c *gin.Context, id any) {
 	var product Product
-	if err := config.DB.Preload("Category").Preload("Subcategory").Preload("Variants").Preload("LocationStocks").First(&product, id).Error; err != nil {
+	if err := config.DB.Preload("Category").Preload("Subcategory").Preload("Categories").Preload("Attributes.Attribute").Scopes(PreloadVariants).Preload("LocationStocks").Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	// Borradores, archivados y publicaciones programadas no son visibles para el público
+	if !IsStaff(c) && !product.IsPurchasable(time.Now()) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
 		return
 	}
@@ -254,12 +316,12 @@ type CreateProductInput struct {
 	Name          string  `json:"name" binding:"required,min=2,max=100"`
 	Description   string  `json:"description"`
 	CategoryID    uint    `json:"category_id" binding:"required,gt=0"`
-	SubcategoryID uint    `json:"subcategory_id" binding:"required,gt=0"`
+	SubcategoryID uint    `json:"subcategory_id"`
 	ImageURL      string  `json:"image_url"`
 	CostPrice     float64 `json:"cost_price" binding:"required,gt=0"`
 	SupplierID    *uint   `json:"supplier_id,omitempty"`
-	Season        string  `json:"season,omitempty"`
 	SeasonID      *uint   `json:"season_id,omitempty"`
+	Recurring     bool    `json:"recurring,omitempty"`
 	Year          *int    `json:"year,omitempty"`
 	SizeTypeID    *uint   `json:"size_type_id,omitempty"`
 	TotalStock    *int    `json:"total_stock,omitempty"`
@@ -276,6 +338,12 @@ type CreateProductInput struct {
 	IsFeatured   bool `json:"is_featured"`
 	IsOffer      bool `json:"is_offer"`
 	IsTrending   bool `json:"is_trending"`
+	// Estado inicial (published por defecto) y publicación programada opcional
+	Status    string     `json:"status,omitempty"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// Categorías adicionales del árbol y valores de atributos por clave (ver attributes.go)
+	CategoryIDs []uint         `json:"category_ids,omitempty"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
 }
 
 func CreateProduct(c *gin.Context) {
@@ -295,15 +363,17 @@ func CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Categoría no encontrada"})
 		return
 	}
-	// Verificar que la subcategoría exista y pertenezca a la categoría
-	var subcat category.Subcategory
-	if err := config.DB.First(&subcat, input.SubcategoryID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Subcategoría no encontrada"})
-		return
-	}
-	if subcat.CategoryID != input.CategoryID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "La subcategoría no pertenece a la categoría seleccionada"})
-		return
+	// Verificar que la subcategoría (opcional con el árbol de categorías) exista y pertenezca a la categoría
+	if input.SubcategoryID > 0 {
+		var subcat category.Subcategory
+		if err := config.DB.First(&subcat, input.SubcategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Subcategoría no encontrada"})
+			return
+		}
+		if subcat.CategoryID != input.CategoryID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La subcategoría no pertenece a la categoría seleccionada"})
+			return
+		}
 	}
 	// Obtener price tiers y calcular precios
 	var tiers []settings.PriceTier
@@ -322,8 +392,8 @@ func CreateProduct(c *gin.Context) {
 		Discount2Price: prices.Discount2Price,
 		VariantType:    input.VariantType,
 		SupplierID:     input.SupplierID,
-		Season:         input.Season,
 		SeasonID:       input.SeasonID,
+		Recurring:      input.Recurring,
 		Year:           input.Year,
 		SizeTypeID:     input.SizeTypeID,
 		TotalStock:     input.TotalStock,
@@ -336,10 +406,29 @@ func CreateProduct(c *gin.Context) {
 		IsOffer:        input.IsOffer,
 		IsTrending:     input.IsTrending,
 	}
-	if err := config.DB.Create(&product).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if input.Status != "" || input.PublishAt != nil {
+		status := input.Status
+		if status == "" {
+			status = StatusPublished
+		}
+		if err := applyStatus(&product, status, input.PublishAt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if err := SetProductCategories(tx, &product, input.CategoryIDs); err != nil {
+			return err
+		}
+		return SetProductAttributes(tx, &product, input.Attributes, true)
+	}); err != nil {
+		respondAttributeError(c, err)
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionCreate, nil)
 	c.JSON(http.StatusCreated, product)
 }
 
@@ -375,12 +464,19 @@ func UpdateProduct(c *gin.Context) {
 		IsFeatured   *bool `json:"is_featured"`
 		IsOffer      *bool `json:"is_offer"`
 		IsTrending   *bool `json:"is_trending"`
+		Recurring    *bool `json:"recurring"`
+		// Slug explícito; si no se envía, cambia junto con el nombre
+		Slug *string `json:"slug"`
+		// Categorías adicionales (reemplaza la lista) y atributos a modificar por clave
+		CategoryIDs *[]uint        `json:"category_ids"`
+		Attributes  map[string]any `json:"attributes"`
 	}
 	var input UpdateProductInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	before := CaptureRevision(config.DB, product.ID)
 	// Si se envía CategoryID, validar que exista
 	if input.CategoryID != nil {
 		var cat category.Category
@@ -456,12 +552,47 @@ func UpdateProduct(c *gin.Context) {
 	if input.IsTrending != nil {
 		updates["is_trending"] = *input.IsTrending
 	}
-	if err := config.DB.Model(&product).Updates(updates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if input.Recurring != nil {
+		updates["recurring"] = *input.Recurring
+	}
+	// El slug anterior queda como redirección (ver slug.go)
+	slugBase := ""
+	if input.Slug != nil && slug.Make(*input.Slug) != "" {
+		slugBase = *input.Slug
+	} else if input.Name != nil && *input.Name != product.Name {
+		slugBase = *input.Name
+	}
+	oldSlug := product.Slug
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+		if slugBase != "" {
+			if _, err := slug.Change(tx, slug.EntityProduct, product.ID, oldSlug, slugBase); err != nil {
+				return err
+			}
+		}
+		if input.CategoryIDs != nil {
+			if err := SetProductCategories(tx, &product, *input.CategoryIDs); err != nil {
+				return err
+			}
+		} else if input.CategoryID != nil {
+			// Cambió la categoría principal: los atributos que ya no aplican se descartan
+			if err := pruneAttributeValues(tx, &product); err != nil {
+				return err
+			}
+		}
+		if input.Attributes != nil {
+			return SetProductAttributes(tx, &product, input.Attributes, false)
+		}
+		return nil
+	}); err != nil {
+		respondAttributeError(c, err)
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionUpdate, before)
 	// Recargar el producto para obtener las relaciones actualizadas
-	if err := config.DB.Preload("Category").Preload("Subcategory").First(&product, id).Error; err != nil {
+	if err := config.DB.Preload("Category").Preload("Subcategory").Preload("Categories").Preload("Attributes.Attribute").First(&product, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al recargar producto"})
 		return
 	}
@@ -485,23 +616,36 @@ func UpdateProductDiscount(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	before := CaptureRevision(config.DB, product.ID)
 	product.DiscountType = input.DiscountType
 	product.DiscountValue = input.DiscountValue
 	if err := config.DB.Save(&product).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionDiscount, before)
 	c.JSON(http.StatusOK, product)
 }
 
-// Eliminar producto
+// Eliminar producto: se archiva en lugar de borrarlo para que siga resolviéndose
+// en pedidos, reportes y reposiciones
 func DeleteProduct(c *gin.Context) {
-	id := c.Param("id")
-	if err := config.DB.Delete(&Product{}, id).Error; err != nil {
+	var product Product
+	if err := config.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	before := CaptureRevision(config.DB, product.ID)
+	if err := applyStatus(&product, StatusArchived, nil); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Model(&product).Select("status", "publish_at", "archived_at").Updates(&product).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Producto eliminado"})
+	RecordRevision(config.DB, c, product.ID, RevisionStatus, before)
+	c.JSON(http.StatusOK, gin.H{"message": "Producto archivado", "product": product})
 }
 
 // Actualizar stock de un producto (solo admin/vendedor)
@@ -516,16 +660,24 @@ func UpdateStock(c *gin.Context) {
 	c.JSON(http.StatusBadRequest, gin.H{"error": "El stock se gestiona por variante y ubicación. Utiliza el endpoint correspondiente."})
 }
 
-// Listar productos con bajo stock (solo admin)
+// Listar variantes con bajo stock por ubicación (solo admin)
+// Usa el punto de reposición de cada variante/ubicación y, si no tiene, el umbral
+// por defecto (query param ?threshold=N, default 5). ?only_configured=true ignora
+// las variantes sin punto de reposición.
 func LowStockProducts(c *gin.Context) {
-	var products []Product
-	// Puedes ajustar el umbral de bajo stock aquí
-	const threshold = 5
-	if err := config.DB.Where("stock <= ?", threshold).Preload("Category").Find(&products).Error; err != nil {
+	threshold := DefaultLowStockThreshold
+	if v, err := strconv.Atoi(c.Query("threshold")); err == nil && v >= 0 {
+		threshold = v
+	}
+	if c.Query("only_configured") == "true" {
+		threshold = -1
+	}
+	rows, err := FindLowStock(config.DB, threshold)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, rows)
 }
 
 // Subir imagen de producto (solo admin)
@@ -541,16 +693,30 @@ func UploadProductImage(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No se recibió archivo"})
 		return
 	}
-	filename := "uploads/product_" + id + "_" + file.Filename
-	if err := c.SaveUploadedFile(file, filename); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	store, ok := uploadStorage(c)
+	if !ok {
 		return
 	}
-	product.ImageURL = "/" + filename
-	if err := config.DB.Save(&product).Error; err != nil {
+	set, err := imaging.StoreUpload(c.Request.Context(), store, file)
+	if err != nil {
+		respondImageError(c, err)
+		return
+	}
+	before := CaptureRevision(config.DB, product.ID)
+	product.ImageURL = set.Src
+	product.ImageSet = set
+	// La imagen pasa a ser la principal de la galería
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		return setPrimaryImage(tx, product.ID, ImageRoleMain, set)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionImages, before)
 	c.JSON(http.StatusOK, product)
 }
 
@@ -572,26 +738,33 @@ func UploadProductImages(c *gin.Context) {
 
 	uploadedImages := make(map[string]string)
 	imageFields := []string{"image_main", "image_model", "image_hanger"}
+	fieldRoles := map[string]string{"image_main": ImageRoleMain, "image_model": ImageRoleModel, "image_hanger": ImageRoleHanger}
+	roleSets := make(map[string]*imaging.ImageSet)
 
 	for _, fieldName := range imageFields {
 		files := form.File[fieldName]
 		if len(files) > 0 {
 			file := files[0] // tomar el primer archivo de cada campo
-			filename := "uploads/product_" + id + "_" + fieldName + "_" + file.Filename
-			if err := c.SaveUploadedFile(file, filename); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar " + fieldName})
+			store, ok := uploadStorage(c)
+			if !ok {
+				return
+			}
+			set, err := imaging.StoreUpload(c.Request.Context(), store, file)
+			if err != nil {
+				respondImageError(c, err)
 				return
 			}
-			uploadedImages[fieldName] = "/" + filename
+			uploadedImages[fieldName] = set.Src
+			roleSets[fieldRoles[fieldName]] = set
 
 			// Actualizar el campo correspondiente en el producto
 			switch fieldName {
 			case "image_main":
-				product.ImageURL = "/" + filename
+				product.ImageURL, product.ImageSet = set.Src, set
 			case "image_model":
-				product.ImageModel = "/" + filename
+				product.ImageModel, product.ImageModelSet = set.Src, set
 			case "image_hanger":
-				product.ImageHanger = "/" + filename
+				product.ImageHanger, product.ImageHangerSet = set.Src, set
 			}
 		}
 	}
@@ -601,10 +774,24 @@ func UploadProductImages(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Save(&product).Error; err != nil {
+	// Cada slot reemplaza la imagen principal de su rol en la galería
+	before := CaptureRevision(config.DB, product.ID)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&product).Error; err != nil {
+			return err
+		}
+		for role, set := range roleSets {
+			if err := setPrimaryImage(tx, product.ID, role, set); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionImages, before)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":         "Imágenes subidas exitosamente",
@@ -615,10 +802,13 @@ func UploadProductImages(c *gin.Context) {
 
 // --- Extended create: crear producto + variantes + stocks iniciales en una transacción ---
 type VariantInput struct {
-	SKU      string `json:"sku"`
-	Color    string `json:"color"`
-	Size     string `json:"size"`
-	ImageURL string `json:"image_url"`
+	SKU         string `json:"sku"`
+	Color       string `json:"color"`
+	Size        string `json:"size"`
+	ColorID     *uint  `json:"color_id,omitempty"`
+	SizeValueID *uint  `json:"size_value_id,omitempty"`
+	ImageURL    string `json:"image_url"`
+	Barcode     string `json:"barcode,omitempty"` // código del proveedor; si no viene se genera un EAN-13
 }
 
 type InitialStockInput struct {
@@ -632,12 +822,13 @@ type CreateProductFullInput struct {
 	Name          string              `json:"name" binding:"required,min=2,max=100"`
 	Description   string              `json:"description"`
 	CategoryID    uint                `json:"category_id" binding:"required,gt=0"`
-	SubcategoryID uint                `json:"subcategory_id" binding:"required,gt=0"`
+	SubcategoryID uint                `json:"subcategory_id"`
 	ImageURL      string              `json:"image_url"`
 	CostPrice     float64             `json:"cost_price" binding:"required,gt=0"`
 	VariantType   string              `json:"variant_type" binding:"omitempty,oneof=talle_unico color_surtido ambos sin_variantes"`
 	SupplierID    *uint               `json:"supplier_id,omitempty"`
-	Season        string              `json:"season,omitempty"`
+	SeasonID      *uint               `json:"season_id,omitempty"`
+	Recurring     bool                `json:"recurring,omitempty"`
 	Year          *int                `json:"year,omitempty"`
 	SizeTypeID    *uint               `json:"size_type_id,omitempty"`
 	TotalStock    *int                `json:"total_stock,omitempty"`
@@ -648,6 +839,9 @@ type CreateProductFullInput struct {
 	// Descuento al crear completo
 	DiscountType  string  `json:"discount_type" binding:"omitempty,oneof=none percent fixed"`
 	DiscountValue float64 `json:"discount_value" binding:"omitempty,gte=0"`
+	// Categorías adicionales y atributos cargados en el wizard
+	CategoryIDs []uint         `json:"category_ids,omitempty"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
 }
 
 func CreateProductFull(c *gin.Context) {
@@ -662,14 +856,16 @@ func CreateProductFull(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Categoría no encontrada"})
 		return
 	}
-	var subcat category.Subcategory
-	if err := config.DB.First(&subcat, input.SubcategoryID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Subcategoría no encontrada"})
-		return
-	}
-	if subcat.CategoryID != input.CategoryID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "La subcategoría no pertenece a la categoría seleccionada"})
-		return
+	if input.SubcategoryID > 0 {
+		var subcat category.Subcategory
+		if err := config.DB.First(&subcat, input.SubcategoryID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Subcategoría no encontrada"})
+			return
+		}
+		if subcat.CategoryID != input.CategoryID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "La subcategoría no pertenece a la categoría seleccionada"})
+			return
+		}
 	}
 	// Obtener price tiers y calcular precios
 	var tiers []settings.PriceTier
@@ -687,6 +883,9 @@ func CreateProductFull(c *gin.Context) {
 		Discount1Price: prices.Discount1Price,
 		Discount2Price: prices.Discount2Price,
 		VariantType:    input.VariantType,
+		SizeTypeID:     input.SizeTypeID,
+		SeasonID:       input.SeasonID,
+		Recurring:      input.Recurring,
 	}
 
 	// transacción para crear product, variantes y stocks
@@ -694,9 +893,26 @@ func CreateProductFull(c *gin.Context) {
 		if err := tx.Create(&product).Error; err != nil {
 			return err
 		}
+		if err := SetProductCategories(tx, &product, input.CategoryIDs); err != nil {
+			return err
+		}
+		if err := SetProductAttributes(tx, &product, input.Attributes, true); err != nil {
+			return err
+		}
+		vc, err := loadVariantCatalog(tx, product.SizeTypeID)
+		if err != nil {
+			return err
+		}
 		createdVariants := make([]ProductVariant, 0, len(input.Variants))
 		for _, v := range input.Variants {
-			pv := ProductVariant{ProductID: product.ID, Color: v.Color, Size: v.Size, SKU: v.SKU, ImageURL: v.ImageURL}
+			pv := ProductVariant{ProductID: product.ID, Color: v.Color, Size: v.Size, ColorID: v.ColorID, SizeValueID: v.SizeValueID, SKU: v.SKU, ImageURL: v.ImageURL}
+			if err := vc.resolveVariant(&pv); err != nil {
+				return err
+			}
+			pv.Barcode = &v.Barcode
+			if err := assignBarcode(tx, &pv); err != nil {
+				return err
+			}
 			if err := tx.Create(&pv).Error; err != nil {
 				return err
 			}
@@ -735,11 +951,12 @@ func CreateProductFull(c *gin.Context) {
 		return nil
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondVariantInputError(c, err)
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionCreate, nil)
 	var out Product
-	if err := config.DB.Preload("Variants").Preload("LocationStocks").Preload("Category").Preload("Subcategory").First(&out, product.ID).Error; err != nil {
+	if err := config.DB.Scopes(PreloadVariants).Preload("LocationStocks").Preload("Category").Preload("Subcategory").Preload("Categories").Preload("Attributes.Attribute").First(&out, product.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
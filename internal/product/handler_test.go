@@ -10,6 +10,9 @@ import (
 	"testing"
 
 	"go-modaMayor/config"
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/slug"
 
 	"github.com/gin-gonic/gin"
 	"github.com/glebarez/sqlite"
@@ -24,7 +27,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("failed to open test db: %v", err)
 	}
 	// Automigrate required models
-	err = db.AutoMigrate(&Product{}, &ProductVariant{}, &LocationStock{}, &SizeType{}, &SizeValue{}, &Supplier{}, &Color{})
+	err = db.AutoMigrate(&Product{}, &ProductVariant{}, &LocationStock{}, &SizeType{}, &SizeValue{}, &Supplier{}, &Color{}, &ProductImage{}, &ProductRevision{}, &SizeCurve{}, &SizeCurveItem{}, &sequence.Sequence{},
+		&category.Category{}, &category.CategoryAttribute{}, &ProductAttributeValue{}, &slug.Redirect{})
 	if err != nil {
 		t.Fatalf("auto migrate failed: %v", err)
 	}
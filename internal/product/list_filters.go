@@ -0,0 +1,236 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/slug"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// priceTierColumns mapea el parámetro price_tier a la columna de precio
+var priceTierColumns = map[string]string{
+	"wholesale": "products.wholesale_price",
+	"discount1": "products.discount1_price",
+	"discount2": "products.discount2_price",
+	"cost":      "products.cost_price",
+}
+
+// tagColumns mapea los valores de ?tags= a las columnas booleanas de Product
+var tagColumns = map[string]string{
+	"new_arrival": "products.is_new_arrival",
+	"featured":    "products.is_featured",
+	"offer":       "products.is_offer",
+	"trending":    "products.is_trending",
+}
+
+// splitParam separa un parámetro de query con valores separados por coma
+func splitParam(c *gin.Context, key string) []string {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil
+	}
+	out := []string{}
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// applyProductFilters agrega al query de GET /products los filtros opcionales:
+//   - status: draft,published,hidden,archived (GetProducts además restringe al público a publicados)
+//   - category (id o slug): incluye sus subcategorías del árbol y las categorías adicionales del producto
+//   - subcategory
+//   - attr.<clave>=v1,v2 y attr.<clave>.min/.max: atributos filtrables de categoría
+//   - color, size: valores separados por coma; se filtra por variante (ambos deben darse en la misma variante)
+//   - season_id, supplier_id
+//   - min_price, max_price sobre el tier indicado en price_tier (wholesale por defecto)
+//   - tags: new_arrival,featured,offer,trending (todos deben cumplirse)
+//   - in_stock=true: disponible (stock - reserved) > 0, en location si se indica
+//   - location: sólo productos con disponible en esa ubicación
+//
+// Devuelve joined=true si se unió una tabla uno-a-muchos (puede haber filas repetidas por producto).
+func applyProductFilters(c *gin.Context, base *gorm.DB) (*gorm.DB, bool, error) {
+	joined := false
+
+	if statuses := splitParam(c, "status"); len(statuses) > 0 {
+		for _, s := range statuses {
+			if !validStatuses[s] {
+				return nil, false, fmt.Errorf("status inválido: %s", s)
+			}
+		}
+		base = base.Where("products.status IN ?", statuses)
+	}
+	if categoryID := c.Query("category"); categoryID != "" {
+		// Acepta el id o el slug (actual o anterior) de la categoría
+		id, err := strconv.ParseUint(categoryID, 10, 64)
+		if err != nil {
+			resolved, _, err := slug.Resolve(base.Session(&gorm.Session{NewDB: true}), slug.EntityCategory, categoryID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, false, err
+			}
+			id = uint64(resolved)
+		}
+		subtree := category.SubtreeIDs(base.Session(&gorm.Session{NewDB: true}), uint(id))
+		base = base.Where("(products.category_id IN (?) OR products.id IN (SELECT product_id FROM product_categories WHERE category_id IN (?)))", subtree, subtree)
+	}
+	if subcategoryID := c.Query("subcategory"); subcategoryID != "" {
+		base = base.Where("products.subcategory_id = ?", subcategoryID)
+	}
+	base, err := applyAttributeFilters(c, base)
+	if err != nil {
+		return nil, false, err
+	}
+
+	colors := splitParam(c, "color")
+	sizes := splitParam(c, "size")
+	if len(colors) > 0 || len(sizes) > 0 {
+		base = base.Joins("JOIN product_variants fv ON fv.product_id = products.id AND fv.deleted_at IS NULL")
+		if len(colors) > 0 {
+			base = base.Where("LOWER(fv.color) IN ?", lowerAll(colors))
+		}
+		if len(sizes) > 0 {
+			base = base.Where("LOWER(fv.size) IN ?", lowerAll(sizes))
+		}
+		joined = true
+	}
+
+	if seasonID := c.Query("season_id"); seasonID != "" {
+		base = base.Where("products.season_id = ?", seasonID)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		base = base.Where("products.supplier_id = ?", supplierID)
+	}
+
+	priceColumn := priceTierColumns["wholesale"]
+	if tier := c.Query("price_tier"); tier != "" {
+		col, ok := priceTierColumns[tier]
+		if !ok {
+			return nil, false, fmt.Errorf("price_tier inválido: %s", tier)
+		}
+		priceColumn = col
+	}
+	if v := c.Query("min_price"); v != "" {
+		minPrice, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("min_price inválido")
+		}
+		base = base.Where(priceColumn+" >= ?", minPrice)
+	}
+	if v := c.Query("max_price"); v != "" {
+		maxPrice, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("max_price inválido")
+		}
+		base = base.Where(priceColumn+" <= ?", maxPrice)
+	}
+
+	for _, tag := range splitParam(c, "tags") {
+		col, ok := tagColumns[tag]
+		if !ok {
+			return nil, false, fmt.Errorf("tag inválido: %s", tag)
+		}
+		base = base.Where(col+" = ?", true)
+	}
+
+	location := c.Query("location")
+	if c.Query("in_stock") == "true" || location != "" {
+		stockFilter := "SELECT product_id FROM location_stocks WHERE deleted_at IS NULL AND stock - reserved > 0"
+		if location != "" {
+			base = base.Where("products.id IN ("+stockFilter+" AND location = ?)", location)
+		} else {
+			base = base.Where("products.id IN (" + stockFilter + ")")
+		}
+	}
+	return base, joined, nil
+}
+
+// FilteredProductIDs devuelve los ids (ascendentes, sin repetir) de los productos que
+// cumplen los mismos filtros que GET /products, incluida la búsqueda por texto.
+// Los errores de validación de filtros se devuelven como *FilterError.
+func FilteredProductIDs(c *gin.Context, db *gorm.DB) ([]uint, error) {
+	base, _, err := applyProductFilters(c, db.Model(&Product{}))
+	if err != nil {
+		return nil, &FilterError{Message: err.Error()}
+	}
+	if search := strings.TrimSpace(c.Query("search")); search != "" {
+		hits, err := SearchProductIDs(db, search)
+		if err != nil {
+			return nil, err
+		}
+		if len(hits) == 0 {
+			return []uint{}, nil
+		}
+		hitIDs := make([]uint, 0, len(hits))
+		for _, h := range hits {
+			hitIDs = append(hitIDs, h.ID)
+		}
+		base = base.Where("products.id IN ?", hitIDs)
+	}
+	var ids []uint
+	if err := base.Distinct("products.id").Order("products.id").Pluck("products.id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FilterError indica un parámetro de filtro inválido (corresponde a un 400)
+type FilterError struct {
+	Message string
+}
+
+func (e *FilterError) Error() string { return e.Message }
+
+// productSorts define los órdenes admitidos por ?sort=. Cada uno indica el JOIN
+// necesario, la expresión a seleccionar junto al id (para DISTINCT) y el ORDER BY.
+type productSort struct {
+	join    string
+	column  string
+	orderBy string
+}
+
+const latestBestsellerRanks = "(SELECT product_id, MIN(rank) as rank FROM bestseller_snapshots WHERE deleted_at IS NULL AND snapshot_at = (SELECT MAX(snapshot_at) FROM bestseller_snapshots WHERE deleted_at IS NULL) GROUP BY product_id)"
+
+func productSortFor(sort, priceColumn string) (productSort, bool) {
+	switch sort {
+	case "price_asc":
+		return productSort{column: priceColumn + " as sort_value", orderBy: "sort_value ASC, products.id DESC"}, true
+	case "price_desc":
+		return productSort{column: priceColumn + " as sort_value", orderBy: "sort_value DESC, products.id DESC"}, true
+	case "newest":
+		return productSort{column: "products.created_at as sort_value", orderBy: "sort_value DESC, products.id DESC"}, true
+	case "name_asc":
+		return productSort{column: "products.name as sort_value", orderBy: "sort_value ASC, products.id DESC"}, true
+	case "name_desc":
+		return productSort{column: "products.name as sort_value", orderBy: "sort_value DESC, products.id DESC"}, true
+	case "bestseller":
+		// Productos sin ranking en el último snapshot van al final
+		return productSort{
+			join:    "LEFT JOIN " + latestBestsellerRanks + " bs ON bs.product_id = products.id",
+			column:  "COALESCE(bs.rank, 2147483647) as sort_value",
+			orderBy: "sort_value ASC, products.id DESC",
+		}, true
+	case "", "stock":
+		return productSort{
+			join:    "LEFT JOIN (SELECT product_id, SUM(COALESCE(stock,0)) as total_stock FROM location_stocks GROUP BY product_id) stocks ON stocks.product_id = products.id",
+			column:  "COALESCE(stocks.total_stock,0) as sort_value",
+			orderBy: "sort_value DESC, products.id DESC",
+		}, true
+	}
+	return productSort{}, false
+}
+
+func lowerAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.ToLower(v)
+	}
+	return out
+}
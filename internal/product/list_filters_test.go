@@ -0,0 +1,79 @@
+package product
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-modaMayor/internal/category"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetProducts_FiltersAndSorting(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&category.Category{}, &category.Subcategory{}, &Season{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM product_variants")
+	db.Exec("DELETE FROM location_stocks")
+	db.Exec("CREATE TABLE IF NOT EXISTS bestseller_snapshots (id INTEGER PRIMARY KEY, product_id INTEGER, rank INTEGER, snapshot_at DATETIME, deleted_at DATETIME)")
+	db.Exec("DELETE FROM bestseller_snapshots")
+
+	cheap := Product{Name: "Remera Lisa", Code: "TEST-F-1", WholesalePrice: 1000, IsOffer: true, Status: StatusPublished}
+	db.Create(&cheap)
+	pricey := Product{Name: "Campera Cuero", Code: "TEST-F-2", WholesalePrice: 9000, Status: StatusPublished}
+	db.Create(&pricey)
+	other := Product{Name: "Remera Estampada", Code: "TEST-F-3", WholesalePrice: 3000, Status: StatusPublished}
+	db.Create(&other)
+	// Dos variantes negras del mismo producto: no debe duplicarse en el resultado
+	db.Create(&ProductVariant{ProductID: cheap.ID, SKU: "f-1-n-s", Color: "Negro", Size: "S"})
+	db.Create(&ProductVariant{ProductID: cheap.ID, SKU: "f-1-n-m", Color: "Negro", Size: "M"})
+	db.Create(&ProductVariant{ProductID: pricey.ID, SKU: "f-2-n-m", Color: "Negro", Size: "M"})
+	db.Create(&ProductVariant{ProductID: other.ID, SKU: "f-3-b-m", Color: "Blanco", Size: "M"})
+	db.Create(&LocationStock{ProductID: pricey.ID, Location: "salta", Stock: 3})
+	now := time.Now()
+	db.Exec("INSERT INTO bestseller_snapshots (product_id, rank, snapshot_at) VALUES (?, 1, ?), (?, 2, ?)", other.ID, now, cheap.ID, now)
+
+	router := gin.New()
+	router.GET("/products", GetProducts)
+	get := func(query string) ([]uint, int) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Items []Product `json:"items"`
+			Total int       `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ids := []uint{}
+		for _, p := range resp.Items {
+			ids = append(ids, p.ID)
+		}
+		return ids, resp.Total
+	}
+
+	if ids, total := get("color=negro&sort=price_desc"); total != 2 || len(ids) != 2 || ids[0] != pricey.ID || ids[1] != cheap.ID {
+		t.Fatalf("color filter + price sort: got %v (total %d)", ids, total)
+	}
+	if ids, _ := get("color=negro&size=s"); len(ids) != 1 || ids[0] != cheap.ID {
+		t.Fatalf("color+size must match the same variant: got %v", ids)
+	}
+	if ids, _ := get("sort=bestseller"); len(ids) != 3 || ids[0] != other.ID || ids[1] != cheap.ID {
+		t.Fatalf("bestseller sort: got %v", ids)
+	}
+	if ids, _ := get("tags=offer&max_price=2000"); len(ids) != 1 || ids[0] != cheap.ID {
+		t.Fatalf("tags + price range: got %v", ids)
+	}
+	if ids, _ := get("location=salta"); len(ids) != 1 || ids[0] != pricey.ID {
+		t.Fatalf("location availability: got %v", ids)
+	}
+	if ids, total := get("search=remera&sort=name_asc&limit=1&page=2"); total != 2 || len(ids) != 1 || ids[0] != cheap.ID {
+		t.Fatalf("search + name sort + paging: got %v (total %d)", ids, total)
+	}
+}
@@ -1,8 +1,12 @@
 package product
 
 import (
-	"fmt"
+	"time"
+
 	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/imaging"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/slug"
 
 	"gorm.io/gorm"
 )
@@ -11,16 +15,27 @@ type Product struct {
 	gorm.Model
 	Code          string               `json:"code" gorm:"unique"` // código único del producto
 	Name          string               `json:"name" gorm:"not null"`
+	Slug          string               `json:"slug" gorm:"type:varchar(160);index"` // ver slug.go
 	Description   string               `json:"description"`
 	CategoryID    uint                 `json:"category_id"`
 	Category      category.Category    `json:"category" gorm:"foreignKey:CategoryID"`
 	SubcategoryID uint                 `json:"subcategory_id"`
 	Subcategory   category.Subcategory `json:"subcategory" gorm:"foreignKey:SubcategoryID"`
+	// Categorías adicionales del árbol (la principal es CategoryID) y valores de sus atributos
+	Categories []category.Category      `json:"categories,omitempty" gorm:"many2many:product_categories;"`
+	Attributes []ProductAttributeValue `json:"attributes,omitempty" gorm:"foreignKey:ProductID"`
 	ImageURL      string               `json:"image_url"`
+	// Versiones procesadas (thumb/card/zoom, WebP) de image_url, image_model e image_hanger
+	ImageSet       *imaging.ImageSet `json:"image_set,omitempty" gorm:"type:text"`
+	ImageModelSet  *imaging.ImageSet `json:"image_model_set,omitempty" gorm:"type:text"`
+	ImageHangerSet *imaging.ImageSet `json:"image_hanger_set,omitempty" gorm:"type:text"`
 	// Nuevo: proveedor configurable por admin
 	SupplierID     *uint   `json:"supplier_id" gorm:"index"`
-	Season         string  `json:"season" gorm:"type:varchar(20)"` // e.g. SS, AW (deprecated, usar SeasonID)
-	SeasonID       *uint   `json:"season_id" gorm:"index"`         // FK a tabla seasons
+	SeasonID       *uint   `json:"season_id" gorm:"index"` // FK a tabla seasons
+	// Recurrente: básico que se repite cada temporada y se clona en el rollover (ver season.go)
+	Recurring bool `json:"recurring" gorm:"default:false"`
+	// Producto de la temporada anterior del que se clonó este
+	PreviousProductID *uint `json:"previous_product_id,omitempty" gorm:"index"`
 	Year           *int    `json:"year"`
 	SizeTypeID     *uint   `json:"size_type_id" gorm:"index"`
 	TotalStock     *int    `json:"total_stock"`  // optional total stock at product level
@@ -40,25 +55,36 @@ type Product struct {
 	IsTrending     bool             `json:"is_trending" gorm:"default:false"`
 	LocationStocks []LocationStock  `json:"location_stocks" gorm:"foreignKey:ProductID"`
 	Variants       []ProductVariant `json:"variants" gorm:"foreignKey:ProductID"`
+	Images         []ProductImage   `json:"images,omitempty" gorm:"foreignKey:ProductID"`
 	VariantType    string           `json:"variant_type" gorm:"type:varchar(20);not null;default:'sin_variantes'"`
+	// Ciclo de vida (ver status.go). Los productos nuevos se publican salvo que se cree
+	// con otro estado (ej. draft desde el alta); el default de la columna mantiene
+	// publicados los productos existentes al migrar.
+	Status     string     `json:"status" gorm:"type:varchar(20);not null;default:'published';index"`
+	PublishAt  *time.Time `json:"publish_at"`  // publicación programada
+	ArchivedAt *time.Time `json:"archived_at"` // cuándo se discontinuó
 }
 
 // BeforeCreate hook para generar código único automáticamente si no se proporciona
 func (p *Product) BeforeCreate(tx *gorm.DB) error {
+	if p.Status == "" {
+		p.Status = StatusPublished
+	}
 	if p.Code == "" {
-		// Buscar el código más alto usado (incluyendo productos eliminados)
-		var lastProduct Product
-		tx.Unscoped().Order("code DESC").Limit(1).Find(&lastProduct)
-
-		nextNum := 1
-		if lastProduct.Code != "" {
-			// Extraer el número del código (formato: PROD-000001)
-			var num int
-			if _, err := fmt.Sscanf(lastProduct.Code, "PROD-%d", &num); err == nil {
-				nextNum = num + 1
-			}
+		// El número sale de la secuencia "product" (formato: PROD-000001),
+		// incrementada en la misma transacción que inserta el producto
+		code, err := sequence.Next(tx, sequence.Product)
+		if err != nil {
+			return err
 		}
-		p.Code = fmt.Sprintf("PROD-%06d", nextNum)
+		p.Code = code
+	}
+	if p.Slug == "" {
+		s, err := slug.Unique(tx, slug.EntityProduct, p.Name, 0)
+		if err != nil {
+			return err
+		}
+		p.Slug = s
 	}
 	return nil
 }
@@ -87,8 +113,28 @@ type Season struct {
 	gorm.Model
 	Code   string `json:"code" gorm:"not null;unique"` // ej: SS25, AW25
 	Name   string `json:"name" gorm:"not null"`        // ej: "Primavera/Verano 2025"
+	Slug   string `json:"slug" gorm:"type:varchar(160);index"`
 	Year   int    `json:"year" gorm:"not null"`
-	Active bool   `json:"active" gorm:"default:true"`
+	Active bool   `json:"active" gorm:"default:true"` // false cuando la temporada está cerrada
+	// Rango de fechas y ciclo de vida (ver season.go). ClearanceAt es cuándo empieza la
+	// liquidación; ClearanceDiscount el % que se aplica a sus productos al liquidar.
+	StartsAt          *time.Time `json:"starts_at"`
+	EndsAt            *time.Time `json:"ends_at"`
+	ClearanceAt       *time.Time `json:"clearance_at"`
+	Status            string     `json:"status" gorm:"type:varchar(20);not null;default:'current';index"`
+	ClearanceDiscount float64    `json:"clearance_discount"`
+}
+
+// BeforeCreate genera el slug de la temporada a partir del nombre
+func (s *Season) BeforeCreate(tx *gorm.DB) error {
+	if s.Slug == "" {
+		unique, err := slug.Unique(tx, slug.EntitySeason, s.Name, 0)
+		if err != nil {
+			return err
+		}
+		s.Slug = unique
+	}
+	return nil
 }
 
 type SizeType struct {
@@ -0,0 +1,208 @@
+package product
+
+import (
+	"fmt"
+	"go-modaMayor/config"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReorderPoint define los niveles de stock deseados para una variante en una ubicación.
+// Cuando el disponible (stock - reserved) cae a ReorderPoint o menos se genera una alerta;
+// la reposición sugerida lleva el disponible hasta MaxStock.
+type ReorderPoint struct {
+	gorm.Model
+	ProductID    uint   `json:"product_id" gorm:"index;not null"`
+	VariantID    uint   `json:"variant_id" gorm:"uniqueIndex:idx_reorder_variant_location;not null"`
+	Location     string `json:"location" gorm:"type:varchar(50);uniqueIndex:idx_reorder_variant_location;not null"`
+	MinStock     int    `json:"min_stock" gorm:"default:0"`
+	MaxStock     int    `json:"max_stock" gorm:"default:0"`
+	ReorderPoint int    `json:"reorder_point" gorm:"default:0"`
+}
+
+// Trigger devuelve el umbral que dispara la alerta (reorder_point, o min_stock si no se definió)
+func (rp *ReorderPoint) Trigger() int {
+	if rp.ReorderPoint > 0 {
+		return rp.ReorderPoint
+	}
+	return rp.MinStock
+}
+
+// Target devuelve el nivel al que se quiere reponer (max_stock, o el doble del umbral si no se definió)
+func (rp *ReorderPoint) Target() int {
+	if rp.MaxStock > 0 {
+		return rp.MaxStock
+	}
+	return rp.Trigger() * 2
+}
+
+// ListReorderPoints lista puntos de reposición con filtros opcionales por producto, variante y ubicación
+func ListReorderPoints(c *gin.Context) {
+	query := config.DB.Model(&ReorderPoint{})
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if variantID := c.Query("variant_id"); variantID != "" {
+		query = query.Where("variant_id = ?", variantID)
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location = ?", location)
+	}
+	var points []ReorderPoint
+	if err := query.Order("product_id, variant_id, location").Find(&points).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, points)
+}
+
+type ReorderPointInput struct {
+	VariantID    uint   `json:"variant_id" binding:"required,gt=0"`
+	Location     string `json:"location" binding:"required"`
+	MinStock     int    `json:"min_stock" binding:"gte=0"`
+	MaxStock     int    `json:"max_stock" binding:"gte=0"`
+	ReorderPoint int    `json:"reorder_point" binding:"gte=0"`
+}
+
+type UpsertReorderPointsInput struct {
+	Points []ReorderPointInput `json:"points" binding:"required,dive"`
+}
+
+// UpsertReorderPoints crea o actualiza puntos de reposición por variante y ubicación.
+// Ruta: PUT /inventory/reorder-points
+func UpsertReorderPoints(c *gin.Context) {
+	var input UpsertReorderPointsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]ReorderPoint, 0, len(input.Points))
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, p := range input.Points {
+			if p.MaxStock > 0 && p.MaxStock < p.ReorderPoint {
+				return fmt.Errorf("max_stock debe ser mayor o igual a reorder_point (variante %d)", p.VariantID)
+			}
+			var v ProductVariant
+			if err := tx.First(&v, p.VariantID).Error; err != nil {
+				return fmt.Errorf("Variante %d no encontrada", p.VariantID)
+			}
+			rp := ReorderPoint{
+				ProductID:    v.ProductID,
+				VariantID:    v.ID,
+				Location:     p.Location,
+				MinStock:     p.MinStock,
+				MaxStock:     p.MaxStock,
+				ReorderPoint: p.ReorderPoint,
+			}
+			// El índice único incluye los puntos eliminados: volver a cargarlo los reactiva
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "variant_id"}, {Name: "location"}},
+				DoUpdates: clause.AssignmentColumns([]string{"min_stock", "max_stock", "reorder_point", "updated_at", "deleted_at"}),
+			}).Create(&rp).Error; err != nil {
+				return err
+			}
+			results = append(results, rp)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// DeleteReorderPoint elimina un punto de reposición
+func DeleteReorderPoint(c *gin.Context) {
+	id := c.Param("id")
+	if err := config.DB.Delete(&ReorderPoint{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Punto de reposición eliminado"})
+}
+
+// LowStockRow es una variante/ubicación cuyo disponible está en o por debajo de su umbral
+type LowStockRow struct {
+	ProductID    uint   `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	VariantID    uint   `json:"variant_id"`
+	SKU          string `json:"sku"`
+	Color        string `json:"color"`
+	Size         string `json:"size"`
+	Location     string `json:"location"`
+	Stock        int    `json:"stock"`
+	Reserved     int    `json:"reserved"`
+	Available    int    `json:"available"`
+	Threshold    int    `json:"threshold"`
+	HasReorderPt bool   `json:"has_reorder_point"`
+}
+
+// DefaultLowStockThreshold se usa para variantes sin punto de reposición configurado
+const DefaultLowStockThreshold = 5
+
+// FindLowStock devuelve las variantes por ubicación con disponible <= umbral.
+// Usa el punto de reposición si existe, o defaultThreshold en caso contrario
+// (defaultThreshold < 0 limita el resultado a variantes con punto configurado).
+func FindLowStock(db *gorm.DB, defaultThreshold int) ([]LowStockRow, error) {
+	type row struct {
+		ProductID      uint
+		ProductName    string
+		VariantID      uint
+		SKU            string
+		Color          string
+		Size           string
+		Location       string
+		Stock          int
+		Reserved       int
+		ReorderPointID *uint
+		MinStock       int
+		ReorderPoint   int
+	}
+	var rows []row
+	err := db.Table("location_stocks ls").
+		Select("ls.product_id, products.name as product_name, ls.variant_id, pv.sku, pv.color, pv.size, ls.location, ls.stock, ls.reserved, rp.id as reorder_point_id, COALESCE(rp.min_stock,0) as min_stock, COALESCE(rp.reorder_point,0) as reorder_point").
+		Joins("JOIN product_variants pv ON pv.id = ls.variant_id AND pv.deleted_at IS NULL").
+		Joins("JOIN products ON products.id = ls.product_id AND products.deleted_at IS NULL").
+		Joins("LEFT JOIN reorder_points rp ON rp.variant_id = ls.variant_id AND rp.location = ls.location AND rp.deleted_at IS NULL").
+		Where("ls.deleted_at IS NULL AND ls.variant_id IS NOT NULL").
+		Order("ls.product_id, ls.variant_id, ls.location").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]LowStockRow, 0)
+	for _, r := range rows {
+		threshold := defaultThreshold
+		if r.ReorderPointID != nil {
+			rp := ReorderPoint{MinStock: r.MinStock, ReorderPoint: r.ReorderPoint}
+			threshold = rp.Trigger()
+		} else if defaultThreshold < 0 {
+			continue
+		}
+		available := r.Stock - r.Reserved
+		if available > threshold {
+			continue
+		}
+		out = append(out, LowStockRow{
+			ProductID:    r.ProductID,
+			ProductName:  r.ProductName,
+			VariantID:    r.VariantID,
+			SKU:          r.SKU,
+			Color:        r.Color,
+			Size:         r.Size,
+			Location:     r.Location,
+			Stock:        r.Stock,
+			Reserved:     r.Reserved,
+			Available:    available,
+			Threshold:    threshold,
+			HasReorderPt: r.ReorderPointID != nil,
+		})
+	}
+	return out, nil
+}
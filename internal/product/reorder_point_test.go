@@ -0,0 +1,61 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpsertReorderPoints_RestoresDeletedPoint(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&ReorderPoint{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	p := Product{Name: "Remera Reposición", Code: "TEST-RP-1"}
+	db.Create(&p)
+	v := ProductVariant{ProductID: p.ID, SKU: "TEST-RP-1-M", Size: "M"}
+	db.Create(&v)
+
+	router := gin.New()
+	router.GET("/inventory/reorder-points", ListReorderPoints)
+	router.PUT("/inventory/reorder-points", UpsertReorderPoints)
+	router.DELETE("/inventory/reorder-points/:id", DeleteReorderPoint)
+	upsert := func(point int) {
+		body := fmt.Sprintf(`{"points":[{"variant_id":%d,"location":"deposito","reorder_point":%d}]}`, v.ID, point)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/inventory/reorder-points", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("upsert: %d %s", w.Code, w.Body.String())
+		}
+	}
+	list := func() []ReorderPoint {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/inventory/reorder-points?variant_id=%d", v.ID), nil))
+		var points []ReorderPoint
+		json.Unmarshal(w.Body.Bytes(), &points)
+		return points
+	}
+
+	upsert(5)
+	points := list()
+	if len(points) != 1 {
+		t.Fatalf("expected 1 point, got %d", len(points))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/inventory/reorder-points/%d", points[0].ID), nil))
+	if len(list()) != 0 {
+		t.Fatal("deleted point should not be listed")
+	}
+
+	// Volver a cargar la misma variante/ubicación la reactiva con los valores nuevos
+	upsert(8)
+	points = list()
+	if len(points) != 1 || points[0].ReorderPoint != 8 {
+		t.Fatalf("expected the point to be restored with reorder_point 8, got %+v", points)
+	}
+}
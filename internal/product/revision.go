@@ -0,0 +1,503 @@
+package product
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/imaging"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Acciones registradas en el historial del producto
+const (
+	RevisionInitial  = "initial" // estado previo a la primera revisión registrada
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionDiscount = "discount"
+	RevisionPrices   = "prices"
+	RevisionStatus   = "status"
+	RevisionImages   = "images"
+	RevisionVariants = "variants"
+	RevisionRestore  = "restore"
+)
+
+// ProductRevision guarda el estado del producto después de un cambio
+// (datos, precios, tags, variantes e imágenes) y el diff contra el estado anterior.
+type ProductRevision struct {
+	gorm.Model
+	ProductID uint            `json:"product_id" gorm:"index;not null"`
+	Number    int             `json:"number" gorm:"not null"`
+	Action    string          `json:"action" gorm:"size:20;not null"`
+	UserID    *uint           `json:"user_id"`
+	UserName  string          `json:"user_name"`
+	Changes   FieldChanges    `json:"changes" gorm:"type:text"`
+	Snapshot  ProductSnapshot `json:"snapshot,omitempty" gorm:"type:text"`
+	// Revisión restaurada (sólo para action=restore)
+	RestoredFromID *uint `json:"restored_from_id,omitempty"`
+}
+
+// ProductSnapshot es el estado versionado de un producto
+type ProductSnapshot struct {
+	Product  SnapshotProduct   `json:"product"`
+	Variants []SnapshotVariant `json:"variants"`
+	Images   []SnapshotImage   `json:"images"`
+}
+
+type SnapshotProduct struct {
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	CategoryID     uint              `json:"category_id"`
+	SubcategoryID  uint              `json:"subcategory_id"`
+	SupplierID     *uint             `json:"supplier_id"`
+	SeasonID       *uint             `json:"season_id"`
+	Year           *int              `json:"year"`
+	SizeTypeID     *uint             `json:"size_type_id"`
+	TotalStock     *int              `json:"total_stock"`
+	VariantType    string            `json:"variant_type"`
+	CostPrice      float64           `json:"cost_price"`
+	WholesalePrice float64           `json:"wholesale_price"`
+	Discount1Price float64           `json:"discount1_price"`
+	Discount2Price float64           `json:"discount2_price"`
+	DiscountType   string            `json:"discount_type"`
+	DiscountValue  float64           `json:"discount_value"`
+	IsNewArrival   bool              `json:"is_new_arrival"`
+	IsFeatured     bool              `json:"is_featured"`
+	IsOffer        bool              `json:"is_offer"`
+	IsTrending     bool              `json:"is_trending"`
+	Recurring      bool              `json:"recurring"`
+	Status         string            `json:"status"`
+	PublishAt      *time.Time        `json:"publish_at"`
+	ImageURL       string            `json:"image_url"`
+	ImageModel     string            `json:"image_model"`
+	ImageHanger    string            `json:"image_hanger"`
+	ImageSet       *imaging.ImageSet `json:"image_set,omitempty"`
+	ImageModelSet  *imaging.ImageSet `json:"image_model_set,omitempty"`
+	ImageHangerSet *imaging.ImageSet `json:"image_hanger_set,omitempty"`
+	// Categorías adicionales y valores de atributos por clave
+	CategoryIDs []uint              `json:"category_ids"`
+	Attributes  map[string][]string `json:"attributes"`
+}
+
+type SnapshotVariant struct {
+	ID          uint              `json:"id"`
+	SKU         string            `json:"sku"`
+	Color       string            `json:"color"`
+	Size        string            `json:"size"`
+	ColorID     *uint             `json:"color_id"`
+	SizeValueID *uint             `json:"size_value_id"`
+	ImageURL    string            `json:"image_url"`
+	ImageSet    *imaging.ImageSet `json:"image_set,omitempty"`
+}
+
+type SnapshotImage struct {
+	ID       uint              `json:"id"`
+	Role     string            `json:"role"`
+	Color    string            `json:"color"`
+	Position int               `json:"position"`
+	AltText  string            `json:"alt_text"`
+	URL      string            `json:"url"`
+	ImageSet *imaging.ImageSet `json:"image_set,omitempty"`
+}
+
+// FieldChange es un campo modificado. Path usa "campo", "variants[<id>].campo" o "images[<id>].campo".
+type FieldChange struct {
+	Path   string `json:"path"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+type FieldChanges []FieldChange
+
+// Value serializa el snapshot como JSON
+func (s ProductSnapshot) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan lee el snapshot desde JSON
+func (s *ProductSnapshot) Scan(value any) error {
+	return scanJSON(value, s)
+}
+
+// Value serializa los cambios como JSON
+func (f FieldChanges) Value() (driver.Value, error) {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan lee los cambios desde JSON
+func (f *FieldChanges) Scan(value any) error {
+	return scanJSON(value, f)
+}
+
+func scanJSON(value any, dst any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("tipo no soportado para %T: %T", dst, value)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// CaptureRevision devuelve el estado actual del producto para compararlo después
+// de un cambio; nil si no se pudo leer (el historial nunca bloquea la operación).
+func CaptureRevision(db *gorm.DB, productID uint) *ProductSnapshot {
+	snap, err := loadSnapshot(db, productID)
+	if err != nil {
+		return nil
+	}
+	return snap
+}
+
+func loadSnapshot(db *gorm.DB, productID uint) (*ProductSnapshot, error) {
+	var p Product
+	if err := db.First(&p, productID).Error; err != nil {
+		return nil, err
+	}
+	snap := &ProductSnapshot{
+		Product: SnapshotProduct{
+			Name: p.Name, Description: p.Description, CategoryID: p.CategoryID, SubcategoryID: p.SubcategoryID,
+			SupplierID: p.SupplierID, SeasonID: p.SeasonID, Year: p.Year, SizeTypeID: p.SizeTypeID, TotalStock: p.TotalStock,
+			VariantType: p.VariantType, CostPrice: p.CostPrice, WholesalePrice: p.WholesalePrice,
+			Discount1Price: p.Discount1Price, Discount2Price: p.Discount2Price, DiscountType: p.DiscountType, DiscountValue: p.DiscountValue,
+			IsNewArrival: p.IsNewArrival, IsFeatured: p.IsFeatured, IsOffer: p.IsOffer, IsTrending: p.IsTrending,
+			Recurring: p.Recurring, Status: p.Status, PublishAt: p.PublishAt,
+			ImageURL: p.ImageURL, ImageModel: p.ImageModel, ImageHanger: p.ImageHanger,
+			ImageSet: p.ImageSet, ImageModelSet: p.ImageModelSet, ImageHangerSet: p.ImageHangerSet,
+		},
+		Variants: []SnapshotVariant{},
+		Images:   []SnapshotImage{},
+	}
+	snap.Product.CategoryIDs = []uint{}
+	if err := db.Table("product_categories").Where("product_id = ?", productID).Order("category_id").Pluck("category_id", &snap.Product.CategoryIDs).Error; err != nil {
+		return nil, err
+	}
+	attrs, err := productAttributeValues(db, productID)
+	if err != nil {
+		return nil, err
+	}
+	snap.Product.Attributes = attrs
+	var variants []ProductVariant
+	if err := db.Where("product_id = ?", productID).Order("id").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		snap.Variants = append(snap.Variants, SnapshotVariant{ID: v.ID, SKU: v.SKU, Color: v.Color, Size: v.Size,
+			ColorID: v.ColorID, SizeValueID: v.SizeValueID, ImageURL: v.ImageURL, ImageSet: v.ImageSet})
+	}
+	var images []ProductImage
+	if err := db.Where("product_id = ?", productID).Order("position, id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	for _, img := range images {
+		snap.Images = append(snap.Images, SnapshotImage{ID: img.ID, Role: img.Role, Color: img.Color, Position: img.Position, AltText: img.AltText, URL: img.URL, ImageSet: img.ImageSet})
+	}
+	return snap, nil
+}
+
+// flattenSnapshot lleva el snapshot a pares ruta → valor (JSON). Los image_set
+// se omiten del diff porque cambian junto con la URL correspondiente.
+func flattenSnapshot(s *ProductSnapshot) map[string]any {
+	out := map[string]any{}
+	if s == nil {
+		return out
+	}
+	flattenInto(out, "", s.Product)
+	for _, v := range s.Variants {
+		flattenInto(out, fmt.Sprintf("variants[%d].", v.ID), v)
+	}
+	for _, img := range s.Images {
+		flattenInto(out, fmt.Sprintf("images[%d].", img.ID), img)
+	}
+	return out
+}
+
+func flattenInto(out map[string]any, prefix string, v any) {
+	data, _ := json.Marshal(v)
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	for k, val := range fields {
+		if k == "id" || strings.HasSuffix(k, "_set") {
+			continue
+		}
+		out[prefix+k] = val
+	}
+}
+
+// diffSnapshots devuelve los campos que cambiaron, ordenados por ruta
+func diffSnapshots(before, after *ProductSnapshot) FieldChanges {
+	a, b := flattenSnapshot(before), flattenSnapshot(after)
+	paths := map[string]bool{}
+	for k := range a {
+		paths[k] = true
+	}
+	for k := range b {
+		paths[k] = true
+	}
+	changes := FieldChanges{}
+	for path := range paths {
+		if !reflect.DeepEqual(a[path], b[path]) {
+			changes = append(changes, FieldChange{Path: path, Before: a[path], After: b[path]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// revisionUser toma el usuario del contexto (user_name o, si no hay, user_email)
+func revisionUser(c *gin.Context) (*uint, string) {
+	if c == nil {
+		return nil, ""
+	}
+	var userID *uint
+	if v, ok := c.Get("user_id"); ok {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+	name := c.GetString("user_name")
+	if name == "" {
+		name = c.GetString("user_email")
+	}
+	return userID, name
+}
+
+// saveRevision registra el estado actual del producto comparándolo con before.
+// Si el producto todavía no tenía historial, antes se guarda before como revisión
+// inicial para poder volver al estado anterior al primer cambio registrado.
+// Devuelve nil sin error si no hubo cambios. Tiene que correr dentro de una
+// transacción: bloquea la fila del producto para que dos cambios simultáneos no
+// tomen el mismo número de revisión.
+func saveRevision(db *gorm.DB, c *gin.Context, productID uint, action string, before *ProductSnapshot, restoredFrom *uint) (*ProductRevision, error) {
+	var locked []uint
+	if err := db.Unscoped().Model(&Product{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", productID).Pluck("id", &locked).Error; err != nil {
+		return nil, err
+	}
+	after, err := loadSnapshot(db, productID)
+	if err != nil {
+		return nil, err
+	}
+	var last ProductRevision
+	err = db.Where("product_id = ?", productID).Order("number DESC").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		last = ProductRevision{}
+		if before != nil {
+			last = ProductRevision{ProductID: productID, Number: 1, Action: RevisionInitial, Changes: FieldChanges{}, Snapshot: *before}
+			if err := db.Create(&last).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	if before == nil && last.ID != 0 {
+		before = &last.Snapshot
+	}
+	changes := diffSnapshots(before, after)
+	if len(changes) == 0 && action != RevisionCreate && action != RevisionRestore {
+		return nil, nil
+	}
+	userID, userName := revisionUser(c)
+	rev := ProductRevision{ProductID: productID, Number: last.Number + 1, Action: action, UserID: userID, UserName: userName,
+		Changes: changes, Snapshot: *after, RestoredFromID: restoredFrom}
+	if err := db.Create(&rev).Error; err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// RecordRevision registra un cambio del producto en su historial. Los errores se
+// loguean y no interrumpen la operación que originó el cambio: la revisión se graba
+// en su propia transacción, o en un savepoint si db ya es una transacción, así un
+// insert fallido no aborta la transacción de quien llama.
+func RecordRevision(db *gorm.DB, c *gin.Context, productID uint, action string, before *ProductSnapshot) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := saveRevision(tx, c, productID, action, before, nil)
+		return err
+	})
+	if err != nil {
+		log.Printf("revisión de producto %d (%s): %v", productID, action, err)
+	}
+}
+
+// ListProductRevisions devuelve el historial del producto (sin snapshots), del más nuevo al más viejo
+// GET /products/:id/revisions
+func ListProductRevisions(c *gin.Context) {
+	var revisions []ProductRevision
+	if err := config.DB.Omit("snapshot").Where("product_id = ?", c.Param("id")).Order("number DESC").Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetProductRevision devuelve una revisión con su snapshot y el diff contra el estado actual
+// (lo que cambiaría al restaurarla)
+// GET /products/:id/revisions/:revisionId
+func GetProductRevision(c *gin.Context) {
+	var rev ProductRevision
+	if err := config.DB.Where("product_id = ?", c.Param("id")).First(&rev, c.Param("revisionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revisión no encontrada"})
+		return
+	}
+	current, err := loadSnapshot(config.DB, rev.ProductID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revision": rev, "restore_changes": diffSnapshots(current, &rev.Snapshot)})
+}
+
+// RestoreProductRevision vuelve el producto, sus variantes e imágenes al estado de la revisión.
+// Las variantes creadas después de la revisión se conservan (pueden tener stock o pedidos);
+// se informan en kept_variant_ids. El restore queda registrado como una revisión nueva.
+// POST /products/:id/revisions/:revisionId/restore
+func RestoreProductRevision(c *gin.Context) {
+	var rev ProductRevision
+	if err := config.DB.Where("product_id = ?", c.Param("id")).First(&rev, c.Param("revisionId")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revisión no encontrada"})
+		return
+	}
+	var created *ProductRevision
+	var kept []uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := loadSnapshot(tx, rev.ProductID)
+		if err != nil {
+			return err
+		}
+		if kept, err = applySnapshot(tx, rev.ProductID, &rev.Snapshot); err != nil {
+			return err
+		}
+		created, err = saveRevision(tx, c, rev.ProductID, RevisionRestore, before, &rev.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revision": created, "kept_variant_ids": kept})
+}
+
+// applySnapshot escribe el snapshot sobre el producto. Devuelve los ids de variantes
+// actuales que no estaban en el snapshot.
+func applySnapshot(tx *gorm.DB, productID uint, snap *ProductSnapshot) ([]uint, error) {
+	p := snap.Product
+	if err := tx.Model(&Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"name": p.Name, "description": p.Description, "category_id": p.CategoryID, "subcategory_id": p.SubcategoryID,
+		"supplier_id": p.SupplierID, "season_id": p.SeasonID, "year": p.Year, "size_type_id": p.SizeTypeID, "total_stock": p.TotalStock,
+		"variant_type": p.VariantType, "cost_price": p.CostPrice, "wholesale_price": p.WholesalePrice,
+		"discount1_price": p.Discount1Price, "discount2_price": p.Discount2Price, "discount_type": p.DiscountType, "discount_value": p.DiscountValue,
+		"is_new_arrival": p.IsNewArrival, "is_featured": p.IsFeatured, "is_offer": p.IsOffer, "is_trending": p.IsTrending,
+		"recurring": p.Recurring, "status": p.Status, "publish_at": p.PublishAt,
+		"image_url": p.ImageURL, "image_model": p.ImageModel, "image_hanger": p.ImageHanger,
+		"image_set": p.ImageSet, "image_model_set": p.ImageModelSet, "image_hanger_set": p.ImageHangerSet,
+	}).Error; err != nil {
+		return nil, err
+	}
+	// Categorías y atributos: los que ya no existen se omiten
+	product := Product{}
+	if err := tx.First(&product, productID).Error; err != nil {
+		return nil, err
+	}
+	var catIDs []uint
+	if len(p.CategoryIDs) > 0 {
+		if err := tx.Model(&category.Category{}).Where("id IN ?", p.CategoryIDs).Pluck("id", &catIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := SetProductCategories(tx, &product, catIDs); err != nil {
+		return nil, err
+	}
+	if err := restoreAttributeValues(tx, &product, p.Attributes); err != nil {
+		return nil, err
+	}
+
+	inSnapshot := map[uint]bool{}
+	for _, v := range snap.Variants {
+		inSnapshot[v.ID] = true
+		// Unscoped: la variante pudo haberse eliminado después de la revisión
+		res := tx.Unscoped().Model(&ProductVariant{}).Where("id = ? AND product_id = ?", v.ID, productID).Updates(map[string]interface{}{
+			"deleted_at": nil, "sku": v.SKU, "color": v.Color, "size": v.Size, "color_id": v.ColorID, "size_value_id": v.SizeValueID,
+			"image_url": v.ImageURL, "image_set": v.ImageSet,
+		})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			variant := ProductVariant{ProductID: productID, SKU: v.SKU, Color: v.Color, Size: v.Size, ColorID: v.ColorID, SizeValueID: v.SizeValueID, ImageURL: v.ImageURL, ImageSet: v.ImageSet}
+			variant.ID = v.ID
+			if err := assignBarcode(tx, &variant); err != nil {
+				return nil, err
+			}
+			if err := tx.Create(&variant).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	var current []ProductVariant
+	if err := tx.Where("product_id = ?", productID).Order("id").Find(&current).Error; err != nil {
+		return nil, err
+	}
+	kept := []uint{}
+	for _, v := range current {
+		if !inSnapshot[v.ID] {
+			kept = append(kept, v.ID)
+		}
+	}
+
+	imageIDs := []uint{}
+	for _, img := range snap.Images {
+		imageIDs = append(imageIDs, img.ID)
+		res := tx.Unscoped().Model(&ProductImage{}).Where("id = ? AND product_id = ?", img.ID, productID).Updates(map[string]interface{}{
+			"deleted_at": nil, "role": img.Role, "color": img.Color, "position": img.Position, "alt_text": img.AltText, "url": img.URL, "image_set": img.ImageSet,
+		})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			image := ProductImage{ProductID: productID, Role: img.Role, Color: img.Color, Position: img.Position, AltText: img.AltText, URL: img.URL, ImageSet: img.ImageSet}
+			image.ID = img.ID
+			if err := tx.Create(&image).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	extra := tx.Where("product_id = ?", productID)
+	if len(imageIDs) > 0 {
+		extra = extra.Where("id NOT IN ?", imageIDs)
+	}
+	if err := extra.Delete(&ProductImage{}).Error; err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
@@ -0,0 +1,94 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProductRevisions_DiffAndRestore(t *testing.T) {
+	db := setupTestDB(t)
+	prod := Product{Name: "Remera", Code: "TEST-R-1", WholesalePrice: 1000, Status: StatusPublished}
+	db.Create(&prod)
+	variant := ProductVariant{ProductID: prod.ID, SKU: "rev-1-negro", Color: "Negro"}
+	db.Create(&variant)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", uint(7)); c.Set("user_email", "admin@test") })
+	router.PUT("/products/:id", UpdateProduct)
+	router.DELETE("/variants/:id", DeleteVariant)
+	router.GET("/products/:id/revisions", ListProductRevisions)
+	router.POST("/products/:id/revisions/:revisionId/restore", RestoreProductRevision)
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPut, fmt.Sprintf("/products/%d", prod.ID), gin.H{"name": "Remera Lisa", "wholesale_price": 1200, "is_offer": true}); w.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, fmt.Sprintf("/variants/%d", variant.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("delete variant: %d %s", w.Code, w.Body.String())
+	}
+
+	w := do(http.MethodGet, fmt.Sprintf("/products/%d/revisions", prod.ID), nil)
+	var revisions []ProductRevision
+	json.Unmarshal(w.Body.Bytes(), &revisions)
+	if len(revisions) != 3 {
+		t.Fatalf("expected initial + 2 revisions, got %d: %s", len(revisions), w.Body.String())
+	}
+	update, initial := revisions[1], revisions[2]
+	if initial.Action != RevisionInitial || update.Action != RevisionUpdate || revisions[0].Action != RevisionVariants {
+		t.Fatalf("unexpected actions: %s %s %s", revisions[0].Action, update.Action, initial.Action)
+	}
+	if update.UserID == nil || *update.UserID != 7 || update.UserName != "admin@test" {
+		t.Fatalf("revision author not recorded: %+v %q", update.UserID, update.UserName)
+	}
+	paths := map[string]FieldChange{}
+	for _, ch := range update.Changes {
+		paths[ch.Path] = ch
+	}
+	if len(paths) != 3 || paths["name"].Before != "Remera" || paths["name"].After != "Remera Lisa" || paths["wholesale_price"].After != float64(1200) {
+		t.Fatalf("unexpected diff: %+v", update.Changes)
+	}
+	removed := fmt.Sprintf("variants[%d].sku", variant.ID)
+	if ch, ok := findChange(revisions[0].Changes, removed); !ok || ch.After != nil {
+		t.Fatalf("variant removal not in diff: %+v", revisions[0].Changes)
+	}
+
+	// Restaurar la revisión inicial devuelve datos y variante
+	if w := do(http.MethodPost, fmt.Sprintf("/products/%d/revisions/%d/restore", prod.ID, initial.ID), nil); w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body.String())
+	}
+	var restored Product
+	db.Preload("Variants").First(&restored, prod.ID)
+	if restored.Name != "Remera" || restored.WholesalePrice != 1000 || restored.IsOffer {
+		t.Fatalf("product not restored: %+v", restored)
+	}
+	if len(restored.Variants) != 1 || restored.Variants[0].ID != variant.ID {
+		t.Fatalf("variant not restored: %+v", restored.Variants)
+	}
+	var last ProductRevision
+	db.Where("product_id = ?", prod.ID).Order("number DESC").First(&last)
+	if last.Action != RevisionRestore || last.RestoredFromID == nil || *last.RestoredFromID != initial.ID {
+		t.Fatalf("restore not recorded: %+v", last)
+	}
+}
+
+func findChange(changes FieldChanges, path string) (FieldChange, bool) {
+	for _, ch := range changes {
+		if ch.Path == path {
+			return ch, true
+		}
+	}
+	return FieldChange{}, false
+}
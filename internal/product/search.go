@@ -0,0 +1,203 @@
+package product
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// SearchHit es un producto que coincide con la búsqueda y su puntaje de relevancia
+type SearchHit struct {
+	ID    uint
+	Score float64
+}
+
+// SearchProductIDs devuelve los productos que coinciden con term ordenados por relevancia.
+// En Postgres usa el tsvector products.search_vector (configuración spanish + unaccent)
+// más similitud de trigramas (pg_trgm) para tolerar errores de tipeo ("remerra").
+// En otros motores (sqlite en tests) puntúa en memoria con la misma normalización.
+func SearchProductIDs(db *gorm.DB, term string) ([]SearchHit, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil, nil
+	}
+	var hits []SearchHit
+	var err error
+	if db.Dialector.Name() == "postgres" {
+		hits, err = searchPostgres(db, term)
+	} else {
+		hits, err = searchInMemory(db, term)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	return hits, nil
+}
+
+// searchPostgres requiere la migración 20261019_05_product_full_text_search.sql. Cada
+// rama de matches usa su índice: GIN de search_vector (que ya incluye categoría y
+// subcategoría), trigramas del nombre con <% y trigramas del SKU con LIKE.
+func searchPostgres(db *gorm.DB, term string) ([]SearchHit, error) {
+	const query = `
+WITH matches AS (
+	SELECT id FROM products WHERE search_vector @@ websearch_to_tsquery('spanish', f_unaccent(@term))
+	UNION
+	SELECT id FROM products WHERE lower(f_unaccent(@term)) <% lower(f_unaccent(name))
+	UNION
+	SELECT product_id FROM product_variants WHERE deleted_at IS NULL AND lower(sku) LIKE @like
+)
+SELECT p.id,
+	ts_rank(p.search_vector, websearch_to_tsquery('spanish', f_unaccent(@term))) * 4
+		+ word_similarity(lower(f_unaccent(@term)), lower(f_unaccent(p.name))) AS score
+FROM products p
+JOIN matches m ON m.id = p.id
+WHERE p.deleted_at IS NULL`
+	var hits []SearchHit
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Umbral de <% para tolerar errores de tipeo en el nombre (por defecto 0.6)
+		if err := tx.Exec("SET LOCAL pg_trgm.word_similarity_threshold = 0.5").Error; err != nil {
+			return err
+		}
+		return tx.Raw(query, map[string]interface{}{
+			"term": term,
+			"like": "%" + strings.ToLower(term) + "%",
+		}).Scan(&hits).Error
+	})
+	return hits, err
+}
+
+func searchInMemory(db *gorm.DB, term string) ([]SearchHit, error) {
+	type doc struct {
+		ID          uint
+		Name        string
+		Code        string
+		Description string
+		Category    string
+		Subcategory string
+	}
+	var docs []doc
+	if err := db.Table("products").
+		Select("products.id, products.name, products.code, products.description, categories.name as category, subcategories.name as subcategory").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Joins("LEFT JOIN subcategories ON subcategories.id = products.subcategory_id").
+		Where("products.deleted_at IS NULL").
+		Scan(&docs).Error; err != nil {
+		return nil, err
+	}
+	type skuRow struct {
+		ProductID uint
+		SKU       string
+	}
+	var skus []skuRow
+	if err := db.Table("product_variants").Select("product_id, sku").Where("deleted_at IS NULL").Scan(&skus).Error; err != nil {
+		return nil, err
+	}
+	skusByProduct := make(map[uint][]string)
+	for _, s := range skus {
+		skusByProduct[s.ProductID] = append(skusByProduct[s.ProductID], strings.ToLower(s.SKU))
+	}
+
+	terms := searchTokens(term)
+	lowerTerm := strings.ToLower(term)
+	hits := make([]SearchHit, 0)
+	for _, d := range docs {
+		name := searchTokens(d.Name + " " + d.Code)
+		cats := searchTokens(d.Category + " " + d.Subcategory)
+		desc := searchTokens(d.Description)
+		score := 0.0
+		matched := 0
+		for _, t := range terms {
+			s := tokenScore(t, name)*3 + tokenScore(t, cats)*2 + tokenScore(t, desc)
+			if s > 0 {
+				matched++
+			}
+			score += s
+		}
+		for _, sku := range skusByProduct[d.ID] {
+			if strings.Contains(sku, lowerTerm) {
+				score += 3
+				matched = len(terms)
+				break
+			}
+		}
+		// Todas las palabras deben coincidir (como el AND de tsquery)
+		if matched < len(terms) || score == 0 {
+			continue
+		}
+		hits = append(hits, SearchHit{ID: d.ID, Score: score})
+	}
+	return hits, nil
+}
+
+// tokenScore devuelve 1 por coincidencia exacta, 0.75 por prefijo y 0.5 por
+// coincidencia aproximada (distancia de edición acotada por largo de la palabra)
+func tokenScore(term string, tokens []string) float64 {
+	best := 0.0
+	for _, tok := range tokens {
+		switch {
+		case tok == term:
+			return 1
+		case len(term) >= 3 && strings.HasPrefix(tok, term):
+			best = max(best, 0.75)
+		case withinTypoDistance(term, tok):
+			best = max(best, 0.5)
+		}
+	}
+	return best
+}
+
+func withinTypoDistance(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 4 || len(rb) < 4 {
+		return false
+	}
+	maxDist := 1
+	if len(ra) >= 8 {
+		maxDist = 2
+	}
+	if d := len(ra) - len(rb); d > maxDist || -d > maxDist {
+		return false
+	}
+	return levenshtein(ra, rb) <= maxDist
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+var accentReplacer = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n")
+
+// NormalizeSearchText pasa a minúsculas y quita acentos (equivalente a lower(unaccent(...)))
+func NormalizeSearchText(s string) string {
+	return accentReplacer.Replace(strings.ToLower(s))
+}
+
+func searchTokens(s string) []string {
+	return strings.FieldsFunc(NormalizeSearchText(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
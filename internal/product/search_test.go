@@ -0,0 +1,50 @@
+package product
+
+import (
+	"testing"
+
+	"go-modaMayor/internal/category"
+)
+
+func TestSearchProductIDs_RelevanceAndTypos(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&category.Category{}, &category.Subcategory{}, &Season{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM product_variants")
+
+	remera := Product{Name: "Remera Básica Algodón", Code: "TEST-S-1"}
+	db.Create(&remera)
+	buzo := Product{Name: "Buzo Canguro", Code: "TEST-S-2", Description: "Ideal para combinar con una remera"}
+	db.Create(&buzo)
+	jean := Product{Name: "Jean Mom", Code: "TEST-S-3"}
+	db.Create(&jean)
+	db.Create(&ProductVariant{ProductID: jean.ID, SKU: "JEAN-MOM-AZ-38", Color: "azul", Size: "38"})
+
+	hits, err := SearchProductIDs(db, "remerra")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hits) != 2 || hits[0].ID != remera.ID {
+		t.Fatalf("expected typo match with name hit first, got %+v", hits)
+	}
+
+	hits, _ = SearchProductIDs(db, "algodon")
+	if len(hits) != 1 || hits[0].ID != remera.ID {
+		t.Fatalf("expected accent-insensitive match, got %+v", hits)
+	}
+
+	hits, _ = SearchProductIDs(db, "jean-mom-az")
+	if len(hits) != 1 || hits[0].ID != jean.ID {
+		t.Fatalf("expected SKU match, got %+v", hits)
+	}
+
+	facets, err := BuildProductFacets(db, []uint{jean.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(facets.Colors) != 1 || facets.Colors[0].Value != "azul" || facets.OutOfStock != 1 {
+		t.Fatalf("unexpected facets: %+v", facets)
+	}
+}
@@ -0,0 +1,401 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ciclo de vida de una temporada. El orden importa: el job sólo avanza estados.
+//   - upcoming: todavía no empezó (starts_at futuro)
+//   - current: en curso
+//   - clearance: liquidación; sus productos pasan a oferta con clearance_discount
+//   - closed: terminada (ends_at pasado); la temporada deja de estar activa
+const (
+	SeasonUpcoming  = "upcoming"
+	SeasonCurrent   = "current"
+	SeasonClearance = "clearance"
+	SeasonClosed    = "closed"
+)
+
+var seasonStatusOrder = map[string]int{SeasonUpcoming: 0, SeasonCurrent: 1, SeasonClearance: 2, SeasonClosed: 3}
+
+// ValidSeasonStatus indica si el estado existe
+func ValidSeasonStatus(status string) bool {
+	_, ok := seasonStatusOrder[status]
+	return ok
+}
+
+// SeasonClearanceItem guarda cómo estaba un producto antes de que la liquidación lo
+// pasara a oferta, para poder deshacerlo si la temporada vuelve a current
+type SeasonClearanceItem struct {
+	gorm.Model
+	SeasonID          uint    `json:"season_id" gorm:"not null;uniqueIndex:idx_season_clearance_product"`
+	ProductID         uint    `json:"product_id" gorm:"not null;uniqueIndex:idx_season_clearance_product"`
+	PrevIsOffer       bool    `json:"prev_is_offer"`
+	PrevDiscountType  string  `json:"prev_discount_type" gorm:"type:varchar(20)"`
+	PrevDiscountValue float64 `json:"prev_discount_value"`
+	DiscountValue     float64 `json:"discount_value"` // descuento que aplicó la liquidación
+}
+
+// SeasonStatusForDate devuelve el estado que corresponde a la temporada según sus fechas.
+// Sin fechas devuelve el estado actual.
+func SeasonStatusForDate(s Season, now time.Time) string {
+	switch {
+	case s.EndsAt != nil && !now.Before(*s.EndsAt):
+		return SeasonClosed
+	case s.ClearanceAt != nil && !now.Before(*s.ClearanceAt):
+		return SeasonClearance
+	case s.StartsAt != nil && now.Before(*s.StartsAt):
+		return SeasonUpcoming
+	case s.StartsAt != nil:
+		return SeasonCurrent
+	}
+	return s.Status
+}
+
+// validateSeasonDates controla que el rango de fechas sea coherente
+func validateSeasonDates(s Season) error {
+	if s.StartsAt != nil && s.EndsAt != nil && !s.EndsAt.After(*s.StartsAt) {
+		return errors.New("La fecha de fin debe ser posterior a la de inicio")
+	}
+	if s.ClearanceAt != nil {
+		if s.StartsAt != nil && s.ClearanceAt.Before(*s.StartsAt) {
+			return errors.New("La liquidación no puede empezar antes que la temporada")
+		}
+		if s.EndsAt != nil && !s.ClearanceAt.Before(*s.EndsAt) {
+			return errors.New("La liquidación debe empezar antes del fin de la temporada")
+		}
+	}
+	if s.ClearanceDiscount < 0 || s.ClearanceDiscount > 100 {
+		return errors.New("El descuento de liquidación debe estar entre 0 y 100")
+	}
+	return nil
+}
+
+// SetSeasonStatus cambia el estado de la temporada. Al entrar en liquidación marca sus
+// productos (no archivados) como oferta con el descuento de liquidación; si vuelve a
+// upcoming/current se restauran los valores anteriores de los productos que no se
+// tocaron a mano. Cerrar la temporada deja las ofertas como están. Devuelve la
+// cantidad de productos modificados.
+func SetSeasonStatus(db *gorm.DB, c *gin.Context, season *Season, status string) (int, error) {
+	if !ValidSeasonStatus(status) {
+		return 0, fmt.Errorf("estado de temporada inválido: %s", status)
+	}
+	if season.Status == status {
+		return 0, nil
+	}
+	entering := status == SeasonClearance
+	leaving := seasonStatusOrder[status] < seasonStatusOrder[SeasonClearance]
+
+	// Productos afectados y su revisión previa (para el historial)
+	var ids []uint
+	switch {
+	case entering:
+		if err := db.Model(&Product{}).Where("season_id = ? AND status <> ?", season.ID, StatusArchived).Order("id").Pluck("id", &ids).Error; err != nil {
+			return 0, err
+		}
+	case leaving:
+		if err := db.Model(&SeasonClearanceItem{}).Where("season_id = ?", season.ID).Order("product_id").Pluck("product_id", &ids).Error; err != nil {
+			return 0, err
+		}
+	}
+	before := make(map[uint]*ProductSnapshot, len(ids))
+	for _, id := range ids {
+		before[id] = CaptureRevision(db, id)
+	}
+
+	changed := []uint{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(season).Updates(map[string]interface{}{"status": status, "active": status != SeasonClosed}).Error; err != nil {
+			return err
+		}
+		season.Status, season.Active = status, status != SeasonClosed
+		if entering {
+			for _, id := range ids {
+				var p Product
+				if err := tx.Select("id", "is_offer", "discount_type", "discount_value").First(&p, id).Error; err != nil {
+					return err
+				}
+				item := SeasonClearanceItem{SeasonID: season.ID, ProductID: id, PrevIsOffer: p.IsOffer,
+					PrevDiscountType: p.DiscountType, PrevDiscountValue: p.DiscountValue, DiscountValue: season.ClearanceDiscount}
+				// Si ya se había liquidado (clearance -> closed -> clearance) se conserva el estado original
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error; err != nil {
+					return err
+				}
+				updates := map[string]interface{}{"is_offer": true}
+				if season.ClearanceDiscount > 0 {
+					updates["discount_type"] = "percent"
+					updates["discount_value"] = season.ClearanceDiscount
+				}
+				if err := tx.Model(&Product{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+					return err
+				}
+				changed = append(changed, id)
+			}
+		}
+		if leaving {
+			var items []SeasonClearanceItem
+			if err := tx.Where("season_id = ?", season.ID).Find(&items).Error; err != nil {
+				return err
+			}
+			for _, it := range items {
+				// Sólo se restaura si el descuento sigue siendo el de la liquidación
+				res := tx.Model(&Product{}).
+					Where("id = ? AND is_offer = ? AND (discount_value = ? OR ? = 0)", it.ProductID, true, it.DiscountValue, it.DiscountValue).
+					Updates(map[string]interface{}{"is_offer": it.PrevIsOffer, "discount_type": it.PrevDiscountType, "discount_value": it.PrevDiscountValue})
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected > 0 {
+					changed = append(changed, it.ProductID)
+				}
+			}
+			if err := tx.Unscoped().Where("season_id = ?", season.ID).Delete(&SeasonClearanceItem{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range changed {
+		RecordRevision(db, c, id, RevisionPrices, before[id])
+	}
+	return len(changed), nil
+}
+
+// AdvanceSeasons pasa cada temporada con fechas al estado que le corresponde. Sólo
+// avanza (upcoming -> current -> clearance -> closed) para no pisar un cambio manual
+// hecho antes de la fecha.
+func AdvanceSeasons(db *gorm.DB, now time.Time) error {
+	var seasons []Season
+	if err := db.Where("status <> ? AND (starts_at IS NOT NULL OR ends_at IS NOT NULL OR clearance_at IS NOT NULL)", SeasonClosed).Find(&seasons).Error; err != nil {
+		return err
+	}
+	for i := range seasons {
+		s := &seasons[i]
+		target := SeasonStatusForDate(*s, now)
+		if seasonStatusOrder[target] <= seasonStatusOrder[s.Status] {
+			continue
+		}
+		from := s.Status
+		n, err := SetSeasonStatus(db, nil, s, target)
+		if err != nil {
+			log.Printf("❌ Error pasando la temporada %s a %s: %v", s.Code, target, err)
+			continue
+		}
+		log.Printf("📅 Temporada %s: %s → %s (%d productos actualizados)", s.Code, from, target, n)
+	}
+	return nil
+}
+
+// StartSeasonStatusJob lanza una goroutine que actualiza el estado de las temporadas periódicamente
+func StartSeasonStatusJob(interval time.Duration) {
+	go func() {
+		log.Printf("🚀 Iniciando job de estado de temporadas (intervalo: %v)", interval)
+
+		if err := AdvanceSeasons(config.DB, time.Now()); err != nil {
+			log.Printf("❌ Error en primera ejecución del job de temporadas: %v", err)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			if err := AdvanceSeasons(config.DB, time.Now()); err != nil {
+				log.Printf("❌ Error en job de temporadas: %v", err)
+			}
+		}
+	}()
+}
+
+// UpdateSeasonStatus cambia manualmente el estado de una temporada
+// PUT /seasons/:id/status {"status": "clearance"}
+func UpdateSeasonStatus(c *gin.Context) {
+	var season Season
+	if err := config.DB.First(&season, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=upcoming current clearance closed"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	n, err := SetSeasonStatus(config.DB, c, &season, input.Status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"season": season, "products_updated": n})
+}
+
+// RolloverResult es un producto clonado por el rollover
+type RolloverResult struct {
+	FromID    uint   `json:"from_id"`
+	ProductID uint   `json:"product_id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+}
+
+// RolloverSeason clona los productos recurrentes de una temporada en otra. Los clones
+// quedan en draft, sin stock, con variantes nuevas (SKU y código de barras propios) y
+// las mismas categorías, atributos e imágenes. Los productos que ya tienen un clon en
+// la temporada destino se saltean, así se puede volver a correr sin duplicar.
+func RolloverSeason(db *gorm.DB, from, to Season, productIDs []uint) ([]RolloverResult, []uint, error) {
+	q := db.Preload("Categories").Preload("Attributes").Preload("Variants").Preload("Images").
+		Where("season_id = ? AND status <> ?", from.ID, StatusArchived).Order("id")
+	if len(productIDs) > 0 {
+		q = q.Where("id IN ?", productIDs)
+	} else {
+		q = q.Where("recurring = ?", true)
+	}
+	var products []Product
+	if err := q.Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+
+	created := []RolloverResult{}
+	skipped := []uint{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, src := range products {
+			var count int64
+			if err := tx.Model(&Product{}).Where("previous_product_id = ? AND season_id = ?", src.ID, to.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				skipped = append(skipped, src.ID)
+				continue
+			}
+			clone, err := cloneProductForSeason(tx, src, to)
+			if err != nil {
+				return fmt.Errorf("producto %s: %w", src.Code, err)
+			}
+			created = append(created, RolloverResult{FromID: src.ID, ProductID: clone.ID, Code: clone.Code, Name: clone.Name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, skipped, nil
+}
+
+// cloneProductForSeason crea la copia de src en la temporada indicada
+func cloneProductForSeason(tx *gorm.DB, src Product, season Season) (*Product, error) {
+	year := season.Year
+	srcID := src.ID
+	clone := src
+	clone.Model = gorm.Model{}
+	clone.Code, clone.Slug = "", ""
+	clone.SeasonID, clone.Year = &season.ID, &year
+	clone.PreviousProductID = &srcID
+	// Se publica al empezar la temporada nueva (o ya, si empezó o no tiene fecha)
+	clone.Status, clone.PublishAt, clone.ArchivedAt = StatusPublished, nil, nil
+	if season.StartsAt != nil && season.StartsAt.After(time.Now()) {
+		startsAt := *season.StartsAt
+		clone.PublishAt = &startsAt
+	}
+	// La liquidación de la temporada anterior no pasa a la nueva
+	clone.IsOffer, clone.DiscountType, clone.DiscountValue = false, "none", 0
+	clone.Categories, clone.Attributes, clone.Variants, clone.Images, clone.LocationStocks = nil, nil, nil, nil, nil
+	if err := tx.Omit(clause.Associations).Create(&clone).Error; err != nil {
+		return nil, err
+	}
+
+	categoryIDs := make([]uint, 0, len(src.Categories))
+	for _, cat := range src.Categories {
+		categoryIDs = append(categoryIDs, cat.ID)
+	}
+	if err := SetProductCategories(tx, &clone, categoryIDs); err != nil {
+		return nil, err
+	}
+	for _, av := range src.Attributes {
+		av.ID, av.ProductID = 0, clone.ID
+		if err := tx.Omit(clause.Associations).Create(&av).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, img := range src.Images {
+		img.Model, img.ProductID = gorm.Model{}, clone.ID
+		if err := tx.Create(&img).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, v := range src.Variants {
+		parts := []string{fmt.Sprintf("%d", clone.ID)}
+		if v.Color != "" {
+			parts = append(parts, sanitizeSKU(v.Color))
+		}
+		if v.Size != "" {
+			parts = append(parts, sanitizeSKU(v.Size))
+		}
+		sku, err := uniqueSKU(tx, strings.Join(parts, "-"))
+		if err != nil {
+			return nil, err
+		}
+		nv := ProductVariant{ProductID: clone.ID, Color: v.Color, Size: v.Size, SKU: sku, ColorID: v.ColorID,
+			SizeValueID: v.SizeValueID, ImageURL: v.ImageURL, ImageSet: v.ImageSet}
+		if err := assignBarcode(tx, &nv); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&nv).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &clone, nil
+}
+
+// RolloverSeasonHandler clona los productos recurrentes de la temporada en otra
+// POST /seasons/:id/rollover {"target_season_id": 5, "product_ids": [opcional]}
+func RolloverSeasonHandler(c *gin.Context) {
+	var from Season
+	if err := config.DB.First(&from, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+	var input struct {
+		TargetSeasonID uint   `json:"target_season_id" binding:"required,gt=0"`
+		ProductIDs     []uint `json:"product_ids"` // vacío = todos los recurrentes
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var to Season
+	if err := config.DB.First(&to, input.TargetSeasonID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Temporada destino no encontrada"})
+		return
+	}
+	if to.ID == from.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La temporada destino debe ser distinta de la de origen"})
+		return
+	}
+	if to.Status == SeasonClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La temporada destino está cerrada"})
+		return
+	}
+	created, skipped, err := RolloverSeason(config.DB, from, to, input.ProductIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, r := range created {
+		RecordRevision(config.DB, c, r.ProductID, RevisionCreate, nil)
+	}
+	c.JSON(http.StatusOK, gin.H{"created": created, "skipped": skipped})
+}
@@ -2,15 +2,23 @@ package product
 
 import (
 	"go-modaMayor/config"
+	"go-modaMayor/internal/slug"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// ListSeasons devuelve todas las temporadas
+// ListSeasons devuelve todas las temporadas (?status=current,clearance filtra por estado)
 func ListSeasons(c *gin.Context) {
 	var seasons []Season
-	if err := config.DB.Order("year DESC, id DESC").Find(&seasons).Error; err != nil {
+	q := config.DB.Order("year DESC, id DESC")
+	if v := c.Query("status"); v != "" {
+		q = q.Where("status IN ?", strings.Split(v, ","))
+	}
+	if err := q.Find(&seasons).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -45,12 +53,30 @@ func CreateSeason(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "El año es obligatorio"})
 		return
 	}
+	if err := validateSeasonDates(season); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Sin estado explícito se toma el que corresponde a las fechas
+	if season.Status == "" {
+		season.Status = SeasonStatusForDate(Season{Status: SeasonCurrent, StartsAt: season.StartsAt, EndsAt: season.EndsAt, ClearanceAt: season.ClearanceAt}, time.Now())
+	}
+	if !ValidSeasonStatus(season.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado de temporada inválido"})
+		return
+	}
+	season.Active = season.Status != SeasonClosed
 
 	if err := config.DB.Create(&season).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// active tiene default true en la base: un false explícito no lo inserta Create
+	if !season.Active {
+		config.DB.Model(&season).Update("active", false)
+	}
+
 	c.JSON(http.StatusCreated, season)
 }
 
@@ -70,15 +96,51 @@ func UpdateSeason(c *gin.Context) {
 	}
 
 	// Actualizar campos
+	oldName := season.Name
 	season.Name = input.Name
 	season.Code = input.Code
 	season.Year = input.Year
-	season.Active = input.Active
+	season.StartsAt = input.StartsAt
+	season.EndsAt = input.EndsAt
+	season.ClearanceAt = input.ClearanceAt
+	season.ClearanceDiscount = input.ClearanceDiscount
+	if err := validateSeasonDates(season); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Status != "" && !ValidSeasonStatus(input.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Estado de temporada inválido"})
+		return
+	}
 
-	if err := config.DB.Save(&season).Error; err != nil {
+	// El slug sigue al nombre salvo que se envíe uno explícito; el anterior queda como redirección
+	slugBase := ""
+	if slug.Make(input.Slug) != "" && input.Slug != season.Slug {
+		slugBase = input.Slug
+	} else if season.Name != oldName || season.Slug == "" {
+		slugBase = season.Name
+	}
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&season).Error; err != nil {
+			return err
+		}
+		if slugBase == "" {
+			return nil
+		}
+		newSlug, err := slug.Change(tx, slug.EntitySeason, season.ID, season.Slug, slugBase)
+		season.Slug = newSlug
+		return err
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// El cambio de estado aplica o deshace la liquidación de los productos (ver season.go)
+	if input.Status != "" && input.Status != season.Status {
+		if _, err := SetSeasonStatus(config.DB, c, &season, input.Status); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, season)
 }
@@ -0,0 +1,80 @@
+package product
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeasonLifecycle_ClearanceAndRollover(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&Season{}, &SeasonClearanceItem{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM product_variants")
+
+	now := time.Now()
+	starts, clearance, ends := now.Add(-48*time.Hour), now.Add(-time.Hour), now.Add(72*time.Hour)
+	ss := Season{Code: "SS26", Name: "Primavera/Verano 2026", Year: 2026, Status: SeasonCurrent, Active: true,
+		StartsAt: &starts, ClearanceAt: &clearance, EndsAt: &ends, ClearanceDiscount: 30}
+	db.Create(&ss)
+	nextStart := time.Now().AddDate(0, 2, 0)
+	next := Season{Code: "AW26", Name: "Otoño/Invierno 2026", Year: 2026, Status: SeasonUpcoming, Active: true, StartsAt: &nextStart}
+	db.Create(&next)
+
+	basic := Product{Name: "Remera Básica", Code: "TEST-SEA-1", SeasonID: &ss.ID, Recurring: true, Status: StatusPublished, WholesalePrice: 100}
+	db.Create(&basic)
+	db.Create(&ProductVariant{ProductID: basic.ID, SKU: "TEST-SEA-1-M", Color: "Negro", Size: "M"})
+	promo := Product{Name: "Vestido", Code: "TEST-SEA-2", SeasonID: &ss.ID, Status: StatusPublished, IsOffer: true, DiscountType: "fixed", DiscountValue: 10}
+	db.Create(&promo)
+
+	// El job sólo avanza: la temporada con fecha de liquidación vencida pasa a clearance
+	if err := AdvanceSeasons(db, now); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	db.First(&ss, ss.ID)
+	if ss.Status != SeasonClearance || !ss.Active {
+		t.Fatalf("expected active clearance season, got %q active=%v", ss.Status, ss.Active)
+	}
+	var got Product
+	db.First(&got, basic.ID)
+	if !got.IsOffer || got.DiscountType != "percent" || got.DiscountValue != 30 {
+		t.Fatalf("clearance not applied: offer=%v %s %v", got.IsOffer, got.DiscountType, got.DiscountValue)
+	}
+
+	// Volver a current restaura la oferta que tenía cada producto
+	if _, err := SetSeasonStatus(db, nil, &ss, SeasonCurrent); err != nil {
+		t.Fatalf("set status: %v", err)
+	}
+	var restored, cleared Product
+	db.First(&restored, promo.ID)
+	if !restored.IsOffer || restored.DiscountType != "fixed" || restored.DiscountValue != 10 {
+		t.Fatalf("previous offer not restored: offer=%v %s %v", restored.IsOffer, restored.DiscountType, restored.DiscountValue)
+	}
+	db.First(&cleared, basic.ID)
+	if cleared.IsOffer {
+		t.Fatal("clearance offer should be removed when leaving clearance")
+	}
+
+	created, skipped, err := RolloverSeason(db, ss, next, nil)
+	if err != nil {
+		t.Fatalf("rollover: %v", err)
+	}
+	if len(created) != 1 || len(skipped) != 0 || created[0].FromID != basic.ID {
+		t.Fatalf("only the recurring product should be cloned: %+v skipped=%v", created, skipped)
+	}
+	var clone Product
+	db.Preload("Variants").First(&clone, created[0].ProductID)
+	if clone.Status != StatusPublished || clone.PublishAt == nil || !clone.PublishAt.Equal(nextStart) || clone.SeasonID == nil || *clone.SeasonID != next.ID || clone.PreviousProductID == nil || *clone.PreviousProductID != basic.ID {
+		t.Fatalf("unexpected clone: %+v", clone)
+	}
+	if len(clone.Variants) != 1 || clone.Variants[0].SKU == "TEST-SEA-1-M" || clone.Variants[0].Size != "M" {
+		t.Fatalf("clone should get new variants with their own SKU: %+v", clone.Variants)
+	}
+
+	// Correrlo de nuevo no duplica
+	created, skipped, err = RolloverSeason(db, ss, next, nil)
+	if err != nil || len(created) != 0 || len(skipped) != 1 {
+		t.Fatalf("rerun should skip already cloned products: created=%v skipped=%v err=%v", created, skipped, err)
+	}
+}
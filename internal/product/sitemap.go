@@ -0,0 +1,79 @@
+package product
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/category"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// siteURL es la URL pública de la tienda (SITE_URL). No se arma con el Host del
+// request: un Host falso quedaría en un sitemap que se cachea.
+func siteURL() string {
+	return strings.TrimSuffix(strings.TrimSpace(os.Getenv("SITE_URL")), "/")
+}
+
+// Sitemap genera sitemap.xml con los productos publicados, las categorías y las
+// temporadas activas, usando las rutas del front (/productos/<slug>, /productos?categoria=<slug>).
+func Sitemap(c *gin.Context) {
+	base := siteURL()
+	if base == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "SITE_URL no está configurada"})
+		return
+	}
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9", URLs: []sitemapURL{{Loc: base + "/"}}}
+	lastMod := func(t time.Time) string { return t.UTC().Format("2006-01-02") }
+
+	var products []Product
+	if err := config.DB.Model(&Product{}).Scopes(Published).Select("products.id", "products.slug", "products.updated_at").
+		Where("products.slug <> ''").Order("products.id").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, p := range products {
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + "/productos/" + url.PathEscape(p.Slug), LastMod: lastMod(p.UpdatedAt)})
+	}
+
+	var cats []category.Category
+	if err := config.DB.Select("id", "slug", "updated_at").Where("slug <> ''").Order("path, id").Find(&cats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, cat := range cats {
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + "/productos?categoria=" + url.QueryEscape(cat.Slug), LastMod: lastMod(cat.UpdatedAt)})
+	}
+
+	var seasons []Season
+	if err := config.DB.Select("id", "slug", "updated_at").Where("active = ? AND slug <> ''", true).Order("year DESC, id DESC").Find(&seasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, s := range seasons {
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + "/productos?temporada=" + url.QueryEscape(s.Slug), LastMod: lastMod(s.UpdatedAt)})
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", append([]byte(xml.Header), out...))
+}
@@ -0,0 +1,72 @@
+package product
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/slug"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// redirectToSlug responde 301 a la misma ruta con el slug actual
+func redirectToSlug(c *gin.Context, current string) {
+	base := strings.TrimSuffix(c.Request.URL.Path, c.Param("slug"))
+	target := base + current
+	if q := c.Request.URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	c.Redirect(http.StatusMovedPermanently, target)
+}
+
+// GetProductBySlug devuelve el detalle del producto por slug. Si es un slug
+// anterior (el producto cambió de nombre) redirige con 301 al actual.
+func GetProductBySlug(c *gin.Context) {
+	id, redirected, err := slug.Resolve(config.DB, slug.EntityProduct, c.Param("slug"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if redirected {
+		var p Product
+		// No revelar el slug nuevo de productos que el público no puede ver
+		if err := config.DB.Select("id", "slug", "status", "publish_at").First(&p, id).Error; err != nil || (!IsStaff(c) && !p.IsPurchasable(time.Now())) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+			return
+		}
+		redirectToSlug(c, p.Slug)
+		return
+	}
+	respondProductDetail(c, id)
+}
+
+// GetSeasonBySlug devuelve una temporada por slug (redirige si es un slug anterior)
+func GetSeasonBySlug(c *gin.Context) {
+	id, redirected, err := slug.Resolve(config.DB, slug.EntitySeason, c.Param("slug"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var season Season
+	if err := config.DB.First(&season, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temporada no encontrada"})
+		return
+	}
+	if redirected {
+		redirectToSlug(c, season.Slug)
+		return
+	}
+	c.JSON(http.StatusOK, season)
+}
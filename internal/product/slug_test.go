@@ -0,0 +1,73 @@
+package product
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProductSlugs_LookupRedirectAndSitemap(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&Season{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM slug_redirects")
+	t.Setenv("SITE_URL", "https://tienda.test/")
+
+	jean := Product{Name: "Campera de Jeán", Code: "TEST-SL-1", Status: StatusPublished}
+	db.Create(&jean)
+	twin := Product{Name: "Campera de Jean", Code: "TEST-SL-2", Status: StatusPublished}
+	db.Create(&twin)
+	draft := Product{Name: "Borrador Secreto", Code: "TEST-SL-3", Status: StatusDraft}
+	db.Create(&draft)
+	if jean.Slug != "campera-de-jean" || twin.Slug != "campera-de-jean-2" {
+		t.Fatalf("unexpected slugs: %q %q", jean.Slug, twin.Slug)
+	}
+
+	router := gin.New()
+	router.GET("/products/by-slug/:slug", GetProductBySlug)
+	router.PUT("/products/:id", UpdateProduct)
+	router.GET("/sitemap.xml", Sitemap)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, "/products/by-slug/campera-de-jean-2", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"code":"TEST-SL-2"`) {
+		t.Fatalf("lookup: %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, fmt.Sprintf("/products/%d", jean.ID), `{"name":"Campera Denim"}`); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"slug":"campera-denim"`) {
+		t.Fatalf("rename: %d %s", w.Code, w.Body.String())
+	}
+	w := do(http.MethodGet, "/products/by-slug/campera-de-jean?ref=ig", "")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/products/by-slug/campera-denim?ref=ig" {
+		t.Fatalf("expected redirect to new slug, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	// Un borrador no se encuentra por slug para el público
+	if w := do(http.MethodGet, "/products/by-slug/borrador-secreto", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected draft hidden, got %d", w.Code)
+	}
+
+	w = do(http.MethodGet, "/sitemap.xml", "")
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") {
+		t.Fatalf("sitemap: %d %s", w.Code, body)
+	}
+	if !strings.Contains(body, "<loc>https://tienda.test/productos/campera-denim</loc>") || strings.Contains(body, "borrador-secreto") {
+		t.Fatalf("unexpected sitemap: %s", body)
+	}
+
+	// Sin SITE_URL no se usa el Host del request
+	t.Setenv("SITE_URL", "")
+	if w := do(http.MethodGet, "/sitemap.xml", ""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without SITE_URL, got %d %s", w.Code, w.Body.String())
+	}
+}
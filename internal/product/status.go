@@ -0,0 +1,107 @@
+package product
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-modaMayor/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Estados del ciclo de vida del producto
+//   - draft: en preparación, sólo lo ve el staff
+//   - published: visible en el catálogo público (a partir de publish_at si está programado)
+//   - hidden: fuera de listados y búsquedas, pero accesible y comprable por link directo
+//   - archived: discontinuado; no se vende pero sigue resolviéndose en pedidos y reportes
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusHidden    = "hidden"
+	StatusArchived  = "archived"
+)
+
+var validStatuses = map[string]bool{StatusDraft: true, StatusPublished: true, StatusHidden: true, StatusArchived: true}
+
+// staffRoles ven todos los productos sin importar el estado
+var staffRoles = map[string]bool{"admin": true, "encargado": true, "vendedor": true}
+
+// IsStaff indica si el request viene de un usuario del staff (requiere AuthMiddleware u OptionalAuthMiddleware)
+func IsStaff(c *gin.Context) bool {
+	role, _ := c.Get("user_role")
+	r, _ := role.(string)
+	return staffRoles[r]
+}
+
+// Published es un scope con los productos visibles en el catálogo público
+func Published(db *gorm.DB) *gorm.DB {
+	return db.Where("products.status = ? AND (products.publish_at IS NULL OR products.publish_at <= ?)", StatusPublished, time.Now())
+}
+
+// IsPublic indica si el producto aparece en listados públicos
+func (p *Product) IsPublic(now time.Time) bool {
+	return p.Status == StatusPublished && (p.PublishAt == nil || !p.PublishAt.After(now))
+}
+
+// IsPurchasable indica si el producto se puede ver por link directo y agregar al carrito
+func (p *Product) IsPurchasable(now time.Time) bool {
+	return (p.Status == StatusPublished || p.Status == StatusHidden) && (p.PublishAt == nil || !p.PublishAt.After(now))
+}
+
+// applyStatus valida y asigna estado y fecha de publicación. Programar una
+// publicación es publicar con publish_at futuro: el producto aparece solo al llegar la fecha.
+func applyStatus(p *Product, status string, publishAt *time.Time) error {
+	status = strings.TrimSpace(status)
+	if !validStatuses[status] {
+		return errors.New("Estado inválido: use draft, published, hidden o archived")
+	}
+	if publishAt != nil && status != StatusPublished {
+		return errors.New("publish_at sólo aplica a productos publicados")
+	}
+	p.Status = status
+	p.PublishAt = publishAt
+	if status == StatusArchived {
+		if p.ArchivedAt == nil {
+			now := time.Now()
+			p.ArchivedAt = &now
+		}
+	} else {
+		p.ArchivedAt = nil
+	}
+	return nil
+}
+
+// UpdateProductStatusInput cambia el estado de un producto
+type UpdateProductStatusInput struct {
+	Status    string     `json:"status" binding:"required"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// UpdateProductStatus publica, oculta, archiva o vuelve a borrador un producto.
+// PUT /products/:id/status
+func UpdateProductStatus(c *gin.Context) {
+	var product Product
+	if err := config.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	var input UpdateProductStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := CaptureRevision(config.DB, product.ID)
+	if err := applyStatus(&product, input.Status, input.PublishAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := config.DB.Model(&product).Select("status", "publish_at", "archived_at").Updates(&product).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	RecordRevision(config.DB, c, product.ID, RevisionStatus, before)
+	c.JSON(http.StatusOK, product)
+}
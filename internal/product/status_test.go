@@ -0,0 +1,98 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProductStatus_PublicVisibility(t *testing.T) {
+	db := setupTestDB(t)
+	db.Exec("DELETE FROM products")
+
+	unset := Product{Name: "Sin estado", Code: "TEST-S-0"}
+	db.Create(&unset)
+	if unset.Status != StatusPublished {
+		t.Fatalf("new products should be published by default, got %q", unset.Status)
+	}
+	db.Unscoped().Delete(&unset)
+	draft := Product{Name: "Borrador", Code: "TEST-S-1", Status: StatusDraft}
+	db.Create(&draft)
+	future := time.Now().Add(24 * time.Hour)
+	published := Product{Name: "Publicado", Code: "TEST-S-2", Status: StatusPublished}
+	scheduled := Product{Name: "Programado", Code: "TEST-S-3", Status: StatusPublished, PublishAt: &future}
+	hidden := Product{Name: "Oculto", Code: "TEST-S-4", Status: StatusHidden}
+	db.Create(&published)
+	db.Create(&scheduled)
+	db.Create(&hidden)
+
+	asRole := func(role string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if role != "" {
+				c.Set("user_role", role)
+			}
+		}
+	}
+	list := func(role string) []uint {
+		router := gin.New()
+		router.GET("/products", asRole(role), GetProducts)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products?sort=name_asc", nil))
+		var resp struct {
+			Items []Product `json:"items"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		ids := []uint{}
+		for _, p := range resp.Items {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	if ids := list(""); len(ids) != 1 || ids[0] != published.ID {
+		t.Fatalf("public list should only contain the published product, got %v", ids)
+	}
+	if ids := list("admin"); len(ids) != 4 {
+		t.Fatalf("admin should see every product, got %v", ids)
+	}
+
+	detail := func(id uint) int {
+		router := gin.New()
+		router.GET("/products/:id", GetProduct)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/products/%d", id), nil))
+		return w.Code
+	}
+	if code := detail(hidden.ID); code != http.StatusOK {
+		t.Fatalf("hidden product should be reachable by direct link, got %d", code)
+	}
+	if code := detail(draft.ID); code != http.StatusNotFound {
+		t.Fatalf("draft product should not be public, got %d", code)
+	}
+	if code := detail(scheduled.ID); code != http.StatusNotFound {
+		t.Fatalf("scheduled product should not be public before publish_at, got %d", code)
+	}
+
+	// Borrar archiva: el producto sigue existiendo para pedidos y reportes
+	router := gin.New()
+	router.DELETE("/products/:id", DeleteProduct)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/products/%d", published.ID), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: %d %s", w.Code, w.Body.String())
+	}
+	var archived Product
+	if err := db.First(&archived, published.ID).Error; err != nil {
+		t.Fatalf("archived product should still resolve: %v", err)
+	}
+	if archived.Status != StatusArchived || archived.ArchivedAt == nil {
+		t.Fatalf("expected archived status, got %q", archived.Status)
+	}
+	if code := detail(published.ID); code != http.StatusNotFound {
+		t.Fatalf("archived product should not be public, got %d", code)
+	}
+}
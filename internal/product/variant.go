@@ -1,6 +1,10 @@
 package product
 
-import "gorm.io/gorm"
+import (
+	"go-modaMayor/internal/imaging"
+
+	"gorm.io/gorm"
+)
 
 type ProductVariant struct {
 	gorm.Model
@@ -8,6 +12,17 @@ type ProductVariant struct {
 	Color     string `json:"color"`
 	Size      string `json:"size"`
 	SKU       string `json:"sku" gorm:"unique;not null"`
-	ImageURL  string `json:"image_url"`
+	// Código de barras: EAN-13 generado con el prefijo de empresa o código del proveedor importado
+	Barcode     *string `json:"barcode" gorm:"type:varchar(64);uniqueIndex"`
+	BarcodeType string  `json:"barcode_type,omitempty" gorm:"type:varchar(10)"` // ean13, code128
+	// Referencias a las tablas colors y size_values. Color y Size conservan el nombre
+	// canónico para mostrar y para el histórico.
+	ColorID     *uint      `json:"color_id" gorm:"index"`
+	SizeValueID *uint      `json:"size_value_id" gorm:"index"`
+	ColorInfo   *Color     `json:"color_info,omitempty" gorm:"foreignKey:ColorID"`
+	SizeValue   *SizeValue `json:"size_value,omitempty" gorm:"foreignKey:SizeValueID"`
+	ImageURL    string     `json:"image_url"`
+	// Versiones procesadas de image_url (srcset)
+	ImageSet *imaging.ImageSet `json:"image_set,omitempty" gorm:"type:text"`
 	// El stock se maneja por ubicación en LocationStock
 }
@@ -1,11 +1,13 @@
 package product
 
 import (
+	"errors"
 	"fmt"
 	"go-modaMayor/config"
+	"go-modaMayor/internal/imaging"
+	"go-modaMayor/internal/storage"
 	"net/http"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -28,10 +30,27 @@ func CreateVariant(c *gin.Context) {
 		return
 	}
 	input.ProductID = pid
-	if err := config.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	vc, err := catalogForProduct(config.DB, pid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+	if err := vc.resolveVariant(&input); err != nil {
+		respondVariantInputError(c, err)
+		return
+	}
+	before := CaptureRevision(config.DB, pid)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := assignBarcode(tx, &input); err != nil {
+			return err
+		}
+		return tx.Create(&input).Error
+	})
+	if err != nil {
+		respondBarcodeError(c, err)
 		return
 	}
+	RecordRevision(config.DB, c, pid, RevisionVariants, before)
 	c.JSON(http.StatusCreated, input)
 }
 
@@ -39,7 +58,7 @@ func CreateVariant(c *gin.Context) {
 func ListVariantsByProduct(c *gin.Context) {
 	productID := c.Param("product_id")
 	var variants []ProductVariant
-	if err := config.DB.Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+	if err := config.DB.Scopes(withVariantRefs).Where("product_id = ?", productID).Find(&variants).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -59,20 +78,52 @@ func UpdateVariant(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Si cambia color o talle, se vuelven a resolver contra las tablas
+	input.ColorInfo, input.SizeValue = nil, nil
+	// El código de barras se cambia con PUT /variants/:id/barcode, que lo valida
+	input.Barcode, input.BarcodeType = nil, ""
+	if input.Color != "" || input.Size != "" || input.ColorID != nil || input.SizeValueID != nil {
+		vc, err := catalogForProduct(config.DB, variant.ProductID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		merged := ProductVariant{Color: variant.Color, Size: variant.Size}
+		if input.Color != "" || input.ColorID != nil {
+			merged.Color, merged.ColorID = input.Color, input.ColorID
+		}
+		if input.Size != "" || input.SizeValueID != nil {
+			merged.Size, merged.SizeValueID = input.Size, input.SizeValueID
+		}
+		if err := vc.resolveVariant(&merged); err != nil {
+			respondVariantInputError(c, err)
+			return
+		}
+		input.Color, input.ColorID, input.Size, input.SizeValueID = merged.Color, merged.ColorID, merged.Size, merged.SizeValueID
+	}
+	before := CaptureRevision(config.DB, variant.ProductID)
 	if err := config.DB.Model(&variant).Updates(input).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, variant.ProductID, RevisionVariants, before)
 	c.JSON(http.StatusOK, variant)
 }
 
 // Eliminar variante
 func DeleteVariant(c *gin.Context) {
 	id := c.Param("id")
-	if err := config.DB.Delete(&ProductVariant{}, id).Error; err != nil {
+	var variant ProductVariant
+	if err := config.DB.First(&variant, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Variante no encontrada"})
+		return
+	}
+	before := CaptureRevision(config.DB, variant.ProductID)
+	if err := config.DB.Delete(&variant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, variant.ProductID, RevisionVariants, before)
 	c.JSON(http.StatusOK, gin.H{"message": "Variante eliminada"})
 }
 
@@ -92,34 +143,30 @@ func UploadVariantImage(c *gin.Context) {
 		return
 	}
 
-	// Asegurar carpeta uploads
-	uploadDir := "uploads"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Validar, generar tamaños y guardar con nombre por hash de contenido
+	store, ok := uploadStorage(c)
+	if !ok {
 		return
 	}
-
-	// Nombre seguro: variant_<id>_<original>
-	filename := fmt.Sprintf("variant_%s_%s", id, filepath.Base(file.Filename))
-	destPath := filepath.Join(uploadDir, filename)
-
-	if err := c.SaveUploadedFile(file, destPath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	set, err := imaging.StoreUpload(c.Request.Context(), store, file)
+	if err != nil {
+		respondImageError(c, err)
 		return
 	}
-
-	// Guardar URL relativa
-	variant.ImageURL = "/" + filepath.ToSlash(filepath.Join(uploadDir, filename))
+	before := CaptureRevision(config.DB, variant.ProductID)
+	variant.ImageURL = set.Src
+	variant.ImageSet = set
 	if err := config.DB.Save(&variant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, variant.ProductID, RevisionImages, before)
 
 	c.JSON(http.StatusOK, variant)
 }
 
 // PropagateVariantImage permite subir una imagen (multipart field 'image') o
-// recibir un JSON { "color": "...", "image_url": "..." } y propagar el
+// recibir un JSON { "color": "..." o "color_id": N, "image_url": "..." } y propagar el
 // image_url a todas las variantes del producto que tengan el mismo color
 // (comparación normalizada: LOWER(TRIM(color))). Devuelve el conteo y los
 // ids actualizados.
@@ -130,13 +177,17 @@ func PropagateVariantImage(c *gin.Context) {
 	// Intentar bind JSON primero (color + image_url)
 	var payload struct {
 		Color    string `json:"color"`
+		ColorID  *uint  `json:"color_id"`
 		ImageURL string `json:"image_url"`
 	}
 	var color string
+	var colorID *uint
 	var imageURL string
+	var imageSet *imaging.ImageSet
 
 	if err := c.ShouldBindJSON(&payload); err == nil && payload.ImageURL != "" {
 		color = payload.Color
+		colorID = payload.ColorID
 		imageURL = payload.ImageURL
 	} else {
 		// Si no vino JSON con image_url, intentar multipart: campo 'image' y postform 'color'
@@ -146,61 +197,108 @@ func PropagateVariantImage(c *gin.Context) {
 			return
 		}
 		color = c.PostForm("color")
+		if v, err := strconv.ParseUint(c.PostForm("color_id"), 10, 64); err == nil {
+			id := uint(v)
+			colorID = &id
+		}
 
-		// Guardar archivo en uploads similar a UploadVariantImage
-		uploadDir := "uploads"
-		if err := os.MkdirAll(uploadDir, 0755); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Procesar y guardar igual que UploadVariantImage
+		store, ok := uploadStorage(c)
+		if !ok {
 			return
 		}
-		// Nombre: propagate_product_<id>_<original>
-		filename := fmt.Sprintf("propagate_product_%s_%s", productID, filepath.Base(file.Filename))
-		destPath := filepath.Join(uploadDir, filename)
-		if err := c.SaveUploadedFile(file, destPath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		set, err := imaging.StoreUpload(c.Request.Context(), store, file)
+		if err != nil {
+			respondImageError(c, err)
 			return
 		}
-		imageURL = "/" + filepath.ToSlash(filepath.Join(uploadDir, filename))
+		imageURL, imageSet = set.Src, set
 	}
 
+	// Con color_id se usa el nombre canónico del color
+	if colorID != nil {
+		var col Color
+		if err := config.DB.First(&col, *colorID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Color no encontrado"})
+			return
+		}
+		color = colorDisplayName(col)
+	}
 	// Normalizar color para la consulta
 	normColor := strings.TrimSpace(strings.ToLower(color))
 
-	// Ejecutar en transacción: buscar variantes del producto y luego filtrar
-	// por color normalizado en Go, para evitar problemas de portabilidad SQL.
-	var updatedIDs []uint
+	var product Product
+	if err := config.DB.First(&product, productID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Producto no encontrado"})
+		return
+	}
+
+	// Ejecutar en transacción: la imagen queda como primera del color en la
+	// galería y la sincronización la copia a las variantes de ese color.
+	updatedIDs := []uint{}
+	before := CaptureRevision(config.DB, product.ID)
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
-		var variants []ProductVariant
-		if err := tx.Where("product_id = ?", productID).Find(&variants).Error; err != nil {
+		if err := setColorImage(tx, product.ID, color, imageURL, imageSet); err != nil {
 			return err
 		}
-		if len(variants) == 0 {
-			// No hay variantes para este producto
-			return nil
+		if err := syncLegacyImages(tx, product.ID); err != nil {
+			return err
+		}
+		var variants []ProductVariant
+		if err := tx.Where("product_id = ?", product.ID).Find(&variants).Error; err != nil {
+			return err
 		}
-		ids := make([]uint, 0, len(variants))
 		for _, v := range variants {
-			if strings.TrimSpace(strings.ToLower(v.Color)) == normColor {
-				ids = append(ids, v.ID)
+			if normalizeColor(v.Color) == normColor || (colorID != nil && v.ColorID != nil && *v.ColorID == *colorID) {
+				updatedIDs = append(updatedIDs, v.ID)
 			}
 		}
-		if len(ids) == 0 {
-			// No hay variantes con el color solicitado
-			return nil
-		}
-		// Actualizar image_url para esas variantes
-		if err := tx.Model(&ProductVariant{}).Where("id IN ?", ids).Update("image_url", imageURL).Error; err != nil {
-			return err
-		}
-		updatedIDs = ids
 		return nil
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionImages, before)
 
-	c.JSON(http.StatusOK, gin.H{"product_id": productID, "color": normColor, "image_url": imageURL, "updated_count": len(updatedIDs), "updated_ids": updatedIDs})
+	c.JSON(http.StatusOK, gin.H{"product_id": productID, "color": normColor, "image_url": imageURL, "image_set": imageSet, "updated_count": len(updatedIDs), "updated_ids": updatedIDs})
+}
+
+// respondVariantInputError responde 400 con los colores/talles rechazados o el código de barras
+// inválido, y 500 ante otros errores
+func respondVariantInputError(c *gin.Context, err error) {
+	var inputErr *VariantInputError
+	if errors.As(err, &inputErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Colores o talles inválidos", "errors": inputErr.Messages})
+		return
+	}
+	var attrErr *AttributeInputError
+	if errors.As(err, &attrErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": attrErr.Message})
+		return
+	}
+	respondBarcodeError(c, err)
+}
+
+// uploadStorage devuelve el almacenamiento de uploads; si la configuración es
+// inválida responde 500 y devuelve ok=false
+func uploadStorage(c *gin.Context) (storage.Storage, bool) {
+	store, err := storage.Default()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return store, true
+}
+
+// respondImageError responde 400 si la imagen fue rechazada y 500 ante otros errores
+func respondImageError(c *gin.Context, err error) {
+	var invalid *imaging.InvalidImageError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalid.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 // GenerateVariantsInput permite crear combinaciones color x size en bloque.
@@ -258,120 +356,130 @@ func GenerateVariants(c *gin.Context) {
 		return
 	}
 
-	created := make([]ProductVariant, 0)
-	skipped := 0
-
-	// Transacción para crear variantes
+	var created []ProductVariant
+	var skipped int
+	before := CaptureRevision(config.DB, product.ID)
 	err := config.DB.Transaction(func(tx *gorm.DB) error {
-		// Si sizes vacíos, sólo iterar colors; si colors vacíos, sólo iterar sizes
-		if len(colors) > 0 && len(sizes) > 0 {
-			for _, col := range colors {
-				for _, sz := range sizes {
-					// Si ya existe, saltar
-					var exists ProductVariant
-					q := tx.Where("product_id = ? AND color = ? AND size = ?", product.ID, col, sz).First(&exists)
-					if q.Error == nil {
-						skipped++
-						continue
-					}
-					if q.Error != nil && q.Error != gorm.ErrRecordNotFound {
-						return q.Error
-					}
-					// Generar SKU simple: prefix + pid + color + size (sanitizado)
-					sku := fmt.Sprintf("%s-%d-%s-%s", input.SKUPrefix, product.ID, sanitizeSKU(col), sanitizeSKU(sz))
-					// Asegurar unicidad del SKU; si choca, añadir sufijo incremental
-					baseSKU := sku
-					suffix := 1
-					for {
-						var bySKU ProductVariant
-						if err := tx.Where("sku = ?", sku).First(&bySKU).Error; err == gorm.ErrRecordNotFound {
-							break
-						} else if err != nil {
-							return err
-						}
-						sku = fmt.Sprintf("%s-%d", baseSKU, suffix)
-						suffix++
-					}
-					pv := ProductVariant{ProductID: product.ID, Color: col, Size: sz, SKU: sku}
-					if err := tx.Create(&pv).Error; err != nil {
-						return err
-					}
-					created = append(created, pv)
-				}
-			}
-		} else if len(colors) > 0 {
-			for _, col := range colors {
-				var exists ProductVariant
-				q := tx.Where("product_id = ? AND color = ?", product.ID, col).First(&exists)
-				if q.Error == nil {
-					skipped++
-					continue
-				}
-				if q.Error != nil && q.Error != gorm.ErrRecordNotFound {
-					return q.Error
-				}
-				sku := fmt.Sprintf("%s-%d-%s", input.SKUPrefix, product.ID, sanitizeSKU(col))
-				baseSKU := sku
-				suffix := 1
-				for {
-					var bySKU ProductVariant
-					if err := tx.Where("sku = ?", sku).First(&bySKU).Error; err == gorm.ErrRecordNotFound {
-						break
-					} else if err != nil {
-						return err
-					}
-					sku = fmt.Sprintf("%s-%d", baseSKU, suffix)
-					suffix++
-				}
-				pv := ProductVariant{ProductID: product.ID, Color: col, SKU: sku}
-				if err := tx.Create(&pv).Error; err != nil {
-					return err
-				}
-				created = append(created, pv)
-			}
-		} else {
-			// only sizes
-			for _, sz := range sizes {
-				var exists ProductVariant
-				q := tx.Where("product_id = ? AND size = ?", product.ID, sz).First(&exists)
-				if q.Error == nil {
-					skipped++
-					continue
-				}
-				if q.Error != nil && q.Error != gorm.ErrRecordNotFound {
-					return q.Error
-				}
-				sku := fmt.Sprintf("%s-%d-%s", input.SKUPrefix, product.ID, sanitizeSKU(sz))
-				baseSKU := sku
-				suffix := 1
-				for {
-					var bySKU ProductVariant
-					if err := tx.Where("sku = ?", sku).First(&bySKU).Error; err == gorm.ErrRecordNotFound {
-						break
-					} else if err != nil {
-						return err
-					}
-					sku = fmt.Sprintf("%s-%d", baseSKU, suffix)
-					suffix++
-				}
-				pv := ProductVariant{ProductID: product.ID, Size: sz, SKU: sku}
-				if err := tx.Create(&pv).Error; err != nil {
-					return err
-				}
-				created = append(created, pv)
-			}
-		}
-		return nil
+		var err error
+		created, skipped, err = GenerateVariantsTx(tx, product.ID, colors, sizes, input.SKUPrefix)
+		return err
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondVariantInputError(c, err)
 		return
 	}
+	RecordRevision(config.DB, c, product.ID, RevisionVariants, before)
 
 	c.JSON(http.StatusCreated, gin.H{"created": created, "skipped": skipped})
 }
 
+// GenerateVariantsTx crea dentro de tx las combinaciones color x talle que todavía no
+// existan para el producto. Si una de las listas está vacía se generan variantes sólo por
+// la otra. Colores y talles se validan contra las tablas colors y el tipo de talle del
+// producto (*VariantInputError si alguno no corresponde) y se guardan con sus ids.
+// El SKU es prefijo-productID-COLOR-TALLE (sanitizado) con sufijo incremental si choca.
+// Devuelve las variantes creadas y la cantidad de combinaciones ya existentes.
+func GenerateVariantsTx(tx *gorm.DB, productID uint, colors, sizes []string, skuPrefix string) ([]ProductVariant, int, error) {
+	vc, err := catalogForProduct(tx, productID)
+	if err != nil {
+		return nil, 0, err
+	}
+	type ref struct {
+		id   *uint
+		name string
+	}
+	var msgs []string
+	colorRefs := []ref{{}}
+	if len(colors) > 0 {
+		colorRefs = colorRefs[:0]
+		for _, col := range colors {
+			id, name, err := vc.resolveColor(nil, col)
+			if err != nil {
+				msgs = append(msgs, err.Error())
+				continue
+			}
+			colorRefs = append(colorRefs, ref{id, name})
+		}
+	}
+	sizeRefs := []ref{{}}
+	if len(sizes) > 0 {
+		sizeRefs = sizeRefs[:0]
+		for _, sz := range sizes {
+			id, name, err := vc.resolveSize(nil, sz)
+			if err != nil {
+				msgs = append(msgs, err.Error())
+				continue
+			}
+			sizeRefs = append(sizeRefs, ref{id, name})
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, 0, &VariantInputError{Messages: msgs}
+	}
+	created := make([]ProductVariant, 0)
+	skipped := 0
+	for _, colRef := range colorRefs {
+		for _, szRef := range sizeRefs {
+			col, sz := colRef.name, szRef.name
+			// Si ya existe, saltar
+			q := tx.Where("product_id = ?", productID)
+			if col != "" {
+				q = q.Where("color = ?", col)
+			}
+			if sz != "" {
+				q = q.Where("size = ?", sz)
+			}
+			var exists ProductVariant
+			err := q.First(&exists).Error
+			if err == nil {
+				skipped++
+				continue
+			}
+			if err != gorm.ErrRecordNotFound {
+				return nil, 0, err
+			}
+			// Generar SKU simple: prefix + pid + color + size (sanitizado)
+			parts := []string{skuPrefix, fmt.Sprintf("%d", productID)}
+			if col != "" {
+				parts = append(parts, sanitizeSKU(col))
+			}
+			if sz != "" {
+				parts = append(parts, sanitizeSKU(sz))
+			}
+			sku, err := uniqueSKU(tx, strings.Join(parts, "-"))
+			if err != nil {
+				return nil, 0, err
+			}
+			pv := ProductVariant{ProductID: productID, Color: col, Size: sz, SKU: sku, ColorID: colRef.id, SizeValueID: szRef.id}
+			if err := assignBarcode(tx, &pv); err != nil {
+				return nil, 0, err
+			}
+			if err := tx.Create(&pv).Error; err != nil {
+				return nil, 0, err
+			}
+			created = append(created, pv)
+		}
+	}
+	return created, skipped, nil
+}
+
+// uniqueSKU devuelve sku o, si ya lo usa otra variante, sku-1, sku-2...
+func uniqueSKU(tx *gorm.DB, sku string) (string, error) {
+	baseSKU := sku
+	suffix := 1
+	for {
+		var bySKU ProductVariant
+		if err := tx.Where("sku = ?", sku).First(&bySKU).Error; err == gorm.ErrRecordNotFound {
+			return sku, nil
+		} else if err != nil {
+			return "", err
+		}
+		sku = fmt.Sprintf("%s-%d", baseSKU, suffix)
+		suffix++
+	}
+}
+
 // sanitizeSKU normaliza cadenas para generar SKUs simples: elimina espacios y pone mayúsculas
 func sanitizeSKU(s string) string {
 	out := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", "-"))
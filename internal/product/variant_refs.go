@@ -0,0 +1,154 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// VariantInputError junta los colores/talles rechazados al crear variantes (corresponde a un 400)
+type VariantInputError struct {
+	Messages []string
+}
+
+func (e *VariantInputError) Error() string { return strings.Join(e.Messages, "; ") }
+
+// variantCatalog resuelve colores y talles escritos a mano contra las tablas colors
+// y size_values. Igual que la importación de catálogo: si no hay colores cargados se
+// aceptan como texto libre, y si el tipo de talle del producto no tiene valores
+// (o el producto no tiene tipo) el talle queda sin SizeValueID.
+type variantCatalog struct {
+	colors   map[string]Color
+	colorsBy map[uint]Color
+	sizeType *SizeType
+	sizes    map[string]SizeValue
+	sizesBy  map[uint]SizeValue
+}
+
+func loadVariantCatalog(tx *gorm.DB, sizeTypeID *uint) (*variantCatalog, error) {
+	vc := &variantCatalog{colors: map[string]Color{}, colorsBy: map[uint]Color{}, sizes: map[string]SizeValue{}, sizesBy: map[uint]SizeValue{}}
+	var colors []Color
+	if err := tx.Where("active = ?", true).Find(&colors).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range colors {
+		vc.colorsBy[c.ID] = c
+		vc.colors[normalizeColor(c.Key)] = c
+		if c.Name != "" {
+			vc.colors[normalizeColor(c.Name)] = c
+		}
+	}
+	if sizeTypeID != nil {
+		var st SizeType
+		err := tx.Preload("Values").First(&st, *sizeTypeID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			vc.sizeType = &st
+			for _, sv := range st.Values {
+				vc.sizesBy[sv.ID] = sv
+				vc.sizes[normalizeColor(sv.Value)] = sv
+			}
+		}
+	}
+	return vc, nil
+}
+
+func colorDisplayName(c Color) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Key
+}
+
+// resolveColor devuelve el color canónico y su id a partir del id o del texto
+func (vc *variantCatalog) resolveColor(colorID *uint, name string) (*uint, string, error) {
+	if colorID != nil && *colorID != 0 {
+		c, ok := vc.colorsBy[*colorID]
+		if !ok {
+			return nil, "", fmt.Errorf("color_id %d inexistente o inactivo", *colorID)
+		}
+		id := c.ID
+		return &id, colorDisplayName(c), nil
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len(vc.colorsBy) == 0 {
+		return nil, name, nil
+	}
+	c, ok := vc.colors[normalizeColor(name)]
+	if !ok {
+		return nil, "", fmt.Errorf("color '%s' desconocido", name)
+	}
+	id := c.ID
+	return &id, colorDisplayName(c), nil
+}
+
+// resolveSize devuelve el talle canónico y su id validándolo contra el tipo de talle del producto
+func (vc *variantCatalog) resolveSize(sizeValueID *uint, size string) (*uint, string, error) {
+	if sizeValueID != nil && *sizeValueID != 0 {
+		sv, ok := vc.sizesBy[*sizeValueID]
+		if !ok {
+			return nil, "", fmt.Errorf("size_value_id %d no pertenece al tipo de talle del producto", *sizeValueID)
+		}
+		id := sv.ID
+		return &id, sv.Value, nil
+	}
+	size = strings.TrimSpace(size)
+	if size == "" || len(vc.sizesBy) == 0 {
+		return nil, size, nil
+	}
+	sv, ok := vc.sizes[normalizeColor(size)]
+	if !ok {
+		return nil, "", fmt.Errorf("talle '%s' no pertenece al tipo %s", size, vc.sizeType.Key)
+	}
+	id := sv.ID
+	return &id, sv.Value, nil
+}
+
+// resolveVariant completa ColorID/SizeValueID y normaliza Color/Size de la variante
+func (vc *variantCatalog) resolveVariant(v *ProductVariant) error {
+	var msgs []string
+	colorID, color, err := vc.resolveColor(v.ColorID, v.Color)
+	if err != nil {
+		msgs = append(msgs, err.Error())
+	}
+	sizeID, size, err := vc.resolveSize(v.SizeValueID, v.Size)
+	if err != nil {
+		msgs = append(msgs, err.Error())
+	}
+	if len(msgs) > 0 {
+		return &VariantInputError{Messages: msgs}
+	}
+	v.ColorID, v.Color = colorID, color
+	v.SizeValueID, v.Size = sizeID, size
+	v.ColorInfo, v.SizeValue = nil, nil
+	return nil
+}
+
+// catalogForProduct carga el catálogo de colores/talles según el tipo de talle del producto
+func catalogForProduct(tx *gorm.DB, productID uint) (*variantCatalog, error) {
+	var p Product
+	if err := tx.Select("id", "size_type_id").First(&p, productID).Error; err != nil {
+		return nil, err
+	}
+	return loadVariantCatalog(tx, p.SizeTypeID)
+}
+
+// OrderVariants ordena variantes por ordinal del talle (sin talle vinculado al final),
+// luego por color
+func OrderVariants(db *gorm.DB) *gorm.DB {
+	return db.Order("COALESCE((SELECT sv.ordinal FROM size_values sv WHERE sv.id = product_variants.size_value_id), 999999), product_variants.color, product_variants.id")
+}
+
+// PreloadVariants precarga las variantes ordenadas con su color (hex para swatches) y talle
+func PreloadVariants(db *gorm.DB) *gorm.DB {
+	return db.Preload("Variants", OrderVariants).Preload("Variants.ColorInfo").Preload("Variants.SizeValue")
+}
+
+// withVariantRefs precarga color y talle al listar variantes directamente
+func withVariantRefs(db *gorm.DB) *gorm.DB {
+	return db.Preload("ColorInfo").Preload("SizeValue").Scopes(OrderVariants)
+}
@@ -0,0 +1,72 @@
+package product
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGenerateVariants_ResolvesColorAndSizeRefs(t *testing.T) {
+	db := setupTestDB(t)
+	st := SizeType{Key: "letras-ref", Name: "Letras"}
+	db.Create(&st)
+	small := SizeValue{SizeTypeID: st.ID, Value: "S", Ordinal: 1}
+	large := SizeValue{SizeTypeID: st.ID, Value: "L", Ordinal: 3}
+	medium := SizeValue{SizeTypeID: st.ID, Value: "M", Ordinal: 2}
+	db.Create(&small)
+	db.Create(&large)
+	db.Create(&medium)
+	black := Color{Key: "negro-ref", Name: "Negro Azabache", Hex: "#111111", Active: true}
+	db.Create(&black)
+	prod := Product{Name: "Buzo", Code: "TEST-V-1", SizeTypeID: &st.ID}
+	db.Create(&prod)
+
+	router := gin.New()
+	router.POST("/products/:id/variants/generate", GenerateVariants)
+	router.GET("/products/:id/variants", GetProductVariants)
+	generate := func(body gin.H) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/products/%d/variants/generate", prod.ID), bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Un talle que no pertenece al tipo del producto rechaza todo el lote
+	if w := generate(gin.H{"colors": []string{"negro-ref"}, "sizes": []string{"S", "XXL"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for size outside the size type, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := generate(gin.H{"colors": []string{"Violeta"}, "sizes": []string{"S"}}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown color, got %d", w.Code)
+	}
+
+	if w := generate(gin.H{"colors": []string{" NEGRO-REF "}, "sizes": []string{"l", "s", "m"}}); w.Code != http.StatusCreated {
+		t.Fatalf("generate: %d %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/products/%d/variants", prod.ID), nil))
+	var variants []ProductVariant
+	json.Unmarshal(w.Body.Bytes(), &variants)
+	if len(variants) != 3 {
+		t.Fatalf("expected 3 variants, got %d: %s", len(variants), w.Body.String())
+	}
+	for i, want := range []SizeValue{small, medium, large} {
+		v := variants[i]
+		if v.Size != want.Value || v.SizeValueID == nil || *v.SizeValueID != want.ID {
+			t.Fatalf("variant %d: expected size %s (id %d), got %q %v", i, want.Value, want.ID, v.Size, v.SizeValueID)
+		}
+		if v.Color != "Negro Azabache" || v.ColorID == nil || *v.ColorID != black.ID {
+			t.Fatalf("variant %d: color not resolved: %q %v", i, v.Color, v.ColorID)
+		}
+		if v.ColorInfo == nil || v.ColorInfo.Hex != "#111111" {
+			t.Fatalf("variant %d: color hex missing: %+v", i, v.ColorInfo)
+		}
+	}
+}
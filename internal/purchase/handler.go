@@ -0,0 +1,221 @@
+package purchase
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LineInput struct {
+	VariantID uint    `json:"variant_id" binding:"required,gt=0"`
+	Quantity  int     `json:"quantity" binding:"required,gt=0"`
+	UnitCost  float64 `json:"unit_cost" binding:"gte=0"`
+}
+
+type PurchaseOrderInput struct {
+	SupplierID   uint        `json:"supplier_id" binding:"required,gt=0"`
+	Location     string      `json:"location"`
+	ExpectedDate *time.Time  `json:"expected_date"`
+	Notes        string      `json:"notes"`
+	Lines        []LineInput `json:"lines" binding:"required,min=1,dive"`
+}
+
+// buildLines valida las variantes y arma las líneas de la orden.
+// Si no se informa unit_cost se usa el cost_price actual del producto.
+func buildLines(tx *gorm.DB, supplierID uint, inputs []LineInput) ([]PurchaseOrderLine, float64, error) {
+	var sup product.Supplier
+	if err := tx.First(&sup, supplierID).Error; err != nil {
+		return nil, 0, fmt.Errorf("Proveedor %d no encontrado", supplierID)
+	}
+	lines := make([]PurchaseOrderLine, 0, len(inputs))
+	total := 0.0
+	for _, in := range inputs {
+		var v product.ProductVariant
+		if err := tx.First(&v, in.VariantID).Error; err != nil {
+			return nil, 0, fmt.Errorf("Variante %d no encontrada", in.VariantID)
+		}
+		cost := in.UnitCost
+		if cost == 0 {
+			var p product.Product
+			if err := tx.Select("id", "cost_price").First(&p, v.ProductID).Error; err != nil {
+				return nil, 0, fmt.Errorf("Producto %d no encontrado", v.ProductID)
+			}
+			cost = p.CostPrice
+		}
+		lines = append(lines, PurchaseOrderLine{
+			ProductID: v.ProductID,
+			VariantID: v.ID,
+			Quantity:  in.Quantity,
+			UnitCost:  cost,
+		})
+		total += cost * float64(in.Quantity)
+	}
+	return lines, total, nil
+}
+
+// CreatePurchaseOrder crea una orden de compra en estado borrador
+// Ruta: POST /purchase-orders
+func CreatePurchaseOrder(c *gin.Context) {
+	var input PurchaseOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	location := input.Location
+	if location == "" {
+		location = "deposito"
+	}
+
+	var po PurchaseOrder
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		lines, total, err := buildLines(tx, input.SupplierID, input.Lines)
+		if err != nil {
+			return err
+		}
+		number, err := sequence.Next(tx, sequence.PurchaseOrder)
+		if err != nil {
+			return err
+		}
+		po = PurchaseOrder{
+			Number:       number,
+			SupplierID:   input.SupplierID,
+			Status:       "borrador",
+			Location:     location,
+			ExpectedDate: input.ExpectedDate,
+			Notes:        input.Notes,
+			Total:        total,
+			Lines:        lines,
+		}
+		if uid, ok := c.Get("user_id"); ok {
+			if u, ok := uid.(uint); ok {
+				po.CreatedByUserID = &u
+			}
+		}
+		return tx.Create(&po).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, po)
+}
+
+// ListPurchaseOrders lista órdenes de compra (?status=, ?supplier_id=)
+func ListPurchaseOrders(c *gin.Context) {
+	query := config.DB.Preload("Supplier").Preload("Lines").Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	var orders []PurchaseOrder
+	if err := query.Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetPurchaseOrder devuelve una orden con sus líneas y recepciones
+func GetPurchaseOrder(c *gin.Context) {
+	var po PurchaseOrder
+	if err := config.DB.Preload("Supplier").Preload("Lines.Variant").Preload("Receipts.Lines").First(&po, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+	c.JSON(http.StatusOK, po)
+}
+
+// UpdatePurchaseOrder reemplaza los datos y líneas de una orden en borrador
+func UpdatePurchaseOrder(c *gin.Context) {
+	var po PurchaseOrder
+	if err := config.DB.First(&po, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+	if po.Status != "borrador" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sólo se pueden editar órdenes en borrador"})
+		return
+	}
+	var input PurchaseOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		lines, total, err := buildLines(tx, input.SupplierID, input.Lines)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("purchase_order_id = ?", po.ID).Delete(&PurchaseOrderLine{}).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].PurchaseOrderID = po.ID
+		}
+		if err := tx.Create(&lines).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"supplier_id":   input.SupplierID,
+			"expected_date": input.ExpectedDate,
+			"notes":         input.Notes,
+			"total":         total,
+		}
+		if input.Location != "" {
+			updates["location"] = input.Location
+		}
+		return tx.Model(&po).Updates(updates).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config.DB.Preload("Supplier").Preload("Lines").First(&po, po.ID)
+	c.JSON(http.StatusOK, po)
+}
+
+// SendPurchaseOrder marca la orden como enviada al proveedor
+func SendPurchaseOrder(c *gin.Context) {
+	var po PurchaseOrder
+	if err := config.DB.First(&po, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+	if po.Status != "borrador" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("La orden ya fue enviada (estado: %s)", po.Status)})
+		return
+	}
+	now := time.Now()
+	if err := config.DB.Model(&po).Updates(map[string]interface{}{"status": "enviada", "sent_at": now}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, po)
+}
+
+// CancelPurchaseOrder cancela una orden que todavía no recibió mercadería
+func CancelPurchaseOrder(c *gin.Context) {
+	var po PurchaseOrder
+	if err := config.DB.First(&po, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+	if po.Status != "borrador" && po.Status != "enviada" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("No se puede cancelar una orden en estado %s", po.Status)})
+		return
+	}
+	if err := config.DB.Model(&po).Update("status", "cancelada").Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, po)
+}
@@ -0,0 +1,70 @@
+package purchase
+
+import (
+	"time"
+
+	"go-modaMayor/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// PurchaseOrder es una orden de compra a un proveedor.
+// Estados: borrador, enviada, parcial, recibida, cancelada
+type PurchaseOrder struct {
+	gorm.Model
+	Number          string              `json:"number" gorm:"type:varchar(32);uniqueIndex;not null"`
+	SupplierID      uint                `json:"supplier_id" gorm:"index;not null"`
+	Supplier        product.Supplier    `json:"supplier" gorm:"foreignKey:SupplierID"`
+	Status          string              `json:"status" gorm:"type:varchar(20);default:'borrador';index"`
+	Location        string              `json:"location" gorm:"type:varchar(50);default:'deposito'"` // ubicación de recepción por defecto
+	ExpectedDate    *time.Time          `json:"expected_date"`
+	SentAt          *time.Time          `json:"sent_at"`
+	ReceivedAt      *time.Time          `json:"received_at"` // fecha de recepción completa
+	Notes           string              `json:"notes"`
+	Total           float64             `json:"total"`
+	CreatedByUserID *uint               `json:"created_by_user_id"`
+	Lines           []PurchaseOrderLine `json:"lines" gorm:"foreignKey:PurchaseOrderID"`
+	Receipts        []GoodsReceipt      `json:"receipts,omitempty" gorm:"foreignKey:PurchaseOrderID"`
+}
+
+// PurchaseOrderLine es una línea de la orden por variante
+type PurchaseOrderLine struct {
+	gorm.Model
+	PurchaseOrderID  uint                    `json:"purchase_order_id" gorm:"index"`
+	ProductID        uint                    `json:"product_id" gorm:"index"`
+	VariantID        uint                    `json:"variant_id" gorm:"index"`
+	Variant          *product.ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	Quantity         int                     `json:"quantity"`
+	ReceivedQuantity int                     `json:"received_quantity" gorm:"default:0"`
+	UnitCost         float64                 `json:"unit_cost"`
+}
+
+// Pending devuelve la cantidad que falta recibir
+func (l *PurchaseOrderLine) Pending() int {
+	if l.ReceivedQuantity >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.ReceivedQuantity
+}
+
+// GoodsReceipt registra una recepción (total o parcial) de mercadería
+type GoodsReceipt struct {
+	gorm.Model
+	PurchaseOrderID uint               `json:"purchase_order_id" gorm:"index"`
+	Location        string             `json:"location" gorm:"type:varchar(50);not null"`
+	CostMethod      string             `json:"cost_method" gorm:"type:varchar(20)"` // last, weighted_average, none
+	ReceivedAt      time.Time          `json:"received_at"`
+	UserID          *uint              `json:"user_id"`
+	Notes           string             `json:"notes"`
+	Lines           []GoodsReceiptLine `json:"lines" gorm:"foreignKey:GoodsReceiptID"`
+}
+
+type GoodsReceiptLine struct {
+	gorm.Model
+	GoodsReceiptID      uint    `json:"goods_receipt_id" gorm:"index"`
+	PurchaseOrderLineID uint    `json:"purchase_order_line_id" gorm:"index"`
+	ProductID           uint    `json:"product_id"`
+	VariantID           uint    `json:"variant_id"`
+	Quantity            int     `json:"quantity"`
+	UnitCost            float64 `json:"unit_cost"`
+}
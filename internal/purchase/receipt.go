@@ -0,0 +1,239 @@
+package purchase
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Métodos de actualización de costo al recibir mercadería
+const (
+	CostMethodLast            = "last"             // el costo de la última compra reemplaza al actual
+	CostMethodWeightedAverage = "weighted_average" // promedio ponderado con el stock existente
+	CostMethodNone            = "none"             // no modificar el costo del producto
+)
+
+type ReceiptLineInput struct {
+	LineID   uint     `json:"line_id" binding:"required,gt=0"`
+	Quantity int      `json:"quantity" binding:"required,gt=0"`
+	UnitCost *float64 `json:"unit_cost"` // opcional: costo real facturado si difiere de la orden
+}
+
+type ReceiptInput struct {
+	Location   string             `json:"location"`
+	CostMethod string             `json:"cost_method"`
+	Notes      string             `json:"notes"`
+	Lines      []ReceiptLineInput `json:"lines" binding:"required,min=1,dive"`
+}
+
+// ReceiveGoods registra una recepción sobre la orden dentro de tx: incrementa el
+// LocationStock de la ubicación de recepción, registra movimientos "compra",
+// actualiza cantidades recibidas, el costo de los productos y el estado de la orden.
+func ReceiveGoods(tx *gorm.DB, poID uint, input ReceiptInput, userID *uint, userName string) (*GoodsReceipt, error) {
+	// La orden y sus líneas se bloquean antes de validar lo pendiente: dos recepciones
+	// simultáneas de la misma orden no pueden recibir dos veces las mismas unidades.
+	var po PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&po, poID).Error; err != nil {
+		return nil, fmt.Errorf("Orden de compra no encontrada")
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("purchase_order_id = ?", po.ID).Order("id").Find(&po.Lines).Error; err != nil {
+		return nil, err
+	}
+	if po.Status != "enviada" && po.Status != "parcial" {
+		return nil, fmt.Errorf("No se puede recibir mercadería en una orden en estado %s", po.Status)
+	}
+
+	location := input.Location
+	if location == "" {
+		location = po.Location
+	}
+	costMethod := input.CostMethod
+	if costMethod == "" {
+		costMethod = CostMethodLast
+	}
+	if costMethod != CostMethodLast && costMethod != CostMethodWeightedAverage && costMethod != CostMethodNone {
+		return nil, fmt.Errorf("cost_method inválido: %s", costMethod)
+	}
+
+	linesByID := make(map[uint]*PurchaseOrderLine, len(po.Lines))
+	for i := range po.Lines {
+		linesByID[po.Lines[i].ID] = &po.Lines[i]
+	}
+
+	now := time.Now()
+	receipt := GoodsReceipt{
+		PurchaseOrderID: po.ID,
+		Location:        location,
+		CostMethod:      costMethod,
+		ReceivedAt:      now,
+		UserID:          userID,
+		Notes:           input.Notes,
+	}
+
+	// Costo ingresado por producto, para actualizar cost_price una vez por producto
+	type costAcc struct {
+		qty   int
+		total float64
+	}
+	costs := map[uint]*costAcc{}
+	productOrder := []uint{}
+
+	for _, in := range input.Lines {
+		line, ok := linesByID[in.LineID]
+		if !ok {
+			return nil, fmt.Errorf("La línea %d no pertenece a la orden %s", in.LineID, po.Number)
+		}
+		if in.Quantity > line.Pending() {
+			return nil, fmt.Errorf("La línea %d tiene %d unidades pendientes, se intentó recibir %d", line.ID, line.Pending(), in.Quantity)
+		}
+		unitCost := line.UnitCost
+		if in.UnitCost != nil {
+			unitCost = *in.UnitCost
+		}
+
+		// Stock total del producto antes de esta recepción (para promedio ponderado)
+		if _, seen := costs[line.ProductID]; !seen {
+			costs[line.ProductID] = &costAcc{}
+			productOrder = append(productOrder, line.ProductID)
+		}
+		costs[line.ProductID].qty += in.Quantity
+		costs[line.ProductID].total += unitCost * float64(in.Quantity)
+
+		variantID := line.VariantID
+		var ls product.LocationStock
+		err := tx.Where("product_id = ? AND variant_id = ? AND location = ?", line.ProductID, variantID, location).First(&ls).Error
+		previous := 0
+		if err == nil {
+			previous = ls.Stock
+			if err := tx.Model(&ls).Update("stock", gorm.Expr("stock + ?", in.Quantity)).Error; err != nil {
+				return nil, err
+			}
+		} else if err == gorm.ErrRecordNotFound {
+			ls = product.LocationStock{ProductID: line.ProductID, VariantID: &variantID, Location: location, Stock: in.Quantity}
+			if err := tx.Create(&ls).Error; err != nil {
+				return nil, err
+			}
+		} else {
+			return nil, err
+		}
+
+		if err := tx.Create(&product.StockMovement{
+			ProductID:     line.ProductID,
+			VariantID:     &variantID,
+			Location:      location,
+			MovementType:  "compra",
+			Quantity:      in.Quantity,
+			PreviousStock: previous,
+			NewStock:      previous + in.Quantity,
+			Reason:        fmt.Sprintf("Recepción de orden de compra %s", po.Number),
+			Reference:     po.Number,
+			UserID:        userID,
+			UserName:      userName,
+			Notes:         input.Notes,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		line.ReceivedQuantity += in.Quantity
+		if err := tx.Model(line).Update("received_quantity", line.ReceivedQuantity).Error; err != nil {
+			return nil, err
+		}
+
+		receipt.Lines = append(receipt.Lines, GoodsReceiptLine{
+			PurchaseOrderLineID: line.ID,
+			ProductID:           line.ProductID,
+			VariantID:           variantID,
+			Quantity:            in.Quantity,
+			UnitCost:            unitCost,
+		})
+	}
+
+	if costMethod != CostMethodNone {
+		for _, productID := range productOrder {
+			acc := costs[productID]
+			newCost := acc.total / float64(acc.qty)
+			if costMethod == CostMethodWeightedAverage {
+				var p product.Product
+				if err := tx.Select("id", "cost_price").First(&p, productID).Error; err != nil {
+					return nil, err
+				}
+				// El stock ya incluye lo recibido: restarlo para obtener el existente
+				var stock int64
+				if err := tx.Model(&product.LocationStock{}).Where("product_id = ?", productID).
+					Select("COALESCE(SUM(stock),0)").Scan(&stock).Error; err != nil {
+					return nil, err
+				}
+				newCost = product.WeightedAverageCost(p.CostPrice, int(stock)-acc.qty, newCost, acc.qty)
+			}
+			if err := product.ApplyCostPrice(tx, productID, newCost); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Create(&receipt).Error; err != nil {
+		return nil, err
+	}
+
+	complete := true
+	for _, l := range po.Lines {
+		if l.Pending() > 0 {
+			complete = false
+			break
+		}
+	}
+	updates := map[string]interface{}{"status": "parcial"}
+	if complete {
+		updates["status"] = "recibida"
+		updates["received_at"] = now
+	}
+	if err := tx.Model(&po).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// ReceivePurchaseOrder registra la recepción de mercadería de una orden
+// Ruta: POST /purchase-orders/:id/receipts
+func ReceivePurchaseOrder(c *gin.Context) {
+	var po PurchaseOrder
+	if err := config.DB.First(&po, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Orden de compra no encontrada"})
+		return
+	}
+	var input ReceiptInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userID *uint
+	if v, ok := c.Get("user_id"); ok {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+	userName := ""
+	if v, ok := c.Get("user_name"); ok {
+		userName, _ = v.(string)
+	}
+
+	var receipt *GoodsReceipt
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		receipt, err = ReceiveGoods(tx, po.ID, input, userID, userName)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, receipt)
+}
@@ -0,0 +1,81 @@
+package purchase
+
+import (
+	"testing"
+
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/settings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:purchase_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &product.ProductVariant{}, &product.LocationStock{}, &product.StockMovement{},
+		&product.Supplier{}, &settings.PriceTier{}, &PurchaseOrder{}, &PurchaseOrderLine{}, &GoodsReceipt{}, &GoodsReceiptLine{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	return db
+}
+
+func TestReceiveGoods_PartialThenCompleteWithWeightedAverage(t *testing.T) {
+	db := setupTestDB(t)
+	db.Create(&settings.PriceTier{Name: "wholesale", DisplayName: "Mayorista", FormulaType: "multiplier", Multiplier: 2, OrderIndex: 1, Active: true, IsDefault: true})
+	sup := product.Supplier{Name: "Proveedor Test"}
+	db.Create(&sup)
+	prod := product.Product{Name: "Jean", Code: "TEST-PO-1", CostPrice: 100}
+	db.Create(&prod)
+	pv := product.ProductVariant{ProductID: prod.ID, SKU: "po-sku-1"}
+	db.Create(&pv)
+	// 10 unidades existentes a costo 100
+	db.Create(&product.LocationStock{ProductID: prod.ID, VariantID: &pv.ID, Location: "deposito", Stock: 10})
+
+	po := PurchaseOrder{Number: "OC-TEST-1", SupplierID: sup.ID, Status: "enviada", Location: "deposito",
+		Lines: []PurchaseOrderLine{{ProductID: prod.ID, VariantID: pv.ID, Quantity: 20, UnitCost: 160}}}
+	db.Create(&po)
+	lineID := po.Lines[0].ID
+
+	// Recepción parcial de 10 unidades a 160 -> promedio (10*100 + 10*160) / 20 = 130
+	if _, err := ReceiveGoods(db, po.ID, ReceiptInput{CostMethod: CostMethodWeightedAverage,
+		Lines: []ReceiptLineInput{{LineID: lineID, Quantity: 10}}}, nil, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ls product.LocationStock
+	db.Where("variant_id = ? AND location = ?", pv.ID, "deposito").First(&ls)
+	if ls.Stock != 20 {
+		t.Fatalf("expected stock 20 after receipt, got %d", ls.Stock)
+	}
+	var p product.Product
+	db.First(&p, prod.ID)
+	if p.CostPrice != 130 || p.WholesalePrice != 260 {
+		t.Fatalf("expected cost 130 and wholesale 260, got %v / %v", p.CostPrice, p.WholesalePrice)
+	}
+	var mv product.StockMovement
+	db.Where("reference = ?", po.Number).First(&mv)
+	if mv.MovementType != "compra" || mv.PreviousStock != 10 || mv.NewStock != 20 {
+		t.Fatalf("unexpected stock movement: %+v", mv)
+	}
+	db.First(&po, po.ID)
+	if po.Status != "parcial" {
+		t.Fatalf("expected status parcial, got %s", po.Status)
+	}
+
+	// No se puede recibir más de lo pendiente
+	if _, err := ReceiveGoods(db, po.ID, ReceiptInput{Lines: []ReceiptLineInput{{LineID: lineID, Quantity: 11}}}, nil, ""); err == nil {
+		t.Fatalf("expected error when receiving more than pending")
+	}
+
+	if _, err := ReceiveGoods(db, po.ID, ReceiptInput{CostMethod: CostMethodNone,
+		Lines: []ReceiptLineInput{{LineID: lineID, Quantity: 10}}}, nil, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db.First(&po, po.ID)
+	if po.Status != "recibida" || po.ReceivedAt == nil {
+		t.Fatalf("expected status recibida with received_at, got %s", po.Status)
+	}
+}
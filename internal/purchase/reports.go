@@ -0,0 +1,157 @@
+package purchase
+
+import (
+	"net/http"
+	"time"
+
+	"go-modaMayor/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OpenPurchaseOrderRow struct {
+	ID            uint       `json:"id"`
+	Number        string     `json:"number"`
+	SupplierID    uint       `json:"supplier_id"`
+	SupplierName  string     `json:"supplier_name"`
+	Status        string     `json:"status"`
+	ExpectedDate  *time.Time `json:"expected_date"`
+	OrderedQty    int        `json:"ordered_qty"`
+	ReceivedQty   int        `json:"received_qty"`
+	PendingQty    int        `json:"pending_qty"`
+	PendingAmount float64    `json:"pending_amount"`
+	Overdue       bool       `json:"overdue"`
+}
+
+// GetOpenPurchaseOrdersReport lista órdenes enviadas o parcialmente recibidas con lo pendiente
+// Ruta: GET /reports/purchase-orders/open (?supplier_id=)
+func GetOpenPurchaseOrdersReport(c *gin.Context) {
+	query := config.DB.Preload("Supplier").Preload("Lines").
+		Where("status IN ?", []string{"enviada", "parcial"}).
+		Order("expected_date ASC")
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	var orders []PurchaseOrder
+	if err := query.Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	rows := make([]OpenPurchaseOrderRow, 0, len(orders))
+	totalPending := 0.0
+	for _, po := range orders {
+		row := OpenPurchaseOrderRow{
+			ID:           po.ID,
+			Number:       po.Number,
+			SupplierID:   po.SupplierID,
+			SupplierName: po.Supplier.Name,
+			Status:       po.Status,
+			ExpectedDate: po.ExpectedDate,
+			Overdue:      po.ExpectedDate != nil && po.ExpectedDate.Before(now),
+		}
+		for _, l := range po.Lines {
+			row.OrderedQty += l.Quantity
+			row.ReceivedQty += l.ReceivedQuantity
+			row.PendingQty += l.Pending()
+			row.PendingAmount += float64(l.Pending()) * l.UnitCost
+		}
+		totalPending += row.PendingAmount
+		rows = append(rows, row)
+	}
+	c.JSON(http.StatusOK, gin.H{"orders": rows, "total_pending_amount": totalPending})
+}
+
+type SupplierPerformance struct {
+	SupplierID      uint    `json:"supplier_id"`
+	SupplierName    string  `json:"supplier_name"`
+	Orders          int     `json:"orders"`
+	OrderedQty      int     `json:"ordered_qty"`
+	ReceivedQty     int     `json:"received_qty"`
+	FillRate        float64 `json:"fill_rate"`          // recibido / pedido
+	CompletedOrders int     `json:"completed_orders"`   // órdenes recibidas en su totalidad
+	OnTimeRate      float64 `json:"on_time_rate"`       // completas a tiempo / completas con fecha esperada
+	AvgLeadTimeDays float64 `json:"avg_lead_time_days"` // envío → recepción completa
+	TotalPurchased  float64 `json:"total_purchased"`
+}
+
+// GetSupplierPerformanceReport resume cumplimiento por proveedor de órdenes enviadas en el período
+// Ruta: GET /reports/suppliers/performance (?from=YYYY-MM-DD&to=YYYY-MM-DD)
+func GetSupplierPerformanceReport(c *gin.Context) {
+	query := config.DB.Preload("Supplier").Preload("Lines").
+		Where("status IN ?", []string{"enviada", "parcial", "recibida"})
+	if from := c.Query("from"); from != "" {
+		if t, err := time.Parse("2006-01-02", from); err == nil {
+			query = query.Where("sent_at >= ?", t)
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if t, err := time.Parse("2006-01-02", to); err == nil {
+			query = query.Where("sent_at < ?", t.AddDate(0, 0, 1))
+		}
+	}
+	var orders []PurchaseOrder
+	if err := query.Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, buildSupplierPerformance(orders))
+}
+
+func buildSupplierPerformance(orders []PurchaseOrder) []SupplierPerformance {
+	type acc struct {
+		SupplierPerformance
+		withExpected int
+		onTime       int
+		leadDays     float64
+		leadCount    int
+	}
+	bySupplier := map[uint]*acc{}
+	order := []uint{}
+	for _, po := range orders {
+		a, ok := bySupplier[po.SupplierID]
+		if !ok {
+			a = &acc{SupplierPerformance: SupplierPerformance{SupplierID: po.SupplierID, SupplierName: po.Supplier.Name}}
+			bySupplier[po.SupplierID] = a
+			order = append(order, po.SupplierID)
+		}
+		a.Orders++
+		for _, l := range po.Lines {
+			a.OrderedQty += l.Quantity
+			a.ReceivedQty += l.ReceivedQuantity
+			a.TotalPurchased += float64(l.ReceivedQuantity) * l.UnitCost
+		}
+		if po.Status != "recibida" || po.ReceivedAt == nil {
+			continue
+		}
+		a.CompletedOrders++
+		if po.ExpectedDate != nil {
+			a.withExpected++
+			// A tiempo si se completó antes del final del día esperado
+			if po.ReceivedAt.Before(po.ExpectedDate.AddDate(0, 0, 1)) {
+				a.onTime++
+			}
+		}
+		if po.SentAt != nil {
+			a.leadDays += po.ReceivedAt.Sub(*po.SentAt).Hours() / 24
+			a.leadCount++
+		}
+	}
+
+	out := make([]SupplierPerformance, 0, len(order))
+	for _, id := range order {
+		a := bySupplier[id]
+		if a.OrderedQty > 0 {
+			a.FillRate = float64(a.ReceivedQty) / float64(a.OrderedQty)
+		}
+		if a.withExpected > 0 {
+			a.OnTimeRate = float64(a.onTime) / float64(a.withExpected)
+		}
+		if a.leadCount > 0 {
+			a.AvgLeadTimeDays = a.leadDays / float64(a.leadCount)
+		}
+		out = append(out, a.SupplierPerformance)
+	}
+	return out
+}
@@ -9,6 +9,7 @@ import (
 
 	"go-modaMayor/config"
 	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -78,13 +79,11 @@ func ListRemitosInternosHistorico(c *gin.Context) {
    c.JSON(http.StatusOK, remitos)
 }
 
-// GenerarNumeroRemito genera un número único para el remito interno
-func GenerarNumeroRemito() (string, error) {
-	var count int64
-	if err := config.DB.Model(&RemitoInterno{}).Count(&count).Error; err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("RI-%05d", count+1), nil
+// GenerarNumeroRemito genera un número único para el remito interno.
+// Debe llamarse con la transacción que crea el remito para que el número
+// se consuma sólo si el remito se guarda.
+func GenerarNumeroRemito(tx *gorm.DB) (string, error) {
+	return sequence.Next(tx, sequence.Remito)
 }
 
 // ListRemitosInternosPendientes lista todos los remitos internos pendientes de recepción
@@ -0,0 +1,202 @@
+package returns
+
+import (
+	"fmt"
+	"net/http"
+
+	"go-modaMayor/config"
+	"go-modaMayor/internal/notification"
+	"go-modaMayor/internal/order"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func currentUser(c *gin.Context) (*uint, string, string) {
+	var userID *uint
+	if v, ok := c.Get("user_id"); ok {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+	role, _ := c.Get("user_role")
+	roleStr, _ := role.(string)
+	name, _ := c.Get("user_name")
+	nameStr, _ := name.(string)
+	return userID, roleStr, nameStr
+}
+
+// CreateReturnHandler registra una solicitud de devolución.
+// Los clientes sólo pueden devolver ítems de sus propias órdenes.
+// Ruta: POST /returns
+func CreateReturnHandler(c *gin.Context) {
+	var input CreateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID, role, _ := currentUser(c)
+	if role == "cliente" {
+		var ord order.Order
+		if err := config.DB.Select("id", "user_id").First(&ord, input.OrderID).Error; err != nil || userID == nil || ord.UserID != *userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No autorizado para devolver esta orden"})
+			return
+		}
+	}
+
+	var ret *Return
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ret, err = CreateReturn(tx, input, userID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ret)
+}
+
+// ListReturns lista devoluciones (?status=, ?order_id=); los clientes ven sólo las propias
+func ListReturns(c *gin.Context) {
+	userID, role, _ := currentUser(c)
+	query := config.DB.Preload("Items").Preload("CreditNote").Order("created_at DESC")
+	if role == "cliente" {
+		if userID == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No autenticado"})
+			return
+		}
+		query = query.Where("user_id = ?", *userID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if orderID := c.Query("order_id"); orderID != "" {
+		query = query.Where("order_id = ?", orderID)
+	}
+	var list []Return
+	if err := query.Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// GetReturn devuelve una devolución con sus ítems y nota de crédito
+func GetReturn(c *gin.Context) {
+	var ret Return
+	if err := config.DB.Preload("Items").Preload("CreditNote").First(&ret, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Devolución no encontrada"})
+		return
+	}
+	userID, role, _ := currentUser(c)
+	if role == "cliente" && (userID == nil || ret.UserID != *userID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No autorizado"})
+		return
+	}
+	c.JSON(http.StatusOK, ret)
+}
+
+// InspectReturnHandler registra la inspección y reingreso de stock
+// Ruta: POST /returns/:id/inspect
+func InspectReturnHandler(c *gin.Context) {
+	var input InspectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var existing Return
+	if err := config.DB.Select("id").First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Devolución no encontrada"})
+		return
+	}
+	userID, _, userName := currentUser(c)
+	var ret *Return
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ret, err = InspectReturn(tx, existing.ID, input, userID, userName)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ret)
+}
+
+// ResolveReturnHandler emite crédito, reembolso o cambio para una devolución inspeccionada
+// Ruta: POST /returns/:id/resolve
+func ResolveReturnHandler(c *gin.Context) {
+	var input ResolveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var existing Return
+	if err := config.DB.Select("id").First(&existing, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Devolución no encontrada"})
+		return
+	}
+	var ret *Return
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ret, err = ResolveReturn(tx, existing.ID, input)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	msg := fmt.Sprintf("Tu devolución %s fue resuelta: %s por $%.2f", ret.Number, ret.Resolution, ret.Amount)
+	if ret.CreditNote != nil {
+		msg += fmt.Sprintf(" (nota de crédito %s)", ret.CreditNote.Number)
+	}
+	config.DB.Create(&notification.Notification{UserID: ret.UserID, Message: msg})
+	c.JSON(http.StatusOK, ret)
+}
+
+// RejectReturn rechaza una devolución todavía no inspeccionada
+// Ruta: POST /returns/:id/reject
+func RejectReturn(c *gin.Context) {
+	var ret Return
+	if err := config.DB.First(&ret, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Devolución no encontrada"})
+		return
+	}
+	if ret.Status != "solicitada" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("No se puede rechazar una devolución en estado %s", ret.Status)})
+		return
+	}
+	var input struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.ShouldBindJSON(&input)
+	updates := map[string]interface{}{"status": "rechazada"}
+	if input.Notes != "" {
+		updates["notes"] = input.Notes
+	}
+	if err := config.DB.Model(&ret).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	config.DB.Create(&notification.Notification{UserID: ret.UserID, Message: fmt.Sprintf("Tu devolución %s fue rechazada", ret.Number)})
+	c.JSON(http.StatusOK, ret)
+}
+
+// ListCreditNotes lista notas de crédito (?user_id=, ?type=)
+func ListCreditNotes(c *gin.Context) {
+	query := config.DB.Order("created_at DESC")
+	if uid := c.Query("user_id"); uid != "" {
+		query = query.Where("user_id = ?", uid)
+	}
+	if t := c.Query("type"); t != "" {
+		query = query.Where("type = ?", t)
+	}
+	var notes []CreditNote
+	if err := query.Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
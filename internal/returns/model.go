@@ -0,0 +1,91 @@
+package returns
+
+import (
+	"time"
+
+	"go-modaMayor/internal/sequence"
+
+	"gorm.io/gorm"
+)
+
+// Return es una devolución (RMA) de un cliente sobre ítems de una orden.
+// Estados: solicitada -> inspeccionada -> resuelta; o rechazada
+type Return struct {
+	gorm.Model
+	Number          string       `json:"number" gorm:"type:varchar(32);uniqueIndex"`
+	OrderID         uint         `json:"order_id" gorm:"index;not null"`
+	UserID          uint         `json:"user_id" gorm:"index"` // cliente dueño de la orden
+	Status          string       `json:"status" gorm:"type:varchar(20);default:'solicitada';index"`
+	Resolution      string       `json:"resolution" gorm:"type:varchar(20)"` // credito, reembolso, cambio
+	Amount          float64      `json:"amount"`                             // valor de los ítems devueltos
+	Notes           string       `json:"notes"`
+	CreatedByUserID *uint        `json:"created_by_user_id"`
+	ExchangeCartID  *uint        `json:"exchange_cart_id"` // carrito generado para un cambio
+	ResolvedAt      *time.Time   `json:"resolved_at"`
+	Items           []ReturnItem `json:"items" gorm:"foreignKey:ReturnID"`
+	CreditNote      *CreditNote  `json:"credit_note,omitempty" gorm:"foreignKey:ReturnID"`
+}
+
+// BeforeCreate asigna el número de devolución desde la secuencia "return"
+func (r *Return) BeforeCreate(tx *gorm.DB) error {
+	if r.Number == "" {
+		number, err := sequence.Next(tx, sequence.Return)
+		if err != nil {
+			return err
+		}
+		r.Number = number
+	}
+	return nil
+}
+
+// Motivos de devolución
+var ValidReasons = map[string]bool{
+	"defecto":          true,
+	"talle_incorrecto": true,
+	"color_incorrecto": true,
+	"no_coincide":      true, // no coincide con lo publicado
+	"otro":             true,
+}
+
+// ReturnItem es una línea devuelta, ligada al ítem de la orden original.
+// Condition: pendiente (sin inspeccionar), reingresado (vuelve a stock) o danado
+type ReturnItem struct {
+	gorm.Model
+	ReturnID    uint       `json:"return_id" gorm:"index"`
+	OrderItemID uint       `json:"order_item_id" gorm:"index"`
+	ProductID   uint       `json:"product_id"`
+	VariantID   *uint      `json:"variant_id"`
+	Quantity    int        `json:"quantity"`
+	UnitPrice   float64    `json:"unit_price"` // precio pagado en la orden
+	Reason      string     `json:"reason" gorm:"type:varchar(30)"`
+	Condition   string     `json:"condition" gorm:"type:varchar(20);default:'pendiente'"`
+	Location    string     `json:"location"` // ubicación donde se reingresó el stock
+	InspectedAt *time.Time `json:"inspected_at"`
+}
+
+// CreditNote es el crédito o reembolso emitido por una devolución.
+// Type: credito (saldo a favor), reembolso (devolución de dinero) o cambio (aplicado a un carrito)
+type CreditNote struct {
+	gorm.Model
+	Number          string  `json:"number" gorm:"type:varchar(32);uniqueIndex"`
+	ReturnID        uint    `json:"return_id" gorm:"index"`
+	OrderID         uint    `json:"order_id" gorm:"index"`
+	UserID          uint    `json:"user_id" gorm:"index"`
+	Type            string  `json:"type" gorm:"type:varchar(20)"`
+	Amount          float64 `json:"amount"`
+	Status          string  `json:"status" gorm:"type:varchar(20);default:'emitida'"` // emitida, aplicada, reembolsada
+	RefundMethod    string  `json:"refund_method" gorm:"size:64"`
+	RefundReference string  `json:"refund_reference" gorm:"size:255"`
+}
+
+// BeforeCreate asigna el número de nota de crédito desde la secuencia "credit_note"
+func (n *CreditNote) BeforeCreate(tx *gorm.DB) error {
+	if n.Number == "" {
+		number, err := sequence.Next(tx, sequence.CreditNote)
+		if err != nil {
+			return err
+		}
+		n.Number = number
+	}
+	return nil
+}
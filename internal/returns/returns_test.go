@@ -0,0 +1,84 @@
+package returns
+
+import (
+	"testing"
+
+	"go-modaMayor/internal/cart"
+	"go-modaMayor/internal/order"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/user"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:returns_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&sequence.Sequence{}, &user.User{}, &product.Product{}, &product.ProductVariant{}, &product.LocationStock{},
+		&product.StockMovement{}, &order.Order{}, &order.OrderItem{}, &cart.Cart{}, &cart.CartItem{}, &Return{}, &ReturnItem{}, &CreditNote{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	return db
+}
+
+func TestReturnFlow_RestockAndCredit(t *testing.T) {
+	db := setupTestDB(t)
+	prod := product.Product{Name: "Buzo", Code: "TEST-RET-1"}
+	db.Create(&prod)
+	pv := product.ProductVariant{ProductID: prod.ID, SKU: "ret-sku-1", Size: "M"}
+	db.Create(&pv)
+	db.Create(&product.LocationStock{ProductID: prod.ID, VariantID: &pv.ID, Location: "deposito", Stock: 4})
+
+	ord := order.Order{UserID: 7, Status: "pagado", Total: 300,
+		Items: []order.OrderItem{{ProductID: prod.ID, VariantID: &pv.ID, Quantity: 3, Price: 100}}}
+	db.Create(&ord)
+	itemID := ord.Items[0].ID
+
+	ret, err := CreateReturn(db, CreateInput{OrderID: ord.ID, Items: []ItemInput{
+		{OrderItemID: itemID, Quantity: 1, Reason: "defecto"},
+		{OrderItemID: itemID, Quantity: 1, Reason: "talle_incorrecto"},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ret.Amount != 200 || ret.Number == "" {
+		t.Fatalf("expected amount 200 and a number, got %+v", ret)
+	}
+	// Sólo queda 1 unidad sin devolver
+	if _, err := CreateReturn(db, CreateInput{OrderID: ord.ID, Items: []ItemInput{{OrderItemID: itemID, Quantity: 2, Reason: "otro"}}}, nil); err == nil {
+		t.Fatalf("expected error when returning more than purchased")
+	}
+
+	ret, err = InspectReturn(db, ret.ID, InspectInput{Items: []InspectItemInput{
+		{ReturnItemID: ret.Items[0].ID, Condition: "danado"},
+		{ReturnItemID: ret.Items[1].ID, Condition: "reingresado", Location: "deposito"},
+	}}, nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ret.Status != "inspeccionada" {
+		t.Fatalf("expected inspeccionada, got %s", ret.Status)
+	}
+	var ls product.LocationStock
+	db.Where("variant_id = ? AND location = ?", pv.ID, "deposito").First(&ls)
+	if ls.Stock != 5 {
+		t.Fatalf("expected only the restocked unit to be added (5), got %d", ls.Stock)
+	}
+
+	ret, err = ResolveReturn(db, ret.ID, ResolveInput{Resolution: "credito"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ret.CreditNote == nil || ret.CreditNote.Amount != 200 || ret.CreditNote.Number == "" {
+		t.Fatalf("expected credit note for 200, got %+v", ret.CreditNote)
+	}
+	var updated order.Order
+	db.Preload("Items").First(&updated, ord.ID)
+	if updated.Total != 100 || updated.ReturnedTotal != 200 || updated.Items[0].ReturnedQuantity != 2 {
+		t.Fatalf("unexpected order after return: total=%v returned=%v qty=%d", updated.Total, updated.ReturnedTotal, updated.Items[0].ReturnedQuantity)
+	}
+}
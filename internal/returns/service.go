@@ -0,0 +1,305 @@
+package returns
+
+import (
+	"fmt"
+	"time"
+
+	"go-modaMayor/internal/cart"
+	"go-modaMayor/internal/order"
+	"go-modaMayor/internal/product"
+
+	"gorm.io/gorm"
+)
+
+// Estados de orden sobre los que se aceptan devoluciones
+var returnableOrderStatuses = map[string]bool{"pagado": true, "enviado": true, "completado": true}
+
+type ItemInput struct {
+	OrderItemID uint   `json:"order_item_id" binding:"required,gt=0"`
+	Quantity    int    `json:"quantity" binding:"required,gt=0"`
+	Reason      string `json:"reason" binding:"required"`
+}
+
+type CreateInput struct {
+	OrderID uint        `json:"order_id" binding:"required,gt=0"`
+	Notes   string      `json:"notes"`
+	Items   []ItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+// returnedQuantity devuelve las unidades de un ítem de orden ya incluidas en devoluciones no rechazadas
+func returnedQuantity(tx *gorm.DB, orderItemID uint) (int, error) {
+	var qty int64
+	err := tx.Table("return_items").
+		Joins("JOIN returns ON returns.id = return_items.return_id AND returns.deleted_at IS NULL").
+		Where("return_items.order_item_id = ? AND return_items.deleted_at IS NULL AND returns.status <> ?", orderItemID, "rechazada").
+		Select("COALESCE(SUM(return_items.quantity),0)").Scan(&qty).Error
+	return int(qty), err
+}
+
+// CreateReturn registra una devolución en estado solicitada validando que las
+// cantidades no superen lo comprado menos lo ya devuelto.
+func CreateReturn(tx *gorm.DB, input CreateInput, createdBy *uint) (*Return, error) {
+	var ord order.Order
+	if err := tx.Preload("Items").First(&ord, input.OrderID).Error; err != nil {
+		return nil, fmt.Errorf("Orden no encontrada")
+	}
+	if !returnableOrderStatuses[ord.Status] {
+		return nil, fmt.Errorf("No se aceptan devoluciones para órdenes en estado %s", ord.Status)
+	}
+	itemsByID := make(map[uint]order.OrderItem, len(ord.Items))
+	for _, it := range ord.Items {
+		itemsByID[it.ID] = it
+	}
+
+	ret := Return{OrderID: ord.ID, UserID: ord.UserID, Status: "solicitada", Notes: input.Notes, CreatedByUserID: createdBy}
+	requested := map[uint]int{}
+	for _, in := range input.Items {
+		if !ValidReasons[in.Reason] {
+			return nil, fmt.Errorf("Motivo de devolución inválido: %s", in.Reason)
+		}
+		it, ok := itemsByID[in.OrderItemID]
+		if !ok {
+			return nil, fmt.Errorf("El ítem %d no pertenece a la orden %s", in.OrderItemID, ord.Number)
+		}
+		already, err := returnedQuantity(tx, it.ID)
+		if err != nil {
+			return nil, err
+		}
+		requested[it.ID] += in.Quantity
+		if already+requested[it.ID] > it.Quantity {
+			return nil, fmt.Errorf("El ítem %d tiene %d unidades compradas y %d ya devueltas", it.ID, it.Quantity, already)
+		}
+		ret.Items = append(ret.Items, ReturnItem{
+			OrderItemID: it.ID,
+			ProductID:   it.ProductID,
+			VariantID:   it.VariantID,
+			Quantity:    in.Quantity,
+			UnitPrice:   it.Price,
+			Reason:      in.Reason,
+			Condition:   "pendiente",
+		})
+		ret.Amount += it.Price * float64(in.Quantity)
+	}
+	if err := tx.Create(&ret).Error; err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+type InspectItemInput struct {
+	ReturnItemID uint   `json:"return_item_id" binding:"required,gt=0"`
+	Condition    string `json:"condition" binding:"required,oneof=reingresado danado"`
+	Location     string `json:"location"` // requerido si se reingresa
+}
+
+type InspectInput struct {
+	Items []InspectItemInput `json:"items" binding:"required,min=1,dive"`
+}
+
+// InspectReturn registra la inspección de los ítems: los reingresados suman stock en la
+// ubicación elegida con un movimiento "return"; los dañados quedan fuera del stock vendible.
+// Cuando todos los ítems fueron inspeccionados la devolución pasa a inspeccionada.
+func InspectReturn(tx *gorm.DB, returnID uint, input InspectInput, userID *uint, userName string) (*Return, error) {
+	var ret Return
+	if err := tx.Preload("Items").First(&ret, returnID).Error; err != nil {
+		return nil, fmt.Errorf("Devolución no encontrada")
+	}
+	if ret.Status != "solicitada" {
+		return nil, fmt.Errorf("La devolución ya está en estado %s", ret.Status)
+	}
+	itemsByID := make(map[uint]*ReturnItem, len(ret.Items))
+	for i := range ret.Items {
+		itemsByID[ret.Items[i].ID] = &ret.Items[i]
+	}
+
+	now := time.Now()
+	for _, in := range input.Items {
+		item, ok := itemsByID[in.ReturnItemID]
+		if !ok {
+			return nil, fmt.Errorf("El ítem %d no pertenece a la devolución %s", in.ReturnItemID, ret.Number)
+		}
+		if item.Condition != "pendiente" {
+			return nil, fmt.Errorf("El ítem %d ya fue inspeccionado", item.ID)
+		}
+		if in.Condition == "reingresado" {
+			if in.Location == "" {
+				return nil, fmt.Errorf("location es requerido para reingresar el ítem %d", item.ID)
+			}
+			if err := restock(tx, &ret, item, in.Location, userID, userName); err != nil {
+				return nil, err
+			}
+			item.Location = in.Location
+		}
+		item.Condition = in.Condition
+		item.InspectedAt = &now
+		if err := tx.Model(item).Updates(map[string]interface{}{
+			"condition":    item.Condition,
+			"location":     item.Location,
+			"inspected_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	pending := false
+	for _, it := range ret.Items {
+		if it.Condition == "pendiente" {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		ret.Status = "inspeccionada"
+		if err := tx.Model(&ret).Update("status", ret.Status).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &ret, nil
+}
+
+func restock(tx *gorm.DB, ret *Return, item *ReturnItem, location string, userID *uint, userName string) error {
+	var ls product.LocationStock
+	query := tx.Where("product_id = ? AND location = ?", item.ProductID, location)
+	if item.VariantID != nil {
+		query = query.Where("variant_id = ?", *item.VariantID)
+	} else {
+		query = query.Where("variant_id IS NULL")
+	}
+	previous := 0
+	err := query.First(&ls).Error
+	if err == nil {
+		previous = ls.Stock
+		if err := tx.Model(&ls).Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error; err != nil {
+			return err
+		}
+	} else if err == gorm.ErrRecordNotFound {
+		ls = product.LocationStock{ProductID: item.ProductID, VariantID: item.VariantID, Location: location, Stock: item.Quantity}
+		if err := tx.Create(&ls).Error; err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+	return tx.Create(&product.StockMovement{
+		ProductID:     item.ProductID,
+		VariantID:     item.VariantID,
+		Location:      location,
+		MovementType:  "return",
+		Quantity:      item.Quantity,
+		PreviousStock: previous,
+		NewStock:      previous + item.Quantity,
+		Reason:        fmt.Sprintf("Devolución %s (%s)", ret.Number, item.Reason),
+		Reference:     ret.Number,
+		UserID:        userID,
+		UserName:      userName,
+	}).Error
+}
+
+type ExchangeItemInput struct {
+	ProductID uint  `json:"product_id" binding:"required,gt=0"`
+	VariantID *uint `json:"variant_id"`
+	Quantity  int   `json:"quantity" binding:"required,gt=0"`
+}
+
+type ResolveInput struct {
+	Resolution      string              `json:"resolution" binding:"required,oneof=credito reembolso cambio"`
+	RefundMethod    string              `json:"refund_method"`
+	RefundReference string              `json:"refund_reference"`
+	ExchangeItems   []ExchangeItemInput `json:"exchange_items" binding:"dive"`
+}
+
+// ResolveReturn emite la nota de crédito (crédito, reembolso o cambio), descuenta el
+// importe del total de la orden y marca las unidades devueltas en sus ítems para que
+// los reportes de ventas reflejen ventas netas. Para un cambio genera un carrito nuevo
+// del cliente asignado a la vendedora de la orden, que sigue el circuito habitual de reserva.
+func ResolveReturn(tx *gorm.DB, returnID uint, input ResolveInput) (*Return, error) {
+	var ret Return
+	if err := tx.Preload("Items").First(&ret, returnID).Error; err != nil {
+		return nil, fmt.Errorf("Devolución no encontrada")
+	}
+	if ret.Status != "inspeccionada" {
+		return nil, fmt.Errorf("La devolución debe estar inspeccionada para resolverse (estado: %s)", ret.Status)
+	}
+	var ord order.Order
+	if err := tx.First(&ord, ret.OrderID).Error; err != nil {
+		return nil, fmt.Errorf("Orden no encontrada")
+	}
+
+	note := CreditNote{
+		ReturnID:        ret.ID,
+		OrderID:         ord.ID,
+		UserID:          ord.UserID,
+		Type:            input.Resolution,
+		Amount:          ret.Amount,
+		Status:          "emitida",
+		RefundMethod:    input.RefundMethod,
+		RefundReference: input.RefundReference,
+	}
+	switch input.Resolution {
+	case "reembolso":
+		if input.RefundMethod == "" {
+			return nil, fmt.Errorf("refund_method es requerido para un reembolso")
+		}
+		note.Status = "reembolsada"
+	case "cambio":
+		if len(input.ExchangeItems) == 0 {
+			return nil, fmt.Errorf("exchange_items es requerido para un cambio")
+		}
+		exchange := cart.Cart{UserID: ord.UserID, VendedorID: ord.AssignedTo, Estado: "esperando_vendedora"}
+		for _, ex := range input.ExchangeItems {
+			var p product.Product
+			if err := tx.Select("id").First(&p, ex.ProductID).Error; err != nil {
+				return nil, fmt.Errorf("Producto %d no encontrado", ex.ProductID)
+			}
+			if ex.VariantID != nil {
+				var v product.ProductVariant
+				if err := tx.Where("id = ? AND product_id = ?", *ex.VariantID, ex.ProductID).First(&v).Error; err != nil {
+					return nil, fmt.Errorf("Variante %d no encontrada para el producto %d", *ex.VariantID, ex.ProductID)
+				}
+			}
+			exchange.Items = append(exchange.Items, cart.CartItem{
+				ProductID:          ex.ProductID,
+				VariantID:          ex.VariantID,
+				Quantity:           ex.Quantity,
+				RequiresStockCheck: true,
+				PendingReason:      "cambio",
+			})
+		}
+		if err := tx.Create(&exchange).Error; err != nil {
+			return nil, err
+		}
+		ret.ExchangeCartID = &exchange.ID
+		note.Status = "aplicada"
+	}
+	if err := tx.Create(&note).Error; err != nil {
+		return nil, err
+	}
+
+	for _, it := range ret.Items {
+		if err := tx.Model(&order.OrderItem{}).Where("id = ?", it.OrderItemID).
+			Update("returned_quantity", gorm.Expr("returned_quantity + ?", it.Quantity)).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Model(&ord).Updates(map[string]interface{}{
+		"total":          gorm.Expr("total - ?", ret.Amount),
+		"returned_total": gorm.Expr("returned_total + ?", ret.Amount),
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ret.Status = "resuelta"
+	ret.Resolution = input.Resolution
+	ret.ResolvedAt = &now
+	if err := tx.Model(&ret).Updates(map[string]interface{}{
+		"status":           ret.Status,
+		"resolution":       ret.Resolution,
+		"resolved_at":      now,
+		"exchange_cart_id": ret.ExchangeCartID,
+	}).Error; err != nil {
+		return nil, err
+	}
+	ret.CreditNote = &note
+	return &ret, nil
+}
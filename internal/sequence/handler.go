@@ -0,0 +1,57 @@
+package sequence
+
+import (
+	"go-modaMayor/config"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSequences devuelve todas las secuencias con su valor actual (solo admin)
+func ListSequences(c *gin.Context) {
+	var seqs []Sequence
+	if err := config.DB.Order("name ASC").Find(&seqs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, seqs)
+}
+
+// UpdateSequence permite cambiar prefijo y relleno. El valor actual sólo puede
+// avanzarse (nunca retroceder) para no reutilizar números ya emitidos.
+func UpdateSequence(c *gin.Context) {
+	name := c.Param("name")
+	var seq Sequence
+	if err := config.DB.Where("name = ?", name).First(&seq).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Secuencia no encontrada"})
+		return
+	}
+	var input struct {
+		Prefix       *string `json:"prefix"`
+		Padding      *int    `json:"padding" binding:"omitempty,gte=0,lte=20"`
+		CurrentValue *int64  `json:"current_value"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updates := map[string]interface{}{}
+	if input.Prefix != nil {
+		updates["prefix"] = *input.Prefix
+	}
+	if input.Padding != nil {
+		updates["padding"] = *input.Padding
+	}
+	if input.CurrentValue != nil {
+		if *input.CurrentValue < seq.CurrentValue {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "El valor actual no puede ser menor al último número emitido"})
+			return
+		}
+		updates["current_value"] = *input.CurrentValue
+	}
+	if err := config.DB.Model(&seq).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, seq)
+}
@@ -0,0 +1,54 @@
+package sequence
+
+import "gorm.io/gorm"
+
+// Sequence es un contador con nombre usado para numerar documentos
+// (remitos, productos, órdenes, facturas, notas de crédito).
+// El valor se incrementa dentro de la misma transacción que crea el
+// documento, por lo que un rollback no deja huecos en la numeración.
+type Sequence struct {
+	gorm.Model
+	Name         string `json:"name" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Prefix       string `json:"prefix" gorm:"type:varchar(20)"`
+	Padding      int    `json:"padding" gorm:"default:0"`
+	CurrentValue int64  `json:"current_value" gorm:"default:0"`
+}
+
+// Nombres de las secuencias usadas por el sistema
+const (
+	Remito     = "remito"
+	Product    = "product"
+	Order      = "order"
+	Invoice    = "invoice"
+	CreditNote = "credit_note"
+	// Órdenes de compra a proveedores
+	PurchaseOrder = "purchase_order"
+	// Devoluciones de clientes (RMA)
+	Return = "return"
+	// Códigos EAN-13 de variantes: el prefijo es el prefijo de empresa GS1
+	// (por defecto 200, rango de uso interno) y el valor el número de artículo
+	Barcode = "barcode"
+)
+
+// Definition describe prefijo y relleno por defecto de una secuencia. Table y
+// Column indican dónde están los números ya emitidos, para arrancar la secuencia
+// después del último y no repetir números existentes.
+type Definition struct {
+	Prefix  string
+	Padding int
+	Table   string
+	Column  string
+}
+
+// Defaults se usa para crear la secuencia la primera vez que se pide un número.
+// Los códigos de barras no necesitan tabla: NextEAN13 saltea los ya usados.
+var Defaults = map[string]Definition{
+	Remito:        {Prefix: "RI-", Padding: 5, Table: "remitos_internos", Column: "numero"},
+	Product:       {Prefix: "PROD-", Padding: 6, Table: "products", Column: "code"},
+	Order:         {Prefix: "ORD-", Padding: 6, Table: "orders", Column: "number"},
+	Invoice:       {Prefix: "FAC-", Padding: 8},
+	CreditNote:    {Prefix: "NC-", Padding: 8, Table: "credit_notes", Column: "number"},
+	PurchaseOrder: {Prefix: "OC-", Padding: 6, Table: "purchase_orders", Column: "number"},
+	Return:        {Prefix: "DEV-", Padding: 6, Table: "returns", Column: "number"},
+	Barcode:       {Prefix: "200", Padding: 9},
+}
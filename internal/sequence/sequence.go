@@ -0,0 +1,96 @@
+package sequence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Next incrementa atómicamente la secuencia y devuelve el número formateado.
+// Debe llamarse con la transacción que crea el documento: el UPDATE bloquea la
+// fila hasta el commit, así dos documentos concurrentes nunca reciben el mismo
+// número, y si la transacción falla el incremento se revierte.
+func Next(tx *gorm.DB, name string) (string, error) {
+	seq, err := NextValue(tx, name)
+	if err != nil {
+		return "", err
+	}
+	return seq.Format(seq.CurrentValue), nil
+}
+
+// NextValue es como Next pero devuelve la secuencia con el valor ya incrementado
+func NextValue(tx *gorm.DB, name string) (*Sequence, error) {
+	res := tx.Model(&Sequence{}).Where("name = ?", name).
+		UpdateColumn("current_value", gorm.Expr("current_value + 1"))
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		// Primera vez: crear con la definición por defecto a partir del último número
+		// emitido (ignorando si otra transacción la creó en paralelo) y volver a incrementar
+		def, ok := Defaults[name]
+		if !ok {
+			return nil, fmt.Errorf("secuencia desconocida: %s", name)
+		}
+		last, err := lastIssued(tx, def)
+		if err != nil {
+			return nil, err
+		}
+		seed := Sequence{Name: name, Prefix: def.Prefix, Padding: def.Padding, CurrentValue: last}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&seed).Error; err != nil {
+			return nil, err
+		}
+		res = tx.Model(&Sequence{}).Where("name = ?", name).
+			UpdateColumn("current_value", gorm.Expr("current_value + 1"))
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil, fmt.Errorf("no se pudo inicializar la secuencia %s", name)
+		}
+	}
+
+	var seq Sequence
+	if err := tx.Where("name = ?", name).First(&seq).Error; err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// lastIssued devuelve el mayor número con el prefijo de la secuencia que ya existe en
+// su tabla (0 si no hay tabla o números). Con relleno fijo el más largo y, a igual
+// largo, el mayor como texto es el mayor número; los códigos que no son prefijo +
+// dígitos se ignoran.
+func lastIssued(tx *gorm.DB, def Definition) (int64, error) {
+	if def.Table == "" || !tx.Migrator().HasTable(def.Table) {
+		return 0, nil
+	}
+	rows, err := tx.Table(def.Table).Select(def.Column).Where(def.Column+" LIKE ?", def.Prefix+"%").
+		Order("LENGTH(" + def.Column + ") DESC, " + def.Column + " DESC").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return 0, err
+		}
+		digits := strings.TrimPrefix(value, def.Prefix)
+		if digits == "" || strings.Trim(digits, "0123456789") != "" {
+			continue
+		}
+		if n, err := strconv.ParseInt(digits, 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, rows.Err()
+}
+
+// Format aplica prefijo y relleno con ceros al valor dado
+func (s *Sequence) Format(value int64) string {
+	return fmt.Sprintf("%s%0*d", s.Prefix, s.Padding, value)
+}
@@ -0,0 +1,81 @@
+package sequence
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:sequence_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&Sequence{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	db.Exec("DELETE FROM sequences")
+	return db
+}
+
+func TestNext_FormatsAndIncrements(t *testing.T) {
+	db := setupTestDB(t)
+
+	first, err := Next(db, Remito)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := Next(db, Remito)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != "RI-00001" || second != "RI-00002" {
+		t.Fatalf("expected RI-00001, RI-00002 got %s, %s", first, second)
+	}
+}
+
+func TestNext_RollbackLeavesNoGap(t *testing.T) {
+	db := setupTestDB(t)
+
+	if _, err := Next(db, Order); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Una transacción que falla no debe consumir número
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Next(tx, Order); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	got, err := Next(db, Order)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "ORD-000002" {
+		t.Fatalf("expected ORD-000002 after rollback, got %s", got)
+	}
+}
+
+func TestNext_UnknownSequence(t *testing.T) {
+	db := setupTestDB(t)
+	if _, err := Next(db, "desconocida"); err == nil {
+		t.Fatalf("expected error for unknown sequence")
+	}
+}
+
+func TestNext_SeedsFromExistingNumbers(t *testing.T) {
+	db := setupTestDB(t)
+	db.Exec("CREATE TABLE products (code TEXT)")
+	defer db.Exec("DROP TABLE products")
+	db.Exec("INSERT INTO products (code) VALUES ('PROD-000007'), ('PROD-000041'), ('PROD-000041-B'), ('PROD-X')")
+
+	got, err := Next(db, Product)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "PROD-000042" {
+		t.Fatalf("expected PROD-000042 after the existing numbers, got %s", got)
+	}
+}
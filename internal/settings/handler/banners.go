@@ -7,6 +7,7 @@ import (
 
 	"go-modaMayor/config"
 	settings "go-modaMayor/internal/settings"
+	"go-modaMayor/internal/storage"
 
 	"gorm.io/gorm"
 
@@ -56,14 +57,18 @@ func CreateBanner(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No image file provided"})
 		return
 	}
-	// Save file to uploads with unique name
-	filename := "uploads/banner_" + filepath.Base(file.Filename)
-	if err := c.SaveUploadedFile(file, filename); err != nil {
+	// Save file to the configured storage (local uploads or S3)
+	store, ok := uploadStorage(c)
+	if !ok {
+		return
+	}
+	imageURL, err := storage.SaveUploadedFile(c.Request.Context(), store, file, "banner_"+filepath.Base(file.Filename))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	b := settings.Banner{ImageURL: "/" + filename, AltText: alt, Link: link, Order: order, Active: active}
+	b := settings.Banner{ImageURL: imageURL, AltText: alt, Link: link, Order: order, Active: active}
 	if err := config.DB.Create(&b).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -71,6 +76,17 @@ func CreateBanner(c *gin.Context) {
 	c.JSON(http.StatusCreated, b)
 }
 
+// uploadStorage devuelve el almacenamiento de uploads; si la configuración es
+// inválida responde 500 y devuelve ok=false
+func uploadStorage(c *gin.Context) (storage.Storage, bool) {
+	store, err := storage.Default()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return store, true
+}
+
 // PUT /settings/banners/:id - update banner fields (JSON body) or multipart if updating image
 func UpdateBanner(c *gin.Context) {
 	id := c.Param("id")
@@ -83,12 +99,16 @@ func UpdateBanner(c *gin.Context) {
 	// If multipart with image
 	file, _ := c.FormFile("image")
 	if file != nil {
-		filename := "uploads/banner_" + filepath.Base(file.Filename)
-		if err := c.SaveUploadedFile(file, filename); err != nil {
+		store, ok := uploadStorage(c)
+		if !ok {
+			return
+		}
+		imageURL, err := storage.SaveUploadedFile(c.Request.Context(), store, file, "banner_"+filepath.Base(file.Filename))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		b.ImageURL = "/" + filename
+		b.ImageURL = imageURL
 	}
 
 	// parse optional form fields
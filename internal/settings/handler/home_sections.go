@@ -39,7 +39,7 @@ func GetPublicHomeSections(c *gin.Context) {
 				Order("\"order\" asc").Find(&entries).Error; err == nil {
 				for _, e := range entries {
 					var p product.Product
-					if err := config.DB.First(&p, e.ProductID).Error; err == nil {
+					if err := config.DB.Scopes(product.Published).First(&p, e.ProductID).Error; err == nil {
 						resp[sectionKey] = append(resp[sectionKey], p)
 					}
 				}
@@ -56,28 +56,28 @@ func GetPublicHomeSections(c *gin.Context) {
 			switch sectionKey {
 			case "new_arrivals":
 				var products []product.Product
-				if err := config.DB.Where("is_new_arrival = ?", true).
+				if err := config.DB.Scopes(product.Published).Where("is_new_arrival = ?", true).
 					Order("created_at DESC").Limit(needed).Find(&products).Error; err == nil {
 					resp[sectionKey] = append(resp[sectionKey], products...)
 				}
 
 			case "featured":
 				var products []product.Product
-				if err := config.DB.Where("is_featured = ?", true).
+				if err := config.DB.Scopes(product.Published).Where("is_featured = ?", true).
 					Order("created_at DESC").Limit(needed).Find(&products).Error; err == nil {
 					resp[sectionKey] = append(resp[sectionKey], products...)
 				}
 
 			case "offers":
 				var products []product.Product
-				if err := config.DB.Where("is_offer = ?", true).
+				if err := config.DB.Scopes(product.Published).Where("is_offer = ?", true).
 					Order("created_at DESC").Limit(needed).Find(&products).Error; err == nil {
 					resp[sectionKey] = append(resp[sectionKey], products...)
 				}
 
 			case "trending":
 				var products []product.Product
-				if err := config.DB.Where("is_trending = ?", true).
+				if err := config.DB.Scopes(product.Published).Where("is_trending = ?", true).
 					Order("created_at DESC").Limit(needed).Find(&products).Error; err == nil {
 					resp[sectionKey] = append(resp[sectionKey], products...)
 				}
@@ -96,7 +96,7 @@ func GetPublicHomeSections(c *gin.Context) {
 					Limit(needed).Find(&snapshots).Error; err == nil {
 					for _, snap := range snapshots {
 						var p product.Product
-						if err := config.DB.First(&p, snap.ProductID).Error; err == nil {
+						if err := config.DB.Scopes(product.Published).First(&p, snap.ProductID).Error; err == nil {
 							resp[sectionKey] = append(resp[sectionKey], p)
 						}
 					}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"go-modaMayor/config"
+	"go-modaMayor/internal/product"
 	settings "go-modaMayor/internal/settings"
 	"net/http"
 	"strconv"
@@ -127,6 +128,7 @@ func UpdatePriceTier(c *gin.Context) {
 	updates["percentage"] = input.Percentage
 	updates["flat_amount"] = input.FlatAmount
 	updates["min_quantity"] = input.MinQuantity
+	updates["min_curves"] = input.MinCurves
 	if input.OrderIndex > 0 {
 		updates["order_index"] = input.OrderIndex
 	}
@@ -238,10 +240,12 @@ func RecalculateAllProductPrices(c *gin.Context) {
 			"discount2_price": prices.Discount2Price,
 		}
 
+		before := product.CaptureRevision(config.DB, prod.ID)
 		if err := config.DB.Table("products").Where("id = ?", prod.ID).Updates(updates).Error; err != nil {
 			errors++
 		} else {
 			updated++
+			product.RecordRevision(config.DB, c, prod.ID, product.RevisionPrices, before)
 		}
 	}
 
@@ -256,7 +260,7 @@ func RecalculateAllProductPrices(c *gin.Context) {
 
 // GET /settings/price-tiers/calculate
 // Endpoint auxiliar para calcular precios según diferentes tiers
-// Query params: cost_price (requerido), quantity (opcional, default 1)
+// Query params: cost_price (requerido), quantity (opcional, default 1), curves (opcional, curvas completas)
 // Devuelve todos los tiers aplicables con sus precios calculados
 func CalculatePricesForTiers(c *gin.Context) {
 	costPriceStr := c.Query("cost_price")
@@ -278,6 +282,13 @@ func CalculatePricesForTiers(c *gin.Context) {
 		}
 	}
 
+	curves := 0
+	if curvesStr := c.Query("curves"); curvesStr != "" {
+		if n, err := strconv.Atoi(curvesStr); err == nil {
+			curves = n
+		}
+	}
+
 	var tiers []settings.PriceTier
 	if err := config.DB.Where("active = ?", true).Order("order_index ASC").Find(&tiers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -296,7 +307,7 @@ func CalculatePricesForTiers(c *gin.Context) {
 	// Encontrar el tier aplicable según la cantidad (el de mayor MinQuantity que cumpla)
 	for i := range tiers {
 		tier := &tiers[i]
-		applies := quantity >= tier.MinQuantity
+		applies := tier.Qualifies(quantity, curves)
 
 		if applies && (applicableTier == nil || tier.MinQuantity > applicableTier.MinQuantity) {
 			applicableTier = tier
@@ -322,6 +333,7 @@ func CalculatePricesForTiers(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"cost_price": costPrice,
 		"quantity":   quantity,
+		"curves":     curves,
 		"tiers":      result,
 	})
 }
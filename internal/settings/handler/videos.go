@@ -7,6 +7,7 @@ import (
 
 	"go-modaMayor/config"
 	settings "go-modaMayor/internal/settings"
+	"go-modaMayor/internal/storage"
 
 	"gorm.io/gorm"
 
@@ -59,26 +60,34 @@ func CreateVideo(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "No video file provided or external_url missing"})
 			return
 		}
-		filename = "uploads/video_" + filepath.Base(file.Filename)
-		if err := c.SaveUploadedFile(file, filename); err != nil {
+		store, ok := uploadStorage(c)
+		if !ok {
+			return
+		}
+		url, err := storage.SaveUploadedFile(c.Request.Context(), store, file, "video_"+filepath.Base(file.Filename))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		filename = url
 	}
 
 	// optional thumbnail
 	thumbFile, _ := c.FormFile("thumbnail")
 	thumbName := ""
 	if thumbFile != nil {
-		tn := "uploads/video_thumb_" + filepath.Base(thumbFile.Filename)
-		if err := c.SaveUploadedFile(thumbFile, tn); err == nil {
-			thumbName = "/" + tn
+		store, ok := uploadStorage(c)
+		if !ok {
+			return
+		}
+		if url, err := storage.SaveUploadedFile(c.Request.Context(), store, thumbFile, "video_thumb_"+filepath.Base(thumbFile.Filename)); err == nil {
+			thumbName = url
 		}
 	}
 
 	v := settings.Video{Title: title, Description: desc, VideoURL: "", ExternalURL: external, ThumbnailURL: thumbName, Order: order, Active: active}
 	if filename != "" {
-		v.VideoURL = "/" + filename
+		v.VideoURL = filename
 	}
 	if err := config.DB.Create(&v).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -99,12 +108,16 @@ func UpdateVideo(c *gin.Context) {
 	external := c.PostForm("external_url")
 	file, _ := c.FormFile("video")
 	if file != nil {
-		filename := "uploads/video_" + filepath.Base(file.Filename)
-		if err := c.SaveUploadedFile(file, filename); err != nil {
+		store, ok := uploadStorage(c)
+		if !ok {
+			return
+		}
+		url, err := storage.SaveUploadedFile(c.Request.Context(), store, file, "video_"+filepath.Base(file.Filename))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		v.VideoURL = "/" + filename
+		v.VideoURL = url
 		// clear external url if a file was uploaded
 		v.ExternalURL = ""
 	} else if external != "" {
@@ -115,12 +128,16 @@ func UpdateVideo(c *gin.Context) {
 	// thumbnail
 	thumbFile, _ := c.FormFile("thumbnail")
 	if thumbFile != nil {
-		tn := "uploads/video_thumb_" + filepath.Base(thumbFile.Filename)
-		if err := c.SaveUploadedFile(thumbFile, tn); err != nil {
+		store, ok := uploadStorage(c)
+		if !ok {
+			return
+		}
+		url, err := storage.SaveUploadedFile(c.Request.Context(), store, thumbFile, "video_thumb_"+filepath.Base(thumbFile.Filename))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		v.ThumbnailURL = "/" + tn
+		v.ThumbnailURL = url
 	}
 
 	if title := c.PostForm("title"); title != "" {
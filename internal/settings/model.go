@@ -26,6 +26,7 @@ type PriceTier struct {
 	Percentage   float64 `json:"percentage" gorm:"default:0.0"`                 // para percentage_markup: precio = costo + (costo * percentage/100)
 	FlatAmount   float64 `json:"flat_amount" gorm:"default:0.0"`                // para flat_amount: precio = costo + flat_amount
 	MinQuantity  int     `json:"min_quantity" gorm:"default:0"`                 // cantidad mínima para aplicar este tier
+	MinCurves    int     `json:"min_curves" gorm:"default:0"`                   // curvas completas que también habilitan el tier (0 = no aplica)
 	OrderIndex   int     `json:"order_index" gorm:"not null"`                   // orden de aplicación (menor = mayor prioridad si se cumplen condiciones)
 	Active       bool    `json:"active" gorm:"default:true"`                    // si está activo
 	Description  string  `json:"description" gorm:"type:text"`                  // descripción para el admin
@@ -34,6 +35,14 @@ type PriceTier struct {
 	ColorCode    string  `json:"color_code" gorm:"type:varchar(7)"`             // código de color hex para la UI
 }
 
+// Qualifies indica si el tier aplica para la cantidad de prendas o de curvas completas
+func (pt *PriceTier) Qualifies(quantity, curves int) bool {
+	if quantity >= pt.MinQuantity {
+		return true
+	}
+	return pt.MinCurves > 0 && curves >= pt.MinCurves
+}
+
 // CalculatePrice calcula el precio según el tipo de fórmula
 func (pt *PriceTier) CalculatePrice(costPrice float64) float64 {
 	log.Printf("[DEBUG PriceTier.CalculatePrice] INPUT: costPrice=%.2f, FormulaType=%s, Multiplier=%.2f, Percentage=%.2f, FlatAmount=%.2f", 
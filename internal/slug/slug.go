@@ -0,0 +1,152 @@
+package slug
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Entidades con slug público
+const (
+	EntityProduct  = "product"
+	EntityCategory = "category"
+	EntitySeason   = "season"
+)
+
+// tables indica la tabla de cada entidad (todas con columnas id, slug y deleted_at)
+var tables = map[string]string{
+	EntityProduct:  "products",
+	EntityCategory: "categories",
+	EntitySeason:   "seasons",
+}
+
+// fallbacks se usan cuando el nombre no deja ningún carácter válido
+var fallbacks = map[string]string{
+	EntityProduct:  "producto",
+	EntityCategory: "categoria",
+	EntitySeason:   "temporada",
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "ä", "a", "â", "a", "ã", "a",
+	"é", "e", "è", "e", "ë", "e", "ê", "e",
+	"í", "i", "ì", "i", "ï", "i", "î", "i",
+	"ó", "o", "ò", "o", "ö", "o", "ô", "o", "õ", "o",
+	"ú", "u", "ù", "u", "ü", "u", "û", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// maxLength deja lugar para el sufijo de colisión dentro de varchar(160)
+const maxLength = 150
+
+// Make arma un slug en minúsculas, sin acentos y con guiones ("Camperas de Jeán" -> "camperas-de-jean")
+func Make(s string) string {
+	s = accents.Replace(strings.ToLower(strings.TrimSpace(s)))
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	out := strings.TrimSuffix(b.String(), "-")
+	if len(out) > maxLength {
+		out = strings.TrimSuffix(out[:maxLength], "-")
+	}
+	return out
+}
+
+// Unique devuelve base o base-2, base-3... si ya lo usa otra fila de la entidad.
+// Cuenta también las filas borradas para que un restore no genere duplicados.
+func Unique(db *gorm.DB, entity, base string, excludeID uint) (string, error) {
+	base = Make(base)
+	if base == "" {
+		base = fallbacks[entity]
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		var count int64
+		if err := db.Table(tables[entity]).Where("slug = ? AND id <> ?", candidate, excludeID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// Redirect guarda un slug anterior de una entidad para redirigir a la URL actual
+// cuando cambia el nombre
+type Redirect struct {
+	gorm.Model
+	Entity   string `json:"entity" gorm:"type:varchar(20);not null;index:idx_slug_redirects_lookup"`
+	OldSlug  string `json:"old_slug" gorm:"type:varchar(160);not null;index:idx_slug_redirects_lookup"`
+	TargetID uint   `json:"target_id" gorm:"not null;index"`
+}
+
+func (Redirect) TableName() string { return "slug_redirects" }
+
+// Change asigna a la entidad un slug único a partir de base y, si era distinto
+// del anterior, deja el anterior como redirección. Devuelve el slug final.
+func Change(tx *gorm.DB, entity string, id uint, oldSlug, base string) (string, error) {
+	table, ok := tables[entity]
+	if !ok {
+		return "", fmt.Errorf("entidad sin slug: %s", entity)
+	}
+	newSlug, err := Unique(tx, entity, base, id)
+	if err != nil {
+		return "", err
+	}
+	if newSlug == oldSlug {
+		return oldSlug, nil
+	}
+	if err := tx.Table(table).Where("id = ?", id).Update("slug", newSlug).Error; err != nil {
+		return "", err
+	}
+	// Si vuelve a un slug que tuvo antes, esa redirección ya no hace falta
+	if err := tx.Unscoped().Where("entity = ? AND old_slug = ?", entity, newSlug).Delete(&Redirect{}).Error; err != nil {
+		return "", err
+	}
+	if oldSlug != "" {
+		if err := tx.Unscoped().Where("entity = ? AND old_slug = ?", entity, oldSlug).Delete(&Redirect{}).Error; err != nil {
+			return "", err
+		}
+		if err := tx.Create(&Redirect{Entity: entity, OldSlug: oldSlug, TargetID: id}).Error; err != nil {
+			return "", err
+		}
+	}
+	return newSlug, nil
+}
+
+// Resolve busca la entidad por su slug actual y, si no lo encuentra, entre los
+// slugs anteriores (redirected=true). Devuelve gorm.ErrRecordNotFound si no existe.
+func Resolve(db *gorm.DB, entity, s string) (id uint, redirected bool, err error) {
+	table, ok := tables[entity]
+	if !ok {
+		return 0, false, fmt.Errorf("entidad sin slug: %s", entity)
+	}
+	var ids []uint
+	if err := db.Table(table).Where("slug = ? AND deleted_at IS NULL", s).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, false, err
+	}
+	if len(ids) > 0 {
+		return ids[0], false, nil
+	}
+	var r Redirect
+	if err := db.Where("entity = ? AND old_slug = ?", entity, s).Order("id DESC").First(&r).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, gorm.ErrRecordNotFound
+		}
+		return 0, false, err
+	}
+	return r.TargetID, true, nil
+}
@@ -0,0 +1,68 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMake(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Camperas de Jeán":     "camperas-de-jean",
+		"  Niños / Niñas  ":    "ninos-ninas",
+		"Remeras M/C (Básica)": "remeras-m-c-basica",
+		"¡¡!!":                 "",
+	} {
+		if got := Make(in); got != expected {
+			t.Fatalf("Make(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+	if got := Make(strings.Repeat("ab ", 100)); len(got) > maxLength || strings.HasSuffix(got, "-") {
+		t.Fatalf("expected truncated slug, got %q", got)
+	}
+}
+
+func TestChangeAndResolve(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:slugs?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	db.Exec("CREATE TABLE seasons (id INTEGER PRIMARY KEY, slug TEXT, deleted_at DATETIME)")
+	if err := db.AutoMigrate(&Redirect{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Exec("INSERT INTO seasons (id, slug) VALUES (1, 'verano-2026'), (2, NULL)")
+
+	// Colisión con otra temporada: se agrega sufijo
+	if got, err := Unique(db, EntitySeason, "Verano 2026", 2); err != nil || got != "verano-2026-2" {
+		t.Fatalf("expected suffixed slug, got %s", got)
+	}
+	if got, err := Unique(db, EntitySeason, "???", 2); err != nil || got != "temporada" {
+		t.Fatalf("expected fallback slug, got %s", got)
+	}
+
+	newSlug, err := Change(db, EntitySeason, 1, "verano-2026", "Verano Cálido 2026")
+	if err != nil || newSlug != "verano-calido-2026" {
+		t.Fatalf("change: %s %v", newSlug, err)
+	}
+	if id, redirected, err := Resolve(db, EntitySeason, "verano-2026"); err != nil || id != 1 || !redirected {
+		t.Fatalf("expected redirect, got %d %v %v", id, redirected, err)
+	}
+	if id, redirected, err := Resolve(db, EntitySeason, "verano-calido-2026"); err != nil || id != 1 || redirected {
+		t.Fatalf("expected current slug, got %d %v %v", id, redirected, err)
+	}
+	// Volver al nombre original elimina la redirección que lo pisaba
+	if _, err := Change(db, EntitySeason, 1, "verano-calido-2026", "Verano 2026"); err != nil {
+		t.Fatalf("change back: %v", err)
+	}
+	var count int64
+	db.Model(&Redirect{}).Where("old_slug = ?", "verano-2026").Count(&count)
+	if count != 0 {
+		t.Fatalf("expected stale redirect removed")
+	}
+	if _, _, err := Resolve(db, EntitySeason, "no-existe"); err != gorm.ErrRecordNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+// Package migrate copia los archivos subidos de un almacenamiento a otro (por
+// ejemplo del disco local a S3) y reescribe las URLs guardadas en la base.
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"path"
+
+	"go-modaMayor/internal/imaging"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/settings"
+	"go-modaMayor/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// Report resume la migración
+type Report struct {
+	FilesCopied  int            `json:"files_copied"`
+	RowsUpdated  map[string]int `json:"rows_updated"`
+	Missing      []string       `json:"missing"` // URLs cuyo archivo no existe en el origen
+	DeletedFiles int            `json:"deleted_files"`
+}
+
+// Options controla la migración
+type Options struct {
+	DryRun       bool // no copia ni actualiza, sólo informa
+	DeleteSource bool // borra del origen los archivos copiados al terminar
+}
+
+type migrator struct {
+	ctx      context.Context
+	src, dst storage.Storage
+	opts     Options
+	report   *Report
+	copied   map[string]string // clave -> URL nueva
+	missing  map[string]bool
+}
+
+// Run copia a dst todos los archivos de src referenciados por productos,
+// variantes, la galería de imágenes, banners y videos, y reescribe
+// ImageURL/ImageModel/ImageHanger, los image sets y las URLs de la galería, banners
+// y videos. Las URLs externas no se tocan.
+func Run(ctx context.Context, db *gorm.DB, src, dst storage.Storage, opts Options) (*Report, error) {
+	m := &migrator{ctx: ctx, src: src, dst: dst, opts: opts, report: &Report{RowsUpdated: map[string]int{}},
+		copied: map[string]string{}, missing: map[string]bool{}}
+
+	var products []product.Product
+	err := db.FindInBatches(&products, 100, func(tx *gorm.DB, batch int) error {
+		for _, p := range products {
+			updates := map[string]interface{}{}
+			if err := m.rewriteURL(&p.ImageURL, "image_url", updates); err != nil {
+				return err
+			}
+			if err := m.rewriteURL(&p.ImageModel, "image_model", updates); err != nil {
+				return err
+			}
+			if err := m.rewriteURL(&p.ImageHanger, "image_hanger", updates); err != nil {
+				return err
+			}
+			for column, set := range map[string]*imaging.ImageSet{"image_set": p.ImageSet, "image_model_set": p.ImageModelSet, "image_hanger_set": p.ImageHangerSet} {
+				if err := m.rewriteSet(set, column, updates); err != nil {
+					return err
+				}
+			}
+			if err := m.apply(db, &product.Product{}, p.ID, "products", updates); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var variants []product.ProductVariant
+	err = db.FindInBatches(&variants, 200, func(tx *gorm.DB, batch int) error {
+		for _, v := range variants {
+			updates := map[string]interface{}{}
+			if err := m.rewriteURL(&v.ImageURL, "image_url", updates); err != nil {
+				return err
+			}
+			if err := m.rewriteSet(v.ImageSet, "image_set", updates); err != nil {
+				return err
+			}
+			if err := m.apply(db, &product.ProductVariant{}, v.ID, "product_variants", updates); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// La galería se sincroniza sobre image_url de productos y variantes al editarla:
+	// si quedara con las URLs viejas las volvería a copiar encima de las migradas
+	var images []product.ProductImage
+	err = db.FindInBatches(&images, 200, func(tx *gorm.DB, batch int) error {
+		for _, img := range images {
+			updates := map[string]interface{}{}
+			if err := m.rewriteURL(&img.URL, "url", updates); err != nil {
+				return err
+			}
+			if err := m.rewriteSet(img.ImageSet, "image_set", updates); err != nil {
+				return err
+			}
+			if err := m.apply(db, &product.ProductImage{}, img.ID, "product_images", updates); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var banners []settings.Banner
+	if err := db.Find(&banners).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range banners {
+		updates := map[string]interface{}{}
+		if err := m.rewriteURL(&b.ImageURL, "image_url", updates); err != nil {
+			return nil, err
+		}
+		if err := m.apply(db, &settings.Banner{}, b.ID, "banners", updates); err != nil {
+			return nil, err
+		}
+	}
+
+	var videos []settings.Video
+	if err := db.Find(&videos).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range videos {
+		updates := map[string]interface{}{}
+		if err := m.rewriteURL(&v.VideoURL, "video_url", updates); err != nil {
+			return nil, err
+		}
+		if err := m.rewriteURL(&v.ThumbnailURL, "thumbnail_url", updates); err != nil {
+			return nil, err
+		}
+		if err := m.apply(db, &settings.Video{}, v.ID, "videos", updates); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DeleteSource && !opts.DryRun {
+		for key := range m.copied {
+			if err := src.Delete(ctx, key); err != nil {
+				return m.report, err
+			}
+			m.report.DeletedFiles++
+		}
+	}
+	return m.report, nil
+}
+
+// move copia el archivo de la URL (si pertenece al origen) y devuelve la URL nueva
+func (m *migrator) move(url string) (string, error) {
+	key := storage.KeyFromURL(m.src, url)
+	if key == "" || m.missing[key] {
+		return url, nil
+	}
+	if newURL, ok := m.copied[key]; ok {
+		return newURL, nil
+	}
+	if m.opts.DryRun {
+		exists, err := m.src.Exists(m.ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			m.markMissing(key, url)
+			return url, nil
+		}
+		m.copied[key] = m.dst.URL(key)
+		m.report.FilesCopied++
+		return m.copied[key], nil
+	}
+
+	rc, err := m.src.Open(m.ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		m.markMissing(key, url)
+		return url, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return "", err
+	}
+	newURL, err := m.dst.Save(m.ctx, key, bytes.NewReader(data), int64(len(data)), mime.TypeByExtension(path.Ext(key)))
+	if err != nil {
+		return "", err
+	}
+	m.copied[key] = newURL
+	m.report.FilesCopied++
+	return newURL, nil
+}
+
+func (m *migrator) markMissing(key, url string) {
+	m.missing[key] = true
+	m.report.Missing = append(m.report.Missing, url)
+}
+
+func (m *migrator) rewriteURL(field *string, column string, updates map[string]interface{}) error {
+	if *field == "" {
+		return nil
+	}
+	newURL, err := m.move(*field)
+	if err != nil {
+		return err
+	}
+	if newURL != *field {
+		*field = newURL
+		updates[column] = newURL
+	}
+	return nil
+}
+
+func (m *migrator) rewriteSet(set *imaging.ImageSet, column string, updates map[string]interface{}) error {
+	if set == nil {
+		return nil
+	}
+	changed := false
+	var moveErr error
+	set.RewriteURLs(func(url string) string {
+		if moveErr != nil {
+			return url
+		}
+		newURL, err := m.move(url)
+		if err != nil {
+			moveErr = err
+			return url
+		}
+		if newURL != url {
+			changed = true
+		}
+		return newURL
+	})
+	if moveErr != nil {
+		return moveErr
+	}
+	if changed {
+		updates[column] = set
+	}
+	return nil
+}
+
+func (m *migrator) apply(db *gorm.DB, model interface{}, id uint, table string, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	m.report.RowsUpdated[table]++
+	if m.opts.DryRun {
+		return nil
+	}
+	return db.Model(model).Where("id = ?", id).UpdateColumns(updates).Error
+}
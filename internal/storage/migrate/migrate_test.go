@@ -0,0 +1,117 @@
+package migrate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-modaMayor/internal/imaging"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/settings"
+	"go-modaMayor/internal/storage"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestRun_CopiesFilesAndRewritesURLs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:storage_migrate_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&sequence.Sequence{}, &product.Product{}, &product.ProductVariant{}, &product.ProductImage{}, &settings.Banner{}, &settings.Video{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	ctx := context.Background()
+	src := storage.NewLocal(t.TempDir(), "/uploads")
+	dst := storage.NewLocal(t.TempDir(), "https://cdn.modamayor.test/media")
+	for _, key := range []string{"abc_thumb.jpg", "abc_card.jpg", "banner_home.jpg"} {
+		src.Save(ctx, key, strings.NewReader(key), int64(len(key)), "image/jpeg")
+	}
+
+	set := &imaging.ImageSet{Src: "/uploads/abc_card.jpg", Srcset: "/uploads/abc_thumb.jpg 200w, /uploads/abc_card.jpg 600w",
+		Renditions: []imaging.Rendition{{Size: "thumb", URL: "/uploads/abc_thumb.jpg"}, {Size: "card", URL: "/uploads/abc_card.jpg"}}}
+	p := product.Product{Name: "Remera", ImageURL: "/uploads/abc_card.jpg", ImageSet: set,
+		ImageModel: "https://otro.sitio/modelo.jpg", ImageHanger: "/uploads/no_existe.jpg"}
+	db.Create(&p)
+	v := product.ProductVariant{ProductID: p.ID, SKU: "R-1", ImageURL: "/uploads/abc_thumb.jpg"}
+	db.Create(&v)
+	db.Create(&settings.Banner{ImageURL: "/uploads/banner_home.jpg"})
+
+	dry, err := Run(ctx, db, src, dst, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db.First(&p, p.ID)
+	if dry.FilesCopied != 3 || p.ImageURL != "/uploads/abc_card.jpg" {
+		t.Fatalf("dry run must not change anything: %+v %s", dry, p.ImageURL)
+	}
+
+	report, err := Run(ctx, db, src, dst, Options{DeleteSource: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.FilesCopied != 3 || report.DeletedFiles != 3 || len(report.Missing) != 1 || report.RowsUpdated["products"] != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	db.First(&p, p.ID)
+	cdn := "https://cdn.modamayor.test/media/"
+	if p.ImageURL != cdn+"abc_card.jpg" || p.ImageModel != "https://otro.sitio/modelo.jpg" || p.ImageHanger != "/uploads/no_existe.jpg" {
+		t.Fatalf("unexpected product urls: %s %s %s", p.ImageURL, p.ImageModel, p.ImageHanger)
+	}
+	if p.ImageSet.Src != cdn+"abc_card.jpg" || p.ImageSet.Srcset != cdn+"abc_thumb.jpg 200w, "+cdn+"abc_card.jpg 600w" || p.ImageSet.Renditions[0].URL != cdn+"abc_thumb.jpg" {
+		t.Fatalf("unexpected image set: %+v", p.ImageSet)
+	}
+	db.First(&v, v.ID)
+	var banner settings.Banner
+	db.First(&banner)
+	if v.ImageURL != cdn+"abc_thumb.jpg" || banner.ImageURL != cdn+"banner_home.jpg" {
+		t.Fatalf("unexpected urls: %s %s", v.ImageURL, banner.ImageURL)
+	}
+	if ok, _ := dst.Exists(ctx, "banner_home.jpg"); !ok {
+		t.Fatalf("expected file copied to destination")
+	}
+	if ok, _ := src.Exists(ctx, "banner_home.jpg"); ok {
+		t.Fatalf("expected source file deleted")
+	}
+}
+
+func TestRun_RewritesGalleryImages(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:storage_migrate_gallery_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&sequence.Sequence{}, &product.Product{}, &product.ProductVariant{}, &product.ProductImage{}, &settings.Banner{}, &settings.Video{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	ctx := context.Background()
+	src := storage.NewLocal(t.TempDir(), "/uploads")
+	dst := storage.NewLocal(t.TempDir(), "https://cdn.modamayor.test/media")
+	for _, key := range []string{"gal_thumb.jpg", "gal_zoom.jpg"} {
+		src.Save(ctx, key, strings.NewReader(key), int64(len(key)), "image/jpeg")
+	}
+
+	p := product.Product{Name: "Vestido"}
+	db.Create(&p)
+	set := &imaging.ImageSet{Src: "/uploads/gal_zoom.jpg", Srcset: "/uploads/gal_thumb.jpg 200w, /uploads/gal_zoom.jpg 1600w",
+		Renditions: []imaging.Rendition{{Size: "thumb", URL: "/uploads/gal_thumb.jpg"}, {Size: "zoom", URL: "/uploads/gal_zoom.jpg"}}}
+	img := product.ProductImage{ProductID: p.ID, Role: "main", URL: "/uploads/gal_zoom.jpg", ImageSet: set}
+	db.Create(&img)
+
+	report, err := Run(ctx, db, src, dst, Options{DeleteSource: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.FilesCopied != 2 || report.DeletedFiles != 2 || report.RowsUpdated["product_images"] != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	var got product.ProductImage
+	db.First(&got, img.ID)
+	cdn := "https://cdn.modamayor.test/media/"
+	if got.URL != cdn+"gal_zoom.jpg" || got.ImageSet.Src != cdn+"gal_zoom.jpg" || got.ImageSet.Renditions[0].URL != cdn+"gal_thumb.jpg" {
+		t.Fatalf("gallery image still points at the source storage: %s %+v", got.URL, got.ImageSet)
+	}
+}
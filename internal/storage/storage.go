@@ -0,0 +1,127 @@
+// Package storage abstrae dónde se guardan los archivos subidos (imágenes de
+// productos, banners, videos): disco local servido en /uploads o un bucket
+// compatible con S3 (AWS, MinIO, Cloudflare R2, etc.). Se elige con
+// STORAGE_DRIVER=local|s3; ver FromEnv.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound indica que la clave no existe en el almacenamiento
+var ErrNotFound = errors.New("archivo no encontrado en el almacenamiento")
+
+// Storage guarda y recupera archivos por clave (ej. "3f9a..._card.jpg")
+type Storage interface {
+	// Save guarda el contenido bajo key y devuelve la URL pública
+	Save(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error)
+	// Open abre el archivo; devuelve ErrNotFound si no existe
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// URL devuelve la URL pública de la clave
+	URL(key string) string
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultStorage Storage
+)
+
+// Init valida la configuración de almacenamiento y la deja como predeterminada. El
+// servidor la llama al arrancar: con STORAGE_DRIVER=s3 mal configurado no debe
+// quedar guardando en el disco local, que en Render se pierde en cada deploy.
+func Init() error {
+	s, err := FromEnv()
+	if err != nil {
+		return err
+	}
+	SetDefault(s)
+	return nil
+}
+
+// Default devuelve el almacenamiento configurado por variables de entorno (ver Init).
+// Si la configuración es inválida devuelve el error: nunca cae al disco local.
+func Default() (Storage, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStorage == nil {
+		s, err := FromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("Configuración de almacenamiento inválida: %w", err)
+		}
+		defaultStorage = s
+	}
+	return defaultStorage, nil
+}
+
+// SetDefault reemplaza el almacenamiento usado por los handlers (tests, comandos)
+func SetDefault(s Storage) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStorage = s
+}
+
+// LocalURLPrefix es la ruta en la que el router sirve la carpeta de uploads
+const LocalURLPrefix = "/uploads"
+
+// UploadDir es la carpeta local de uploads (UPLOAD_DIR, por defecto ./uploads).
+// En Render puede apuntar a un disco persistente, ej. /var/data/uploads.
+func UploadDir() string {
+	if dir := os.Getenv("UPLOAD_DIR"); dir != "" {
+		return dir
+	}
+	return "uploads"
+}
+
+// FromEnv arma el almacenamiento a partir de:
+//   - STORAGE_DRIVER: local (por defecto) o s3
+//   - UPLOAD_DIR: carpeta para el driver local
+//   - S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY
+//   - S3_PUBLIC_URL (opcional, ej. CDN), S3_PATH_STYLE (true por defecto), S3_ACL (opcional, ej. public-read)
+func FromEnv() (Storage, error) {
+	switch driver := strings.ToLower(os.Getenv("STORAGE_DRIVER")); driver {
+	case "", "local":
+		return NewLocal(UploadDir(), LocalURLPrefix), nil
+	case "s3":
+		cfg := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+			ACL:       os.Getenv("S3_ACL"),
+		}
+		return NewS3(cfg)
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER desconocido: %s", driver)
+	}
+}
+
+// SaveUploadedFile guarda un archivo de formulario multipart bajo key
+func SaveUploadedFile(ctx context.Context, s Storage, fh *multipart.FileHeader, key string) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return s.Save(ctx, key, f, fh.Size, fh.Header.Get("Content-Type"))
+}
+
+// KeyFromURL devuelve la clave de una URL generada por s, o "" si la URL no le pertenece
+func KeyFromURL(s Storage, url string) string {
+	prefix := strings.TrimSuffix(s.URL(""), "/") + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(url, prefix)
+}
@@ -0,0 +1,183 @@
+// Package xlsx lee y escribe planillas .xlsx simples (una hoja, valores de texto y
+// números) usando sólo la biblioteca estándar.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type xmlSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xmlSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadRows devuelve las filas de la primera hoja como texto. Las celdas vacías
+// intermedias se completan con "" para que las columnas queden alineadas.
+func ReadRows(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("archivo xlsx inválido: %v", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var ss xmlSharedStrings
+		if err := decodeZipXML(f, &ss); err != nil {
+			return nil, err
+		}
+		for _, si := range ss.Items {
+			if len(si.Runs) == 0 {
+				shared = append(shared, si.Text)
+				continue
+			}
+			var sb strings.Builder
+			for _, run := range si.Runs {
+				sb.WriteString(run.Text)
+			}
+			shared = append(shared, sb.String())
+		}
+	}
+
+	sheetFile, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("el archivo xlsx no tiene hojas")
+	}
+	var sheet xmlSheet
+	if err := decodeZipXML(sheetFile, &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		values := []string{}
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				if idx, err := columnIndex(cell.Ref); err == nil {
+					col = idx
+				}
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+			var v string
+			switch cell.Type {
+			case "s":
+				n, err := strconv.Atoi(cell.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("referencia de texto compartido inválida en %s", cell.Ref)
+				}
+				v = shared[n]
+			case "inlineStr":
+				v = cell.Inline.Text
+			default:
+				v = cell.Value
+			}
+			if col < len(values) {
+				values[col] = v
+			} else {
+				values = append(values, v)
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// firstSheetPath resuelve la ruta de la primera hoja declarada en el workbook
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wbFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return fallback
+	}
+	var wb xmlWorkbook
+	if err := decodeZipXML(wbFile, &wb); err != nil || len(wb.Sheets) == 0 {
+		return fallback
+	}
+	relsFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if !ok {
+		return fallback
+	}
+	var rels xmlRelationships
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return fallback
+	}
+	for _, rel := range rels.Items {
+		if rel.ID == wb.Sheets[0].RID {
+			target := strings.TrimPrefix(rel.Target, "/")
+			if strings.HasPrefix(target, "xl/") {
+				return target
+			}
+			return path.Join("xl", target)
+		}
+	}
+	return fallback
+}
+
+// columnIndex convierte una referencia como "C7" en el índice de columna 2
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("referencia inválida: %s", ref)
+	}
+	return col - 1, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
@@ -0,0 +1,42 @@
+package xlsx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Stock & precios")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.WriteRow("SKU", "Color", "Stock", "Precio")
+	w.WriteRow("RL-1-NEGRO-M", "Negro <oscuro>", 12, 1500.5)
+	w.WriteRow("RL-1-BLANCO-M", "", 0, 1500.5)
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := [][]string{
+		{"SKU", "Color", "Stock", "Precio"},
+		{"RL-1-NEGRO-M", "Negro <oscuro>", "12", "1500.5"},
+		{"RL-1-BLANCO-M", "", "0", "1500.5"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("unexpected rows: %q", rows)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for idx, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(idx); got != expected {
+			t.Fatalf("columnName(%d) = %s, expected %s", idx, got, expected)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package zoologic
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go-modaMayor/config"
+
+	"gorm.io/gorm"
+)
+
+// ErrNothingToExport indica que no hay movimientos ni ventas nuevas desde el último lote
+var ErrNothingToExport = errors.New("no hay datos nuevos para exportar")
+
+// soldStatuses son los estados de orden que cuentan como venta para Zoologic
+var soldStatuses = []string{"pagado", "enviado", "completado"}
+
+// generateMu evita que el job y un admin generen a la vez el mismo lote
+var generateMu sync.Mutex
+
+type movementRow struct {
+	ID            uint
+	CreatedAt     time.Time
+	Code          string
+	Name          string
+	SKU           string
+	Color         string
+	Size          string
+	Location      string
+	MovementType  string
+	Quantity      int
+	PreviousStock int
+	NewStock      int
+	Reason        string
+	Reference     string
+	UserName      string
+}
+
+type saleRow struct {
+	OrderID       uint
+	CreatedAt     time.Time
+	Number        string
+	Code          string
+	Name          string
+	SKU           string
+	VariantColor  string
+	VariantSize   string
+	Quantity      int
+	Price         float64
+	Customer      string
+	PaymentMethod string
+}
+
+// loadMovements devuelve los movimientos del lote indicado; batchID = 0 devuelve los
+// que todavía no se exportaron en ningún lote, creados desde from si no es nil
+func loadMovements(db *gorm.DB, batchID uint, from *time.Time) ([]movementRow, error) {
+	q := db.Table("stock_movements sm").
+		Select("sm.id, sm.created_at, products.code, products.name, pv.sku, pv.color, pv.size, sm.location, sm.movement_type, sm.quantity, sm.previous_stock, sm.new_stock, sm.reason, sm.reference, sm.user_name").
+		Joins("JOIN products ON products.id = sm.product_id").
+		Joins("LEFT JOIN product_variants pv ON pv.id = sm.variant_id").
+		Where("sm.deleted_at IS NULL")
+	if batchID > 0 {
+		q = q.Where("sm.id IN (SELECT movement_id FROM export_batch_movements WHERE batch_id = ?)", batchID)
+	} else {
+		q = q.Where("sm.id NOT IN (SELECT movement_id FROM export_batch_movements)")
+		if from != nil {
+			q = q.Where("sm.created_at >= ?", *from)
+		}
+	}
+	var rows []movementRow
+	err := q.Order("sm.id").Scan(&rows).Error
+	return rows, err
+}
+
+func movementRecords(rows []movementRow) []record {
+	out := make([]record, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, record{
+			"id": r.ID, "fecha": r.CreatedAt, "codigo": r.Code, "producto": r.Name, "sku": r.SKU, "color": r.Color,
+			"talle": r.Size, "ubicacion": r.Location, "tipo": r.MovementType, "cantidad": r.Quantity,
+			"stock_anterior": r.PreviousStock, "stock_nuevo": r.NewStock, "motivo": r.Reason,
+			"referencia": r.Reference, "usuario": r.UserName,
+		})
+	}
+	return out
+}
+
+// loadSales devuelve los ítems de las órdenes indicadas ordenados por orden
+func loadSales(db *gorm.DB, orderIDs []uint) ([]saleRow, error) {
+	var rows []saleRow
+	err := db.Table("order_items oi").
+		Select("o.id as order_id, o.created_at, o.number, products.code, products.name, pv.sku, oi.variant_color, oi.variant_size, oi.quantity, oi.price, users.name as customer, o.payment_method").
+		Joins("JOIN orders o ON o.id = oi.order_id").
+		Joins("JOIN products ON products.id = oi.product_id").
+		Joins("LEFT JOIN product_variants pv ON pv.id = oi.variant_id").
+		Joins("LEFT JOIN users ON users.id = o.user_id").
+		Where("oi.deleted_at IS NULL AND o.id IN ?", orderIDs).
+		Order("o.id, oi.id").Scan(&rows).Error
+	return rows, err
+}
+
+func saleRecords(rows []saleRow) []record {
+	out := make([]record, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, record{
+			"fecha": r.CreatedAt, "orden": r.Number, "codigo": r.Code, "producto": r.Name, "sku": r.SKU,
+			"color": r.VariantColor, "talle": r.VariantSize, "cantidad": r.Quantity, "precio": r.Price,
+			"total": r.Price * float64(r.Quantity), "cliente": r.Customer, "medio_pago": r.PaymentMethod,
+		})
+	}
+	return out
+}
+
+// GenerateBatch arma el lote siguiente del tipo indicado con todo lo ocurrido desde
+// el lote anterior (y desde ExportFrom del diseño, si está configurado). Devuelve
+// ErrNothingToExport si no hay filas nuevas.
+func GenerateBatch(db *gorm.DB, kind string, userID *uint) (*ExportBatch, error) {
+	generateMu.Lock()
+	defer generateMu.Unlock()
+
+	format, err := LoadFormat(db, kind)
+	if err != nil {
+		return nil, err
+	}
+	batch := ExportBatch{Kind: kind, UserID: userID}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var records []record
+		var movementIDs, orderIDs []uint
+		switch kind {
+		case KindMovements:
+			rows, err := loadMovements(tx, 0, format.ExportFrom)
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return ErrNothingToExport
+			}
+			for _, r := range rows {
+				movementIDs = append(movementIDs, r.ID)
+			}
+			batch.FromID, batch.ToID = rows[0].ID, rows[len(rows)-1].ID
+			records = movementRecords(rows)
+		case KindSales:
+			q := tx.Table("orders").
+				Where("deleted_at IS NULL AND status IN ?", soldStatuses).
+				Where("id NOT IN (SELECT order_id FROM export_batch_orders)")
+			if format.ExportFrom != nil {
+				q = q.Where("created_at >= ?", *format.ExportFrom)
+			}
+			if err := q.Order("id").Pluck("id", &orderIDs).Error; err != nil {
+				return err
+			}
+			if len(orderIDs) == 0 {
+				return ErrNothingToExport
+			}
+			rows, err := loadSales(tx, orderIDs)
+			if err != nil {
+				return err
+			}
+			batch.FromID, batch.ToID = orderIDs[0], orderIDs[len(orderIDs)-1]
+			records = saleRecords(rows)
+		}
+
+		content, err := format.Render(records)
+		if err != nil {
+			return err
+		}
+		batch.Content = content
+		batch.RowCount = len(records)
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+		if len(movementIDs) > 0 {
+			marks := make([]ExportBatchMovement, 0, len(movementIDs))
+			for _, id := range movementIDs {
+				marks = append(marks, ExportBatchMovement{BatchID: batch.ID, MovementID: id})
+			}
+			if err := tx.CreateInBatches(&marks, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(orderIDs) > 0 {
+			marks := make([]ExportBatchOrder, 0, len(orderIDs))
+			for _, id := range orderIDs {
+				marks = append(marks, ExportBatchOrder{BatchID: batch.ID, OrderID: id})
+			}
+			if err := tx.CreateInBatches(&marks, 500).Error; err != nil {
+				return err
+			}
+		}
+		batch.FileName = fmt.Sprintf("zoologic_%s_%06d.%s", kind, batch.ID, format.Extension)
+		return tx.Model(&batch).Update("file_name", batch.FileName).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// RegenerateBatch vuelve a armar el archivo de un lote con el diseño actual, sobre
+// exactamente los mismos movimientos u órdenes.
+func RegenerateBatch(db *gorm.DB, batchID uint) (*ExportBatch, error) {
+	var batch ExportBatch
+	if err := db.First(&batch, batchID).Error; err != nil {
+		return nil, err
+	}
+	format, err := LoadFormat(db, batch.Kind)
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	switch batch.Kind {
+	case KindMovements:
+		rows, err := loadMovements(db, batch.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		records = movementRecords(rows)
+	case KindSales:
+		var orderIDs []uint
+		if err := db.Model(&ExportBatchOrder{}).Where("batch_id = ?", batch.ID).Order("order_id").Pluck("order_id", &orderIDs).Error; err != nil {
+			return nil, err
+		}
+		rows, err := loadSales(db, orderIDs)
+		if err != nil {
+			return nil, err
+		}
+		records = saleRecords(rows)
+	}
+	content, err := format.Render(records)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	batch.Content = content
+	batch.RowCount = len(records)
+	batch.FileName = fmt.Sprintf("zoologic_%s_%06d.%s", batch.Kind, batch.ID, format.Extension)
+	batch.RegeneratedAt = &now
+	if err := db.Save(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// StartZoologicExportJob genera periódicamente los lotes de movimientos y ventas
+func StartZoologicExportJob(interval time.Duration) {
+	go func() {
+		log.Printf("🚀 Iniciando job de exportación a Zoologic (intervalo: %v)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			for _, kind := range Kinds {
+				batch, err := GenerateBatch(config.DB, kind, nil)
+				switch {
+				case errors.Is(err, ErrNothingToExport):
+				case err != nil:
+					log.Printf("❌ Error generando lote Zoologic de %s: %v", kind, err)
+				default:
+					log.Printf("✅ Lote Zoologic %s generado: %s (%d filas)", kind, batch.FileName, batch.RowCount)
+				}
+			}
+		}
+	}()
+}
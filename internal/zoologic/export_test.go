@@ -0,0 +1,155 @@
+package zoologic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-modaMayor/internal/order"
+	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/sequence"
+	"go-modaMayor/internal/user"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file:zoologic_test?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	if err := db.AutoMigrate(&sequence.Sequence{}, &user.User{}, &product.Product{}, &product.ProductVariant{}, &product.StockMovement{},
+		&order.Order{}, &order.OrderItem{}, &ExportFormat{}, &ExportBatch{}, &ExportBatchMovement{}, &ExportBatchOrder{}); err != nil {
+		t.Fatalf("auto migrate failed: %v", err)
+	}
+	return db
+}
+
+func TestGenerateBatch_MovementsExportedOnce(t *testing.T) {
+	db := setupTestDB(t)
+	p := product.Product{Code: "ART-1", Name: "Remera"}
+	db.Create(&p)
+	v := product.ProductVariant{ProductID: p.ID, SKU: "ART-1-NEGRO-M", Color: "Negro", Size: "M"}
+	db.Create(&v)
+	db.Create(&product.StockMovement{ProductID: p.ID, VariantID: &v.ID, Location: "deposito", MovementType: "initial", Quantity: 10})
+	db.Create(&product.StockMovement{ProductID: p.ID, VariantID: &v.ID, Location: "deposito", MovementType: "venta", Quantity: -2, Reference: "Venta - Carrito #1"})
+
+	first, err := GenerateBatch(db, KindMovements, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(first.Content), "\r\n")
+	if first.RowCount != 2 || len(lines) != 3 || !strings.Contains(lines[2], ";ART-1;Negro;M;deposito;VTA;-2;Venta - Carrito #1") {
+		t.Fatalf("unexpected batch: %+v\n%s", first, first.Content)
+	}
+
+	// sin movimientos nuevos no se genera lote
+	if _, err := GenerateBatch(db, KindMovements, nil); !errors.Is(err, ErrNothingToExport) {
+		t.Fatalf("expected ErrNothingToExport, got %v", err)
+	}
+
+	// El ID se asigna al insertar: first.ToID+1 queda reservado por una transacción
+	// que todavía no confirmó cuando se genera el segundo lote
+	moved := product.StockMovement{ProductID: p.ID, VariantID: &v.ID, Location: "salta", MovementType: "transfer", Quantity: 3}
+	moved.ID = first.ToID + 2
+	db.Create(&moved)
+	second, err := GenerateBatch(db, KindMovements, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.RowCount != 1 || second.FromID != moved.ID {
+		t.Fatalf("expected only the new movement, got %+v", second)
+	}
+
+	late := product.StockMovement{ProductID: p.ID, VariantID: &v.ID, Location: "deposito", MovementType: "ajuste", Quantity: 1}
+	late.ID = first.ToID + 1
+	db.Create(&late)
+	third, err := GenerateBatch(db, KindMovements, nil)
+	if err != nil {
+		t.Fatalf("late movement should still be exported: %v", err)
+	}
+	if third.RowCount != 1 || third.FromID != late.ID {
+		t.Fatalf("expected only the late movement, got %+v", third)
+	}
+
+	// regenerar con otro diseño mantiene las mismas filas
+	custom := ExportFormat{Kind: KindMovements, Delimiter: "|", DecimalSeparator: ".", DateLayout: "2006-01-02", Extension: "txt",
+		Columns: []FormatColumn{{Header: "SKU", Field: "sku"}, {Header: "CANT", Field: "cantidad"}}}
+	if err := custom.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	db.Create(&custom)
+	regenerated, err := RegenerateBatch(db, first.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if regenerated.Content != "ART-1-NEGRO-M|10\r\nART-1-NEGRO-M|-2\r\n" || !strings.HasSuffix(regenerated.FileName, ".txt") {
+		t.Fatalf("unexpected regenerated content: %q (%s)", regenerated.Content, regenerated.FileName)
+	}
+}
+
+func TestGenerateBatch_SalesExportedOnce(t *testing.T) {
+	db := setupTestDB(t)
+	u := user.User{Name: "Cliente Mayorista", Email: "cliente@zoologic.test", Password: "x"}
+	db.Create(&u)
+	p := product.Product{Code: "ART-2", Name: "Jean"}
+	db.Create(&p)
+	paid := order.Order{UserID: u.ID, Status: "pagado", Total: 3000.5, Items: []order.OrderItem{
+		{ProductID: p.ID, VariantColor: "Azul", VariantSize: "40", Quantity: 2, Price: 1500.25},
+	}}
+	db.Create(&paid)
+	db.Create(&order.Order{UserID: u.ID, Status: "pendiente"})
+
+	batch, err := GenerateBatch(db, KindSales, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.RowCount != 1 || !strings.Contains(batch.Content, paid.Number+";ART-2;Azul;40;2;1500,25;3000,50") {
+		t.Fatalf("unexpected sales batch: %+v\n%s", batch, batch.Content)
+	}
+	if _, err := GenerateBatch(db, KindSales, nil); !errors.Is(err, ErrNothingToExport) {
+		t.Fatalf("expected paid order to be exported only once, got %v", err)
+	}
+}
+
+func TestExportFormat_ValidateUnknownField(t *testing.T) {
+	f := ExportFormat{Kind: KindSales, Delimiter: ";", DecimalSeparator: ",", DateLayout: "02/01/2006",
+		Columns: []FormatColumn{{Header: "X", Field: "ubicacion"}}}
+	if err := f.Validate(); err == nil {
+		t.Fatalf("expected error for field not available in sales")
+	}
+}
+
+func TestGenerateBatch_StartsFromExportFrom(t *testing.T) {
+	db := setupTestDB(t)
+	p := product.Product{Code: "ART-3", Name: "Buzo"}
+	db.Create(&p)
+	old := product.StockMovement{ProductID: p.ID, Location: "deposito", MovementType: "initial", Quantity: 4}
+	old.CreatedAt = time.Now().AddDate(-1, 0, 0)
+	db.Create(&old)
+	recent := product.StockMovement{ProductID: p.ID, Location: "deposito", MovementType: "ajuste", Quantity: 1}
+	db.Create(&recent)
+
+	from := time.Now().AddDate(0, 0, -1)
+	format := DefaultFormats[KindMovements]
+	format.ExportFrom = &from
+	db.Unscoped().Where("kind = ?", KindMovements).Delete(&ExportFormat{})
+	if err := db.Create(&format).Error; err != nil {
+		t.Fatalf("save format: %v", err)
+	}
+
+	batch, err := GenerateBatch(db, KindMovements, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.RowCount != 1 || batch.FromID != recent.ID {
+		t.Fatalf("expected only the movement after export_from, got %+v", batch)
+	}
+	var marked int64
+	db.Model(&ExportBatchMovement{}).Where("batch_id = ?", batch.ID).Count(&marked)
+	if marked != 1 {
+		t.Fatalf("expected 1 exported movement mark, got %d", marked)
+	}
+}
@@ -0,0 +1,152 @@
+package zoologic
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// record es una fila de origen: valores por nombre de campo
+type record map[string]any
+
+// Campos disponibles por tipo de lote para armar las columnas
+var availableFields = map[string][]string{
+	KindMovements: {"id", "fecha", "codigo", "producto", "sku", "color", "talle", "ubicacion", "tipo", "cantidad",
+		"stock_anterior", "stock_nuevo", "motivo", "referencia", "usuario"},
+	KindSales: {"fecha", "orden", "codigo", "producto", "sku", "color", "talle", "cantidad", "precio", "total",
+		"cliente", "medio_pago"},
+}
+
+// DefaultFormats son los diseños usados hasta que el admin configure otros
+var DefaultFormats = map[string]ExportFormat{
+	KindMovements: {
+		Kind: KindMovements, Delimiter: ";", DecimalSeparator: ",", DateLayout: "02/01/2006", IncludeHeader: true, Extension: "csv",
+		Columns: []FormatColumn{
+			{Header: "FECHA", Field: "fecha"},
+			{Header: "ARTICULO", Field: "codigo"},
+			{Header: "COLOR", Field: "color"},
+			{Header: "TALLE", Field: "talle"},
+			{Header: "DEPOSITO", Field: "ubicacion"},
+			{Header: "TIPO", Field: "tipo", Map: map[string]string{
+				"venta": "VTA", "return": "DEV", "compra": "CPA", "transfer": "TRF", "adjustment": "AJU", "initial": "INI",
+			}},
+			{Header: "CANTIDAD", Field: "cantidad"},
+			{Header: "COMPROBANTE", Field: "referencia"},
+		},
+	},
+	KindSales: {
+		Kind: KindSales, Delimiter: ";", DecimalSeparator: ",", DateLayout: "02/01/2006", IncludeHeader: true, Extension: "csv",
+		Columns: []FormatColumn{
+			{Header: "FECHA", Field: "fecha"},
+			{Header: "COMPROBANTE", Field: "orden"},
+			{Header: "ARTICULO", Field: "codigo"},
+			{Header: "COLOR", Field: "color"},
+			{Header: "TALLE", Field: "talle"},
+			{Header: "CANTIDAD", Field: "cantidad"},
+			{Header: "PRECIO", Field: "precio"},
+			{Header: "TOTAL", Field: "total"},
+		},
+	},
+}
+
+// LoadFormat devuelve el diseño configurado para el tipo de lote o el de fábrica
+func LoadFormat(db *gorm.DB, kind string) (ExportFormat, error) {
+	var f ExportFormat
+	err := db.Where("kind = ?", kind).First(&f).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		def, ok := DefaultFormats[kind]
+		if !ok {
+			return ExportFormat{}, fmt.Errorf("tipo de lote inválido: %s", kind)
+		}
+		return def, nil
+	}
+	return f, err
+}
+
+// Validate controla que el diseño se pueda aplicar
+func (f *ExportFormat) Validate() error {
+	fields, ok := availableFields[f.Kind]
+	if !ok {
+		return fmt.Errorf("tipo de lote inválido: %s", f.Kind)
+	}
+	if len([]rune(f.Delimiter)) != 1 {
+		return errors.New("el separador debe ser un único carácter")
+	}
+	if f.DecimalSeparator != "." && f.DecimalSeparator != "," {
+		return errors.New("el separador decimal debe ser '.' o ','")
+	}
+	if f.DateLayout == "" {
+		return errors.New("el formato de fecha es obligatorio")
+	}
+	if len(f.Columns) == 0 {
+		return errors.New("el diseño debe tener al menos una columna")
+	}
+	for _, col := range f.Columns {
+		valid := false
+		for _, field := range fields {
+			if col.Field == field {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("campo desconocido %q (disponibles: %s)", col.Field, strings.Join(fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Render arma el contenido del archivo con las filas en el orden recibido
+func (f *ExportFormat) Render(rows []record) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = []rune(f.Delimiter)[0]
+	w.UseCRLF = true // el archivo se procesa en la PC Windows de Zoologic
+	if f.IncludeHeader {
+		header := make([]string, len(f.Columns))
+		for i, col := range f.Columns {
+			header[i] = col.Header
+		}
+		if err := w.Write(header); err != nil {
+			return "", err
+		}
+	}
+	for _, row := range rows {
+		values := make([]string, len(f.Columns))
+		for i, col := range f.Columns {
+			v := f.formatValue(row[col.Field])
+			if mapped, ok := col.Map[v]; ok {
+				v = mapped
+			}
+			values[i] = v
+		}
+		if err := w.Write(values); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func (f *ExportFormat) formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return val.Format(f.DateLayout)
+	case float64:
+		s := strconv.FormatFloat(val, 'f', 2, 64)
+		if f.DecimalSeparator == "," {
+			s = strings.Replace(s, ".", ",", 1)
+		}
+		return s
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,129 @@
+package zoologic
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go-modaMayor/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListBatches lista los lotes generados (?kind=movimientos|ventas)
+func ListBatches(c *gin.Context) {
+	query := config.DB.Model(&ExportBatch{}).Order("id DESC")
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var batches []ExportBatch
+	if err := query.Limit(100).Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// CreateBatch genera a pedido el lote siguiente de un tipo
+func CreateBatch(c *gin.Context) {
+	var input struct {
+		Kind string `json:"kind" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, ok := availableFields[input.Kind]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipo de lote inválido: use movimientos o ventas"})
+		return
+	}
+	var userID *uint
+	if v, ok := c.Get("user_id"); ok {
+		if uid, ok := v.(uint); ok {
+			userID = &uid
+		}
+	}
+	batch, err := GenerateBatch(config.DB, input.Kind, userID)
+	if errors.Is(err, ErrNothingToExport) {
+		c.JSON(http.StatusOK, gin.H{"message": "No hay datos nuevos desde el último lote"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, batch)
+}
+
+// DownloadBatch descarga el archivo de un lote
+func DownloadBatch(c *gin.Context) {
+	var batch ExportBatch
+	if err := config.DB.First(&batch, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote no encontrado"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+batch.FileName+`"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(batch.Content))
+}
+
+// RegenerateBatchHandler rearma un lote con el diseño vigente (mismas filas)
+func RegenerateBatchHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return
+	}
+	batch, err := RegenerateBatch(config.DB, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lote no encontrado"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// ListFormats devuelve el diseño vigente de cada tipo de lote y los campos disponibles
+func ListFormats(c *gin.Context) {
+	formats := make([]ExportFormat, 0, len(Kinds))
+	for _, kind := range Kinds {
+		f, err := LoadFormat(config.DB, kind)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		formats = append(formats, f)
+	}
+	c.JSON(http.StatusOK, gin.H{"formats": formats, "fields": availableFields})
+}
+
+// UpdateFormat reemplaza el diseño de archivo de un tipo de lote
+func UpdateFormat(c *gin.Context) {
+	var input ExportFormat
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.Kind = c.Param("kind")
+	if input.Extension == "" {
+		input.Extension = "csv"
+	}
+	if err := input.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing ExportFormat
+	if err := config.DB.Where("kind = ?", input.Kind).First(&existing).Error; err == nil {
+		input.ID = existing.ID
+		input.CreatedAt = existing.CreatedAt
+	}
+	if err := config.DB.Save(&input).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, input)
+}
@@ -0,0 +1,94 @@
+// Package zoologic genera los archivos por lotes con los que el stock y las ventas
+// de la web se reflejan en el back-office Zoologic/Dragonfish (ver
+// Reunion_Cliente_Definicion_Sistema.md, propuesta A). Cada lote exporta sólo lo
+// ocurrido desde el lote anterior y queda guardado para descargarlo o regenerarlo.
+package zoologic
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de lote
+const (
+	KindMovements = "movimientos" // StockMovement desde el último lote
+	KindSales     = "ventas"      // ítems de órdenes vendidas aún no exportadas
+)
+
+// Kinds lista los tipos de lote admitidos
+var Kinds = []string{KindMovements, KindSales}
+
+// FormatColumn define una columna del archivo: encabezado, campo de origen y,
+// opcionalmente, una tabla de traducción de valores (ej. tipo de movimiento -> código Zoologic).
+type FormatColumn struct {
+	Header string            `json:"header"`
+	Field  string            `json:"field"`
+	Map    map[string]string `json:"map,omitempty"`
+}
+
+// ExportFormat es el diseño de archivo que importa el operador de Zoologic para un tipo de lote
+type ExportFormat struct {
+	gorm.Model
+	Kind             string         `json:"kind" gorm:"size:20;uniqueIndex;not null"`
+	Delimiter        string         `json:"delimiter" gorm:"size:5;default:';'"`
+	DecimalSeparator string         `json:"decimal_separator" gorm:"size:1;default:','"`
+	DateLayout       string         `json:"date_layout" gorm:"size:40;default:'02/01/2006'"` // layout de Go
+	IncludeHeader    bool           `json:"include_header"`
+	Extension        string         `json:"extension" gorm:"size:10;default:'csv'"`
+	// ExportFrom limita los lotes a lo creado desde esa fecha, para que el primero no
+	// traiga todo el historial. nil exporta todo lo que no se exportó antes.
+	ExportFrom *time.Time `json:"export_from"`
+	ColumnsJSON      string         `json:"-" gorm:"column:columns;type:text"`
+	Columns          []FormatColumn `json:"columns" gorm:"-"`
+}
+
+// BeforeSave serializa las columnas
+func (f *ExportFormat) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(f.Columns)
+	if err != nil {
+		return err
+	}
+	f.ColumnsJSON = string(data)
+	return nil
+}
+
+// AfterFind deserializa las columnas
+func (f *ExportFormat) AfterFind(tx *gorm.DB) error {
+	if f.ColumnsJSON == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(f.ColumnsJSON), &f.Columns)
+}
+
+// ExportBatch es un archivo generado. FromID y ToID son el primer y el último ID
+// (movimiento u orden) incluidos, sólo informativos: los ids se asignan al insertar y
+// no al confirmar la transacción, así que un movimiento con ID menor puede aparecer
+// después de generado un lote. Por eso lo exportado se registra fila por fila en
+// ExportBatchMovement y ExportBatchOrder.
+type ExportBatch struct {
+	gorm.Model
+	Kind          string     `json:"kind" gorm:"size:20;index;not null"`
+	FromID        uint       `json:"from_id"`
+	ToID          uint       `json:"to_id" gorm:"index"`
+	RowCount      int        `json:"row_count"`
+	FileName      string     `json:"file_name"`
+	Content       string     `json:"-" gorm:"type:text"`
+	UserID        *uint      `json:"user_id"` // nil si lo generó el job automático
+	RegeneratedAt *time.Time `json:"regenerated_at"`
+}
+
+// ExportBatchMovement marca un movimiento de stock como ya exportado en un lote
+type ExportBatchMovement struct {
+	ID         uint `json:"id" gorm:"primaryKey"`
+	BatchID    uint `json:"batch_id" gorm:"index;not null"`
+	MovementID uint `json:"movement_id" gorm:"uniqueIndex;not null"`
+}
+
+// ExportBatchOrder marca una orden como ya exportada en un lote de ventas
+type ExportBatchOrder struct {
+	ID      uint `json:"id" gorm:"primaryKey"`
+	BatchID uint `json:"batch_id" gorm:"index;not null"`
+	OrderID uint `json:"order_id" gorm:"uniqueIndex;not null"`
+}
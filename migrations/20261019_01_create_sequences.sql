@@ -0,0 +1,41 @@
+-- Secuencias de numeración de documentos (reemplaza COUNT(*)+1 y MAX(code)+1)
+CREATE TABLE IF NOT EXISTS sequences (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    name VARCHAR(50) NOT NULL,
+    prefix VARCHAR(20),
+    padding INTEGER DEFAULT 0,
+    current_value BIGINT DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sequences_name ON sequences(name);
+CREATE INDEX IF NOT EXISTS idx_sequences_deleted_at ON sequences(deleted_at);
+
+-- Inicializar con el último número emitido para no repetir números existentes
+INSERT INTO sequences (name, prefix, padding, current_value)
+SELECT 'remito', 'RI-', 5, COALESCE(MAX(CAST(SUBSTRING(numero FROM 4) AS BIGINT)), 0)
+FROM remitos_internos WHERE numero ~ '^RI-[0-9]+$'
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO sequences (name, prefix, padding, current_value)
+SELECT 'product', 'PROD-', 6, COALESCE(MAX(CAST(SUBSTRING(code FROM 6) AS BIGINT)), 0)
+FROM products WHERE code ~ '^PROD-[0-9]+$'
+ON CONFLICT (name) DO NOTHING;
+
+-- Número correlativo de órdenes: completar las existentes usando su ID
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS number VARCHAR(32);
+UPDATE orders SET number = 'ORD-' || LPAD(id::text, 6, '0') WHERE number IS NULL OR number = '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_number ON orders(number);
+
+INSERT INTO sequences (name, prefix, padding, current_value)
+SELECT 'order', 'ORD-', 6, COALESCE(MAX(id), 0) FROM orders
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO sequences (name, prefix, padding, current_value) VALUES
+    ('invoice', 'FAC-', 8, 0),
+    ('credit_note', 'NC-', 8, 0)
+ON CONFLICT (name) DO NOTHING;
+
+COMMENT ON TABLE sequences IS 'Contadores de numeración incrementados en la misma transacción que el documento';
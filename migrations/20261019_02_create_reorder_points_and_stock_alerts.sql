@@ -0,0 +1,41 @@
+-- Puntos de reposición por variante y ubicación
+CREATE TABLE IF NOT EXISTS reorder_points (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id INTEGER NOT NULL REFERENCES product_variants(id) ON DELETE CASCADE,
+    location VARCHAR(50) NOT NULL,
+    min_stock INTEGER DEFAULT 0,
+    max_stock INTEGER DEFAULT 0,
+    reorder_point INTEGER DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reorder_variant_location ON reorder_points(variant_id, location);
+CREATE INDEX IF NOT EXISTS idx_reorder_points_product_id ON reorder_points(product_id);
+CREATE INDEX IF NOT EXISTS idx_reorder_points_deleted_at ON reorder_points(deleted_at);
+
+-- Alertas de stock generadas por el job periódico
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    product_id INTEGER,
+    variant_id INTEGER,
+    location VARCHAR(50),
+    available INTEGER,
+    threshold INTEGER,
+    status VARCHAR(20) DEFAULT 'abierta',
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_product_id ON stock_alerts(product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_variant_id ON stock_alerts(variant_id);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_location ON stock_alerts(location);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_status ON stock_alerts(status);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_deleted_at ON stock_alerts(deleted_at);
+
+COMMENT ON COLUMN reorder_points.reorder_point IS 'Disponible (stock - reserved) en o por debajo del cual se genera alerta';
+COMMENT ON COLUMN reorder_points.max_stock IS 'Nivel objetivo al reponer';
@@ -0,0 +1,79 @@
+-- Órdenes de compra a proveedores y recepción de mercadería
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    number VARCHAR(32) NOT NULL,
+    supplier_id INTEGER NOT NULL REFERENCES suppliers(id),
+    status VARCHAR(20) DEFAULT 'borrador',
+    location VARCHAR(50) DEFAULT 'deposito',
+    expected_date TIMESTAMP WITH TIME ZONE,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    received_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    total NUMERIC(12,2) DEFAULT 0,
+    created_by_user_id INTEGER
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_purchase_orders_number ON purchase_orders(number);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_deleted_at ON purchase_orders(deleted_at);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    variant_id INTEGER NOT NULL REFERENCES product_variants(id),
+    quantity INTEGER NOT NULL,
+    received_quantity INTEGER DEFAULT 0,
+    unit_cost NUMERIC(12,2) DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_lines_purchase_order_id ON purchase_order_lines(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_lines_product_id ON purchase_order_lines(product_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_lines_variant_id ON purchase_order_lines(variant_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_lines_deleted_at ON purchase_order_lines(deleted_at);
+
+CREATE TABLE IF NOT EXISTS goods_receipts (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id),
+    location VARCHAR(50) NOT NULL,
+    cost_method VARCHAR(20),
+    received_at TIMESTAMP WITH TIME ZONE,
+    user_id INTEGER,
+    notes TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_purchase_order_id ON goods_receipts(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_deleted_at ON goods_receipts(deleted_at);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_lines (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    goods_receipt_id INTEGER NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    purchase_order_line_id INTEGER NOT NULL REFERENCES purchase_order_lines(id),
+    product_id INTEGER NOT NULL,
+    variant_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_cost NUMERIC(12,2) DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_lines_goods_receipt_id ON goods_receipt_lines(goods_receipt_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_lines_purchase_order_line_id ON goods_receipt_lines(purchase_order_line_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_lines_deleted_at ON goods_receipt_lines(deleted_at);
+
+INSERT INTO sequences (name, prefix, padding, current_value) VALUES ('purchase_order', 'OC-', 6, 0)
+ON CONFLICT (name) DO NOTHING;
+
+COMMENT ON TABLE purchase_orders IS 'Órdenes de compra a proveedores (borrador, enviada, parcial, recibida, cancelada)';
+COMMENT ON TABLE goods_receipts IS 'Recepciones de mercadería; cada una genera movimientos de stock tipo compra';
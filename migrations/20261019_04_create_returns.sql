@@ -0,0 +1,76 @@
+-- Devoluciones de clientes (RMA), notas de crédito y ventas netas de devoluciones
+CREATE TABLE IF NOT EXISTS returns (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    number VARCHAR(32),
+    order_id INTEGER NOT NULL REFERENCES orders(id),
+    user_id INTEGER,
+    status VARCHAR(20) DEFAULT 'solicitada',
+    resolution VARCHAR(20),
+    amount NUMERIC(12,2) DEFAULT 0,
+    notes TEXT,
+    created_by_user_id INTEGER,
+    exchange_cart_id INTEGER,
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_returns_number ON returns(number);
+CREATE INDEX IF NOT EXISTS idx_returns_order_id ON returns(order_id);
+CREATE INDEX IF NOT EXISTS idx_returns_user_id ON returns(user_id);
+CREATE INDEX IF NOT EXISTS idx_returns_status ON returns(status);
+CREATE INDEX IF NOT EXISTS idx_returns_deleted_at ON returns(deleted_at);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    return_id INTEGER NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id),
+    product_id INTEGER NOT NULL,
+    variant_id INTEGER,
+    quantity INTEGER NOT NULL,
+    unit_price NUMERIC(12,2) DEFAULT 0,
+    reason VARCHAR(30),
+    condition VARCHAR(20) DEFAULT 'pendiente',
+    location VARCHAR(50),
+    inspected_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_items_return_id ON return_items(return_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item_id ON return_items(order_item_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_deleted_at ON return_items(deleted_at);
+
+CREATE TABLE IF NOT EXISTS credit_notes (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    number VARCHAR(32),
+    return_id INTEGER REFERENCES returns(id),
+    order_id INTEGER,
+    user_id INTEGER,
+    type VARCHAR(20),
+    amount NUMERIC(12,2) DEFAULT 0,
+    status VARCHAR(20) DEFAULT 'emitida',
+    refund_method VARCHAR(64),
+    refund_reference VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_credit_notes_number ON credit_notes(number);
+CREATE INDEX IF NOT EXISTS idx_credit_notes_return_id ON credit_notes(return_id);
+CREATE INDEX IF NOT EXISTS idx_credit_notes_order_id ON credit_notes(order_id);
+CREATE INDEX IF NOT EXISTS idx_credit_notes_user_id ON credit_notes(user_id);
+CREATE INDEX IF NOT EXISTS idx_credit_notes_deleted_at ON credit_notes(deleted_at);
+
+-- Montos y unidades devueltas en órdenes (los reportes usan ventas netas)
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS returned_total NUMERIC(12,2) DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS returned_quantity INTEGER DEFAULT 0;
+
+INSERT INTO sequences (name, prefix, padding, current_value) VALUES ('return', 'DEV-', 6, 0)
+ON CONFLICT (name) DO NOTHING;
+
+COMMENT ON TABLE returns IS 'Devoluciones de clientes (solicitada, inspeccionada, resuelta, rechazada)';
+COMMENT ON TABLE credit_notes IS 'Créditos, reembolsos y cambios emitidos por devoluciones';
@@ -0,0 +1,68 @@
+-- Búsqueda de productos: tsvector en español sin acentos + trigramas para errores de tipeo
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() no es IMMUTABLE; este wrapper permite usarlo en índices
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS $$
+    SELECT public.unaccent('public.unaccent', $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Documento de búsqueda: nombre y código (A), categoría y subcategoría (B), descripción (C)
+CREATE OR REPLACE FUNCTION products_search_document(p_name text, p_code text, p_description text, p_category_id bigint, p_subcategory_id bigint)
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('spanish', f_unaccent(coalesce(p_name, ''))), 'A') ||
+        setweight(to_tsvector('simple', coalesce(p_code, '')), 'A') ||
+        setweight(to_tsvector('spanish', f_unaccent(
+            coalesce((SELECT name FROM categories WHERE id = p_category_id), '') || ' ' ||
+            coalesce((SELECT name FROM subcategories WHERE id = p_subcategory_id), ''))), 'B') ||
+        setweight(to_tsvector('spanish', f_unaccent(coalesce(p_description, ''))), 'C')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION products_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := products_search_document(NEW.name, NEW.code, NEW.description, NEW.category_id, NEW.subcategory_id);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_search_vector ON products;
+CREATE TRIGGER trg_products_search_vector
+    BEFORE INSERT OR UPDATE OF name, code, description, category_id, subcategory_id ON products
+    FOR EACH ROW EXECUTE FUNCTION products_search_vector_update();
+
+-- Renombrar una categoría o subcategoría recalcula el documento de sus productos
+CREATE OR REPLACE FUNCTION categories_search_vector_refresh() RETURNS trigger AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'categories' THEN
+        UPDATE products SET search_vector = products_search_document(name, code, description, category_id, subcategory_id)
+        WHERE category_id = NEW.id;
+    ELSE
+        UPDATE products SET search_vector = products_search_document(name, code, description, category_id, subcategory_id)
+        WHERE subcategory_id = NEW.id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_categories_search_vector ON categories;
+CREATE TRIGGER trg_categories_search_vector
+    AFTER UPDATE OF name ON categories
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION categories_search_vector_refresh();
+
+DROP TRIGGER IF EXISTS trg_subcategories_search_vector ON subcategories;
+CREATE TRIGGER trg_subcategories_search_vector
+    AFTER UPDATE OF name ON subcategories
+    FOR EACH ROW WHEN (OLD.name IS DISTINCT FROM NEW.name)
+    EXECUTE FUNCTION categories_search_vector_refresh();
+
+-- Completar los productos existentes
+UPDATE products SET search_vector = products_search_document(name, code, description, category_id, subcategory_id);
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (lower(f_unaccent(name)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_product_variants_sku_trgm ON product_variants USING GIN (lower(sku) gin_trgm_ops);
+
+COMMENT ON COLUMN products.search_vector IS 'Documento de búsqueda (nombre y código peso A, categoría y subcategoría peso B, descripción peso C), mantenido por trigger';
@@ -0,0 +1,23 @@
+-- Importaciones masivas de catálogo (CSV/XLSX) procesadas en segundo plano
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    file_name VARCHAR(255),
+    format VARCHAR(10),
+    status VARCHAR(30) DEFAULT 'pendiente',
+    total_rows INTEGER DEFAULT 0,
+    processed_rows INTEGER DEFAULT 0,
+    created_products INTEGER DEFAULT 0,
+    created_variants INTEGER DEFAULT 0,
+    errors TEXT,
+    user_id INTEGER,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_status ON import_jobs(status);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_deleted_at ON import_jobs(deleted_at);
+
+COMMENT ON TABLE import_jobs IS 'Importaciones de catálogo: avance y errores por fila (errors en JSON)';
@@ -0,0 +1,62 @@
+-- Exportación por lotes hacia Zoologic/Dragonfish (movimientos de stock y ventas)
+
+-- Diseño de archivo por tipo de lote (columnas en JSON)
+CREATE TABLE IF NOT EXISTS export_formats (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    kind VARCHAR(20) NOT NULL,
+    delimiter VARCHAR(5) DEFAULT ';',
+    decimal_separator VARCHAR(1) DEFAULT ',',
+    date_layout VARCHAR(40) DEFAULT '02/01/2006',
+    include_header BOOLEAN DEFAULT FALSE,
+    extension VARCHAR(10) DEFAULT 'csv',
+    columns TEXT,
+    export_from TIMESTAMP WITH TIME ZONE
+);
+ALTER TABLE export_formats ADD COLUMN IF NOT EXISTS export_from TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_formats_kind ON export_formats(kind);
+CREATE INDEX IF NOT EXISTS idx_export_formats_deleted_at ON export_formats(deleted_at);
+
+-- Lotes generados: from_id/to_id son el primer y último ID incluidos (informativo)
+CREATE TABLE IF NOT EXISTS export_batches (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    kind VARCHAR(20) NOT NULL,
+    from_id INTEGER DEFAULT 0,
+    to_id INTEGER DEFAULT 0,
+    row_count INTEGER DEFAULT 0,
+    file_name VARCHAR(255),
+    content TEXT,
+    user_id INTEGER,
+    regenerated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_batches_kind ON export_batches(kind);
+CREATE INDEX IF NOT EXISTS idx_export_batches_to_id ON export_batches(to_id);
+CREATE INDEX IF NOT EXISTS idx_export_batches_deleted_at ON export_batches(deleted_at);
+
+-- Movimientos ya incluidos en un lote (un ID menor puede confirmarse después de
+-- generado un lote, por eso no alcanza con una marca de agua)
+CREATE TABLE IF NOT EXISTS export_batch_movements (
+    id SERIAL PRIMARY KEY,
+    batch_id INTEGER NOT NULL REFERENCES export_batches(id) ON DELETE CASCADE,
+    movement_id INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_batch_movements_movement_id ON export_batch_movements(movement_id);
+CREATE INDEX IF NOT EXISTS idx_export_batch_movements_batch_id ON export_batch_movements(batch_id);
+
+-- Órdenes ya incluidas en un lote de ventas (cada orden se exporta una sola vez)
+CREATE TABLE IF NOT EXISTS export_batch_orders (
+    id SERIAL PRIMARY KEY,
+    batch_id INTEGER NOT NULL REFERENCES export_batches(id) ON DELETE CASCADE,
+    order_id INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_batch_orders_order_id ON export_batch_orders(order_id);
+CREATE INDEX IF NOT EXISTS idx_export_batch_orders_batch_id ON export_batch_orders(batch_id);
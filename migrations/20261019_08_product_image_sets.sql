@@ -0,0 +1,7 @@
+-- Versiones procesadas de las imágenes (thumb/card/zoom + WebP) en JSON, listas para srcset
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_set TEXT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_model_set TEXT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS image_hanger_set TEXT;
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS image_set TEXT;
+
+COMMENT ON COLUMN products.image_set IS 'Versiones de image_url: {hash, src, srcset, webp_srcset, width, height, renditions}';
@@ -0,0 +1,48 @@
+-- Galería de imágenes del producto: roles (main/model/hanger/detail), imágenes por color y orden.
+-- products.image_url/image_model/image_hanger y product_variants.image_url quedan como copia
+-- sincronizada desde la galería por compatibilidad.
+CREATE TABLE IF NOT EXISTS product_images (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'detail',
+    color VARCHAR(100) DEFAULT '',
+    position INTEGER DEFAULT 0,
+    alt_text VARCHAR(255),
+    url TEXT NOT NULL,
+    image_set TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id ON product_images(product_id);
+CREATE INDEX IF NOT EXISTS idx_product_images_color ON product_images(color);
+CREATE INDEX IF NOT EXISTS idx_product_images_deleted_at ON product_images(deleted_at);
+
+-- Backfill desde los campos existentes (sólo productos sin galería)
+INSERT INTO product_images (created_at, updated_at, product_id, role, color, position, url, image_set)
+SELECT NOW(), NOW(), p.id, s.role, '', s.position, s.url, s.image_set
+FROM products p
+CROSS JOIN LATERAL (VALUES
+    ('main', 0, p.image_url, p.image_set),
+    ('model', 1, p.image_model, p.image_model_set),
+    ('hanger', 2, p.image_hanger, p.image_hanger_set)
+) AS s(role, position, url, image_set)
+WHERE p.deleted_at IS NULL
+  AND COALESCE(s.url, '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM product_images pi WHERE pi.product_id = p.id);
+
+-- Una imagen por color tomada de las variantes que ya tenían foto propia
+INSERT INTO product_images (created_at, updated_at, product_id, role, color, position, url, image_set)
+SELECT NOW(), NOW(), v.product_id, 'main', v.color,
+       3 + ROW_NUMBER() OVER (PARTITION BY v.product_id ORDER BY v.color), v.image_url, v.image_set
+FROM (
+    SELECT DISTINCT ON (product_id, LOWER(TRIM(color))) product_id, TRIM(color) AS color, image_url, image_set
+    FROM product_variants
+    WHERE deleted_at IS NULL AND COALESCE(image_url, '') <> '' AND COALESCE(TRIM(color), '') <> ''
+    ORDER BY product_id, LOWER(TRIM(color)), id
+) v
+WHERE NOT EXISTS (
+    SELECT 1 FROM product_images pi
+    WHERE pi.product_id = v.product_id AND LOWER(TRIM(pi.color)) = LOWER(v.color)
+);
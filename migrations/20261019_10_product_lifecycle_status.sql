@@ -0,0 +1,22 @@
+-- Ciclo de vida del producto: draft, published, hidden, archived (+ publicación programada)
+-- Los productos existentes quedan publicados; los nuevos también salvo que se creen con otro estado.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_status;
+ALTER TABLE products ADD CONSTRAINT chk_products_status CHECK (status IN ('draft', 'published', 'hidden', 'archived'));
+
+CREATE INDEX IF NOT EXISTS idx_products_status ON products(status);
+
+-- DELETE /products/:id hacía soft delete y los pedidos/reportes dejaban de resolver el producto.
+-- Se recuperan como archivados sólo los borrados que tienen historia (ventas o movimientos
+-- de stock); el resto (duplicados, pruebas) sigue borrado.
+UPDATE products
+SET status = 'archived', archived_at = deleted_at, deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND (EXISTS (SELECT 1 FROM order_items oi WHERE oi.product_id = products.id)
+       OR EXISTS (SELECT 1 FROM stock_movements sm WHERE sm.product_id = products.id));
+
+COMMENT ON COLUMN products.status IS 'draft | published | hidden (sólo link directo) | archived (discontinuado)';
+COMMENT ON COLUMN products.publish_at IS 'Publicación programada: un producto published no es visible antes de esta fecha';
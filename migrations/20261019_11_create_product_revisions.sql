@@ -0,0 +1,23 @@
+-- Historial de revisiones por producto: snapshot (producto, precios, tags, variantes e
+-- imágenes) después de cada cambio, diff estructurado contra el estado anterior y autor.
+CREATE TABLE IF NOT EXISTS product_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    user_id BIGINT,
+    user_name TEXT,
+    changes TEXT,
+    snapshot TEXT,
+    restored_from_id BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_revisions_product_id ON product_revisions(product_id);
+CREATE INDEX IF NOT EXISTS idx_product_revisions_deleted_at ON product_revisions(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_revisions_product_number ON product_revisions(product_id, number) WHERE deleted_at IS NULL;
+
+COMMENT ON COLUMN product_revisions.changes IS 'JSON [{path, before, after}] con path "campo", "variants[<id>].campo" o "images[<id>].campo"';
+COMMENT ON COLUMN product_revisions.snapshot IS 'JSON {product, variants, images} con el estado posterior al cambio';
@@ -0,0 +1,43 @@
+-- Variantes vinculadas a colors y size_values en lugar de texto libre.
+-- color y size se conservan con el nombre canónico para mostrar y para el histórico.
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS color_id BIGINT REFERENCES colors(id);
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS size_value_id BIGINT REFERENCES size_values(id);
+CREATE INDEX IF NOT EXISTS idx_product_variants_color_id ON product_variants(color_id);
+CREATE INDEX IF NOT EXISTS idx_product_variants_size_value_id ON product_variants(size_value_id);
+
+-- Colores usados en variantes que todavía no existen en la tabla colors
+INSERT INTO colors (created_at, updated_at, key, name, active)
+SELECT NOW(), NOW(), v.key, MIN(v.name), TRUE
+FROM (
+    SELECT LOWER(TRIM(color)) AS key, INITCAP(TRIM(color)) AS name
+    FROM product_variants
+    WHERE deleted_at IS NULL AND COALESCE(TRIM(color), '') <> ''
+) v
+WHERE NOT EXISTS (
+    SELECT 1 FROM colors c
+    WHERE LOWER(c.key) = v.key OR LOWER(c.name) = v.key
+)
+GROUP BY v.key;
+
+-- Vincular por key o nombre (comparación LOWER(TRIM()))
+UPDATE product_variants pv
+SET color_id = c.id, color = COALESCE(NULLIF(c.name, ''), c.key)
+FROM colors c
+WHERE pv.color_id IS NULL
+  AND c.deleted_at IS NULL
+  AND COALESCE(TRIM(pv.color), '') <> ''
+  AND (LOWER(TRIM(pv.color)) = LOWER(c.key) OR LOWER(TRIM(pv.color)) = LOWER(c.name));
+
+-- Talles: sólo se pueden vincular los de productos con tipo de talle
+UPDATE product_variants pv
+SET size_value_id = sv.id, size = sv.value
+FROM products p
+JOIN size_values sv ON sv.size_type_id = p.size_type_id AND sv.deleted_at IS NULL
+WHERE pv.product_id = p.id
+  AND pv.size_value_id IS NULL
+  AND COALESCE(TRIM(pv.size), '') <> ''
+  AND LOWER(TRIM(pv.size)) = LOWER(TRIM(sv.value));
+
+-- Variantes con talle que no se pudo vincular (producto sin tipo de talle o talle fuera del tipo)
+-- SELECT pv.id, pv.product_id, pv.size FROM product_variants pv
+-- WHERE pv.deleted_at IS NULL AND pv.size_value_id IS NULL AND COALESCE(TRIM(pv.size), '') <> '';
@@ -0,0 +1,33 @@
+-- Curvas de talles: packs con cantidad fija por talle que se venden como unidad
+CREATE TABLE IF NOT EXISTS size_curves (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    size_type_id BIGINT NOT NULL REFERENCES size_types(id),
+    name TEXT NOT NULL,
+    active BOOLEAN DEFAULT TRUE
+);
+CREATE INDEX IF NOT EXISTS idx_size_curves_size_type_id ON size_curves(size_type_id);
+CREATE INDEX IF NOT EXISTS idx_size_curves_deleted_at ON size_curves(deleted_at);
+
+CREATE TABLE IF NOT EXISTS size_curve_items (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    curve_id BIGINT NOT NULL REFERENCES size_curves(id),
+    size_value_id BIGINT NOT NULL REFERENCES size_values(id),
+    quantity BIGINT NOT NULL CHECK (quantity > 0)
+);
+CREATE INDEX IF NOT EXISTS idx_size_curve_items_curve_id ON size_curve_items(curve_id);
+CREATE INDEX IF NOT EXISTS idx_size_curve_items_deleted_at ON size_curve_items(deleted_at);
+
+-- Líneas del carrito agregadas como curva
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS curve_id BIGINT;
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS curve_group TEXT;
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS curve_units BIGINT DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_cart_items_curve_group ON cart_items(curve_group);
+
+-- Tiers que también se habilitan por cantidad de curvas completas
+ALTER TABLE price_tiers ADD COLUMN IF NOT EXISTS min_curves INTEGER DEFAULT 0;
@@ -0,0 +1,9 @@
+-- Códigos de barras por variante (EAN-13 generado o código de proveedor importado).
+-- Las variantes existentes reciben su EAN-13 con POST /variants/barcodes/generate,
+-- que calcula el dígito verificador con el prefijo de empresa de la secuencia "barcode".
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS barcode VARCHAR(64);
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS barcode_type VARCHAR(10);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_variants_barcode ON product_variants(barcode);
+
+INSERT INTO sequences (name, prefix, padding, current_value) VALUES ('barcode', '200', 9, 0)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,86 @@
+-- Árbol de categorías de n niveles: padre, slug, posición, imagen y camino materializado
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES categories(id);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS slug VARCHAR(160);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS position BIGINT DEFAULT 0;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS image_url TEXT;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS path VARCHAR(255);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS depth BIGINT DEFAULT 0;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS legacy_subcategory_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_categories_slug ON categories(slug);
+CREATE INDEX IF NOT EXISTS idx_categories_path ON categories(path);
+
+-- El nombre deja de ser único global: se valida entre hermanas
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_name_key;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS uni_categories_name;
+DROP INDEX IF EXISTS idx_categories_name;
+
+-- Las subcategorías existentes pasan a ser categorías hijas (la tabla subcategories se mantiene por compatibilidad)
+INSERT INTO categories (created_at, updated_at, name, parent_id, legacy_subcategory_id)
+SELECT NOW(), NOW(), s.name, s.category_id, s.id
+FROM subcategories s
+WHERE s.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM categories c WHERE c.legacy_subcategory_id = s.id);
+
+-- Slugs sin acentos; los repetidos llevan el id como sufijo
+UPDATE categories SET slug = trim(both '-' from regexp_replace(
+    translate(lower(name), 'áàäâãéèëêíìïîóòöôõúùüûñç', 'aaaaaeeeeiiiiooooouuuunc'),
+    '[^a-z0-9]+', '-', 'g'))
+WHERE slug IS NULL OR slug = '';
+UPDATE categories c SET slug = c.slug || '-' || c.id
+WHERE EXISTS (SELECT 1 FROM categories o WHERE o.slug = c.slug AND o.id < c.id AND o.deleted_at IS NULL);
+
+-- Caminos "/raiz/.../id/" y profundidad
+WITH RECURSIVE tree AS (
+    SELECT id, '/' || id || '/' AS path, 0 AS depth FROM categories WHERE parent_id IS NULL
+    UNION ALL
+    SELECT c.id, t.path || c.id || '/', t.depth + 1 FROM categories c JOIN tree t ON c.parent_id = t.id
+)
+UPDATE categories SET path = tree.path, depth = tree.depth FROM tree WHERE categories.id = tree.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_categories_slug ON categories(slug) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_categories_parent_name ON categories(COALESCE(parent_id, 0), lower(name)) WHERE deleted_at IS NULL;
+
+-- Atributos tipados por categoría
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    category_id BIGINT NOT NULL REFERENCES categories(id),
+    key VARCHAR(64) NOT NULL,
+    name TEXT NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    options TEXT,
+    unit TEXT,
+    required BOOLEAN DEFAULT FALSE,
+    filterable BOOLEAN DEFAULT TRUE,
+    position BIGINT DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_category_attributes_category_id ON category_attributes(category_id);
+CREATE INDEX IF NOT EXISTS idx_category_attributes_deleted_at ON category_attributes(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_category_attributes_key ON category_attributes(category_id, key) WHERE deleted_at IS NULL;
+
+-- Productos en varias categorías (además de category_id) y valores de atributos
+CREATE TABLE IF NOT EXISTS product_categories (
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    category_id BIGINT NOT NULL REFERENCES categories(id),
+    PRIMARY KEY (product_id, category_id)
+);
+CREATE INDEX IF NOT EXISTS idx_product_categories_category_id ON product_categories(category_id);
+
+-- Los productos con subcategoría quedan también en la categoría hija equivalente
+INSERT INTO product_categories (product_id, category_id)
+SELECT p.id, c.id FROM products p JOIN categories c ON c.legacy_subcategory_id = p.subcategory_id
+WHERE p.subcategory_id IS NOT NULL AND p.subcategory_id > 0
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS product_attribute_values (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    attribute_id BIGINT NOT NULL REFERENCES category_attributes(id),
+    value TEXT NOT NULL,
+    number_value NUMERIC
+);
+CREATE INDEX IF NOT EXISTS idx_product_attribute_values_product_id ON product_attribute_values(product_id);
+CREATE INDEX IF NOT EXISTS idx_product_attribute_values_attribute_id ON product_attribute_values(attribute_id);
@@ -0,0 +1,39 @@
+-- Slugs públicos de productos y temporadas (las categorías ya lo tienen, ver category_tree_attributes)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS slug VARCHAR(160);
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS slug VARCHAR(160);
+CREATE INDEX IF NOT EXISTS idx_products_slug ON products(slug);
+CREATE INDEX IF NOT EXISTS idx_seasons_slug ON seasons(slug);
+
+-- Slugs sin acentos; los repetidos llevan el id como sufijo
+UPDATE products SET slug = left(trim(both '-' from regexp_replace(
+    translate(lower(name), 'áàäâãéèëêíìïîóòöôõúùüûñç', 'aaaaaeeeeiiiiooooouuuunc'),
+    '[^a-z0-9]+', '-', 'g')), 150)
+WHERE slug IS NULL OR slug = '';
+UPDATE products SET slug = 'producto' WHERE slug = '';
+UPDATE products p SET slug = p.slug || '-' || p.id
+WHERE EXISTS (SELECT 1 FROM products o WHERE o.slug = p.slug AND o.id < p.id);
+
+UPDATE seasons SET slug = left(trim(both '-' from regexp_replace(
+    translate(lower(name), 'áàäâãéèëêíìïîóòöôõúùüûñç', 'aaaaaeeeeiiiiooooouuuunc'),
+    '[^a-z0-9]+', '-', 'g')), 150)
+WHERE slug IS NULL OR slug = '';
+UPDATE seasons SET slug = 'temporada' WHERE slug = '';
+UPDATE seasons s SET slug = s.slug || '-' || s.id
+WHERE EXISTS (SELECT 1 FROM seasons o WHERE o.slug = s.slug AND o.id < s.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_products_slug ON products(slug);
+CREATE UNIQUE INDEX IF NOT EXISTS ux_seasons_slug ON seasons(slug);
+
+-- Slugs anteriores para redirigir cuando cambia el nombre
+CREATE TABLE IF NOT EXISTS slug_redirects (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    entity VARCHAR(20) NOT NULL,
+    old_slug VARCHAR(160) NOT NULL,
+    target_id BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_slug_redirects_lookup ON slug_redirects(entity, old_slug);
+CREATE INDEX IF NOT EXISTS idx_slug_redirects_target_id ON slug_redirects(target_id);
+CREATE INDEX IF NOT EXISTS idx_slug_redirects_deleted_at ON slug_redirects(deleted_at);
@@ -0,0 +1,23 @@
+-- Kits a la venta: activación, líneas de carrito y de orden que referencian el kit
+
+-- unit_price pasa a ser sólo un override. Hasta ahora el alta copiaba siempre el
+-- precio mayorista del momento, así que los valores guardados son copias viejas: se
+-- limpian para que el kit siga el precio vigente de cada producto. Sólo la primera vez
+-- (antes de existir kits.active), para no borrar los overrides cargados después.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'kits' AND column_name = 'active') THEN
+        UPDATE kit_items SET unit_price = 0;
+    END IF;
+END $$;
+
+ALTER TABLE kits ADD COLUMN IF NOT EXISTS active BOOLEAN DEFAULT TRUE;
+
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS kit_id BIGINT;
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS kit_group TEXT;
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS kit_units BIGINT DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_cart_items_kit_group ON cart_items(kit_group);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS kit_id BIGINT;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS kit_group TEXT;
+CREATE INDEX IF NOT EXISTS idx_order_items_kit_id ON order_items(kit_id);
@@ -0,0 +1,7 @@
+-- Slots de kit: items sin variante fija en los que el comprador elige talle/color,
+-- limitados opcionalmente a una lista de colores y talles (JSON)
+ALTER TABLE kit_items ADD COLUMN IF NOT EXISTS allowed_colors TEXT;
+ALTER TABLE kit_items ADD COLUMN IF NOT EXISTS allowed_sizes TEXT;
+
+-- Item del kit que originó cada línea del carrito
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS kit_item_id BIGINT;
@@ -0,0 +1,67 @@
+-- Temporadas con rango de fechas, estado (upcoming/current/clearance/closed) y
+-- descuento de liquidación
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS starts_at TIMESTAMPTZ;
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS ends_at TIMESTAMPTZ;
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS clearance_at TIMESTAMPTZ;
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'current';
+ALTER TABLE seasons ADD COLUMN IF NOT EXISTS clearance_discount NUMERIC DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_seasons_status ON seasons(status);
+UPDATE seasons SET status = 'closed' WHERE active = FALSE;
+
+-- Estado previo de los productos que la liquidación pasó a oferta
+CREATE TABLE IF NOT EXISTS season_clearance_items (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    season_id BIGINT NOT NULL REFERENCES seasons(id),
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    prev_is_offer BOOLEAN,
+    prev_discount_type VARCHAR(20),
+    prev_discount_value NUMERIC,
+    discount_value NUMERIC
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_season_clearance_product ON season_clearance_items(season_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_season_clearance_items_deleted_at ON season_clearance_items(deleted_at);
+
+-- Productos recurrentes (se clonan en el rollover) y su producto de origen
+ALTER TABLE products ADD COLUMN IF NOT EXISTS recurring BOOLEAN DEFAULT FALSE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS previous_product_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_products_previous_product_id ON products(previous_product_id);
+
+-- Baja del texto legacy products.season: antes de borrarlo se asocia season_id cuando
+-- el texto coincide sin ambigüedad con el código o el nombre de una temporada. Los que
+-- no coinciden se guardan en legacy_product_seasons para asignarlos a mano:
+--   SELECT l.product_id, p.name, l.season FROM legacy_product_seasons l
+--   JOIN products p ON p.id = l.product_id WHERE p.season_id IS NULL ORDER BY l.season;
+CREATE TABLE IF NOT EXISTS legacy_product_seasons (
+    product_id BIGINT PRIMARY KEY REFERENCES products(id),
+    season TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'products' AND column_name = 'season') THEN
+        UPDATE products p
+        SET season_id = m.season_id
+        FROM (
+            SELECT p2.id AS product_id, MIN(s.id) AS season_id
+            FROM products p2
+            JOIN seasons s ON s.deleted_at IS NULL
+                AND (LOWER(TRIM(p2.season)) = LOWER(s.code) OR LOWER(TRIM(p2.season)) = LOWER(s.name))
+            WHERE p2.season_id IS NULL AND TRIM(COALESCE(p2.season, '')) <> ''
+            GROUP BY p2.id
+            HAVING COUNT(DISTINCT s.id) = 1
+        ) m
+        WHERE p.id = m.product_id;
+
+        INSERT INTO legacy_product_seasons (product_id, season)
+        SELECT id, TRIM(season)
+        FROM products
+        WHERE season_id IS NULL AND TRIM(COALESCE(season, '')) <> ''
+        ON CONFLICT (product_id) DO NOTHING;
+
+        ALTER TABLE products DROP COLUMN season;
+    END IF;
+END $$;
@@ -0,0 +1,18 @@
+-- Recomendaciones por producto calculadas por el job (comprados juntos / similares).
+-- Cada corrida reemplaza todas las filas.
+CREATE TABLE IF NOT EXISTS product_recommendations (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    product_id BIGINT,
+    kind VARCHAR(20),
+    recommended_id BIGINT,
+    score NUMERIC,
+    rank BIGINT,
+    computed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_recommendation_product_kind ON product_recommendations(product_id, kind);
+CREATE INDEX IF NOT EXISTS idx_product_recommendations_recommended_id ON product_recommendations(recommended_id);
+CREATE INDEX IF NOT EXISTS idx_product_recommendations_computed_at ON product_recommendations(computed_at);
+CREATE INDEX IF NOT EXISTS idx_product_recommendations_deleted_at ON product_recommendations(deleted_at);
@@ -5,15 +5,24 @@ import (
 	"go-modaMayor/internal/address"
 	"go-modaMayor/internal/audit"
 	"go-modaMayor/internal/cart"
+	"go-modaMayor/internal/catalogcheck"
+	"go-modaMayor/internal/catalogimport"
 	"go-modaMayor/internal/category"
+	"go-modaMayor/internal/exports"
 	"go-modaMayor/internal/faq"
+	"go-modaMayor/internal/inventory"
 	"go-modaMayor/internal/kit"
 	"go-modaMayor/internal/notification"
 	"go-modaMayor/internal/order"
 	"go-modaMayor/internal/product"
+	"go-modaMayor/internal/purchase"
 	"go-modaMayor/internal/remito"
+	"go-modaMayor/internal/returns"
+	"go-modaMayor/internal/sequence"
 	"go-modaMayor/internal/settings/handler"
+	"go-modaMayor/internal/storage"
 	"go-modaMayor/internal/user"
+	"go-modaMayor/internal/zoologic"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -39,12 +48,23 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
-	r.GET("/products", product.GetProducts)
-	r.GET("/products/:id", product.GetProduct)
+	// Públicos: sin token sólo se ven productos publicados; el staff autenticado ve todos
+	r.GET("/products", user.OptionalAuthMiddleware(), product.GetProducts)
+	r.GET("/products/:id", user.OptionalAuthMiddleware(), product.GetProduct)
+	r.GET("/products/by-slug/:slug", user.OptionalAuthMiddleware(), product.GetProductBySlug)
+	r.GET("/products/:id/recommendations", user.OptionalAuthMiddleware(), order.GetProductRecommendations)
+	r.GET("/sitemap.xml", product.Sitemap)
 	// Permitir que admin y encargados creen productos
 	r.POST("/products", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.CreateProduct)
 	// Crear producto completo: producto + variantes + stocks iniciales (solo admin)
 	r.POST("/products/full", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateProductFull)
+	// Importación masiva de catálogo desde CSV/XLSX (validación en seco y job en segundo plano)
+	r.POST("/imports/catalog", user.AuthMiddleware(), user.RequireRole("admin"), catalogimport.ImportCatalog)
+	r.GET("/imports/catalog", user.AuthMiddleware(), user.RequireRole("admin"), catalogimport.ListImportJobs)
+	r.GET("/imports/catalog/:id", user.AuthMiddleware(), user.RequireRole("admin"), catalogimport.GetImportJob)
+	// Exportación de catálogo y stock en CSV/XLSX (mismos filtros que GET /products)
+	r.GET("/exports/products", user.AuthMiddleware(), user.RequireRole("admin"), exports.ExportProducts)
+	r.GET("/exports/stock", user.AuthMiddleware(), user.RequireRole("admin"), exports.ExportStock)
 
 	// Admin: manage suppliers and size types/values
 	r.GET("/suppliers", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListSuppliers)
@@ -55,10 +75,13 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// Admin: manage seasons (temporadas)
 	r.GET("/seasons", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListSeasons)
 	r.GET("/public/seasons", product.ListSeasons) // Público para el wizard
+	r.GET("/public/seasons/by-slug/:slug", product.GetSeasonBySlug)
 	r.GET("/seasons/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.GetSeason)
 	r.POST("/seasons", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateSeason)
 	r.PUT("/seasons/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.UpdateSeason)
 	r.DELETE("/seasons/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.DeleteSeason)
+	r.PUT("/seasons/:id/status", user.AuthMiddleware(), user.RequireRole("admin"), product.UpdateSeasonStatus)
+	r.POST("/seasons/:id/rollover", user.AuthMiddleware(), user.RequireRole("admin"), product.RolloverSeasonHandler)
 
 	r.GET("/size-types", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListSizeTypes)
 	r.POST("/size-types", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateSizeType)
@@ -69,6 +92,11 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.POST("/size-values", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateSizeValue)
 	r.PUT("/size-values/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.UpdateSizeValue)
 	r.DELETE("/size-values/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.DeleteSizeValue)
+	// Curvas de talles por tipo de talle
+	r.GET("/size-curves", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado", "vendedor"), product.ListSizeCurves)
+	r.POST("/size-curves", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateSizeCurve)
+	r.PUT("/size-curves/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.UpdateSizeCurve)
+	r.DELETE("/size-curves/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.DeleteSizeCurve)
 
 	// Colors (admin-managed, listable by admin/encargado)
 	r.GET("/colors", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListColors)
@@ -102,6 +130,15 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// Permitir que admin o encargado actualicen descuentos
 	r.PUT("/products/:id/discount", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.UpdateProductDiscount)
 	r.DELETE("/products/:id", user.AuthMiddleware(), user.RequireRole("admin"), product.DeleteProduct)
+	// Ciclo de vida: draft, published (con publish_at opcional), hidden, archived
+	r.PUT("/products/:id/status", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.UpdateProductStatus)
+	// Historial de cambios del producto (diff por revisión) y restauración
+	r.GET("/products/:id/revisions", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListProductRevisions)
+	r.GET("/products/:id/revisions/:revisionId", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.GetProductRevision)
+	r.POST("/products/:id/revisions/:revisionId/restore", user.AuthMiddleware(), user.RequireRole("admin"), product.RestoreProductRevision)
+	// Categorías adicionales y atributos del producto
+	r.GET("/products/:id/attributes", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.GetProductAttributes)
+	r.PUT("/products/:id/attributes", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.UpdateProductAttributes)
 	r.GET("/users", user.AuthMiddleware(), user.RequireRole("admin"), user.ListUsers)
 	r.GET("/users/:id", user.AuthMiddleware(), user.RequireRole("admin"), user.GetUser)
 	r.PUT("/users/:id", user.AuthMiddleware(), user.RequireRole("admin"), user.UpdateUser)
@@ -127,12 +164,30 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.GET("/public/categories", category.ListCategories(db))
 	r.PUT("/categories/:id", user.AuthMiddleware(), user.RequireRole("admin"), category.UpdateCategory(db))
 	r.DELETE("/categories/:id", user.AuthMiddleware(), user.RequireRole("admin"), category.DeleteCategory(db))
+	// Árbol de categorías y atributos por categoría (heredados por las subcategorías)
+	r.GET("/categories/tree", user.AuthMiddleware(), user.RequireRole("admin"), category.GetCategoryTree(db))
+	r.GET("/public/categories/tree", category.GetCategoryTree(db))
+	r.GET("/public/categories/by-slug/:slug", category.GetCategoryBySlug(db))
+	r.GET("/categories/:category_id/attributes", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), category.ListCategoryAttributes(db))
+	r.GET("/public/categories/:category_id/attributes", category.ListCategoryAttributes(db))
+	r.POST("/categories/:category_id/attributes", user.AuthMiddleware(), user.RequireRole("admin"), category.CreateCategoryAttribute(db))
+	r.PUT("/category-attributes/:id", user.AuthMiddleware(), user.RequireRole("admin"), category.UpdateCategoryAttribute(db))
+	r.DELETE("/category-attributes/:id", user.AuthMiddleware(), user.RequireRole("admin"), category.DeleteCategoryAttribute(db))
 	r.PUT("/products/:id/stock", user.AuthMiddleware(), user.RequireRole("admin"), product.UpdateStock)
 	r.GET("/products/low-stock", user.AuthMiddleware(), user.RequireRole("admin"), product.LowStockProducts)
-	r.Static("/uploads", "./uploads")
+	r.Static("/uploads", storage.UploadDir())
 	r.POST("/products/:id/image", user.AuthMiddleware(), user.RequireRole("admin"), product.UploadProductImage)
 	// Subir múltiples imágenes de producto (image_main, image_model, image_hanger)
 	r.POST("/products/:id/images", user.AuthMiddleware(), user.RequireRole("admin"), product.UploadProductImages)
+	// Galería de imágenes: roles, imágenes por color y orden
+	r.GET("/products/:id/gallery", user.OptionalAuthMiddleware(), product.ListProductImages)
+	r.POST("/products/:id/gallery", user.AuthMiddleware(), user.RequireRole("admin"), product.AddProductImages)
+	r.PUT("/products/:id/gallery/reorder", user.AuthMiddleware(), user.RequireRole("admin"), product.ReorderProductImages)
+	r.PUT("/products/:id/gallery/:imageId", user.AuthMiddleware(), user.RequireRole("admin"), product.UpdateProductImage)
+	r.DELETE("/products/:id/gallery/:imageId", user.AuthMiddleware(), user.RequireRole("admin"), product.DeleteProductImage)
+	// Curvas de talles disponibles para el producto y curvas completas por ubicación
+	r.GET("/products/:id/curves", user.OptionalAuthMiddleware(), product.GetProductCurves)
+	r.GET("/products/:id/curves/:curveId/availability", user.OptionalAuthMiddleware(), product.GetCurveAvailability)
 
 	// Variantes de producto (solo admin)
 	r.POST("/products/:id/variants", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateVariant)
@@ -150,6 +205,13 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	// Stock por variante (lectura permitida a admin/encargado/vendedor)
 	r.POST("/variants/:id/stock", user.AuthMiddleware(), user.RequireRole("admin"), product.SetVariantStock)
 	r.GET("/variants/:id/stock", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado", "vendedor"), product.GetVariantStock)
+	// Códigos de barras: lectura con escáner, códigos de proveedor y etiquetas
+	r.GET("/variants/by-barcode/:code", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado", "vendedor"), product.GetVariantByBarcode)
+	r.GET("/variants/:id/barcode.png", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado", "vendedor"), product.GetVariantBarcodeImage)
+	r.PUT("/variants/:id/barcode", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.SetVariantBarcode)
+	r.POST("/variants/barcodes/import", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ImportVariantBarcodes)
+	r.POST("/variants/barcodes/generate", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.GenerateMissingBarcodes)
+	r.POST("/variants/labels", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado", "vendedor"), product.PrintBarcodeLabels)
 
 	// Stock por producto (agregar/actualizar múltiples ubicaciones) (solo admin)
 	r.POST("/products/:id/stocks", user.AuthMiddleware(), user.RequireRole("admin"), product.AddProductStocks)
@@ -161,12 +223,44 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.GET("/stock-movements", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListStockMovements)
 	r.POST("/stock-movements", user.AuthMiddleware(), user.RequireRole("admin"), product.CreateStockMovement)
 
+	// Puntos de reposición, alertas de stock y sugerencias de reposición (admin/encargado)
+	r.GET("/inventory/reorder-points", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.ListReorderPoints)
+	r.PUT("/inventory/reorder-points", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.UpsertReorderPoints)
+	r.DELETE("/inventory/reorder-points/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), product.DeleteReorderPoint)
+	r.GET("/inventory/alerts", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), inventory.ListStockAlerts)
+	r.POST("/inventory/alerts/run", user.AuthMiddleware(), user.RequireRole("admin"), inventory.RunStockAlertsNow)
+	r.GET("/inventory/replenishment", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), inventory.GetReplenishmentReport)
+
+	// Órdenes de compra a proveedores y recepción de mercadería (admin/encargado)
+	r.POST("/purchase-orders", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.CreatePurchaseOrder)
+	r.GET("/purchase-orders", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.ListPurchaseOrders)
+	r.GET("/purchase-orders/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.GetPurchaseOrder)
+	r.PUT("/purchase-orders/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.UpdatePurchaseOrder)
+	r.POST("/purchase-orders/:id/send", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.SendPurchaseOrder)
+	r.POST("/purchase-orders/:id/cancel", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.CancelPurchaseOrder)
+	r.POST("/purchase-orders/:id/receipts", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.ReceivePurchaseOrder)
+	r.GET("/reports/purchase-orders/open", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.GetOpenPurchaseOrdersReport)
+	r.GET("/reports/suppliers/performance", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), purchase.GetSupplierPerformanceReport)
+
+	// Devoluciones y cambios (RMA): el cliente solicita, admin/encargado inspecciona y resuelve
+	r.POST("/returns", user.AuthMiddleware(), returns.CreateReturnHandler)
+	r.GET("/returns", user.AuthMiddleware(), returns.ListReturns)
+	r.GET("/returns/:id", user.AuthMiddleware(), returns.GetReturn)
+	r.POST("/returns/:id/inspect", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), returns.InspectReturnHandler)
+	r.POST("/returns/:id/resolve", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), returns.ResolveReturnHandler)
+	r.POST("/returns/:id/reject", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), returns.RejectReturn)
+	r.GET("/credit-notes", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), returns.ListCreditNotes)
+
 	// Kits / Combos (admin/encargado can create/edit)
 	r.POST("/kits", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), kit.CreateKit)
 	r.GET("/kits", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), kit.ListKits)
 	r.GET("/kits/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), kit.GetKit)
 	r.PUT("/kits/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), kit.UpdateKit)
 	r.DELETE("/kits/:id", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), kit.DeleteKit)
+	// Kits a la venta: precio vigente y disponibilidad por ubicación
+	r.GET("/public/kits", user.OptionalAuthMiddleware(), kit.ListKits)
+	r.GET("/public/kits/:id", user.OptionalAuthMiddleware(), kit.GetKit)
+	r.GET("/public/kits/:id/availability", user.OptionalAuthMiddleware(), kit.KitAvailability)
 
 	// Carrito (usuario logueado)
 	r.GET("/cart", user.AuthMiddleware(), cart.GetCart)
@@ -184,6 +278,8 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.GET("/cart/:id", user.AuthMiddleware(), cart.GetCartByID)
 	// Permitir agregar al carrito sin autenticación (opcional, manejado por frontend)
 	r.POST("/cart/add", user.OptionalAuthMiddleware(), cart.AddToCart)
+	r.POST("/cart/add-curve", user.AuthMiddleware(), cart.AddCurveToCart)
+	r.POST("/cart/add-kit", user.AuthMiddleware(), cart.AddKitToCart)
 	r.PUT("/cart/update/:product_id", user.AuthMiddleware(), cart.UpdateCartItem)
 	r.DELETE("/cart/remove/:product_id", user.AuthMiddleware(), cart.RemoveFromCart)
 	r.DELETE("/cart/clear", user.AuthMiddleware(), cart.ClearCart)
@@ -287,6 +383,22 @@ func SetupRouter(db *gorm.DB) *gin.Engine {
 	r.POST("/remitos-internos/:id/confirmar", user.AuthMiddleware(), user.RequireAnyRole("admin", "encargado"), remito.ConfirmarRecepcionRemito)
 	r.GET("/carts/:cart_id/remitos-internos", user.AuthMiddleware(), user.RequireAnyRole("admin", "vendedora", "encargado"), remito.GetRemitosByCart)
 
+	// Secuencias de numeración (remitos, productos, órdenes, facturas, notas de crédito)
+	r.GET("/admin/sequences", user.AuthMiddleware(), user.RequireRole("admin"), sequence.ListSequences)
+	r.PUT("/admin/sequences/:name", user.AuthMiddleware(), user.RequireRole("admin"), sequence.UpdateSequence)
+	// Conciliación del libro de movimientos de stock contra location_stocks y reservas
+	r.GET("/admin/stock/reconciliation", user.AuthMiddleware(), user.RequireRole("admin"), inventory.StockReconciliation)
+	r.POST("/admin/stock/reconciliation", user.AuthMiddleware(), user.RequireRole("admin"), inventory.StockReconciliation)
+	r.GET("/admin/catalog/quality", user.AuthMiddleware(), user.RequireRole("admin"), catalogcheck.CatalogQuality)
+	r.POST("/admin/catalog/quality", user.AuthMiddleware(), user.RequireRole("admin"), catalogcheck.CatalogQuality)
+	// Lotes de exportación a Zoologic/Dragonfish (movimientos de stock y ventas) y su diseño de archivo
+	r.GET("/admin/zoologic/batches", user.AuthMiddleware(), user.RequireRole("admin"), zoologic.ListBatches)
+	r.POST("/admin/zoologic/batches", user.AuthMiddleware(), user.RequireRole("admin"), zoologic.CreateBatch)
+	r.GET("/admin/zoologic/batches/:id/download", user.AuthMiddleware(), user.RequireRole("admin"), zoologic.DownloadBatch)
+	r.POST("/admin/zoologic/batches/:id/regenerate", user.AuthMiddleware(), user.RequireRole("admin"), zoologic.RegenerateBatchHandler)
+	r.GET("/admin/zoologic/formats", user.AuthMiddleware(), user.RequireRole("admin"), zoologic.ListFormats)
+	r.PUT("/admin/zoologic/formats/:kind", user.AuthMiddleware(), user.RequireRole("admin"), zoologic.UpdateFormat)
+
 	return r
 }
 